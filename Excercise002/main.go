@@ -1,8 +1,13 @@
 package main
 
 import (
+	"errors"
 	"fmt"
+	"log"
 
+	"github.com/goodshailesh/My-Vulkan-Projects/shaders"
+	"github.com/goodshailesh/My-Vulkan-Projects/vkutil"
+	"github.com/goodshailesh/My-Vulkan-Projects/vkutil/window"
 	"github.com/vulkan-go/glfw/v3.3/glfw"
 	vk "github.com/vulkan-go/vulkan"
 )
@@ -11,30 +16,51 @@ var width uint32 = 800
 var height uint32 = 600
 
 type appObject struct {
-	window     *glfw.Window
-	instance   vk.Instance
-	semaphores []vk.Semaphore
-	fences     []vk.Fence
+	// Every handle below registers its destroyer here when created
+	resources      *vkutil.DeletionQueue
+	window         *glfw.Window
+	instance       vk.Instance
+	debugMessenger *vkutil.DebugMessenger
+	// Command buffers, semaphores and fences of the frames in flight
+	frameLoop *vkutil.FrameLoop
 	// Surface Specific
 	surface vk.Surface
 	//surfaceFormats []vk.SurfaceFormat
 	surfaceFormat    vk.SurfaceFormat
+	swapchainConfig  vkutil.SwapchainConfig
 	displaySize      vk.Extent2D
 	displayFormat    vk.Format
 	swapchains       []vk.Swapchain
 	swapchainslength []uint32
 	imageViews       []vk.ImageView
+	// Destroyed early when the swapchain is recreated
+	swapchainDeletion    *vkutil.Deletion
+	imageViewDeletions   []*vkutil.Deletion
+	renderPassDeletion   *vkutil.Deletion
+	pipelineDeletion     *vkutil.Deletion
+	frameBufferDeletions []*vkutil.Deletion
+	depthBufferDeletion  *vkutil.Deletion
+	msaaColorDeletion    *vkutil.Deletion
+	resize               *window.ResizeTracker
+	// Depth image sized like the swapchain images, recreated with them
+	depthFormat vk.Format
+	depthBuffer *vkutil.Attachment
+	allocator   *vkutil.Allocator
+	// With more than 1 sample the triangle is drawn into msaaColor, which is
+	// resolved into the swapchain image at the end of the render pass
+	samples   vk.SampleCountFlagBits
+	msaaColor *vkutil.Attachment
 	//FrameBuffer Specific
 	renderPass   vk.RenderPass
 	frameBuffers []vk.Framebuffer
+	pipeline     *vkutil.GraphicsPipeline
 	//Device Specific
 	logicalDevice    vk.Device
 	physicalDevices  []vk.PhysicalDevice
+	physicalDevice   vk.PhysicalDevice
 	graphicsQueuePtr *vk.Queue
-	graphicsQueueIdx []uint32
-	//Command Buffer Specific
-	commandPool    vk.CommandPool
-	commandBuffers []vk.CommandBuffer
+	presentQueuePtr  *vk.Queue
+	queueFamilies    vkutil.QueueFamilyIndices
 }
 
 // type deviceInfo struct {
@@ -61,13 +87,35 @@ const (
 // https://www.khronos.org/registry/vulkan/specs/1.2-extensions/man/html/VkQueueFlagBits.html
 
 func main() {
+	var app appObject
+	app.resources = vkutil.NewDeletionQueue()
+	app.resources.Verbose = true
+
+	err := run(&app)
+	// Cleanup task, everything is destroyed in the reverse order it was created,
+	// also when the setup stopped half way
+	if app.logicalDevice != nil {
+		if waitErr := vkutil.DeviceWaitTillComplete(app.logicalDevice); waitErr != nil {
+			err = errors.Join(err, waitErr)
+		}
+	}
+	app.resources.PrintAlive()
+	if closeErr := app.resources.Close(); closeErr != nil {
+		err = errors.Join(err, closeErr)
+	}
+	if err != nil {
+		log.Fatal(err)
+	}
+}
+
+func run(app *appObject) error {
 	//-------------------------
 	// Initialization Stage
 	//-------------------------
 
-	var app appObject
-
-	orPanic(glfw.Init())
+	if err := glfw.Init(); err != nil {
+		return fmt.Errorf("glfw.Init failed with %w", err)
+	}
 	// GetVulkanGetInstanceProcAddress returns the function pointer used to find Vulkan core or
 	// extension functions. The return value of this function can be passed to the Vulkan library.
 	// Note that this function does not work the same way as the glfwGetInstanceProcAddress.
@@ -76,282 +124,392 @@ func main() {
 	// This function must be called before vulkan.Init()
 	// https://godoc.org/github.com/vulkan-go/vulkan#SetGetInstanceProcAddr
 	//fmt.Println(glfw.GetVulkanGetInstanceProcAddress())
-	vk.SetGetInstanceProcAddr(glfw.GetVulkanGetInstanceProcAddress())
-	orPanic(vk.Init())
+	vkutil.SetGetInstanceProcAddr(glfw.GetVulkanGetInstanceProcAddress())
+	if err := vk.Init(); err != nil {
+		return fmt.Errorf("vk.Init failed with %w", err)
+	}
 
 	// *** 1 List Layers available ***//
 
-	//xPrintInstanceLayerProperties()
-	//xPrintInstanceExtensionProperties()
+	//vkutil.PrintInstanceLayerProperties()
+	//vkutil.PrintInstanceExtensionProperties()
 
 	// *** 2 GLFW Window Creation ***//
 	// Vulkan WSI extensions avaibale for different platforms
 	// https://www.glfw.org/docs/latest/compat.html
-	app.window = xCreateWindowGLFW()
+	var err error
+	if app.window, err = xCreateWindowGLFW(); err != nil {
+		return err
+	}
+	app.resources.Push("window", app.window.Destroy)
 
 	// *** 3 Instance and Application Creation ***//
 
-	app.instance = xCreateInstance()
+	if app.instance, app.debugMessenger, err = xCreateInstance(app.window); err != nil {
+		return err
+	}
+	app.resources.Push("instance", func() { vk.DestroyInstance(app.instance, nil) })
+	app.resources.Push("debug messenger", app.debugMessenger.Destroy)
 	fmt.Println(app.instance)
 
+	// The surface is needed first so only GPUs able to present to it are selected
+	if err := xCreateSurface(app); err != nil {
+		return err
+	}
+
 	//xDevicesInfo(instance)
-	app.physicalDevices, _ = xGetDevices(app.instance)
-	xGetDeviceQueueFamilyProperties(app.physicalDevices[0])
-
-	app.logicalDevice, _ = xCreateLogicalDevice(app.instance)
-
-	// Search for Graphics queue that is capable for supporting Graphics Operations
-	xCreateSurface(&app)
-	//graphicsQueueIndex := xGetGPUQueueSupportingGraphicsOps()
-	app.graphicsQueueIdx = xGetGPUQueueSupportingGraphicsOps(&app)
-	xGetSurfaceFormats(&app)
-	xCreateSwapChain(&app)
-	xCommandBufferInitialization(&app)
-	xCreateImageView(&app)
-	xCreateRenderPass(&app)
-	xCreateFrameBuffer(&app)
-	xCreateSemaphore(&app)
-	xDrawFrameToDevice(&app)
-
-	// Cleanup task
-	for idx := range app.fences {
-		vk.DestroyFence(app.logicalDevice, app.fences[idx], nil)
-	}
-	for idx := range app.semaphores {
-		vk.DestroySemaphore(app.logicalDevice, app.semaphores[idx], nil)
-	}
-	for idx := range app.frameBuffers {
-		vk.DestroyFramebuffer(app.logicalDevice, app.frameBuffers[idx], nil)
-	}
-	vk.DestroyRenderPass(app.logicalDevice, app.renderPass, nil)
-	for _, imageView := range app.imageViews {
-		vk.DestroyImageView(app.logicalDevice, imageView, nil)
-	}
-	vk.DestroyCommandPool(app.logicalDevice, app.commandPool, nil)
-	vk.DestroySwapchain(app.logicalDevice, app.swapchains[0], nil)
-	vk.DestroySurface(app.instance, app.surface, nil)
-	app.window.Destroy()
-	vk.DestroyDevice(app.logicalDevice, nil)
-	vk.DestroyInstance(app.instance, nil)
+	if app.physicalDevices, err = vkutil.GetPhysicalDevices(app.instance); err != nil {
+		return err
+	}
+	if app.physicalDevice, err = xSelectPhysicalDevice(app); err != nil {
+		return err
+	}
+	xGetDeviceQueueFamilyProperties(app.physicalDevice)
+
+	// Search for the queue families supporting Graphics Operations and presentation to our surface
+	if app.queueFamilies, err = xGetQueueFamilies(app); err != nil {
+		return err
+	}
+	if app.logicalDevice, err = xCreateLogicalDevice(app.physicalDevice, app.queueFamilies); err != nil {
+		return err
+	}
+	app.resources.Push("logical device", func() { vk.DestroyDevice(app.logicalDevice, nil) })
+	// Images get a range of a few big memory blocks instead of one vkAllocateMemory each
+	app.allocator = vkutil.NewAllocator(app.logicalDevice, app.physicalDevice, vkutil.AllocatorConfig{})
+	app.resources.PushErr("allocator", app.allocator.Destroy)
+	// 4x MSAA is supported everywhere, unless the GPU offers no MSAA at all
+	app.samples = vkutil.SelectSampleCount(app.physicalDevice, vk.SampleCount4Bit)
+	fmt.Println("MSAA Samples......", app.samples)
+	// Each step needs the previous ones, the first failure stops the setup
+	for _, step := range []func(*appObject) error{
+		xResolveSwapchainConfig,
+		xCreateSwapChain,
+		xCreateImageView,
+		xCreateAttachments,
+		xCreateRenderPass,
+		xCreateGraphicsPipeline,
+		xCreateFrameBuffer,
+		xCreateFrameLoop,
+		xRenderLoop,
+	} {
+		if err := step(app); err != nil {
+			return err
+		}
+	}
+	return nil
 }
 
-func xDrawFrameToScreen(app *appObject, nextImageIdx uint32) {
-	// var nextIdx uint32
-	// vk.Error(vk.AcquireNextImage(app.logicalDevice, app.swapchains[0], vk.MaxUint64, app.semaphores[0], vk.NullFence, &nextIdx))
-	// err := vk.Error(vk.AcquireNextImage(app.logicalDevice, app.swapchains[0], vk.MaxUint64, app.semaphores[0], nil, &nextImageIdx))
-	// if err != nil {
-	// 	fmt.Println("AcquireNextImage failed with ", err)
-	// 	return
-	// }
-	vk.ResetFences(app.logicalDevice, uint32(len(app.fences)), app.fences)
-	submitInfo := []vk.SubmitInfo{{
-		SType: vk.StructureTypeSubmitInfo,
-		// WaitSemaphoreCount: 1,
-		// PWaitSemaphores:    app.semaphores,
-		// PWaitDstStageMask:  nil,
-		CommandBufferCount: 1,
-		PCommandBuffers:    app.commandBuffers[nextImageIdx:],
-	}}
-	err := vk.Error(vk.QueueSubmit(*app.graphicsQueuePtr, 1, submitInfo, app.fences[0]))
-	if err != nil {
-		fmt.Println("AcquireNextImage failed with ", err)
-		return
+// The window is drawn until it is closed, a resize or a minimize recreates the swapchain
+func xRenderLoop(app *appObject) error {
+	app.resize = window.TrackResize(app.window)
+	for !app.window.ShouldClose() {
+		glfw.PollEvents()
+		// Nothing can be presented to a minimized window, sleep until it is restored
+		if window.Minimized(app.window) {
+			window.WaitWhileMinimized(app.window)
+			continue
+		}
+		if err := xDrawFrameToDevice(app); err != nil {
+			return err
+		}
 	}
-	const timeoutNano = 10 * 1000 * 1000 * 1000 // 10 sec
-	err = vk.Error(vk.WaitForFences(app.logicalDevice, 1, app.fences, vk.True, timeoutNano))
-	if err != nil {
-		fmt.Println("AcquireNextImage failed with ", err)
-		return
-	}
-	imageIndices := []uint32{nextImageIdx}
-	presentInfo := vk.PresentInfo{
-		SType: vk.StructureTypePresentInfo,
-		// WaitSemaphoreCount: uint32(len(app.semaphores)),
-		// PWaitSemaphores:    app.semaphores,
-		SwapchainCount: 1,
-		PSwapchains:    app.swapchains,
-		PImageIndices:  imageIndices,
-	}
-	err = vk.Error(vk.QueuePresent(*app.graphicsQueuePtr, &presentInfo))
-
-	// 	fpsTicker := time.NewTicker(time.Second / 30)
-	// 	for {
-	// 		select {
-	// 		case <-exitC:
-	// 			vulkandraw.DestroyInOrder(&v, &s, &r, &b, &gfx)
-	// 			window.Destroy()
-	// 			glfw.Terminate()
-	// 			fpsTicker.Stop()
-	// 			doneC <- struct{}{}
-	// 			return
-	// 		case <-fpsTicker.C:
-	// 			if window.ShouldClose() {
-	// 				exitC <- struct{}{}
-	// 				continue
-	// 			}
-	// 			glfw.PollEvents()
-	// 			//vulkandraw.VulkanDrawFrame(v, s, r)
-	// xDrawFrameToDevice()
-	// 		}
-	// 	}
+	return nil
 }
 
-func xDrawFrameToDevice(app *appObject) {
-	var nextImageIdx uint32
+func xDrawFrameToScreen(app *appObject, frame vkutil.Frame) error {
+	// Submits once the image is available and presents once rendering finished, see vkutil.FrameLoop
+	err := app.frameLoop.EndFrame()
+	if err != nil && !vkutil.SwapchainOutOfDate(err) {
+		return err
+	}
+	// Drivers need not report OUT_OF_DATE after a resize, so the framebuffer size callback is asked too.
+	// Asked every frame, so a resize seen alongside an error is not reported again next frame
+	resized := app.resize.Resized()
+	if err != nil || frame.Suboptimal || resized {
+		reason := "the window was resized"
+		if err != nil {
+			reason = err.Error()
+		} else if frame.Suboptimal {
+			reason = "the image was acquired suboptimal"
+		}
+		fmt.Println("Swapchain no longer matches the surface:", reason)
+		return xRecreateSwapchain(app)
+	}
+	return nil
+}
+
+func xDrawFrameToDevice(app *appObject) error {
 	clearValues := []vk.ClearValue{
 		vk.NewClearValue([]float32{1.0, 0.0, 0.0, 1.0}),
+		vk.NewClearDepthStencil(1.0, 0), // the far plane
+	}
+	// Waits for the oldest frame in flight, acquires the next image and begins the frame's command buffer
+	frame, err := app.frameLoop.BeginFrame()
+	if errors.Is(err, vkutil.ErrOutOfDate) {
+		// No image was acquired, the frame is skipped. The new swapchain covers a pending resize too
+		fmt.Println("Swapchain no longer matches the surface:", err)
+		app.resize.Resized()
+		return xRecreateSwapchain(app)
 	}
-	//err := vk.Error(vk.AcquireNextImage(app.logicalDevice, app.swapchains[0], vk.MaxUint64, app.semaphores[0], nil, &nextImageIdx))
-	err := vk.Error(vk.AcquireNextImage(app.logicalDevice, app.swapchains[0], vk.MaxUint64, app.semaphores[0], nil, &nextImageIdx))
 	if err != nil {
-		fmt.Println("AcquireNextImage failed with ", err)
-		return
-	}
-	cmdBufferBeginInfo := vk.CommandBufferBeginInfo{
-		SType: vk.StructureTypeCommandBufferBeginInfo,
-		//Flags: vk.CommandBufferUsageOneTimeSubmitBit,
-		// VK_COMMAND_BUFFER_USAGE_ONE_TIME_SUBMIT_BIT
-	}
-	for i := range app.commandBuffers {
-
-		renderPassBeginInfo := vk.RenderPassBeginInfo{
-			SType:       vk.StructureTypeRenderPassBeginInfo,
-			RenderPass:  app.renderPass,
-			Framebuffer: app.frameBuffers[i],
-			RenderArea: vk.Rect2D{
-				Offset: vk.Offset2D{
-					X: 0, Y: 0,
-				},
-				Extent: app.displaySize,
+		return err
+	}
+	renderPassBeginInfo := vk.RenderPassBeginInfo{
+		SType:       vk.StructureTypeRenderPassBeginInfo,
+		RenderPass:  app.renderPass,
+		Framebuffer: app.frameBuffers[frame.ImageIndex],
+		RenderArea: vk.Rect2D{
+			Offset: vk.Offset2D{
+				X: 0, Y: 0,
 			},
-			ClearValueCount: 1,
-			PClearValues:    clearValues,
-		}
-		error := vk.Error(vk.BeginCommandBuffer(app.commandBuffers[i], &cmdBufferBeginInfo))
-		if error != nil {
-			fmt.Println("BeginCommandBuffer failed with ", error)
-			return
-		}
-		vk.CmdBeginRenderPass(app.commandBuffers[i], &renderPassBeginInfo, vk.SubpassContentsInline)
+			Extent: app.displaySize,
+		},
+		ClearValueCount: uint32(len(clearValues)),
+		PClearValues:    clearValues,
+	}
+	vk.CmdBeginRenderPass(frame.CommandBuffer, &renderPassBeginInfo, vk.SubpassContentsInline)
+
+	// The viewport and scissor are dynamic so the pipeline survives a resize
+	vk.CmdBindPipeline(frame.CommandBuffer, vk.PipelineBindPointGraphics, app.pipeline.Pipeline)
+	vkutil.CmdSetViewportAndScissor(frame.CommandBuffer, app.displaySize)
+	// The vertex shader makes up the 3 vertices from gl_VertexIndex, no vertex buffer needed
+	vk.CmdDraw(frame.CommandBuffer, 3, 1, 0, 0)
 
-		///////////////////////////////
-		// Actual Geometry Drawing Code goes here
-		///////////////////////////////
+	vk.CmdEndRenderPass(frame.CommandBuffer)
+	return xDrawFrameToScreen(app, frame)
+}
 
-		vk.CmdEndRenderPass(app.commandBuffers[i])
-		vk.EndCommandBuffer(app.commandBuffers[i])
+// Rebuild everything sized after the swapchain images. The new swapchain is created
+// with the old one as OldSwapchain so the presentation engine can hand over its images.
+func xRecreateSwapchain(app *appObject) error {
+	// A minimized window has a 0x0 framebuffer, no swapchain can be created for it
+	window.WaitWhileMinimized(app.window)
+	if app.window.ShouldClose() {
+		return nil
 	}
-	fenceCreateInfo := vk.FenceCreateInfo{
-		SType: vk.StructureTypeFenceCreateInfo,
+	// The old images may still be read by the GPU
+	if err := vkutil.DeviceWaitTillComplete(app.logicalDevice); err != nil {
+		return err
 	}
-	fences := make([]vk.Fence, 1)
-	err = vk.Error(vk.CreateFence(app.logicalDevice, &fenceCreateInfo, nil, &fences[0]))
-	if err != nil {
-		fmt.Println("BeginCommandBuffer failed with ", err)
-		return
+	// Framebuffers and views point into the old swapchain images, the depth and MSAA images have their old size
+	var errs []error
+	for _, d := range app.frameBufferDeletions {
+		errs = append(errs, d.Destroy())
 	}
-	app.fences = fences
-	fmt.Println("Image Draw To Device Successfull......")
-	xDrawFrameToScreen(app, nextImageIdx)
-}
+	errs = append(errs, app.depthBufferDeletion.Destroy(), app.msaaColorDeletion.Destroy())
+	for _, d := range app.imageViewDeletions {
+		errs = append(errs, d.Destroy())
+	}
+	if err := errors.Join(errs...); err != nil {
+		return err
+	}
+	app.frameBuffers, app.frameBufferDeletions = nil, nil
+	app.imageViews, app.imageViewDeletions = nil, nil
 
-func xCreateSemaphore(app *appObject) {
-	var semaphores []vk.Semaphore = make([]vk.Semaphore, 1)
-	semaphoreCreateInfo := vk.SemaphoreCreateInfo{
-		SType: vk.StructureTypeSemaphoreCreateInfo,
+	oldFormat := app.surfaceFormat.Format
+	if err := xResolveSwapchainConfig(app); err != nil {
+		return err
 	}
-	err := vk.Error(vk.CreateSemaphore(app.logicalDevice, &semaphoreCreateInfo, nil, &semaphores[0]))
-	if err != nil {
-		fmt.Println("CreateSemaphore failed with ", err)
-		return
+	if err := xCreateSwapChain(app); err != nil {
+		return err
+	}
+	app.frameLoop.SetSwapchain(app.swapchains[0], app.swapchainslength[0])
+	if err := xCreateImageView(app); err != nil {
+		return err
+	}
+	if err := xCreateAttachments(app); err != nil {
+		return err
+	}
+	// Some platforms hand out a different format after a resize, the pipeline is tied to the render pass
+	if app.surfaceFormat.Format != oldFormat {
+		if err := errors.Join(app.pipelineDeletion.Destroy(), app.renderPassDeletion.Destroy()); err != nil {
+			return err
+		}
+		if err := xCreateRenderPass(app); err != nil {
+			return err
+		}
+		if err := xCreateGraphicsPipeline(app); err != nil {
+			return err
+		}
 	}
-	app.semaphores = semaphores
-	fmt.Println("Created Semaphors(s)......")
+	if err := xCreateFrameBuffer(app); err != nil {
+		return err
+	}
+	fmt.Printf("Recreated Swapchain %vx%v......\n", app.displaySize.Width, app.displaySize.Height)
+	return nil
+}
 
+// Two frames are recorded ahead at most, each with its own command buffer, semaphores and fence
+func xCreateFrameLoop(app *appObject) error {
+	queues := vkutil.Queues{Graphics: *app.graphicsQueuePtr, Present: *app.presentQueuePtr}
+	frameLoop, err := vkutil.NewFrameLoop(app.logicalDevice, app.queueFamilies, queues, app.swapchains[0], app.swapchainslength[0], vkutil.FrameLoopConfig{
+		FramesInFlight: 2,
+	})
+	if err != nil {
+		return err
+	}
+	app.frameLoop = frameLoop
+	app.resources.Push("frame loop", frameLoop.Destroy)
+	fmt.Println("Created Frame Loop......")
+	return nil
 }
 
-func xCreateFrameBuffer(app *appObject) {
+func xCreateFrameBuffer(app *appObject) error {
 	var frameBuffers = make([]vk.Framebuffer, app.swapchainslength[0])
-	var depthView vk.ImageView
-	// We need 4 images in total for Double Buffering: 2 ImageViews for swaping and
-	// 2 for rendering to(these 2 imageview will be used to render store rendered images)
+	var depthView = app.depthBuffer.View
 	for idx := range frameBuffers {
 		attachments := []vk.ImageView{
 			app.imageViews[idx], depthView,
 		}
+		// In the order of xCreateRenderPass: the swapchain image is the resolve target
+		if app.msaaColor != nil {
+			attachments = []vk.ImageView{app.msaaColor.View, depthView, app.imageViews[idx]}
+		}
 		fbCreateInfo := vk.FramebufferCreateInfo{
 			SType:           vk.StructureTypeFramebufferCreateInfo,
 			RenderPass:      app.renderPass,
 			Layers:          1,
-			AttachmentCount: 1, // 2 if has depthView
+			AttachmentCount: uint32(len(attachments)),
 			PAttachments:    attachments,
-			Width:           width,
-			Height:          height,
+			Width:           app.displaySize.Width,
+			Height:          app.displaySize.Height,
 		}
-		if depthView != vk.NullImageView {
-			fbCreateInfo.AttachmentCount = 2
-		}
-		err := vk.Error(vk.CreateFramebuffer(app.logicalDevice, &fbCreateInfo, nil, &frameBuffers[idx]))
-		if err != nil {
-			err = fmt.Errorf("Failed to create Framebuffer with %s", err)
-			return // bail out
+		if err := vkutil.Check("vkCreateFramebuffer", vk.CreateFramebuffer(app.logicalDevice, &fbCreateInfo, nil, &frameBuffers[idx])); err != nil {
+			return err // bail out
 		}
+		frameBuffer := frameBuffers[idx]
+		app.frameBufferDeletions = append(app.frameBufferDeletions, app.resources.Push(fmt.Sprintf("framebuffer %v", idx), func() { vk.DestroyFramebuffer(app.logicalDevice, frameBuffer, nil) }))
 	}
 	app.frameBuffers = frameBuffers
 	fmt.Println("Created FrameBuffer(s)......")
+	return nil
 }
 
-func xCreateRenderPass(app *appObject) {
+func xCreateRenderPass(app *appObject) error {
 	var renderPass vk.RenderPass
-	attachmentDescriptions := []vk.AttachmentDescription{{
-		Format:         app.surfaceFormat.Format,
-		Samples:        vk.SampleCount1Bit,
-		LoadOp:         vk.AttachmentLoadOpClear,
-		StoreOp:        vk.AttachmentStoreOpStore,
-		StencilLoadOp:  vk.AttachmentLoadOpDontCare,
-		StencilStoreOp: vk.AttachmentStoreOpDontCare,
-		InitialLayout:  vk.ImageLayoutColorAttachmentOptimal,
-		FinalLayout:    vk.ImageLayoutColorAttachmentOptimal,
-	}}
+	// The swapchain image comes undefined from the acquire, is cleared and left ready to present
+	attachmentDescriptions := []vk.AttachmentDescription{
+		vkutil.ColorAttachmentDescription(app.surfaceFormat.Format, vk.SampleCount1Bit, vkutil.AttachmentPresent),
+	}
 	colorAttachments := []vk.AttachmentReference{{
 		Attachment: 0,
 		Layout:     vk.ImageLayoutColorAttachmentOptimal,
 	}}
+	// Attachment 1 is the depth buffer, cleared every frame and thrown away after
+	attachmentDescriptions = append(attachmentDescriptions, vkutil.DepthAttachmentDescription(app.depthFormat, app.samples))
+	depthAttachment := vk.AttachmentReference{
+		Attachment: 1,
+		Layout:     vk.ImageLayoutDepthStencilAttachmentOptimal,
+	}
 	subPassDescription := []vk.SubpassDescription{{
 		PipelineBindPoint:       vk.PipelineBindPointGraphics,
 		ColorAttachmentCount:    1,
 		PColorAttachments:       colorAttachments,
-		PDepthStencilAttachment: nil,
+		PDepthStencilAttachment: &depthAttachment,
 	}}
+	// With MSAA attachment 0 is the multisampled image, only needed during the
+	// pass, and attachment 2 the swapchain image it is resolved into
+	if app.samples != vk.SampleCount1Bit {
+		attachmentDescriptions[0] = vkutil.ColorAttachmentDescription(app.surfaceFormat.Format, app.samples, vkutil.AttachmentOnly)
+		attachmentDescriptions = append(attachmentDescriptions, vkutil.ResolveAttachmentDescription(app.surfaceFormat.Format, vk.ImageLayoutPresentSrc))
+		subPassDescription[0].PResolveAttachments = []vk.AttachmentReference{{
+			Attachment: 2,
+			Layout:     vk.ImageLayoutColorAttachmentOptimal,
+		}}
+	}
+	// All the frames in flight share the one depth buffer and MSAA image: the
+	// clear of a frame waits for the depth tests and color writes of the previous
+	// one, and the layout change of the swapchain image for the acquire
+	dependencies := vkutil.SubpassDependencies(attachmentDescriptions)
 	renderPassCreateInfo := vk.RenderPassCreateInfo{
 		SType:           vk.StructureTypeRenderPassCreateInfo,
-		AttachmentCount: 1,
+		AttachmentCount: uint32(len(attachmentDescriptions)),
 		PAttachments:    attachmentDescriptions,
 		SubpassCount:    1,
 		PSubpasses:      subPassDescription,
+		DependencyCount: uint32(len(dependencies)),
+		PDependencies:   dependencies,
 	}
-	err := vk.Error(vk.CreateRenderPass(app.logicalDevice, &renderPassCreateInfo, nil, &renderPass))
-	if err != nil {
-		err = fmt.Errorf("Create RenderPass failed with %s", err)
-		return
+	if err := vkutil.Check("vkCreateRenderPass", vk.CreateRenderPass(app.logicalDevice, &renderPassCreateInfo, nil, &renderPass)); err != nil {
+		return err
 	}
 	app.renderPass = renderPass
+	app.renderPassDeletion = app.resources.Push("render pass", func() { vk.DestroyRenderPass(app.logicalDevice, renderPass, nil) })
 	fmt.Println("Created RenderPass......")
+	return nil
 }
 
-//  Create the image view of the retrieved swapchain images
-func xCreateImageView(app *appObject) {
-	var swapchainImageCount uint32 // If this is populated with '2' by below function, then it means swap chain supports double buffering
-	err := vk.Error(vk.GetSwapchainImages(app.logicalDevice, app.swapchains[0], &swapchainImageCount, nil))
+// The pipeline draws the colored triangle of shaders/triangle.vert in the render pass
+func xCreateGraphicsPipeline(app *appObject) error {
+	vertexShader, err := vkutil.LoadSPIRVFS(shaders.FS, "triangle.vert.spv")
 	if err != nil {
-		err = fmt.Errorf("vkCreateDevice failed with %s", err)
-		return
+		return err
 	}
-	var swapchainImages = make([]vk.Image, swapchainImageCount)
-	err = vk.Error(vk.GetSwapchainImages(app.logicalDevice, app.swapchains[0], &swapchainImageCount, swapchainImages))
+	fragmentShader, err := vkutil.LoadSPIRVFS(shaders.FS, "triangle.frag.spv")
 	if err != nil {
-		err = fmt.Errorf("vkCreateDevice failed with %s", err)
-		return
+		return err
+	}
+	builder := vkutil.NewGraphicsPipelineBuilder(app.renderPass)
+	builder.AddShader(vertexShader, vk.ShaderStageVertexBit)
+	builder.AddShader(fragmentShader, vk.ShaderStageFragmentBit)
+	builder.DepthTest, builder.DepthWrite = true, true
+	builder.Samples = app.samples
+	pipeline, err := builder.Build(app.logicalDevice)
+	if err != nil {
+		return err
+	}
+	app.pipeline = pipeline
+	app.pipelineDeletion = app.resources.Push("graphics pipeline", pipeline.Destroy)
+	fmt.Println("Created Graphics Pipeline......")
+	return nil
+}
+
+// The depth format is picked once, D32 if the GPU has it, then D24S8 or D16; the
+// render pass is created for it. The images follow the swapchain size, the
+// multisampled color image only exists with MSAA.
+func xCreateAttachments(app *appObject) error {
+	if app.depthFormat == vk.FormatUndefined {
+		format, err := vkutil.FindDepthFormat(app.physicalDevice, false)
+		if err != nil {
+			return err
+		}
+		app.depthFormat = format
+	}
+	depthBuffer, err := vkutil.NewDepthBuffer(app.logicalDevice, app.allocator, app.depthFormat, app.displaySize, app.samples)
+	if err != nil {
+		return err
+	}
+	app.depthBuffer = depthBuffer
+	app.depthBufferDeletion = app.resources.Push("depth buffer", depthBuffer.Destroy)
+	fmt.Println("Created Depth Buffer......", app.depthFormat)
+	if app.samples == vk.SampleCount1Bit {
+		return nil
+	}
+	msaaColor, err := vkutil.NewAttachment(app.logicalDevice, app.allocator, vkutil.AttachmentConfig{
+		Format:    app.surfaceFormat.Format,
+		Extent:    app.displaySize,
+		Samples:   app.samples,
+		Transient: true,
+		Name:      "msaa color",
+	})
+	if err != nil {
+		return err
+	}
+	app.msaaColor = msaaColor
+	app.msaaColorDeletion = app.resources.Push("msaa color", msaaColor.Destroy)
+	fmt.Println("Created MSAA Color Image......")
+	return nil
+}
+
+// Create the image view of the retrieved swapchain images
+func xCreateImageView(app *appObject) error {
+	var swapchainImageCount uint32 // If this is populated with '2' by below function, then it means swap chain supports double buffering
+	if err := vkutil.Check("vkGetSwapchainImagesKHR", vk.GetSwapchainImages(app.logicalDevice, app.swapchains[0], &swapchainImageCount, nil)); err != nil {
+		return err
+	}
+	var swapchainImages = make([]vk.Image, swapchainImageCount)
+	if err := vkutil.Check("vkGetSwapchainImagesKHR", vk.GetSwapchainImages(app.logicalDevice, app.swapchains[0], &swapchainImageCount, swapchainImages)); err != nil {
+		return err
 	}
 	//app.swapchainImages = swapchainImages
 	//fmt.Println(swapchainImageCount)
@@ -374,160 +532,121 @@ func xCreateImageView(app *appObject) {
 				LayerCount: 1,
 			},
 		}
-		err := vk.Error(vk.CreateImageView(app.logicalDevice, &imageViewCreateInfo, nil, &imageView))
-		if err != nil {
-			err = fmt.Errorf("ImageView creation failed with %s", err)
-			return
+		if err := vkutil.Check("vkCreateImageView", vk.CreateImageView(app.logicalDevice, &imageViewCreateInfo, nil, &imageView)); err != nil {
+			return err
 		}
 		app.imageViews = append(app.imageViews, imageView)
+		// The swapchain images themselves belong to the swapchain, only the views are ours to destroy
+		app.imageViewDeletions = append(app.imageViewDeletions, app.resources.Push(fmt.Sprintf("swapchain image view %v", len(app.imageViews)-1), func() { vk.DestroyImageView(app.logicalDevice, imageView, nil) }))
 	}
 	swapchainImages = nil
 	fmt.Println("Created Image View......")
+	return nil
 }
 
-func xCommandBufferInitialization(app *appObject) {
-	var commandPool vk.CommandPool
-	cmdPoolCreateInfo := vk.CommandPoolCreateInfo{
-		SType:            vk.StructureTypeCommandPoolCreateInfo,
-		Flags:            vk.CommandPoolCreateFlags(vk.CommandPoolCreateResetCommandBufferBit),
-		QueueFamilyIndex: 0,
-	}
-	err := vk.Error(vk.CreateCommandPool(app.logicalDevice, &cmdPoolCreateInfo, nil, &commandPool))
-	if err != nil {
-		err = fmt.Errorf("vkCreateDevice failed with %s", err)
-		return
-	}
-	app.commandPool = commandPool
-	fmt.Println("Created Command Pool..........")
-	var commandBuffers = make([]vk.CommandBuffer, app.swapchainslength[0])
-	var cmdBufferAllocateInfo = vk.CommandBufferAllocateInfo{
-		SType:              vk.StructureTypeCommandBufferAllocateInfo,
-		CommandPool:        commandPool,
-		Level:              vk.CommandBufferLevelPrimary,
-		CommandBufferCount: app.swapchainslength[0],
-	}
-	err = vk.Error(vk.AllocateCommandBuffers(app.logicalDevice, &cmdBufferAllocateInfo, commandBuffers))
-	if err != nil {
-		err = fmt.Errorf("vk.AllocateCommandBuffers failed with %s", err)
-		return
-	}
-	app.commandBuffers = commandBuffers
-	fmt.Println("Created Command Buffer..........")
-}
-
-func xCreateSwapChain(app *appObject) {
-	var surfaceCapabilities vk.SurfaceCapabilities
-	err := vk.Error(vk.GetPhysicalDeviceSurfaceCapabilities(app.physicalDevices[0], app.surface, &surfaceCapabilities))
-	if err != nil {
-		err = fmt.Errorf("Failed getting surface capabilities with error %s", err)
-		return
-	}
-	surfaceCapabilities.Deref()
-	app.displaySize = surfaceCapabilities.CurrentExtent
-	app.displaySize.Deref()
+func xCreateSwapChain(app *appObject) error {
+	app.displaySize = app.swapchainConfig.Extent
 	app.displayFormat = app.surfaceFormat.Format
-	swapChainCreateInfo := vk.SwapchainCreateInfo{
-		SType:            vk.StructureTypeSwapchainCreateInfo,
-		Surface:          app.surface,
-		MinImageCount:    surfaceCapabilities.MinImageCount,
-		ImageFormat:      app.surfaceFormat.Format,
-		ImageColorSpace:  app.surfaceFormat.ColorSpace,
-		ImageExtent:      surfaceCapabilities.CurrentExtent,
-		ImageUsage:       vk.ImageUsageFlags(vk.ImageUsageColorAttachmentBit),
-		PreTransform:     vk.SurfaceTransformIdentityBit,
-		ImageArrayLayers: 1, //Teels about whether it's virtual 3D view or not - imageArrayLayers is the number of views in a multiview/stereo surface. For non-stereoscopic-3D applications, this value is 1.
-		// https://www.khronos.org/registry/vulkan/specs/1.0-wsi_extensions/html/vkspec.html#VkSwapchainCreateInfoKHR
-		ImageSharingMode:      vk.SharingModeExclusive,
-		QueueFamilyIndexCount: 1,
-		PQueueFamilyIndices:   app.graphicsQueueIdx,
-		PresentMode:           vk.PresentModeFifo,
-		OldSwapchain:          vk.NullSwapchain,
-		Clipped:               vk.False,
-		CompositeAlpha:        vk.CompositeAlphaOpaqueBit,
-	}
+	// The images are exclusive when graphics and present are the same family, concurrent between both otherwise
+	// https://www.khronos.org/registry/vulkan/specs/1.0-wsi_extensions/html/vkspec.html#VkSwapchainCreateInfoKHR
 	var swapChains = make([]vk.Swapchain, 1)
 	var swapchainlength = make([]uint32, 1)
 	swapchainlength[0] = uint32(len(swapChains))
-	err = vk.Error(vk.CreateSwapchain(app.logicalDevice, &swapChainCreateInfo, nil, &swapChains[0]))
-	if err != nil {
-		err = fmt.Errorf("vk.CreateSwapchain failed with %s", err)
-		return
+	// When recreating, the old swapchain is handed over so the presentation engine can reuse its resources
+	oldSwapchain := vk.NullSwapchain
+	if len(app.swapchains) > 0 {
+		oldSwapchain = app.swapchains[0]
+	}
+	var err error
+	if swapChains[0], err = vkutil.CreateSwapChain(app.logicalDevice, app.surface, app.swapchainConfig, app.queueFamilies, oldSwapchain); err != nil {
+		return err
+	}
+	// The old swapchain is retired now but still has to be destroyed
+	if app.swapchainDeletion != nil {
+		if err := app.swapchainDeletion.Destroy(); err != nil {
+			return err
+		}
 	}
 	app.swapchains = swapChains
+	app.swapchainDeletion = app.resources.Push("swapchain", func() { vk.DestroySwapchain(app.logicalDevice, swapChains[0], nil) })
 	app.swapchainslength = swapchainlength
-	err = vk.Error(vk.GetSwapchainImages(app.logicalDevice, swapChains[0], &swapchainlength[0], nil))
-	if err != nil {
-		err = fmt.Errorf("vk.GetSwapchainImages failed with %s", err)
-		return
+	if err := vkutil.Check("vkGetSwapchainImagesKHR", vk.GetSwapchainImages(app.logicalDevice, swapChains[0], &swapchainlength[0], nil)); err != nil {
+		return err
 	}
 	fmt.Println("Create Swapchain.......")
+	return nil
 }
 
-func xGetSurfaceFormats(app *appObject) {
-	var graphicQueue vk.Queue
-	var formatCount uint32
-	vk.GetDeviceQueue(app.logicalDevice, app.graphicsQueueIdx[0], 0, &graphicQueue)
-	app.graphicsQueuePtr = &graphicQueue
-	vk.GetPhysicalDeviceSurfaceFormats(app.physicalDevices[0], app.surface, &formatCount, nil)
-	var surfaceformats = make([]vk.SurfaceFormat, formatCount)
-	vk.GetPhysicalDeviceSurfaceFormats(app.physicalDevices[0], app.surface, &formatCount, surfaceformats)
-	surfaceformats[0].Deref()
-	for i := 0; i < int(formatCount); i++ {
-		if surfaceformats[i].Format == vk.FormatB8g8r8a8Unorm || surfaceformats[i].Format == vk.FormatR8g8b8a8Unorm {
-			app.surfaceFormat = surfaceformats[i]
-			break
-		}
+func xResolveSwapchainConfig(app *appObject) error {
+	queues := vkutil.GetDeviceQueues(app.logicalDevice, app.queueFamilies)
+	app.graphicsQueuePtr = &queues.Graphics
+	app.presentQueuePtr = &queues.Present
+	// The clear color is given in linear UNORM values, so UNORM formats are asked for first
+	config, err := vkutil.ResolveSwapchainConfig(app.physicalDevice, app.surface, vkutil.SwapchainPreferences{
+		Formats: []vk.SurfaceFormat{
+			{Format: vk.FormatB8g8r8a8Unorm, ColorSpace: vk.ColorSpaceSrgbNonlinear},
+			{Format: vk.FormatR8g8b8a8Unorm, ColorSpace: vk.ColorSpaceSrgbNonlinear},
+		},
+		PresentModes: []vk.PresentMode{vk.PresentModeFifo},
+		Extent:       window.FramebufferExtent(app.window),
+	})
+	if err != nil {
+		return err
 	}
-	//app.surfaceFormats = surfaceformats
-	fmt.Println("Retrieved SurfaceFormats.......")
+	vkutil.PrintSwapchainConfig(config)
+	app.swapchainConfig = config
+	app.surfaceFormat = config.Format
+	return nil
 }
 
-func xGetGPUQueueSupportingGraphicsOps(app *appObject) []uint32 {
-	var familyPropertyCount uint32
-	var isPresentationSuported vk.Bool32
-	var index = make(map[uint32]uint32)
-	var uniquekeys []uint32
-	// Get list of Queue family count
-	vk.GetPhysicalDeviceQueueFamilyProperties(app.physicalDevices[0], &familyPropertyCount, nil)
-	for i := 0; i < int(familyPropertyCount); i++ {
-		var idx uint32
-		vk.GetPhysicalDeviceSurfaceSupport(app.physicalDevices[0], idx, app.surface, &isPresentationSuported)
-		if isPresentationSuported == 1 {
-			index[idx] = index[idx] + 1
-		}
+func xGetQueueFamilies(app *appObject) (vkutil.QueueFamilyIndices, error) {
+	queueFamilies, err := vkutil.FindQueueFamilies(app.physicalDevice, app.surface)
+	if err != nil {
+		return queueFamilies, err
 	}
-	for k := range index {
-		uniquekeys = append(uniquekeys, k)
+	if err := queueFamilies.Require(true); err != nil {
+		return queueFamilies, err
 	}
-	return uniquekeys
+	fmt.Println("Queue families:", queueFamilies)
+	return queueFamilies, nil
 }
 
-func xCreateSurface(app *appObject) {
+func xCreateSurface(app *appObject) error {
 	surfacePtr, err := app.window.CreateWindowSurface(app.instance, nil)
 	if err != nil {
-		fmt.Println("Error creating windows surface ", err)
-		app.surface = vk.NullSurface
+		return fmt.Errorf("CreateWindowSurface failed with %w", err)
 	}
 	app.surface = vk.SurfaceFromPointer(surfacePtr)
+	app.resources.Push("surface", func() { vk.DestroySurface(app.instance, app.surface, nil) })
+	return nil
 }
 
-func xCreateWindowGLFW() *glfw.Window {
+func xCreateWindowGLFW() (*glfw.Window, error) {
 	glfw.WindowHint(glfw.ClientAPI, glfw.NoAPI)
+	// The swapchain follows the window size, see xRecreateSwapchain
+	glfw.WindowHint(glfw.Resizable, glfw.True)
 	window, err := glfw.CreateWindow(int(width), int(height), "My Game Engine", nil, nil)
 	if err != nil {
-		fmt.Println("Failed to create window with error ", err)
-		return nil
+		return nil, fmt.Errorf("glfw.CreateWindow failed with %w", err)
 	}
 	fmt.Println("Created GLFW Window.......")
-	return window
+	return window, nil
 }
 
-func xCreateLogicalDevice(instance vk.Instance) (vk.Device, error) {
-	gpudevices, err := xGetDevices(instance)
+func xSelectPhysicalDevice(app *appObject) (vk.PhysicalDevice, error) {
+	selected, candidates, err := vkutil.SelectPhysicalDevice(app.instance, vkutil.DeviceRequirements{
+		QueueFlags: vk.QueueGraphicsBit,
+		Surface:    app.surface,
+		Extensions: []string{"VK_KHR_swapchain"},
+	}, vkutil.DevicePreferences{})
+	vkutil.PrintDeviceCandidates(candidates)
 	if err != nil {
-		err = fmt.Errorf("Failed to get list of physical devices with error: %s", err)
 		return nil, err
 	}
+	return selected.PhysicalDevice, nil
+}
+
+func xCreateLogicalDevice(physicalDevice vk.PhysicalDevice, queueFamilies vkutil.QueueFamilyIndices) (vk.Device, error) {
 	// https://www.khronos.org/registry/vulkan/specs/1.2-extensions/html/vkspec.html#VkDeviceQueueCreateInfo
 	// See output of 'xGetDeviceQueueFamilyProperties()' to see more details
 	// var deviceQueueCreateInfoSlice []vk.DeviceQueueCreateInfo = []vk.DeviceQueueCreateInfo{
@@ -546,27 +665,28 @@ func xCreateLogicalDevice(instance vk.Instance) (vk.Device, error) {
 	// 		Flags:            0x7FFFFFFF,
 	// 	},
 	// }
-	deviceQueueCreateInfoSlice := []vk.DeviceQueueCreateInfo{{
-		SType:            vk.StructureTypeDeviceQueueCreateInfo,
-		QueueCount:       1,
-		PQueuePriorities: []float32{1.0},
-	}}
+	// One queue from every family we use, a family may only be listed once
+	var deviceQueueCreateInfoSlice []vk.DeviceQueueCreateInfo
+	for _, family := range queueFamilies.Unique() {
+		deviceQueueCreateInfoSlice = append(deviceQueueCreateInfoSlice, vk.DeviceQueueCreateInfo{
+			SType:            vk.StructureTypeDeviceQueueCreateInfo,
+			QueueFamilyIndex: family,
+			QueueCount:       1,
+			PQueuePriorities: []float32{1.0},
+		})
+	}
 	//var deviceExtensions = []string{"VK_KHR_surface\x00"}
 	var deviceExtensions = []string{"VK_KHR_swapchain\x00"}
-	var deviceLayers = []string{"VK_LAYER_KHRONOS_validation\x00"}
+	// Device layers are deprecated, the instance layers apply to the device as well
 	var deviceCreateInfo *vk.DeviceCreateInfo = &vk.DeviceCreateInfo{
 		SType:                   vk.StructureTypeDeviceCreateInfo,
 		QueueCreateInfoCount:    uint32(len(deviceQueueCreateInfoSlice)),
 		PQueueCreateInfos:       deviceQueueCreateInfoSlice,
-		EnabledLayerCount:       uint32(len(deviceLayers)),
-		PpEnabledLayerNames:     deviceLayers,
 		EnabledExtensionCount:   uint32(len(deviceExtensions)),
 		PpEnabledExtensionNames: deviceExtensions,
 	}
 	var logicalDevice vk.Device
-	err = vk.Error(vk.CreateDevice(gpudevices[0], deviceCreateInfo, nil, &logicalDevice))
-	if err != nil {
-		err = fmt.Errorf("vkCreateDevice failed with %s", err)
+	if err := vkutil.Check("vkCreateDevice", vk.CreateDevice(physicalDevice, deviceCreateInfo, nil, &logicalDevice)); err != nil {
 		return nil, err
 	}
 	fmt.Println("Created Logical Device.......")
@@ -610,98 +730,27 @@ func xGetDeviceQueueFamilyProperties(device vk.PhysicalDevice) {
 	fmt.Println("Retrieved GPU Graphics Queue information.......")
 }
 
-func xGetDevices(instance vk.Instance) ([]vk.PhysicalDevice, error) {
-
-	var deviceCount uint32
-	err := vk.Error(vk.EnumeratePhysicalDevices(instance, &deviceCount, nil))
+func xCreateInstance(glfwWindow *glfw.Window) (vk.Instance, *vkutil.DebugMessenger, error) {
+	var appInfo = vkutil.NewApplicationInfo("myVulkan Application", "My Game Engine")
+	// Only ask for the validation layer when it is installed, otherwise vkCreateInstance fails
+	layers, err := vkutil.SelectInstanceLayers(vkutil.ValidationLayer)
 	if err != nil {
-		err = fmt.Errorf("Failed to get list of physical devices with error: %s", err)
-		return nil, err
+		return nil, nil, err
 	}
-	fmt.Println(deviceCount, " Physical Device(s) found")
-	var devices = make([]vk.PhysicalDevice, deviceCount)
-	err = vk.Error(vk.EnumeratePhysicalDevices(instance, &deviceCount, devices))
+	// GLFW knows which surface extensions the platform needs (win32, xcb, wayland...)
+	extensions, err := window.InstanceExtensions(glfwWindow, vkutil.DebugUtilsExtension)
 	if err != nil {
-		err = fmt.Errorf("Failed to get properties of physical devices with error: %s", err)
-		return nil, err
+		return nil, nil, err
 	}
-	return devices, nil
-}
-
-func xCreateInstance() vk.Instance {
-	var appInfo *vk.ApplicationInfo = &vk.ApplicationInfo{
-		SType:              vk.StructureTypeApplicationInfo,
-		PNext:              nil,
-		PApplicationName:   "myVulkan Application\x00",
-		ApiVersion:         vk.MakeVersion(1, 0, 0), // Throws 'vulkan error: incompatible driver' error with incorrect version number
-		ApplicationVersion: vk.MakeVersion(1, 0, 0),
-		PEngineName:        "My Game Engine\x00",
-		EngineVersion:      vk.MakeVersion(0, 1, 0),
-	}
-	var instance vk.Instance
-	var layers = []string{"VK_LAYER_KHRONOS_validation\x00"}
-	// For Windows Only
-	var extensions = []string{"VK_KHR_surface\x00", "VK_KHR_win32_surface\x00"}
-	// For Linux
-	//https://software.intel.com/en-us/articles/api-without-secrets-introduction-to-vulkan-part-2
-	//var extensions = []string{"VK_KHR_surface\x00", "VK_KHR_xcb_surface\x00"}
-	var instanceInfo = vk.InstanceCreateInfo{
-		SType: vk.StructureTypeInstanceCreateInfo,
-		PNext: nil,
-		//Flags:                   nil,
-		PApplicationInfo:        appInfo,
-		EnabledLayerCount:       uint32(len(layers)),
-		PpEnabledLayerNames:     layers,
-		EnabledExtensionCount:   uint32(len(extensions)),
-		PpEnabledExtensionNames: extensions,
-	}
-	orPanic(vk.CreateInstance(&instanceInfo, nil, &instance))
-	//InitInstance obtains instance PFNs for Vulkan API functions, this is necessary on OS X using MoltenVK, but for the other platforms it's an option. Not implemented for Android.
-	vk.InitInstance(instance)
-	return instance
-}
-
-func xPrintInstanceExtensionProperties() {
-	var extensionCount uint32
-	vk.EnumerateInstanceExtensionProperties("", &extensionCount, nil)
-	fmt.Println(extensionCount, " Extensions found\n")
-	extensions := make([]vk.ExtensionProperties, extensionCount)
-	vk.EnumerateInstanceExtensionProperties("", &extensionCount, extensions)
-	for idx, e := range extensions {
-		e.Deref()
-		var name string = vk.ToString(e.ExtensionName[:])
-		fmt.Printf("%v\t: %v\n", idx+1, name)
-	}
-}
-
-func xPrintInstanceLayerProperties() {
-	var layerCount uint32
-	vk.EnumerateInstanceLayerProperties(&layerCount, nil)
-	fmt.Println(layerCount, " Layers found\n")
-	instanceLayers := make([]vk.LayerProperties, layerCount)
-	vk.EnumerateInstanceLayerProperties(&layerCount, instanceLayers)
-	for idx, lp := range instanceLayers {
-		lp.Deref()
-		var name string = vk.ToString(lp.LayerName[:])
-		var desc string = vk.ToString(lp.Description[:])
-		fmt.Println("Layer ", idx, ": ", name)
-		fmt.Println(" Description ", desc)
+	instance, err := vkutil.CreateInstance(appInfo, layers, extensions)
+	if err != nil {
+		return nil, nil, err
 	}
-}
-
-func orPanic(err interface{}) {
-	switch v := err.(type) {
-	case error:
-		if v != nil {
-			panic(err)
-		}
-	case vk.Result:
-		if err := vk.Error(v); err != nil {
-			panic(err)
-		}
-	case bool:
-		if !v {
-			panic("condition failed: != true")
-		}
+	// Validation messages come through our callback instead of the loader printing them
+	debugMessenger, err := vkutil.CreateValidationMessenger(instance, layers, extensions, vkutil.DebugSeverityWarning, vkutil.PrintDebugMessage)
+	if err != nil {
+		vk.DestroyInstance(instance, nil)
+		return nil, nil, err
 	}
+	return instance, debugMessenger, nil
 }
@@ -28,10 +28,18 @@ func initWindow() {
 	glfw.WindowHint(glfw.ClientAPI, glfw.NoAPI) // Because GLFW was originally designed to create an OpenGL context, we need to tell it to not create an OpenGL context with a subsequent cal
 	// glfw.WindowHint(glfw.ContextVersionMajor, 4)
 	// glfw.WindowHint(glfw.ContextVersionMinor, 1)
-	glfw.WindowHint(glfw.Resizable, glfw.False)
+	// The window can be resized, the Vulkan examples recreate their swapchain when it is
+	glfw.WindowHint(glfw.Resizable, glfw.True)
 	//glfw.WindowHint(glfw.OpenGLProfile, glfw.OpenGLCoreProfile)
 	//glfw.WindowHint(glfw.OpenGLForwardCompatible, glfw.True)
 	win, err = glfw.CreateWindow(800, 600, "Vulkan", nil, nil)
+	if err != nil {
+		panic(fmt.Errorf("Failed to create GLFW window with error: %v", err))
+	}
+	// The framebuffer size is in pixels, it differs from the window size on HiDPI screens and is 0x0 while minimized
+	win.SetFramebufferSizeCallback(func(w *glfw.Window, width, height int) {
+		fmt.Printf("Framebuffer resized to %vx%v\n", width, height)
+	})
 }
 
 func mainLoop() {
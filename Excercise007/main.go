@@ -4,24 +4,17 @@ import (
 	"fmt"
 	"unsafe"
 
+	"github.com/goodshailesh/My-Vulkan-Projects/vkutil"
 	"github.com/vulkan-go/glfw/v3.3/glfw"
 	vk "github.com/vulkan-go/vulkan"
 )
 
 func main() {
-	glfw.Init()
-	vk.SetGetInstanceProcAddr(glfw.GetVulkanGetInstanceProcAddress())
-	vk.Init()
+	vkutil.OrPanic(glfw.Init())
+	vkutil.SetGetInstanceProcAddr(glfw.GetVulkanGetInstanceProcAddress())
+	vkutil.OrPanic(vk.Init())
 
-	var appInfo *vk.ApplicationInfo = &vk.ApplicationInfo{
-		SType:              vk.StructureTypeApplicationInfo,
-		PNext:              nil,
-		PApplicationName:   "myVulkan Application\x00",
-		ApiVersion:         vk.MakeVersion(1, 0, 0), // Throws 'vulkan error: incompatible driver' error with incorrect version number
-		ApplicationVersion: vk.MakeVersion(1, 0, 0),
-		PEngineName:        "My Game Engine\x00",
-		EngineVersion:      vk.MakeVersion(0, 1, 0),
-	}
+	var appInfo = vkutil.NewApplicationInfo("myVulkan Application", "My Game Engine")
 	// Resources
 	var instance vk.Instance
 	var physicalDevices []vk.PhysicalDevice
@@ -29,75 +22,108 @@ func main() {
 	var physicalDeviceFeatures vk.PhysicalDeviceFeatures
 	var memoryProperties vk.PhysicalDeviceMemoryProperties
 	var pQueueFamilyProperties []vk.QueueFamilyProperties
-	var pLogicalDevice *vk.Device
-	var commandPool *vk.CommandPool
+	var logicalDevice vk.Device
+	var commandPool vk.CommandPool
 	var commandBuffers []vk.CommandBuffer
-	var pBuffer, dstBuffer *vk.Buffer
+	var buffer, dstBuffer vk.Buffer
 	var imageFormatProperties vk.ImageFormatProperties
-	var pImageBuffer *vk.Image
+	var imageBuffer vk.Image
 	var pHostMemory unsafe.Pointer
-	var pDeviceMemory *vk.DeviceMemory
-	var pImageView *vk.ImageView
-	var pQueue *vk.Queue
+	var allocator *vkutil.Allocator
+	var bufferAllocation, imageAllocation *vkutil.Allocation
+	var imageView vk.ImageView
+	var queue vk.Queue
+	var err error
+	// Everything created is registered here and destroyed in reverse order at the end
+	resources := vkutil.NewDeletionQueue()
+	resources.Verbose = true
 
 	//Create Instance
-	var layers = []string{"VK_LAYER_KHRONOS_validation\x00"}
-	// For Windows Only
-	var extensions = []string{"VK_KHR_surface\x00", "VK_KHR_win32_surface\x00"}
-	// For Linux
-	//https://software.intel.com/en-us/articles/api-without-secrets-introduction-to-vulkan-part-2
-	//var extensions = []string{"VK_KHR_surface\x00", "VK_KHR_xcb_surface\x00"}
-	var instanceInfo = vk.InstanceCreateInfo{
-		SType: vk.StructureTypeInstanceCreateInfo,
-		PNext: nil,
-		//Flags:                   nil,
-		PApplicationInfo:        appInfo,
-		EnabledLayerCount:       uint32(len(layers)),
-		PpEnabledLayerNames:     layers,
-		EnabledExtensionCount:   uint32(len(extensions)),
-		PpEnabledExtensionNames: extensions,
-	}
-	result := vk.CreateInstance(&instanceInfo, nil, &instance)
-	if result != vk.Success {
-		fmt.Println(fmt.Errorf("Error creating instance: %v", result))
-	}
-
-	var physicalDeviceIndex int = 1 // 0= NVIDIA Geforce MX150, 1=Intel(R) UHD Graphics 620 [On my HP Laptop]
-
-	physicalDevices = getPhysicalDevices(instance)
-	physicalDeviceProperties = getPhysicalDeviceProperties(physicalDevices[physicalDeviceIndex])
-	physicalDeviceFeatures = getPhysicalDeviceFeatures(physicalDevices[physicalDeviceIndex])
-	memoryProperties = getPhysicalDeviceMemoryProperties(physicalDevices[physicalDeviceIndex])
-	printPhysicalDeviceMemoryProperties(memoryProperties)
-	pQueueFamilyProperties = getPhysicalDeviceQueueFamilyProperties(physicalDevices[physicalDeviceIndex])
-	pLogicalDevice = createDevice(physicalDevices[physicalDeviceIndex], physicalDeviceFeatures)
-	getInstanceLayerProperties()
-	getDeviceLayerProperties(physicalDevices[physicalDeviceIndex])
-	getInstanceExtensionProperties()
-	getDeviceExtensionProperties(physicalDevices[physicalDeviceIndex])
-	deviceWaitTillComplete(pLogicalDevice)
-
-	pBuffer = createBuffer(*pLogicalDevice)
-	imageFormatProperties = getPhysicalDeviceImageProperties(physicalDevices[physicalDeviceIndex])
-	pImageBuffer = createImageBuffer(pLogicalDevice)
+	// Only ask for the validation layer when it is installed, otherwise vkCreateInstance fails
+	layers, err := vkutil.SelectInstanceLayers(vkutil.ValidationLayer)
+	vkutil.OrPanic(err)
+	// No window is created here, so the surface extensions of the platform are only enabled if present
+	extensions, err := vkutil.SelectInstanceExtensions(nil, append(vkutil.PlatformSurfaceExtensions(), vkutil.DebugUtilsExtension))
+	vkutil.OrPanic(err)
+	instance, err = vkutil.CreateInstance(appInfo, layers, extensions)
+	vkutil.OrPanic(err)
+	resources.Push("instance", func() { vk.DestroyInstance(instance, nil) })
+	debugMessenger, err := vkutil.CreateValidationMessenger(instance, layers, extensions, vkutil.DebugSeverityWarning, vkutil.PrintDebugMessage)
+	vkutil.OrPanic(err)
+	resources.Push("debug messenger", debugMessenger.Destroy)
+
+	physicalDevices, err = vkutil.GetPhysicalDevices(instance)
+	vkutil.OrPanic(err)
+	// Score the GPUs instead of hardcoding an index that only fits one laptop,
+	// set Name or VendorID in the preferences to force a specific one
+	selectedDevice, deviceCandidates, err := vkutil.SelectPhysicalDevice(instance, vkutil.DeviceRequirements{
+		QueueFlags: vk.QueueGraphicsBit,
+		Extensions: []string{"VK_KHR_swapchain"},
+	}, vkutil.DevicePreferences{})
+	vkutil.PrintDeviceCandidates(deviceCandidates)
+	vkutil.OrPanic(err)
+	var physicalDeviceIndex int = selectedDevice.Index
+	physicalDeviceProperties = vkutil.GetPhysicalDeviceProperties(physicalDevices[physicalDeviceIndex])
+	physicalDeviceFeatures = vkutil.GetPhysicalDeviceFeatures(physicalDevices[physicalDeviceIndex])
+	memoryProperties = vkutil.GetPhysicalDeviceMemoryProperties(physicalDevices[physicalDeviceIndex])
+	vkutil.PrintPhysicalDeviceMemoryProperties(memoryProperties)
+	pQueueFamilyProperties = vkutil.GetPhysicalDeviceQueueFamilyProperties(physicalDevices[physicalDeviceIndex])
+	// No surface in this exercise, so no present family is looked for
+	queueFamilies, err := vkutil.FindQueueFamilies(physicalDevices[physicalDeviceIndex], vk.NullSurface)
+	vkutil.OrPanic(err)
+	vkutil.OrPanic(queueFamilies.Require(false))
+	logicalDevice, err = vkutil.CreateDevice(physicalDevices[physicalDeviceIndex], queueFamilies, []string{"VK_KHR_swapchain"}, nil)
+	vkutil.OrPanic(err)
+	resources.Push("logical device", func() { vk.DestroyDevice(logicalDevice, nil) })
+	vkutil.PrintInstanceLayerProperties()
+	vkutil.PrintDeviceLayerProperties(physicalDevices[physicalDeviceIndex])
+	vkutil.PrintInstanceExtensionProperties()
+	vkutil.PrintDeviceExtensionProperties(physicalDevices[physicalDeviceIndex])
+	vkutil.OrPanic(vkutil.DeviceWaitTillComplete(logicalDevice))
+	// Buffers and images get a range of a few big blocks instead of one vkAllocateMemory each
+	allocator = vkutil.NewAllocator(logicalDevice, physicalDevices[physicalDeviceIndex], vkutil.AllocatorConfig{})
+	resources.PushErr("allocator", allocator.Destroy)
+
+	buffer, bufferAllocation, err = vkutil.CreateBufferWithMemory(logicalDevice, allocator, 1024*1024, vk.BufferUsageFlags(vk.BufferUsageTransferSrcBit|vk.BufferUsageTransferDstBit),
+		vk.MemoryPropertyFlags(vk.MemoryPropertyDeviceLocalBit), 0, "transfer buffer")
+	vkutil.OrPanic(err)
+	resources.Push("transfer buffer", func() {
+		vk.DestroyBuffer(logicalDevice, buffer, nil)
+		allocator.Free(bufferAllocation)
+	})
+	imageFormatProperties, err = vkutil.GetPhysicalDeviceImageProperties(physicalDevices[physicalDeviceIndex], vk.FormatR8g8b8a8Unorm, vk.ImageType3d, vk.ImageTilingLinear, vk.ImageUsageFlags(vk.ImageUsageColorAttachmentBit), 0)
+	vkutil.OrPanic(err)
+	imageBuffer, err = vkutil.CreateImageBuffer(logicalDevice, vk.FormatR8g8b8a8Unorm, vk.Extent3D{Width: 1024, Height: 1024, Depth: 1}, vkutil.MipLevelCount(vk.Extent2D{Width: 1024, Height: 1024}), vk.ImageUsageFlags(vk.ImageUsageSampledBit))
+	vkutil.OrPanic(err)
 	// List Supported Image Format by GPU
 	//checkSupportedImageFormat(physicalDevices[physicalDeviceIndex])
-	getBufferMemoryRequirements(*pLogicalDevice, *pBuffer)
-	pHostMemory, pDeviceMemory = mapHostMemoryForImage(*pLogicalDevice, memoryProperties, *pImageBuffer)
-	bindImageMemory(*pLogicalDevice, *pImageBuffer, pDeviceMemory)
-	pImageView = createImageView(*pLogicalDevice, *pImageBuffer)
-	pQueue = getDeviceQueue(*pLogicalDevice)
-	//pBufferView = createBufferView(*pLogicalDevice, *pBuffer)
-	// Get the memory properties of the physical device.
-	vk.GetPhysicalDeviceMemoryProperties(physicalDevices[physicalDeviceIndex], &memoryProperties)
+	vkutil.PrintMemoryRequirements(vkutil.GetBufferMemoryRequirements(logicalDevice, buffer))
+	// The memory has to be mappable, device local memory is preferred when the GPU offers it host visible
+	imageAllocation, err = allocator.AllocateForImage(imageBuffer, vk.ImageTilingOptimal,
+		vk.MemoryPropertyFlags(vk.MemoryPropertyHostVisibleBit),
+		vk.MemoryPropertyFlags(vk.MemoryPropertyDeviceLocalBit|vk.MemoryPropertyHostCoherentBit), "image buffer")
+	vkutil.OrPanic(err)
+	resources.Push("image buffer", func() {
+		vk.DestroyImage(logicalDevice, imageBuffer, nil)
+		allocator.Free(imageAllocation)
+	})
+	pHostMemory, err = imageAllocation.Map()
+	vkutil.OrPanic(err)
+	imageView, err = vkutil.CreateImageView(logicalDevice, imageBuffer, vk.FormatR8g8b8a8Unorm)
+	vkutil.OrPanic(err)
+	resources.Push("image view", func() { vk.DestroyImageView(logicalDevice, imageView, nil) })
+	queue = vkutil.GetDeviceQueues(logicalDevice, queueFamilies).Graphics
 
 	//Command Buffer recording
-	commandPool = createCommandPool(*pLogicalDevice)
-	commandBuffers = allocateCommandBuffers(*pLogicalDevice, *commandPool, 2)
-	beginCommandBuffer(commandBuffers)
+	commandPool, err = vkutil.CreateCommandPool(logicalDevice, queueFamilies.Graphics, vk.CommandPoolCreateFlags(vk.CommandPoolCreateResetCommandBufferBit|vk.CommandPoolCreateTransientBit))
+	vkutil.OrPanic(err)
+	// Destroying the pool frees its command buffers too
+	resources.Push("command pool", func() { vk.DestroyCommandPool(logicalDevice, commandPool, nil) })
+	commandBuffers, err = vkutil.AllocateCommandBuffers(logicalDevice, commandPool, 2)
+	vkutil.OrPanic(err)
+	vkutil.OrPanic(vkutil.BeginCommandBuffers(commandBuffers, 0))
 
 	// Verbose - Please don't remove, ignore
-	physicalDeviceProperties.Deref()
 	fmt.Println("\n===========================\n    OUTPUTS    \n===========================")
 	fmt.Println("Physical Devices present....", physicalDevices)
 	//Physical Device name
@@ -105,118 +131,26 @@ func main() {
 	fmt.Println(physicalDeviceFeatures)
 	fmt.Println(memoryProperties)
 	fmt.Println(pQueueFamilyProperties)
-	printDeviceQueueFamilyProperties(pQueueFamilyProperties)
-	fmt.Printf("%T, %v", pLogicalDevice, pLogicalDevice)
-	fmt.Println(pBuffer, dstBuffer)
+	vkutil.PrintDeviceQueueFamilyProperties(pQueueFamilyProperties)
+	fmt.Printf("%T, %v", logicalDevice, logicalDevice)
+	fmt.Println(buffer, dstBuffer)
+	fmt.Println("Buffer Memory ", bufferAllocation.Memory, " Offset ", bufferAllocation.Offset)
 	fmt.Println(&imageFormatProperties)
 	fmt.Println(commandPool)
 	fmt.Println(commandBuffers)
-	fmt.Println(pImageBuffer)
+	fmt.Println(imageBuffer)
 	fmt.Println("Host Memory Pointer ", pHostMemory)
-	fmt.Println("Image Buffer View Pointer ", pImageView)
-	fmt.Println("Device Queue......", pQueue)
-
-	//Cleaningup code
-	vk.FreeCommandBuffers(*pLogicalDevice, *commandPool, 1, commandBuffers)
-	vk.DestroyCommandPool(*pLogicalDevice, *commandPool, nil)
+	fmt.Println("Image Buffer View Pointer ", imageView)
+	fmt.Println("Device Queue......", queue)
+
+	//Cleaningup code, in the reverse order of creation
+	vkutil.OrPanic(vkutil.DeviceWaitTillComplete(logicalDevice))
+	allocator.PrintStats()
+	resources.PrintAlive()
+	vkutil.OrPanic(resources.Close())
 }
 
 func recordCommandIntoCommandBuffer(commandBuffer vk.CommandBuffer) {}
-func beginCommandBuffer(commandBuffer []vk.CommandBuffer) {
-	fmt.Println("Begin Command Buffers.................")
-	var commandBufferBeginInfo = vk.CommandBufferBeginInfo{
-		SType: vk.StructureTypeCommandBufferBeginInfo,
-		Flags: 0x0,
-	}
-	for _, cmdBuffer := range commandBuffer {
-		result := vk.BeginCommandBuffer(cmdBuffer, &commandBufferBeginInfo)
-		if result != vk.Success {
-			fmt.Printf("Failed to begin command buffer with error : %v", result)
-		}
-	}
-}
-
-func allocateCommandBuffers(pLogicalDevice vk.Device, pCommandPool vk.CommandPool, count uint32) []vk.CommandBuffer {
-	fmt.Println("Allocating command buffer...............")
-	var commandBuffers []vk.CommandBuffer
-	commandBuffers = make([]vk.CommandBuffer, count)
-	var commandBufferAllocateInfo = vk.CommandBufferAllocateInfo{
-		SType:              vk.StructureTypeCommandBufferAllocateInfo,
-		CommandPool:        pCommandPool,
-		Level:              vk.CommandBufferLevelPrimary,
-		CommandBufferCount: count,
-	}
-	result := vk.AllocateCommandBuffers(pLogicalDevice, &commandBufferAllocateInfo, commandBuffers)
-	if result != vk.Success {
-		fmt.Printf("Failed to create command buffer with error : %v", result)
-	}
-	return commandBuffers
-}
-
-func createCommandPool(pLogicalDevice vk.Device) *vk.CommandPool {
-	fmt.Println("Creating Command Pool........")
-	// Create CommandPool first
-	var commandPool vk.CommandPool
-	var commandPollCreateInfo = vk.CommandPoolCreateInfo{
-		SType:            vk.StructureTypeCommandPoolCreateInfo,
-		Flags:            vk.CommandPoolCreateFlags(vk.CommandPoolCreateResetCommandBufferBit | vk.CommandPoolCreateTransientBit),
-		QueueFamilyIndex: 0,
-	}
-	result := vk.CreateCommandPool(pLogicalDevice, &commandPollCreateInfo, nil, &commandPool)
-	if result != vk.Success {
-		fmt.Printf("Failed to create command pool with error : %v", result)
-	}
-	return &commandPool
-}
-
-func getDeviceQueue(pLogicalDevice vk.Device) *vk.Queue {
-	fmt.Println("Getting Device Queue................")
-	var queue vk.Queue
-	vk.GetDeviceQueue(pLogicalDevice, 0, 0, &queue)
-	return &queue
-}
-
-func createImageView(pLogicalDevice vk.Device, imageBuffer vk.Image) *vk.ImageView {
-	fmt.Println("Creating Image View........")
-	var imageView vk.ImageView
-	var imageViewCreateInfo = vk.ImageViewCreateInfo{
-		SType:    vk.StructureTypeImageViewCreateInfo,
-		Image:    imageBuffer,
-		ViewType: vk.ImageViewType2d,     // It must be compatible with Image Buffer's ImageType in ImageCreateInfo struct
-		Format:   vk.FormatR8g8b8a8Unorm, // It's same as Format in Image Buffer's ImageCreateInfo struct
-		Components: vk.ComponentMapping{
-			R: vk.ComponentSwizzleR,
-			G: vk.ComponentSwizzleG,
-			B: vk.ComponentSwizzleB,
-			A: vk.ComponentSwizzleA,
-		},
-		SubresourceRange: vk.ImageSubresourceRange{
-			AspectMask: vk.ImageAspectFlags(vk.ImageAspectColorBit),
-			LevelCount: 1,
-			LayerCount: 1,
-		},
-	}
-	result := vk.CreateImageView(pLogicalDevice, &imageViewCreateInfo, nil, &imageView)
-	if result != vk.Success {
-		fmt.Printf("Failed to map memory to image buffer with error : %v", result)
-		return nil
-	}
-	return &imageView
-}
-
-func bindImageMemory(pLogicalDevice vk.Device, imageBuffer vk.Image, pHostMemory *vk.DeviceMemory) {
-	// Before a resource such as a buffer or image can be used by Vulkan to store data, memory must be
-	// bound to it. Before memory is bound to a resource, you should determine what type of memory and
-	// how much of it the resource requires. There is a different function for buffers and for textures.
-	// They are vkGetBufferMemoryRequirements() and vkGetImageMemoryRequirements()
-	// The only difference between these two functions is that vkGetBufferMemoryRequirements() takes a
-	// handle to a buffer object and vkGetImageMemoryRequirements() takes a handle to an image object.
-	fmt.Println("Binding Device Memory............")
-	result := vk.BindImageMemory(pLogicalDevice, imageBuffer, *pHostMemory, vk.DeviceSize(0))
-	if result != vk.Success {
-		fmt.Printf("Failed to failed to bind memory to image with error : %v", result)
-	}
-}
 
 // func createBufferView(pLogicalDevice vk.Device, buffer vk.Buffer) *vk.BufferView {
 // 	var bufferView vk.BufferView
@@ -244,358 +178,10 @@ func bindImageMemory(pLogicalDevice vk.Device, imageBuffer vk.Image, pHostMemory
 
 // }
 
-func mapHostMemoryForImage(pLogicalDevice vk.Device, memoryProperties vk.PhysicalDeviceMemoryProperties, imageBuffer vk.Image) (unsafe.Pointer, *vk.DeviceMemory) {
-	// Access to this memory object must be externally synchronized
-	// A flush is necessary if the host has written to a mapped memory
-	// region and needs the device to see the effect of those writes.
-	// However, if the device writes to a mapped memory region and you
-	// need the host to see the effect of the device’s writes, you need
-	// to invalidate any caches on the host that might now hold stale data.
-	// To do this, call vkInvalidateMappedMemoryRanges()
-	var memReqs vk.MemoryRequirements
-	vk.GetImageMemoryRequirements(pLogicalDevice, imageBuffer, &memReqs)
-	memReqs.Deref()
-	memoryProperties.Deref()
-
-	var pData unsafe.Pointer
-	memAlloc := &vk.MemoryAllocateInfo{
-		SType:           vk.StructureTypeMemoryAllocateInfo,
-		AllocationSize:  memReqs.Size,
-		MemoryTypeIndex: 0, //MemoryTypeIndex is an index into the memory type array returned from a call to vkGetPhysicalDeviceMemoryProperties()
-		// I found index of memory contaning 'MemoryPropertyDeviceLocalBit' and 'MemoryPropertyHostVisibleBit', from the output of printPhysicalDeviceMemoryProperties() function
-	}
-	var mem vk.DeviceMemory
-	result := vk.AllocateMemory(pLogicalDevice, memAlloc, nil, &mem)
-	if result != vk.Success {
-		fmt.Printf("Failed to map memory to image buffer with error : %v", result)
-		return nil, nil
-	}
-	vk.MapMemory(pLogicalDevice, mem, vk.DeviceSize(0), vk.DeviceSize(vk.WholeSize), 0, &pData)
-	return pData, &mem
-}
-
 // func mapHostMemoryForBuffer() {
 
 // }
 
-func createImageBuffer(pLogicalDevice *vk.Device) *vk.Image {
-	fmt.Println("Creating image buffer...........")
-	var imageBuffer vk.Image
-	var extent = vk.Extent3D{
-		Width:  1024,
-		Height: 1024,
-		Depth:  1,
-	}
-	//=================
-	// CUBE MAPS (cube map & cube-map array image)
-	//=================
-	// Set following(top 3) in ImageCreateInfo struct and below 4,5,6 in ImageViewCreateInfo struct and below 7,8,9 inside SubresourceRange section of ImageViewCreateInfo to create a Cube Map:
-	// ImageCreateInfo------------------------
-	// ArrayLayers: 6
-	// TimageType to vk.ImageType2d
-	// Flags: vk.ImageCreateCubeCompatibleBit OR
-	// ImageViewCreateInfo --------------------
-	// ViewType: vk.ImageViewTypeCubeArray
-	// ViewType: vk.ImageViewTypeCube // we create a view of the 2D array parent, but rather than creating a normal 2D (array) view of the image, we create a cube-map view
-	// layerCount : 6 #Cube maps can also form arrays of their own. This is simply a concatenation of an integer multiple of six faces, with each group of six forming a separate cube. To create a cube-map array image, set the viewType field of VkImageViewCreateInfo to VK_IMAGE_VIEW_TYPE_CUBE_ARRAY
-	// baseArrayLayer: #numberOfCubesToMake
-	// layerCount: 6, //To create a single cube, layerCount should be set to 6
-
-	var imageCreateInfo = vk.ImageCreateInfo{
-		SType:                 vk.StructureTypeImageCreateInfo,
-		ImageType:             vk.ImageType2d,
-		Format:                vk.FormatR8g8b8a8Unorm, //vk.FormatR8g8b8a8Srgb,
-		Extent:                extent,
-		MipLevels:             10,
-		ArrayLayers:           1,
-		Samples:               vk.SampleCount1Bit,
-		Tiling:                vk.ImageTilingOptimal,
-		Usage:                 vk.ImageUsageFlags(vk.ImageUsageSampledBit),
-		SharingMode:           vk.SharingModeExclusive,
-		QueueFamilyIndexCount: 0,
-		InitialLayout:         vk.ImageLayoutUndefined,
-	}
-	result := vk.CreateImage(*pLogicalDevice, &imageCreateInfo, nil, &imageBuffer)
-	if result != vk.Success {
-		fmt.Printf("Failed to create image buffer with error : %v", result)
-	}
-	return &imageBuffer
-}
-
-func getBufferMemoryRequirements(pLogicalDevice vk.Device, buffer vk.Buffer) {
-	var memoryProperties = map[string]vk.MemoryPropertyFlagBits{
-		"MemoryPropertyDeviceLocalBit":     vk.MemoryPropertyDeviceLocalBit,
-		"MemoryPropertyHostVisibleBit":     vk.MemoryPropertyHostVisibleBit,
-		"MemoryPropertyHostCoherentBit":    vk.MemoryPropertyHostCoherentBit,
-		"MemoryPropertyHostCachedBit":      vk.MemoryPropertyHostCachedBit,
-		"MemoryPropertyLazilyAllocatedBit": vk.MemoryPropertyLazilyAllocatedBit,
-		"MemoryPropertyProtectedBit":       vk.MemoryPropertyProtectedBit,
-		"MemoryPropertyFlagBitsMaxEnum":    vk.MemoryPropertyFlagBitsMaxEnum,
-	}
-	fmt.Println("Printing Buffer memory requirements..........")
-	var memoryRequirements vk.MemoryRequirements
-	vk.GetBufferMemoryRequirements(pLogicalDevice, buffer, &memoryRequirements)
-	memoryRequirements.Deref()
-	fmt.Println("\t\t* Size Required ...", memoryRequirements.Size, " Bytes")
-	fmt.Println("\t\t* Alignment Required ...", memoryRequirements.Alignment, " Bytes")
-	fmt.Println("\t\t* MemoryTypes Required ...")
-	for key, mp := range memoryProperties {
-		if mp&vk.MemoryPropertyFlagBits(memoryRequirements.MemoryTypeBits) != 0x00000000 {
-			fmt.Println("\t\t\t\t* ", key)
-		}
-	}
-}
-
-func getPhysicalDeviceImageProperties(physicalDevice vk.PhysicalDevice) vk.ImageFormatProperties {
-	fmt.Println("Physical device supported image format for 3D data of sRGB FormatB8g8r8Unorm Format........") // Another popular format vk.FormatR8g8b8Unorm
-	var imageType vk.ImageType = vk.ImageType3d
-	var imageTiling vk.ImageTiling = vk.ImageTilingLinear
-	//var imageUsageFlags vk.ImageUsageFlagBits
-	var imageCreateFlags vk.ImageCreateFlags
-	var imageFormatProperties vk.ImageFormatProperties
-	result := vk.GetPhysicalDeviceImageFormatProperties(physicalDevice, vk.FormatR8g8b8a8Unorm, imageType, imageTiling, vk.ImageUsageFlags(vk.ImageUsageColorAttachmentBit), imageCreateFlags, &imageFormatProperties)
-	if result != vk.Success {
-		fmt.Printf("Failed to get Physical device supported image formats with error : %v", result)
-	}
-	fmt.Printf("\t*\tImageType: %v\t\t \t\t\n", imageType)
-	fmt.Printf("\t*\tImageTiling: %v\t\t \t\t\n", imageTiling)
-	imageFormatProperties.Deref()
-	extent := imageFormatProperties.MaxExtent
-	maxmipmaplevel := imageFormatProperties.MaxMipLevels
-	maxarraylayers := imageFormatProperties.MaxArrayLayers
-	maxResourceSize := imageFormatProperties.MaxResourceSize
-	extent.Deref()
-	fmt.Printf("\t*\tExtent: \t\t \t\t\n")
-	fmt.Printf("\t\t\tWidth: %v\t\t\n", extent.Width)
-	fmt.Printf("\t\t\tHeight: %v\t\t\n", extent.Height)
-	fmt.Printf("\t\t\tDepth: %v\t\t\n", extent.Depth)
-	fmt.Printf("\t*\tMaxMipMap Levels: %v\t\t \t\t\n", maxmipmaplevel)
-	fmt.Printf("\t*\tMaxArrayLayers: %v\t\t \t\t\n", maxarraylayers)
-	fmt.Printf("\t*\tMaxResourceSize: %v\t\t \t\t\n", maxResourceSize)
-	return imageFormatProperties
-}
-
-func createBuffer(device vk.Device) *vk.Buffer {
-	fmt.Println("Creating buffer..........")
-	var buffer vk.Buffer
-	var bufferCreateInfo = vk.BufferCreateInfo{
-		SType:       vk.StructureTypeBufferCreateInfo,
-		Flags:       0x0,
-		Size:        1024 * 1024, // 1Mb or vk.DeviceSize(vertexData.Sizeof()) in cause 'Usage' is set vk.BufferUsageVertexBufferBit
-		Usage:       vk.BufferUsageFlags(vk.BufferUsageTransferSrcBit | vk.BufferUsageTransferDstBit),
-		SharingMode: vk.SharingModeExclusive,
-	}
-	result := vk.CreateBuffer(device, &bufferCreateInfo, nil, &buffer)
-	if result != vk.Success {
-		fmt.Printf("Failed to create buffer : %v", result)
-	}
-	return &buffer
-}
-
-func deviceWaitTillComplete(pLogicalDevice *vk.Device) {
-	// Wait till the device is finished executing any work on behalf of your application
-	// Wait on the host for the completion of outstanding queue operations for all queues on a given logical device
-	fmt.Println("Waiting for the device to complete all pending queue operations.....")
-	result := vk.DeviceWaitIdle(*pLogicalDevice)
-	if result != vk.Success {
-		fmt.Printf("Failed to wait for device to complete the execution with error : %v", result)
-	}
-	fmt.Println("All the queue operations are complete, device is free now....")
-}
-func getDeviceExtensionProperties(physicalDevice vk.PhysicalDevice) {
-	fmt.Println("Listing available Extensions for device only..............")
-	var propertyCount uint32
-	vk.EnumerateDeviceExtensionProperties(physicalDevice, "", &propertyCount, nil)
-	var pProperties []vk.ExtensionProperties = make([]vk.ExtensionProperties, propertyCount)
-	vk.EnumerateDeviceExtensionProperties(physicalDevice, "", &propertyCount, pProperties)
-	for i := 0; i < int(propertyCount); i++ {
-		p := pProperties[i]
-		p.Deref()
-		fmt.Printf("\t*\tExtensionName: %v\n\t\tSpecVersion: %v\n", vk.ToString(p.ExtensionName[:]), p.SpecVersion)
-	}
-}
-
-func getInstanceExtensionProperties() {
-	fmt.Println("Listing available Extensions for instance only..............")
-	var propertyCount uint32
-	//vk.EnumerateInstanceLayerProperties(&propertyCount, nil)
-	vk.EnumerateInstanceExtensionProperties("", &propertyCount, nil)
-	var pProperties []vk.ExtensionProperties = make([]vk.ExtensionProperties, propertyCount)
-	//vk.EnumerateInstanceLayerProperties(&propertyCount, pProperties)
-	vk.EnumerateInstanceExtensionProperties("", &propertyCount, pProperties)
-	for i := 0; i < int(propertyCount); i++ {
-		p := pProperties[i]
-		p.Deref()
-		fmt.Printf("\t*\tExtensionName: %v\n\t\tSpecVersion: %v\n", vk.ToString(p.ExtensionName[:]), p.SpecVersion)
-	}
-}
-
-func getDeviceLayerProperties(physicalDevice vk.PhysicalDevice) {
-	fmt.Println("Listing available layers for device only..............")
-	var propertyCount uint32
-	vk.EnumerateDeviceLayerProperties(physicalDevice, &propertyCount, nil)
-	var pProperties []vk.LayerProperties = make([]vk.LayerProperties, propertyCount)
-	vk.EnumerateDeviceLayerProperties(physicalDevice, &propertyCount, pProperties)
-	for i := 0; i < int(propertyCount); i++ {
-		p := pProperties[i]
-		p.Deref()
-		fmt.Printf("\t*\tName: %v\n\t\tDescription: %v\n\t\tSpecVersion: %v\n\t\tImplementationVersion: %v\n", vk.ToString(p.LayerName[:]), vk.ToString(p.Description[:]), p.SpecVersion, p.ImplementationVersion)
-	}
-}
-
-func getInstanceLayerProperties() {
-	fmt.Println("Listing available layers for instance only..............")
-	var propertyCount uint32
-	vk.EnumerateInstanceLayerProperties(&propertyCount, nil)
-	var pProperties []vk.LayerProperties = make([]vk.LayerProperties, propertyCount)
-	vk.EnumerateInstanceLayerProperties(&propertyCount, pProperties)
-	for i := 0; i < int(propertyCount); i++ {
-		p := pProperties[i]
-		p.Deref()
-		fmt.Printf("\t*\tName: %v\n\t\tDescription: %v\n\t\tSpecVersion: %v\n\t\tImplementationVersion: %v\n", vk.ToString(p.LayerName[:]), vk.ToString(p.Description[:]), p.SpecVersion, p.ImplementationVersion)
-	}
-}
-
-func createDevice(physicalDevice vk.PhysicalDevice, physicalDeviceFeatures vk.PhysicalDeviceFeatures) *vk.Device {
-	fmt.Println("Creating Logical device..............")
-	var logicalDevice vk.Device
-	// var physicalDeviceFeatures []vk.PhysicalDeviceFeatures = []vk.PhysicalDeviceFeatures{
-	// 	physicalDeviceFeatures,
-	// }
-	var pEnabledDeviceFeatures []vk.PhysicalDeviceFeatures = make([]vk.PhysicalDeviceFeatures, 1)
-	deviceQueueCreateInfoSlice := []vk.DeviceQueueCreateInfo{{
-		SType: vk.StructureTypeDeviceQueueCreateInfo,
-		//QueueCount:       16,
-		QueueCount:       1,
-		QueueFamilyIndex: 0,
-		PQueuePriorities: []float32{1.0},
-	}}
-	//var deviceExtensions = []string{"VK_KHR_surface\x00"}
-	//var deviceExtensions = []string{"VK_KHR_swapchain\x00"}
-	//var deviceLayers = []string{"VK_LAYER_KHRONOS_validation\x00"}
-	var deviceCreateInfo *vk.DeviceCreateInfo = &vk.DeviceCreateInfo{
-		SType:                vk.StructureTypeDeviceCreateInfo,
-		QueueCreateInfoCount: uint32(len(deviceQueueCreateInfoSlice)),
-		PQueueCreateInfos:    deviceQueueCreateInfoSlice,
-		// EnabledLayerCount:       uint32(len(deviceLayers)),
-		// PpEnabledLayerNames:     deviceLayers,
-		// EnabledExtensionCount:   uint32(len(deviceExtensions)),
-		// PpEnabledExtensionNames: deviceExtensions,
-		// Following will enable given features from slice
-		//PEnabledFeatures:        physicalDeviceFeatures,
-		// Following will return structure of enable features into the slice
-		PEnabledFeatures: pEnabledDeviceFeatures,
-	}
-	result := vk.CreateDevice(physicalDevice, deviceCreateInfo, nil, &logicalDevice)
-	if result != vk.Success {
-		fmt.Println(fmt.Errorf("Error creating Logical device: %v", result))
-		return nil
-	}
-	fmt.Println("Created Logical device..............")
-	return &logicalDevice
-}
-
-func printDeviceQueueFamilyProperties(qfs []vk.QueueFamilyProperties) {
-	fmt.Println("Print device queue properties..............")
-	fmt.Printf("\tFound %v families\n", len(qfs))
-	for idx, qf := range qfs {
-		qf.Deref()
-		fmt.Printf("\tFamily %v, Number of queues in the family (vk.QueueCount): %v\n", idx, qf.QueueCount)
-		flagBits := vk.QueueFlagBits(qf.QueueFlags)
-		if flagBits&vk.QueueGraphicsBit != 0x00000000 {
-			fmt.Printf("\t\tVK_QUEUE_GRAPHICS_BIT \t\t[vk.QueueGraphicsBit] \t\t[0x00000001\\1]\n")
-		}
-		if flagBits&vk.QueueComputeBit != 0x00000000 {
-			fmt.Printf("\t\tVK_QUEUE_COMPUTE_BIT \t\t[vk.QueueComputeBit] \t\t[0x00000002\\2]\n")
-		}
-		if flagBits&vk.QueueTransferBit != 0x00000000 {
-			fmt.Printf("\t\tVK_QUEUE_TRANSFER_BIT \t\t[vk.QueueTransferBit] \t\t[0x00000004\\4]\n")
-		}
-		if flagBits&vk.QueueSparseBindingBit != 0x00000000 {
-			fmt.Printf("\t\tVK_QUEUE_SPARSE_BINDING_BIT \t[vk.QueueSparseBindingBit] \t[0x00000008\\8]\n")
-		}
-		if flagBits&vk.QueueProtectedBit != 0x00000000 {
-			fmt.Printf("\t\tVK_QUEUE_PROTECTED_BIT \t\t[vk.QueueProtectedBit] \t\t[0x00000010\\16]\n")
-		}
-		if flagBits&vk.QueueFlagBitsMaxEnum != 0x00000000 {
-			fmt.Printf("\t\tVK_QUEUE_FLAG_BITS_MAX_ENUM \t[QueueFlagBitsMaxEnumt] \t[0x7FFFFFFF\\2147483647]\n")
-		}
-	}
-}
-
-func getPhysicalDeviceQueueFamilyProperties(physicalDevice vk.PhysicalDevice) []vk.QueueFamilyProperties {
-	var pQueueFamilyPropertyCount uint32
-	vk.GetPhysicalDeviceQueueFamilyProperties(physicalDevice, &pQueueFamilyPropertyCount, nil)
-	var pQueueFamilyProperties []vk.QueueFamilyProperties
-	pQueueFamilyProperties = make([]vk.QueueFamilyProperties, pQueueFamilyPropertyCount)
-	vk.GetPhysicalDeviceQueueFamilyProperties(physicalDevice, &pQueueFamilyPropertyCount, pQueueFamilyProperties)
-	return pQueueFamilyProperties
-}
-
-func printPhysicalDeviceMemoryProperties(memoryProperties vk.PhysicalDeviceMemoryProperties) {
-	fmt.Println("Printing Device memory properties.......................")
-	memoryProperties.Deref()
-	fmt.Printf("\t*\tMemoryTypeCount: %v\t\t \t\t\n", memoryProperties.MemoryTypeCount)
-	fmt.Printf("\t*\tMemoryHeapCount: %v\t\t \t\t\n", memoryProperties.MemoryHeapCount)
-	var memPropertiesFlags = map[string]vk.MemoryPropertyFlagBits{
-		"MemoryPropertyDeviceLocalBit":     vk.MemoryPropertyDeviceLocalBit,
-		"MemoryPropertyHostVisibleBit":     vk.MemoryPropertyHostVisibleBit,
-		"MemoryPropertyHostCoherentBit":    vk.MemoryPropertyHostCoherentBit,
-		"MemoryPropertyHostCachedBit":      vk.MemoryPropertyHostCachedBit,
-		"MemoryPropertyLazilyAllocatedBit": vk.MemoryPropertyLazilyAllocatedBit,
-		"MemoryPropertyProtectedBit":       vk.MemoryPropertyProtectedBit,
-		"MemoryPropertyFlagBitsMaxEnum":    vk.MemoryPropertyFlagBitsMaxEnum,
-	}
-	fmt.Println("Total MemoryTypes Found :", len(memoryProperties.MemoryTypes))
-	for idx, memoryType := range memoryProperties.MemoryTypes { //HeapIndex
-		memoryType.Deref()
-		fmt.Println("\t* MemoryTypes index: ", idx)
-		fmt.Printf("\t\t*\tMemoryType HeapIndex: %v\t\t \t\t\n", memoryType.HeapIndex)
-		fmt.Printf("\t\t*\tMemoryType PropertyFlags: \t\t \t\t\n")
-		for key, memoryProperty := range memPropertiesFlags {
-			if memoryProperty&vk.MemoryPropertyFlagBits(memoryType.PropertyFlags) != 0x00000000 {
-				fmt.Printf("\t\t\t* Memory Property Flags: %v\t\t \t\t\n", key)
-			}
-		}
-	}
-}
-
-func getPhysicalDeviceMemoryProperties(physicalDevice vk.PhysicalDevice) vk.PhysicalDeviceMemoryProperties {
-	var pMemoryProperties vk.PhysicalDeviceMemoryProperties
-	vk.GetPhysicalDeviceMemoryProperties(physicalDevice, &pMemoryProperties)
-	return pMemoryProperties
-}
-
-func getPhysicalDeviceFeatures(physicalDevice vk.PhysicalDevice) vk.PhysicalDeviceFeatures {
-	var pFeatures vk.PhysicalDeviceFeatures
-	vk.GetPhysicalDeviceFeatures(physicalDevice, &pFeatures)
-	return pFeatures
-}
-
-func getPhysicalDeviceProperties(physicalDevice vk.PhysicalDevice) vk.PhysicalDeviceProperties {
-	var pProperties vk.PhysicalDeviceProperties
-	vk.GetPhysicalDeviceProperties(physicalDevice, &pProperties)
-	return pProperties
-}
-
-func getPhysicalDevices(instance vk.Instance) []vk.PhysicalDevice {
-	fmt.Println("[PhysicalDevice] Getting information....")
-	var deviceCount uint32
-	result := vk.EnumeratePhysicalDevices(instance, &deviceCount, nil)
-	if result != vk.Success {
-		fmt.Println(fmt.Errorf("Error getting physical device count: %v", result))
-		return nil
-	}
-	fmt.Printf("\tFound total %v Physical Device(s).....\n", deviceCount)
-	var physicalDevices = make([]vk.PhysicalDevice, deviceCount)
-	result = vk.EnumeratePhysicalDevices(instance, &deviceCount, physicalDevices)
-	if result != vk.Success {
-		fmt.Println(fmt.Errorf("Error getting physical device count: %v", result))
-		return nil
-	}
-	return physicalDevices
-}
-
 func checkSupportedImageFormat(physicalDevice vk.PhysicalDevice) {
 	fmt.Println("Listing all the supported image formats.............................")
 	var formats = map[string]vk.Format{
@@ -1,725 +1,85 @@
+//go:build ignore
+
 package main
 
 import (
 	"fmt"
-	"unsafe"
+	"log"
 
+	"github.com/goodshailesh/My-Vulkan-Projects/vkutil"
+	"github.com/goodshailesh/My-Vulkan-Projects/vkutil/window"
 	"github.com/vulkan-go/glfw/v3.3/glfw"
 	vk "github.com/vulkan-go/vulkan"
 )
 
 func main() {
-	glfw.Init()
-	vk.SetGetInstanceProcAddr(glfw.GetVulkanGetInstanceProcAddress())
-	vk.Init()
-
-	var appInfo *vk.ApplicationInfo = &vk.ApplicationInfo{
-		SType:              vk.StructureTypeApplicationInfo,
-		PNext:              nil,
-		PApplicationName:   "myVulkan Application\x00",
-		ApiVersion:         vk.MakeVersion(1, 0, 0), // Throws 'vulkan error: incompatible driver' error with incorrect version number
-		ApplicationVersion: vk.MakeVersion(1, 0, 0),
-		PEngineName:        "My Game Engine\x00",
-		EngineVersion:      vk.MakeVersion(0, 1, 0),
+	if err := run(); err != nil {
+		log.Fatal(err)
 	}
-	// Resources
-	var instance vk.Instance
-	var physicalDevices []vk.PhysicalDevice
-	// var physicalDeviceProperties vk.PhysicalDeviceProperties
-	// var physicalDeviceFeatures vk.PhysicalDeviceFeatures
-	// var memoryProperties vk.PhysicalDeviceMemoryProperties
-	// var pQueueFamilyProperties []vk.QueueFamilyProperties
-	// var pLogicalDevice *vk.Device
-	// var commandPool *vk.CommandPool
-	// var commandBuffers []vk.CommandBuffer
-	// var pBuffer, dstBuffer *vk.Buffer
-	// var imageFormatProperties vk.ImageFormatProperties
-	// var pImageBuffer *vk.Image
-	// var pHostMemory unsafe.Pointer
-	// var pDeviceMemory *vk.DeviceMemory
-	// var pImageView *vk.ImageView
-	// var pQueue *vk.Queue
-	var window *glfw.Window
-	var pSurface *vk.Surface
-	// var surfaceCapabilities vk.SurfaceCapabilities
-	// var pSwapChain *vk.Swapchain
+}
 
-	//Create Instance
-	var layers = []string{"VK_LAYER_KHRONOS_validation\x00"}
-	// For Windows Only
-	var extensions = []string{"VK_KHR_surface\x00", "VK_KHR_win32_surface\x00"}
-	// For Linux
-	//https://software.intel.com/en-us/articles/api-without-secrets-introduction-to-vulkan-part-2
-	//var extensions = []string{"VK_KHR_surface\x00", "VK_KHR_xcb_surface\x00"}
-	var instanceInfo = vk.InstanceCreateInfo{
-		SType: vk.StructureTypeInstanceCreateInfo,
-		PNext: nil,
-		//Flags:                   nil,
-		PApplicationInfo:        appInfo,
-		EnabledLayerCount:       uint32(len(layers)),
-		PpEnabledLayerNames:     layers,
-		EnabledExtensionCount:   uint32(len(extensions)),
-		PpEnabledExtensionNames: extensions,
+// run lists the surface formats and image formats the GPU supports. The local
+// copies of the create*/get* helpers this program started with printed
+// "Failed to ..." and carried on with zero handles; the vkutil versions
+// return the error instead.
+func run() error {
+	if err := glfw.Init(); err != nil {
+		return fmt.Errorf("glfw.Init failed with %w", err)
 	}
-	result := vk.CreateInstance(&instanceInfo, nil, &instance)
-	if result != vk.Success {
-		fmt.Println(fmt.Errorf("Error creating instance: %v", result))
+	defer glfw.Terminate()
+	vkutil.SetGetInstanceProcAddr(glfw.GetVulkanGetInstanceProcAddress())
+	if err := vk.Init(); err != nil {
+		return fmt.Errorf("vk.Init failed with %w", err)
 	}
-
-	var physicalDeviceIndex int = 0 // 0= NVIDIA Geforce MX150, 1=Intel(R) UHD Graphics 620 [On my HP Laptop]
-
-	physicalDevices = getPhysicalDevices(instance)
-	// physicalDeviceProperties = getPhysicalDeviceProperties(physicalDevices[physicalDeviceIndex])
-	// physicalDeviceFeatures = getPhysicalDeviceFeatures(physicalDevices[physicalDeviceIndex])
-	// memoryProperties = getPhysicalDeviceMemoryProperties(physicalDevices[physicalDeviceIndex])
-	// printPhysicalDeviceMemoryProperties(memoryProperties)
-	// pQueueFamilyProperties = getPhysicalDeviceQueueFamilyProperties(physicalDevices[physicalDeviceIndex])
-	// pLogicalDevice = createDevice(physicalDevices[physicalDeviceIndex], physicalDeviceFeatures)
-	// getInstanceLayerProperties()
-	// getDeviceLayerProperties(physicalDevices[physicalDeviceIndex])
-	// getInstanceExtensionProperties()
-	// getDeviceExtensionProperties(physicalDevices[physicalDeviceIndex])
-	// deviceWaitTillComplete(pLogicalDevice)
-
-	// pBuffer = createBuffer(*pLogicalDevice)
-	// imageFormatProperties = getPhysicalDeviceImageProperties(physicalDevices[physicalDeviceIndex])
-	// pImageBuffer = createImageBuffer(pLogicalDevice)
-	// // List Supported Image Format by GPU
-	// //checkSupportedImageFormat(physicalDevices[physicalDeviceIndex])
-	// getBufferMemoryRequirements(*pLogicalDevice, *pBuffer)
-	// pHostMemory, pDeviceMemory = mapHostMemoryForImage(*pLogicalDevice, memoryProperties, *pImageBuffer)
-	// bindImageMemory(*pLogicalDevice, *pImageBuffer, pDeviceMemory)
-	// pImageView = createImageView(*pLogicalDevice, *pImageBuffer)
-	// pQueue = getDeviceQueue(*pLogicalDevice)
-	// //pBufferView = createBufferView(*pLogicalDevice, *pBuffer)
-	// // Get the memory properties of the physical device.
-	// vk.GetPhysicalDeviceMemoryProperties(physicalDevices[physicalDeviceIndex], &memoryProperties)
-
-	//Command Buffer recording
-	// commandPool = createCommandPool(*pLogicalDevice)
-	// commandBuffers = allocateCommandBuffers(*pLogicalDevice, *commandPool, 2)
-	// beginCommandBuffer(commandBuffers)
+	resources := vkutil.NewDeletionQueue()
+	defer resources.Close()
 
 	// Window creation related
-	window = createWindow()
-	listRequiredExtensions(window)
-	pSurface = createWindowSurface(&instance, window)
-	//surfaceCapabilities = getPhysicalDeviceSurfaceCapabilities(physicalDevices[physicalDeviceIndex], *pSurface)
-	// List the all the Images Formats supported by GPU
-	getPhysicalDeviceSurfaceFormats(physicalDevices[physicalDeviceIndex], *pSurface)
-	// pSwapChain = createSwapChain(*pLogicalDevice, pSurface, surfaceCapabilities)
-
-	// // Verbose - Please don't remove, ignore
-	// physicalDeviceProperties.Deref()
-	// fmt.Println("\n===========================\n    OUTPUTS    \n===========================")
-	// fmt.Println("Physical Devices present....", physicalDevices)
-	// //Physical Device name
-	// fmt.Println(vk.ToString(physicalDeviceProperties.DeviceName[:]))
-	// fmt.Println(physicalDeviceFeatures)
-	// fmt.Println(memoryProperties)
-	// fmt.Println(pQueueFamilyProperties)
-	// printDeviceQueueFamilyProperties(pQueueFamilyProperties)
-	// fmt.Printf("%T, %v", pLogicalDevice, pLogicalDevice)
-	// fmt.Println(pBuffer, dstBuffer)
-	// fmt.Println(&imageFormatProperties)
-	// fmt.Println(commandPool)
-	// fmt.Println(commandBuffers)
-	// fmt.Println(pImageBuffer)
-	// fmt.Println("Host Memory Pointer ", pHostMemory)
-	// fmt.Println("Image Buffer View Pointer ", pImageView)
-	// fmt.Println("Device Queue......", pQueue)
-	// fmt.Println("SwapChain Pointer........", pSwapChain)
-
-	// //Cleaningup code
-	// vk.FreeCommandBuffers(*pLogicalDevice, *commandPool, 1, commandBuffers)
-	// vk.DestroyCommandPool(*pLogicalDevice, *commandPool, nil)
-	// vk.DestroySwapchain(*pLogicalDevice, *pSwapChain, nil)
-}
-
-//Windows Creation related Begins
-
-func createWindow() *glfw.Window {
-	fmt.Println("Creating Window......")
-	glfw.WindowHint(glfw.ClientAPI, glfw.NoAPI)
-	window, err := glfw.CreateWindow(640, 480, "Vulkan Info", nil, nil)
+	glfwWindow, err := window.CreateWindow(640, 480, "Vulkan Info")
 	if err != nil {
-		fmt.Println("Failed to create window with error :", err)
-	}
-	return window
-}
-
-func listRequiredExtensions(window *glfw.Window) {
-	fmt.Println("Listing Vulkan instance extension required by GLFW for creating Vulkan surfaces for GLFW windows..........")
-	var extensions []string = window.GetRequiredInstanceExtensions()
-	for idx, e := range extensions {
-		fmt.Printf("\t* [%v] %v\n", idx, e)
+		return err
 	}
-}
+	resources.Push("window", glfwWindow.Destroy)
+	window.PrintRequiredExtensions(glfwWindow)
 
-func createWindowSurface(instance *vk.Instance, window *glfw.Window) *vk.Surface {
-	fmt.Println("Creating window surface.......")
-	pSurface, err := window.CreateWindowSurface(*instance, nil)
+	//Create Instance
+	layers, err := vkutil.SelectInstanceLayers(vkutil.ValidationLayer)
 	if err != nil {
-		fmt.Println("Failed to create window surface with error :", err)
-	}
-	var surface = vk.SurfaceFromPointer(pSurface)
-	return &surface
-}
-
-func getPhysicalDeviceSurfaceCapabilities(physicalDevice vk.PhysicalDevice, surface vk.Surface) vk.SurfaceCapabilities {
-	fmt.Println("Listing Device Surface Capabilities..........")
-	var surfaceCapabilities vk.SurfaceCapabilities
-	result := vk.GetPhysicalDeviceSurfaceCapabilities(physicalDevice, surface, &surfaceCapabilities)
-	if result != vk.Success {
-		fmt.Printf("Failed to get physical device properties with error : %v", result)
-	}
-	surfaceCapabilities.Deref()
-	fmt.Printf("\t* MinImageCount : \t%v\n", surfaceCapabilities.MinImageCount)
-	fmt.Printf("\t* MaxImageCount : \t%v\n", surfaceCapabilities.MaxImageCount)
-	fmt.Printf("\t* CurrentExtent : \t%v\n", surfaceCapabilities.CurrentExtent)
-	fmt.Printf("\t* MinImageExtent : \t%v\n", surfaceCapabilities.MinImageExtent)
-	fmt.Printf("\t* MaxImageExtent  : \t%v\n", surfaceCapabilities.MaxImageExtent)
-	fmt.Printf("\t* MaxImageArrayLayers : \t%v\n", surfaceCapabilities.MaxImageArrayLayers)
-	fmt.Printf("\t* SurfaceTransformFlags : \t%v\n", surfaceCapabilities.SupportedTransforms)
-	fmt.Printf("\t* CurrentTransform : \t%v\n", surfaceCapabilities.CurrentTransform)
-	fmt.Printf("\t* SupportedCompositeAlpha : \t%v\n", surfaceCapabilities.SupportedCompositeAlpha)
-	fmt.Printf("\t* SupportedUsageFlags : \t%v\n", surfaceCapabilities.SupportedUsageFlags)
-	return surfaceCapabilities
-}
-
-// How to list all supported Image formats by the Physical Device GPU
-func getPhysicalDeviceSurfaceFormats(physicalDevice vk.PhysicalDevice, surface vk.Surface) {
-	fmt.Println("Listing all supported Image formats and ColorSpaces by the Physical Device [GPU].............")
-	var formatCount uint32
-	vk.GetPhysicalDeviceSurfaceFormats(physicalDevice, surface, &formatCount, nil)
-	fmt.Println("\t Total ", formatCount, " Image Format(s) supported by the GPU...")
-	formats := make([]vk.SurfaceFormat, formatCount)
-	vk.GetPhysicalDeviceSurfaceFormats(physicalDevice, surface, &formatCount, formats)
-
-	for _, format := range formats {
-		format.Deref()
-		fmt.Println("\t\t* Format = ", format.Format, " ColorSpace = ", format.ColorSpace)
-	}
-
-	// chosenFormat := -1
-	// for i := 0; i < int(formatCount); i++ {
-	// 	formats[i].Deref()
-	// 	if formats[i].Format == vk.FormatB8g8r8a8Unorm ||
-	// 		formats[i].Format == vk.FormatR8g8b8a8Unorm {
-	// 		chosenFormat = i
-	// 		break
-	// 	}
-	// }
-	// if chosenFormat < 0 {
-	// 	err := fmt.Errorf("vk.GetPhysicalDeviceSurfaceFormats not found suitable format")
-	// 	return s, err
-	// }
-}
-
-func createSwapChain(logicalDevice vk.Device, surface *vk.Surface, surfaceCapabilities vk.SurfaceCapabilities) *vk.Swapchain {
-	fmt.Println("Creating swap chain..............")
-	queueFamily := []uint32{0}
-	var swapChain vk.Swapchain
-	var swapChainCreateInfo = vk.SwapchainCreateInfo{
-		SType:                 vk.StructureTypeSwapchainCreateInfo,
-		Surface:               *surface,
-		MinImageCount:         3,
-		ImageFormat:           vk.FormatB8g8r8a8Srgb,
-		ImageColorSpace:       vk.ColorspaceSrgbNonlinear,
-		ImageExtent:           surfaceCapabilities.CurrentExtent,
-		ImageUsage:            vk.ImageUsageFlags(vk.ImageUsageColorAttachmentBit),
-		QueueFamilyIndexCount: 1,
-		PQueueFamilyIndices:   queueFamily,
-		PresentMode:           vk.PresentModeFifo,
-		OldSwapchain:          vk.NullSwapchain,
-		Clipped:               vk.False,
-	}
-	result := vk.CreateSwapchain(logicalDevice, &swapChainCreateInfo, nil, &swapChain)
-	if result != vk.Success {
-		fmt.Printf("Failed to create command buffer with error : %v", result)
-		return nil
-	}
-	return &swapChain
-}
-
-//Windows Creation related Ends
-
-func recordCommandIntoCommandBuffer(commandBuffer vk.CommandBuffer) {}
-func beginCommandBuffer(commandBuffer []vk.CommandBuffer) {
-	fmt.Println("Begin Command Buffers.................")
-	var commandBufferBeginInfo = vk.CommandBufferBeginInfo{
-		SType: vk.StructureTypeCommandBufferBeginInfo,
-		Flags: 0x0,
-	}
-	for _, cmdBuffer := range commandBuffer {
-		result := vk.BeginCommandBuffer(cmdBuffer, &commandBufferBeginInfo)
-		if result != vk.Success {
-			fmt.Printf("Failed to begin command buffer with error : %v", result)
-		}
-	}
-}
-
-func allocateCommandBuffers(pLogicalDevice vk.Device, pCommandPool vk.CommandPool, count uint32) []vk.CommandBuffer {
-	fmt.Println("Allocating command buffer...............")
-	var commandBuffers []vk.CommandBuffer
-	commandBuffers = make([]vk.CommandBuffer, count)
-	var commandBufferAllocateInfo = vk.CommandBufferAllocateInfo{
-		SType:              vk.StructureTypeCommandBufferAllocateInfo,
-		CommandPool:        pCommandPool,
-		Level:              vk.CommandBufferLevelPrimary,
-		CommandBufferCount: count,
-	}
-	result := vk.AllocateCommandBuffers(pLogicalDevice, &commandBufferAllocateInfo, commandBuffers)
-	if result != vk.Success {
-		fmt.Printf("Failed to create command buffer with error : %v", result)
-	}
-	return commandBuffers
-}
-
-func createCommandPool(pLogicalDevice vk.Device) *vk.CommandPool {
-	fmt.Println("Creating Command Pool........")
-	// Create CommandPool first
-	var commandPool vk.CommandPool
-	var commandPollCreateInfo = vk.CommandPoolCreateInfo{
-		SType:            vk.StructureTypeCommandPoolCreateInfo,
-		Flags:            vk.CommandPoolCreateFlags(vk.CommandPoolCreateResetCommandBufferBit | vk.CommandPoolCreateTransientBit),
-		QueueFamilyIndex: 0,
-	}
-	result := vk.CreateCommandPool(pLogicalDevice, &commandPollCreateInfo, nil, &commandPool)
-	if result != vk.Success {
-		fmt.Printf("Failed to create command pool with error : %v", result)
-	}
-	return &commandPool
-}
-
-func getDeviceQueue(pLogicalDevice vk.Device) *vk.Queue {
-	fmt.Println("Getting Device Queue................")
-	var queue vk.Queue
-	vk.GetDeviceQueue(pLogicalDevice, 0, 0, &queue)
-	return &queue
-}
-
-func createImageView(pLogicalDevice vk.Device, imageBuffer vk.Image) *vk.ImageView {
-	fmt.Println("Creating Image View........")
-	var imageView vk.ImageView
-	var imageViewCreateInfo = vk.ImageViewCreateInfo{
-		SType:    vk.StructureTypeImageViewCreateInfo,
-		Image:    imageBuffer,
-		ViewType: vk.ImageViewType2d,     // It must be compatible with Image Buffer's ImageType in ImageCreateInfo struct
-		Format:   vk.FormatR8g8b8a8Unorm, // It's same as Format in Image Buffer's ImageCreateInfo struct
-		Components: vk.ComponentMapping{
-			R: vk.ComponentSwizzleR,
-			G: vk.ComponentSwizzleG,
-			B: vk.ComponentSwizzleB,
-			A: vk.ComponentSwizzleA,
-		},
-		SubresourceRange: vk.ImageSubresourceRange{
-			AspectMask: vk.ImageAspectFlags(vk.ImageAspectColorBit),
-			LevelCount: 1,
-			LayerCount: 1,
-		},
-	}
-	result := vk.CreateImageView(pLogicalDevice, &imageViewCreateInfo, nil, &imageView)
-	if result != vk.Success {
-		fmt.Printf("Failed to map memory to image buffer with error : %v", result)
-		return nil
-	}
-	return &imageView
-}
-
-func bindImageMemory(pLogicalDevice vk.Device, imageBuffer vk.Image, pHostMemory *vk.DeviceMemory) {
-	// Before a resource such as a buffer or image can be used by Vulkan to store data, memory must be
-	// bound to it. Before memory is bound to a resource, you should determine what type of memory and
-	// how much of it the resource requires. There is a different function for buffers and for textures.
-	// They are vkGetBufferMemoryRequirements() and vkGetImageMemoryRequirements()
-	// The only difference between these two functions is that vkGetBufferMemoryRequirements() takes a
-	// handle to a buffer object and vkGetImageMemoryRequirements() takes a handle to an image object.
-	fmt.Println("Binding Device Memory............")
-	result := vk.BindImageMemory(pLogicalDevice, imageBuffer, *pHostMemory, vk.DeviceSize(0))
-	if result != vk.Success {
-		fmt.Printf("Failed to failed to bind memory to image with error : %v", result)
-	}
-}
-
-// func createBufferView(pLogicalDevice vk.Device, buffer vk.Buffer) *vk.BufferView {
-// 	var bufferView vk.BufferView
-// 	var bufferViewCreateInfo = vk.BufferViewCreateInfo{
-// 		SType:  vk.StructureTypeBufferViewCreateInfo,
-// 		PNext:  nil,
-// 		Buffer: buffer,
-// 		Offset: 0,
-// 		Range:  1024, //Just uing 1Kb only
-// 	}
-// 	result := vk.CreateBufferView(pLogicalDevice, &bufferViewCreateInfo, nil, &bufferView)
-// 	if result != vk.Success {
-// 		fmt.Printf("Failed to create buffer view with error : %v", result)
-// 		return nil
-// 	}
-// 	return &bufferView
-// }
-
-// func bindMemoryToBuffer() {
-// 	vertexData := linmath.ArrayFloat32([]float32{
-// 		-1, -1, 0,
-// 		1, -1, 0,
-// 		0, 1, 0,
-// 	})
-
-// }
-
-func mapHostMemoryForImage(pLogicalDevice vk.Device, memoryProperties vk.PhysicalDeviceMemoryProperties, imageBuffer vk.Image) (unsafe.Pointer, *vk.DeviceMemory) {
-	// Access to this memory object must be externally synchronized
-	// A flush is necessary if the host has written to a mapped memory
-	// region and needs the device to see the effect of those writes.
-	// However, if the device writes to a mapped memory region and you
-	// need the host to see the effect of the device’s writes, you need
-	// to invalidate any caches on the host that might now hold stale data.
-	// To do this, call vkInvalidateMappedMemoryRanges()
-	var memReqs vk.MemoryRequirements
-	vk.GetImageMemoryRequirements(pLogicalDevice, imageBuffer, &memReqs)
-	memReqs.Deref()
-	memoryProperties.Deref()
-
-	var pData unsafe.Pointer
-	memAlloc := &vk.MemoryAllocateInfo{
-		SType:           vk.StructureTypeMemoryAllocateInfo,
-		AllocationSize:  memReqs.Size,
-		MemoryTypeIndex: 0, //MemoryTypeIndex is an index into the memory type array returned from a call to vkGetPhysicalDeviceMemoryProperties()
-		// I found index of memory contaning 'MemoryPropertyDeviceLocalBit' and 'MemoryPropertyHostVisibleBit', from the output of printPhysicalDeviceMemoryProperties() function
-	}
-	var mem vk.DeviceMemory
-	result := vk.AllocateMemory(pLogicalDevice, memAlloc, nil, &mem)
-	if result != vk.Success {
-		fmt.Printf("Failed to map memory to image buffer with error : %v", result)
-		return nil, nil
-	}
-	vk.MapMemory(pLogicalDevice, mem, vk.DeviceSize(0), vk.DeviceSize(vk.WholeSize), 0, &pData)
-	return pData, &mem
-}
-
-// func mapHostMemoryForBuffer() {
-
-// }
-
-func createImageBuffer(pLogicalDevice *vk.Device) *vk.Image {
-	fmt.Println("Creating image buffer...........")
-	var imageBuffer vk.Image
-	var extent = vk.Extent3D{
-		Width:  1024,
-		Height: 1024,
-		Depth:  1,
-	}
-	//=================
-	// CUBE MAPS (cube map & cube-map array image)
-	//=================
-	// Set following(top 3) in ImageCreateInfo struct and below 4,5,6 in ImageViewCreateInfo struct and below 7,8,9 inside SubresourceRange section of ImageViewCreateInfo to create a Cube Map:
-	// ImageCreateInfo------------------------
-	// ArrayLayers: 6
-	// TimageType to vk.ImageType2d
-	// Flags: vk.ImageCreateCubeCompatibleBit OR
-	// ImageViewCreateInfo --------------------
-	// ViewType: vk.ImageViewTypeCubeArray
-	// ViewType: vk.ImageViewTypeCube // we create a view of the 2D array parent, but rather than creating a normal 2D (array) view of the image, we create a cube-map view
-	// layerCount : 6 #Cube maps can also form arrays of their own. This is simply a concatenation of an integer multiple of six faces, with each group of six forming a separate cube. To create a cube-map array image, set the viewType field of VkImageViewCreateInfo to VK_IMAGE_VIEW_TYPE_CUBE_ARRAY
-	// baseArrayLayer: #numberOfCubesToMake
-	// layerCount: 6, //To create a single cube, layerCount should be set to 6
-
-	var imageCreateInfo = vk.ImageCreateInfo{
-		SType:                 vk.StructureTypeImageCreateInfo,
-		ImageType:             vk.ImageType2d,
-		Format:                vk.FormatR8g8b8a8Unorm, //vk.FormatR8g8b8a8Srgb,
-		Extent:                extent,
-		MipLevels:             10,
-		ArrayLayers:           1,
-		Samples:               vk.SampleCount1Bit,
-		Tiling:                vk.ImageTilingOptimal,
-		Usage:                 vk.ImageUsageFlags(vk.ImageUsageSampledBit),
-		SharingMode:           vk.SharingModeExclusive,
-		QueueFamilyIndexCount: 0,
-		InitialLayout:         vk.ImageLayoutUndefined,
-	}
-	result := vk.CreateImage(*pLogicalDevice, &imageCreateInfo, nil, &imageBuffer)
-	if result != vk.Success {
-		fmt.Printf("Failed to create image buffer with error : %v", result)
-	}
-	return &imageBuffer
-}
-
-func getBufferMemoryRequirements(pLogicalDevice vk.Device, buffer vk.Buffer) {
-	var memoryProperties = map[string]vk.MemoryPropertyFlagBits{
-		"MemoryPropertyDeviceLocalBit":     vk.MemoryPropertyDeviceLocalBit,
-		"MemoryPropertyHostVisibleBit":     vk.MemoryPropertyHostVisibleBit,
-		"MemoryPropertyHostCoherentBit":    vk.MemoryPropertyHostCoherentBit,
-		"MemoryPropertyHostCachedBit":      vk.MemoryPropertyHostCachedBit,
-		"MemoryPropertyLazilyAllocatedBit": vk.MemoryPropertyLazilyAllocatedBit,
-		"MemoryPropertyProtectedBit":       vk.MemoryPropertyProtectedBit,
-		"MemoryPropertyFlagBitsMaxEnum":    vk.MemoryPropertyFlagBitsMaxEnum,
-	}
-	fmt.Println("Printing Buffer memory requirements..........")
-	var memoryRequirements vk.MemoryRequirements
-	vk.GetBufferMemoryRequirements(pLogicalDevice, buffer, &memoryRequirements)
-	memoryRequirements.Deref()
-	fmt.Println("\t\t* Size Required ...", memoryRequirements.Size, " Bytes")
-	fmt.Println("\t\t* Alignment Required ...", memoryRequirements.Alignment, " Bytes")
-	fmt.Println("\t\t* MemoryTypes Required ...")
-	for key, mp := range memoryProperties {
-		if mp&vk.MemoryPropertyFlagBits(memoryRequirements.MemoryTypeBits) != 0x00000000 {
-			fmt.Println("\t\t\t\t* ", key)
-		}
-	}
-}
-
-func getPhysicalDeviceImageProperties(physicalDevice vk.PhysicalDevice) vk.ImageFormatProperties {
-	fmt.Println("Physical device supported image format for 3D data of sRGB FormatB8g8r8Unorm Format........") // Another popular format vk.FormatR8g8b8Unorm
-	var imageType vk.ImageType = vk.ImageType3d
-	var imageTiling vk.ImageTiling = vk.ImageTilingLinear
-	//var imageUsageFlags vk.ImageUsageFlagBits
-	var imageCreateFlags vk.ImageCreateFlags
-	var imageFormatProperties vk.ImageFormatProperties
-	result := vk.GetPhysicalDeviceImageFormatProperties(physicalDevice, vk.FormatR8g8b8a8Unorm, imageType, imageTiling, vk.ImageUsageFlags(vk.ImageUsageColorAttachmentBit), imageCreateFlags, &imageFormatProperties)
-	if result != vk.Success {
-		fmt.Printf("Failed to get Physical device supported image formats with error : %v", result)
-	}
-	fmt.Printf("\t*\tImageType: %v\t\t \t\t\n", imageType)
-	fmt.Printf("\t*\tImageTiling: %v\t\t \t\t\n", imageTiling)
-	imageFormatProperties.Deref()
-	extent := imageFormatProperties.MaxExtent
-	maxmipmaplevel := imageFormatProperties.MaxMipLevels
-	maxarraylayers := imageFormatProperties.MaxArrayLayers
-	maxResourceSize := imageFormatProperties.MaxResourceSize
-	extent.Deref()
-	fmt.Printf("\t*\tExtent: \t\t \t\t\n")
-	fmt.Printf("\t\t\tWidth: %v\t\t\n", extent.Width)
-	fmt.Printf("\t\t\tHeight: %v\t\t\n", extent.Height)
-	fmt.Printf("\t\t\tDepth: %v\t\t\n", extent.Depth)
-	fmt.Printf("\t*\tMaxMipMap Levels: %v\t\t \t\t\n", maxmipmaplevel)
-	fmt.Printf("\t*\tMaxArrayLayers: %v\t\t \t\t\n", maxarraylayers)
-	fmt.Printf("\t*\tMaxResourceSize: %v\t\t \t\t\n", maxResourceSize)
-	return imageFormatProperties
-}
-
-func createBuffer(device vk.Device) *vk.Buffer {
-	fmt.Println("Creating buffer..........")
-	queueFamilyIdx := []uint32{0}
-	var buffer vk.Buffer
-	var bufferCreateInfo = vk.BufferCreateInfo{
-		SType:                 vk.StructureTypeBufferCreateInfo,
-		Flags:                 0x0,
-		Size:                  1024 * 1024, // 1Mb or vk.DeviceSize(vertexData.Sizeof()) in cause 'Usage' is set vk.BufferUsageVertexBufferBit
-		Usage:                 vk.BufferUsageFlags(vk.BufferUsageTransferSrcBit | vk.BufferUsageTransferDstBit),
-		SharingMode:           vk.SharingModeExclusive,
-		QueueFamilyIndexCount: 1,
-		PQueueFamilyIndices:   queueFamilyIdx,
-	}
-	result := vk.CreateBuffer(device, &bufferCreateInfo, nil, &buffer)
-	if result != vk.Success {
-		fmt.Printf("Failed to create buffer : %v", result)
-	}
-	return &buffer
-}
-
-func deviceWaitTillComplete(pLogicalDevice *vk.Device) {
-	// Wait till the device is finished executing any work on behalf of your application
-	// Wait on the host for the completion of outstanding queue operations for all queues on a given logical device
-	fmt.Println("Waiting for the device to complete all pending queue operations.....")
-	result := vk.DeviceWaitIdle(*pLogicalDevice)
-	if result != vk.Success {
-		fmt.Printf("Failed to wait for device to complete the execution with error : %v", result)
-	}
-	fmt.Println("All the queue operations are complete, device is free now....")
-}
-func getDeviceExtensionProperties(physicalDevice vk.PhysicalDevice) {
-	fmt.Println("Listing available Extensions for device only..............")
-	var propertyCount uint32
-	vk.EnumerateDeviceExtensionProperties(physicalDevice, "", &propertyCount, nil)
-	var pProperties []vk.ExtensionProperties = make([]vk.ExtensionProperties, propertyCount)
-	vk.EnumerateDeviceExtensionProperties(physicalDevice, "", &propertyCount, pProperties)
-	for i := 0; i < int(propertyCount); i++ {
-		p := pProperties[i]
-		p.Deref()
-		fmt.Printf("\t*\tExtensionName: %v\n\t\tSpecVersion: %v\n", vk.ToString(p.ExtensionName[:]), p.SpecVersion)
-	}
-}
-
-func getInstanceExtensionProperties() {
-	fmt.Println("Listing available Extensions for instance only..............")
-	var propertyCount uint32
-	//vk.EnumerateInstanceLayerProperties(&propertyCount, nil)
-	vk.EnumerateInstanceExtensionProperties("", &propertyCount, nil)
-	var pProperties []vk.ExtensionProperties = make([]vk.ExtensionProperties, propertyCount)
-	//vk.EnumerateInstanceLayerProperties(&propertyCount, pProperties)
-	vk.EnumerateInstanceExtensionProperties("", &propertyCount, pProperties)
-	for i := 0; i < int(propertyCount); i++ {
-		p := pProperties[i]
-		p.Deref()
-		fmt.Printf("\t*\tExtensionName: %v\n\t\tSpecVersion: %v\n", vk.ToString(p.ExtensionName[:]), p.SpecVersion)
-	}
-}
-
-func getDeviceLayerProperties(physicalDevice vk.PhysicalDevice) {
-	fmt.Println("Listing available layers for device only..............")
-	var propertyCount uint32
-	vk.EnumerateDeviceLayerProperties(physicalDevice, &propertyCount, nil)
-	var pProperties []vk.LayerProperties = make([]vk.LayerProperties, propertyCount)
-	vk.EnumerateDeviceLayerProperties(physicalDevice, &propertyCount, pProperties)
-	for i := 0; i < int(propertyCount); i++ {
-		p := pProperties[i]
-		p.Deref()
-		fmt.Printf("\t*\tName: %v\n\t\tDescription: %v\n\t\tSpecVersion: %v\n\t\tImplementationVersion: %v\n", vk.ToString(p.LayerName[:]), vk.ToString(p.Description[:]), p.SpecVersion, p.ImplementationVersion)
-	}
-}
-
-func getInstanceLayerProperties() {
-	fmt.Println("Listing available layers for instance only..............")
-	var propertyCount uint32
-	vk.EnumerateInstanceLayerProperties(&propertyCount, nil)
-	var pProperties []vk.LayerProperties = make([]vk.LayerProperties, propertyCount)
-	vk.EnumerateInstanceLayerProperties(&propertyCount, pProperties)
-	for i := 0; i < int(propertyCount); i++ {
-		p := pProperties[i]
-		p.Deref()
-		fmt.Printf("\t*\tName: %v\n\t\tDescription: %v\n\t\tSpecVersion: %v\n\t\tImplementationVersion: %v\n", vk.ToString(p.LayerName[:]), vk.ToString(p.Description[:]), p.SpecVersion, p.ImplementationVersion)
+		return err
 	}
-}
-
-func createDevice(physicalDevice vk.PhysicalDevice, physicalDeviceFeatures vk.PhysicalDeviceFeatures) *vk.Device {
-	fmt.Println("Creating Logical device..............")
-	var logicalDevice vk.Device
-	// var physicalDeviceFeatures []vk.PhysicalDeviceFeatures = []vk.PhysicalDeviceFeatures{
-	// 	physicalDeviceFeatures,
-	// }
-	var pEnabledDeviceFeatures []vk.PhysicalDeviceFeatures = make([]vk.PhysicalDeviceFeatures, 1)
-	deviceQueueCreateInfoSlice := []vk.DeviceQueueCreateInfo{{
-		SType: vk.StructureTypeDeviceQueueCreateInfo,
-		//QueueCount:       16,
-		QueueCount:       1,
-		QueueFamilyIndex: 0,
-		PQueuePriorities: []float32{1.0},
-	}}
-	//var deviceExtensions = []string{"VK_KHR_surface\x00"}
-	//var deviceExtensions = []string{"VK_KHR_swapchain\x00"}
-	//var deviceLayers = []string{"VK_LAYER_KHRONOS_validation\x00"}
-	var deviceCreateInfo *vk.DeviceCreateInfo = &vk.DeviceCreateInfo{
-		SType:                vk.StructureTypeDeviceCreateInfo,
-		QueueCreateInfoCount: uint32(len(deviceQueueCreateInfoSlice)),
-		PQueueCreateInfos:    deviceQueueCreateInfoSlice,
-		// EnabledLayerCount:       uint32(len(deviceLayers)),
-		// PpEnabledLayerNames:     deviceLayers,
-		// EnabledExtensionCount:   uint32(len(deviceExtensions)),
-		// PpEnabledExtensionNames: deviceExtensions,
-		// Following will enable given features from slice
-		//PEnabledFeatures:        physicalDeviceFeatures,
-		// Following will return structure of enable features into the slice
-		PEnabledFeatures: pEnabledDeviceFeatures,
+	extensions, err := window.InstanceExtensions(glfwWindow)
+	if err != nil {
+		return err
 	}
-	result := vk.CreateDevice(physicalDevice, deviceCreateInfo, nil, &logicalDevice)
-	if result != vk.Success {
-		fmt.Println(fmt.Errorf("Error creating Logical device: %v", result))
-		return nil
+	instance, err := vkutil.CreateInstance(vkutil.NewApplicationInfo("myVulkan Application", "My Game Engine"), layers, extensions)
+	if err != nil {
+		return err
 	}
-	fmt.Println("Created Logical device..............")
-	return &logicalDevice
-}
-
-func printDeviceQueueFamilyProperties(qfs []vk.QueueFamilyProperties) {
-	fmt.Println("Print device queue properties..............")
-	fmt.Printf("\tFound %v families\n", len(qfs))
-	for idx, qf := range qfs {
-		qf.Deref()
-		fmt.Printf("\tFamily %v, Number of queues in the family (vk.QueueCount): %v\n", idx, qf.QueueCount)
-		flagBits := vk.QueueFlagBits(qf.QueueFlags)
-		if flagBits&vk.QueueGraphicsBit != 0x00000000 {
-			fmt.Printf("\t\tVK_QUEUE_GRAPHICS_BIT \t\t[vk.QueueGraphicsBit] \t\t[0x00000001\\1]\n")
-		}
-		if flagBits&vk.QueueComputeBit != 0x00000000 {
-			fmt.Printf("\t\tVK_QUEUE_COMPUTE_BIT \t\t[vk.QueueComputeBit] \t\t[0x00000002\\2]\n")
-		}
-		if flagBits&vk.QueueTransferBit != 0x00000000 {
-			fmt.Printf("\t\tVK_QUEUE_TRANSFER_BIT \t\t[vk.QueueTransferBit] \t\t[0x00000004\\4]\n")
-		}
-		if flagBits&vk.QueueSparseBindingBit != 0x00000000 {
-			fmt.Printf("\t\tVK_QUEUE_SPARSE_BINDING_BIT \t[vk.QueueSparseBindingBit] \t[0x00000008\\8]\n")
-		}
-		if flagBits&vk.QueueProtectedBit != 0x00000000 {
-			fmt.Printf("\t\tVK_QUEUE_PROTECTED_BIT \t\t[vk.QueueProtectedBit] \t\t[0x00000010\\16]\n")
-		}
-		if flagBits&vk.QueueFlagBitsMaxEnum != 0x00000000 {
-			fmt.Printf("\t\tVK_QUEUE_FLAG_BITS_MAX_ENUM \t[QueueFlagBitsMaxEnumt] \t[0x7FFFFFFF\\2147483647]\n")
-		}
+	resources.Push("instance", func() { vk.DestroyInstance(instance, nil) })
+	surface, err := window.CreateWindowSurface(instance, glfwWindow)
+	if err != nil {
+		return err
 	}
-}
-
-func getPhysicalDeviceQueueFamilyProperties(physicalDevice vk.PhysicalDevice) []vk.QueueFamilyProperties {
-	var pQueueFamilyPropertyCount uint32
-	vk.GetPhysicalDeviceQueueFamilyProperties(physicalDevice, &pQueueFamilyPropertyCount, nil)
-	var pQueueFamilyProperties []vk.QueueFamilyProperties
-	pQueueFamilyProperties = make([]vk.QueueFamilyProperties, pQueueFamilyPropertyCount)
-	vk.GetPhysicalDeviceQueueFamilyProperties(physicalDevice, &pQueueFamilyPropertyCount, pQueueFamilyProperties)
-	return pQueueFamilyProperties
-}
+	resources.Push("surface", func() { vk.DestroySurface(instance, surface, nil) })
 
-func printPhysicalDeviceMemoryProperties(memoryProperties vk.PhysicalDeviceMemoryProperties) {
-	fmt.Println("Printing Device memory properties.......................")
-	memoryProperties.Deref()
-	fmt.Printf("\t*\tMemoryTypeCount: %v\t\t \t\t\n", memoryProperties.MemoryTypeCount)
-	fmt.Printf("\t*\tMemoryHeapCount: %v\t\t \t\t\n", memoryProperties.MemoryHeapCount)
-	var memPropertiesFlags = map[string]vk.MemoryPropertyFlagBits{
-		"MemoryPropertyDeviceLocalBit":     vk.MemoryPropertyDeviceLocalBit,
-		"MemoryPropertyHostVisibleBit":     vk.MemoryPropertyHostVisibleBit,
-		"MemoryPropertyHostCoherentBit":    vk.MemoryPropertyHostCoherentBit,
-		"MemoryPropertyHostCachedBit":      vk.MemoryPropertyHostCachedBit,
-		"MemoryPropertyLazilyAllocatedBit": vk.MemoryPropertyLazilyAllocatedBit,
-		"MemoryPropertyProtectedBit":       vk.MemoryPropertyProtectedBit,
-		"MemoryPropertyFlagBitsMaxEnum":    vk.MemoryPropertyFlagBitsMaxEnum,
-	}
-	fmt.Println("Total MemoryTypes Found :", len(memoryProperties.MemoryTypes))
-	for idx, memoryType := range memoryProperties.MemoryTypes { //HeapIndex
-		memoryType.Deref()
-		fmt.Println("\t* MemoryTypes index: ", idx)
-		fmt.Printf("\t\t*\tMemoryType HeapIndex: %v\t\t \t\t\n", memoryType.HeapIndex)
-		fmt.Printf("\t\t*\tMemoryType PropertyFlags: \t\t \t\t\n")
-		for key, memoryProperty := range memPropertiesFlags {
-			if memoryProperty&vk.MemoryPropertyFlagBits(memoryType.PropertyFlags) != 0x00000000 {
-				fmt.Printf("\t\t\t* Memory Property Flags: %v\t\t \t\t\n", key)
-			}
-		}
+	selectedDevice, deviceCandidates, err := vkutil.SelectPhysicalDevice(instance, vkutil.DeviceRequirements{Surface: surface}, vkutil.DevicePreferences{})
+	vkutil.PrintDeviceCandidates(deviceCandidates)
+	if err != nil {
+		return err
 	}
-}
-
-func getPhysicalDeviceMemoryProperties(physicalDevice vk.PhysicalDevice) vk.PhysicalDeviceMemoryProperties {
-	var pMemoryProperties vk.PhysicalDeviceMemoryProperties
-	vk.GetPhysicalDeviceMemoryProperties(physicalDevice, &pMemoryProperties)
-	return pMemoryProperties
-}
-
-func getPhysicalDeviceFeatures(physicalDevice vk.PhysicalDevice) vk.PhysicalDeviceFeatures {
-	var pFeatures vk.PhysicalDeviceFeatures
-	vk.GetPhysicalDeviceFeatures(physicalDevice, &pFeatures)
-	return pFeatures
-}
-
-func getPhysicalDeviceProperties(physicalDevice vk.PhysicalDevice) vk.PhysicalDeviceProperties {
-	var pProperties vk.PhysicalDeviceProperties
-	vk.GetPhysicalDeviceProperties(physicalDevice, &pProperties)
-	return pProperties
-}
-
-func getPhysicalDevices(instance vk.Instance) []vk.PhysicalDevice {
-	fmt.Println("[PhysicalDevice] Getting information....")
-	var deviceCount uint32
-	result := vk.EnumeratePhysicalDevices(instance, &deviceCount, nil)
-	if result != vk.Success {
-		fmt.Println(fmt.Errorf("Error getting physical device count: %v", result))
-		return nil
+	// List the all the Images Formats supported by GPU
+	fmt.Println("Listing all supported Image formats and ColorSpaces by the Physical Device [GPU].............")
+	formats, err := vkutil.GetPhysicalDeviceSurfaceFormats(selectedDevice.PhysicalDevice, surface)
+	if err != nil {
+		return err
 	}
-	fmt.Printf("\tFound total %v Physical Device(s).....\n", deviceCount)
-	var physicalDevices = make([]vk.PhysicalDevice, deviceCount)
-	result = vk.EnumeratePhysicalDevices(instance, &deviceCount, physicalDevices)
-	if result != vk.Success {
-		fmt.Println(fmt.Errorf("Error getting physical device count: %v", result))
-		return nil
+	fmt.Println("\t Total ", len(formats), " Image Format(s) supported by the GPU...")
+	for _, format := range formats {
+		fmt.Println("\t\t* Format = ", format.Format, " ColorSpace = ", format.ColorSpace)
 	}
-	return physicalDevices
+	// List Supported Image Format by GPU
+	//checkSupportedImageFormat(selectedDevice.PhysicalDevice)
+	return nil
 }
 
 func checkSupportedImageFormat(physicalDevice vk.PhysicalDevice) {
@@ -1,28 +1,29 @@
 package main
 
 import (
+	"flag"
 	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
 	"time"
-	"unsafe"
 
+	"github.com/goodshailesh/My-Vulkan-Projects/vkutil"
+	"github.com/goodshailesh/My-Vulkan-Projects/vkutil/window"
 	"github.com/vulkan-go/glfw/v3.3/glfw"
 	vk "github.com/vulkan-go/vulkan"
 )
 
+var texturePath = flag.String("texture", "", "PNG or JPEG file to upload, a checkerboard when empty")
+var cubeMapPath = flag.String("cubemap", "", "PNG or JPEG cube map laid out as a cross or strip, six colored faces when empty")
+
 func main() {
-	glfw.Init()
-	vk.SetGetInstanceProcAddr(glfw.GetVulkanGetInstanceProcAddress())
-	vk.Init()
+	flag.Parse()
+	vkutil.OrPanic(glfw.Init())
+	vkutil.SetGetInstanceProcAddr(glfw.GetVulkanGetInstanceProcAddress())
+	vkutil.OrPanic(vk.Init())
 
-	var appInfo *vk.ApplicationInfo = &vk.ApplicationInfo{
-		SType:              vk.StructureTypeApplicationInfo,
-		PNext:              nil,
-		PApplicationName:   "myVulkan Application\x00",
-		ApiVersion:         vk.MakeVersion(1, 0, 0), // Throws 'vulkan error: incompatible driver' error with incorrect version number
-		ApplicationVersion: vk.MakeVersion(1, 0, 0),
-		PEngineName:        "My Game Engine\x00",
-		EngineVersion:      vk.MakeVersion(0, 1, 0),
-	}
+	var appInfo = vkutil.NewApplicationInfo("myVulkan Application", "My Game Engine")
 	// Resources
 	var instance vk.Instance
 	var physicalDevices []vk.PhysicalDevice
@@ -30,90 +31,164 @@ func main() {
 	var physicalDeviceFeatures vk.PhysicalDeviceFeatures
 	var memoryProperties vk.PhysicalDeviceMemoryProperties
 	var pQueueFamilyProperties []vk.QueueFamilyProperties
-	var pLogicalDevice *vk.Device
-	var commandPool *vk.CommandPool
+	var logicalDevice vk.Device
+	var commandPool vk.CommandPool
 	var commandBuffers []vk.CommandBuffer
-	var pBuffer, dstBuffer *vk.Buffer
+	var vertexBuffer, indexBuffer *vkutil.DeviceBuffer
 	var imageFormatProperties vk.ImageFormatProperties
-	var pImageBuffer *vk.Image
-	var pHostMemory unsafe.Pointer
-	var pDeviceMemory *vk.DeviceMemory
-	var pImageView *vk.ImageView
-	var pQueue *vk.Queue
-	var window *glfw.Window
-	var pSurface *vk.Surface
+	var texture, cubeMap *vkutil.Texture
+	var allocator *vkutil.Allocator
+	var queue vk.Queue
+	var glfwWindow *glfw.Window
+	var surface vk.Surface
 	var surfaceCapabilities vk.SurfaceCapabilities
 	var formats []vk.SurfaceFormat
 	var swapChains []vk.Swapchain
+	var err error
+	// Everything created is registered here and destroyed in reverse order at the end
+	resources := vkutil.NewDeletionQueue()
+	resources.Verbose = true
 
 	//Create Instance
-	var layers = []string{"VK_LAYER_KHRONOS_validation\x00"}
-	// For Windows Only
-	var extensions = []string{"VK_KHR_surface\x00", "VK_KHR_win32_surface\x00"}
-	// For Linux
-	//https://software.intel.com/en-us/articles/api-without-secrets-introduction-to-vulkan-part-2
-	//var extensions = []string{"VK_KHR_surface\x00", "VK_KHR_xcb_surface\x00"}
-	var instanceInfo = vk.InstanceCreateInfo{
-		SType: vk.StructureTypeInstanceCreateInfo,
-		PNext: nil,
-		//Flags:                   nil,
-		PApplicationInfo:        appInfo,
-		EnabledLayerCount:       uint32(len(layers)),
-		PpEnabledLayerNames:     layers,
-		EnabledExtensionCount:   uint32(len(extensions)),
-		PpEnabledExtensionNames: extensions,
-	}
-	result := vk.CreateInstance(&instanceInfo, nil, &instance)
-	if result != vk.Success {
-		fmt.Println(fmt.Errorf("Error creating instance: %v", result))
-	}
-
-	var physicalDeviceIndex int = 1 // 0= NVIDIA Geforce MX150, 1=Intel(R) UHD Graphics 620 [On my HP Laptop]
-
-	physicalDevices = getPhysicalDevices(instance)
-	physicalDeviceProperties = getPhysicalDeviceProperties(physicalDevices[physicalDeviceIndex])
-	physicalDeviceFeatures = getPhysicalDeviceFeatures(physicalDevices[physicalDeviceIndex])
-	memoryProperties = getPhysicalDeviceMemoryProperties(physicalDevices[physicalDeviceIndex])
-	printPhysicalDeviceMemoryProperties(memoryProperties)
-	pQueueFamilyProperties = getPhysicalDeviceQueueFamilyProperties(physicalDevices[physicalDeviceIndex])
-	pLogicalDevice = createDevice(physicalDevices[physicalDeviceIndex], physicalDeviceFeatures)
-	getInstanceLayerProperties()
-	getDeviceLayerProperties(physicalDevices[physicalDeviceIndex])
-	getInstanceExtensionProperties()
-	getDeviceExtensionProperties(physicalDevices[physicalDeviceIndex])
-	deviceWaitTillComplete(pLogicalDevice)
-
-	pBuffer = createBuffer(*pLogicalDevice)
-	imageFormatProperties = getPhysicalDeviceImageProperties(physicalDevices[physicalDeviceIndex])
-	pImageBuffer = createImageBuffer(pLogicalDevice)
+	// Only ask for the validation layer when it is installed, otherwise vkCreateInstance fails
+	layers, err := vkutil.SelectInstanceLayers(vkutil.ValidationLayer)
+	vkutil.OrPanic(err)
+	// The window is created first so GLFW can tell which surface extensions this platform needs
+	glfwWindow, err = window.CreateWindow(640, 480, "Vulkan Info")
+	vkutil.OrPanic(err)
+	resources.Push("window", glfwWindow.Destroy)
+	window.PrintRequiredExtensions(glfwWindow)
+	extensions, err := window.InstanceExtensions(glfwWindow, vkutil.DebugUtilsExtension)
+	vkutil.OrPanic(err)
+	instance, err = vkutil.CreateInstance(appInfo, layers, extensions)
+	vkutil.OrPanic(err)
+	resources.Push("instance", func() { vk.DestroyInstance(instance, nil) })
+	debugMessenger, err := vkutil.CreateValidationMessenger(instance, layers, extensions, vkutil.DebugSeverityWarning, vkutil.PrintDebugMessage)
+	vkutil.OrPanic(err)
+	resources.Push("debug messenger", debugMessenger.Destroy)
+
+	// The surface is needed before picking the GPU and its queue families, not every family can present
+	surface, err = window.CreateWindowSurface(instance, glfwWindow)
+	vkutil.OrPanic(err)
+	resources.Push("surface", func() { vk.DestroySurface(instance, surface, nil) })
+
+	physicalDevices, err = vkutil.GetPhysicalDevices(instance)
+	vkutil.OrPanic(err)
+	// Score the GPUs instead of hardcoding an index that only fits one laptop,
+	// set Name or VendorID in the preferences to force a specific one
+	selectedDevice, deviceCandidates, err := vkutil.SelectPhysicalDevice(instance, vkutil.DeviceRequirements{
+		QueueFlags: vk.QueueGraphicsBit,
+		Surface:    surface,
+		Extensions: []string{"VK_KHR_swapchain"},
+	}, vkutil.DevicePreferences{})
+	vkutil.PrintDeviceCandidates(deviceCandidates)
+	vkutil.OrPanic(err)
+	var physicalDeviceIndex int = selectedDevice.Index
+	physicalDeviceProperties = vkutil.GetPhysicalDeviceProperties(physicalDevices[physicalDeviceIndex])
+	physicalDeviceFeatures = vkutil.GetPhysicalDeviceFeatures(physicalDevices[physicalDeviceIndex])
+	memoryProperties = vkutil.GetPhysicalDeviceMemoryProperties(physicalDevices[physicalDeviceIndex])
+	vkutil.PrintPhysicalDeviceMemoryProperties(memoryProperties)
+	pQueueFamilyProperties = vkutil.GetPhysicalDeviceQueueFamilyProperties(physicalDevices[physicalDeviceIndex])
+	queueFamilies, err := vkutil.FindQueueFamilies(physicalDevices[physicalDeviceIndex], surface)
+	vkutil.OrPanic(err)
+	vkutil.OrPanic(queueFamilies.Require(true))
+	fmt.Println("Queue families:", queueFamilies)
+	// Anisotropic filtering is optional, only ask for it where the GPU has it
+	enabledFeatures := vk.PhysicalDeviceFeatures{SamplerAnisotropy: physicalDeviceFeatures.SamplerAnisotropy}
+	logicalDevice, err = vkutil.CreateDevice(physicalDevices[physicalDeviceIndex], queueFamilies, []string{"VK_KHR_swapchain"}, &enabledFeatures)
+	vkutil.OrPanic(err)
+	resources.Push("logical device", func() { vk.DestroyDevice(logicalDevice, nil) })
+	vkutil.PrintInstanceLayerProperties()
+	vkutil.PrintDeviceLayerProperties(physicalDevices[physicalDeviceIndex])
+	vkutil.PrintInstanceExtensionProperties()
+	vkutil.PrintDeviceExtensionProperties(physicalDevices[physicalDeviceIndex])
+	vkutil.OrPanic(vkutil.DeviceWaitTillComplete(logicalDevice))
+	// Buffers and images get a range of a few big blocks instead of one vkAllocateMemory each
+	allocator = vkutil.NewAllocator(logicalDevice, physicalDevices[physicalDeviceIndex], vkutil.AllocatorConfig{})
+	resources.PushErr("allocator", allocator.Destroy)
+
+	// Vertices and indices go through a host visible staging buffer, the copy to
+	// device local memory runs on the transfer queue
+	queues := vkutil.GetDeviceQueues(logicalDevice, queueFamilies)
+	uploader, err := vkutil.NewUploader(logicalDevice, physicalDevices[physicalDeviceIndex], &enabledFeatures, allocator, queueFamilies, queues)
+	vkutil.OrPanic(err)
+	resources.Push("uploader", uploader.Destroy)
+	vertexBuffer, err = vkutil.CreateVertexBuffer(uploader, [][3]float32{
+		{-1, -1, 0},
+		{1, -1, 0},
+		{0, 1, 0},
+	}, "vertex buffer")
+	vkutil.OrPanic(err)
+	resources.Push("vertex buffer", vertexBuffer.Destroy)
+	indexBuffer, err = vkutil.CreateIndexBuffer(uploader, []uint16{0, 1, 2}, "index buffer")
+	vkutil.OrPanic(err)
+	resources.Push("index buffer", indexBuffer.Destroy)
+	imageFormatProperties, err = vkutil.GetPhysicalDeviceImageProperties(physicalDevices[physicalDeviceIndex], vk.FormatR8g8b8a8Unorm, vk.ImageType3d, vk.ImageTilingLinear, vk.ImageUsageFlags(vk.ImageUsageColorAttachmentBit), 0)
+	vkutil.OrPanic(err)
+	// The texture goes through a staging buffer like the vertices, and ends up
+	// SHADER_READ_ONLY_OPTIMAL with a view and a sampler for a descriptor. Its
+	// mip levels are blitted by the GPU, or computed on the CPU for formats it
+	// cannot blit
+	fmt.Println("GPU mipmaps for R8G8B8A8_SRGB: ", vkutil.CanBlitMipmaps(physicalDevices[physicalDeviceIndex], vk.FormatR8g8b8a8Srgb))
+	// Textures filtering alike share a sampler from the cache
+	samplers := vkutil.NewSamplerCache(logicalDevice, physicalDevices[physicalDeviceIndex], &enabledFeatures)
+	resources.Push("samplers", samplers.Destroy)
+	fmt.Println("Max sampler anisotropy: ", samplers.MaxAnisotropy())
+	textureConfig := vkutil.TextureConfig{MaxAnisotropy: 16, Samplers: samplers}
+	if *texturePath != "" {
+		texture, err = uploader.LoadTexture(*texturePath, textureConfig)
+	} else {
+		textureConfig.Name = "checkerboard"
+		texture, err = uploader.CreateTexture(checkerboard(1024, 1024, 64), textureConfig)
+	}
+	vkutil.OrPanic(err)
+	resources.Push("texture", texture.Destroy)
+	// The cube map is six layers of one cube compatible image, +X, -X, +Y, -Y, +Z, -Z
+	cubeConfig := vkutil.TextureConfig{MaxAnisotropy: 16, Samplers: samplers}
+	if *cubeMapPath != "" {
+		cubeMap, err = uploader.LoadCubeMap(*cubeMapPath, vkutil.CubeLayoutAuto, cubeConfig)
+	} else {
+		cubeConfig.Name = "colored cube"
+		cubeMap, err = uploader.CreateTextureArray(coloredFaces(256), vk.ImageViewTypeCube, cubeConfig)
+	}
+	vkutil.OrPanic(err)
+	resources.Push("cube map", cubeMap.Destroy)
 	// List Supported Image Format by GPU
 	//checkSupportedImageFormat(physicalDevices[physicalDeviceIndex])
-	getBufferMemoryRequirements(*pLogicalDevice, *pBuffer)
-	pHostMemory, pDeviceMemory = mapHostMemoryForImage(*pLogicalDevice, memoryProperties, *pImageBuffer)
-	bindImageMemory(*pLogicalDevice, *pImageBuffer, pDeviceMemory)
-	pImageView = createImageView(*pLogicalDevice, *pImageBuffer)
-	pQueue = getDeviceQueue(*pLogicalDevice)
-	//pBufferView = createBufferView(*pLogicalDevice, *pBuffer)
-	// Get the memory properties of the physical device.
-	vk.GetPhysicalDeviceMemoryProperties(physicalDevices[physicalDeviceIndex], &memoryProperties)
+	vkutil.PrintMemoryRequirements(vkutil.GetBufferMemoryRequirements(logicalDevice, vertexBuffer.Buffer))
+	queue = queues.Graphics
 
 	//Command Buffer recording
-	commandPool = createCommandPool(*pLogicalDevice)
-	commandBuffers = allocateCommandBuffers(*pLogicalDevice, *commandPool, 2)
-	beginCommandBuffer(commandBuffers)
+	commandPool, err = vkutil.CreateCommandPool(logicalDevice, queueFamilies.Graphics, vk.CommandPoolCreateFlags(vk.CommandPoolCreateResetCommandBufferBit|vk.CommandPoolCreateTransientBit))
+	vkutil.OrPanic(err)
+	// Destroying the pool frees its command buffers too
+	resources.Push("command pool", func() { vk.DestroyCommandPool(logicalDevice, commandPool, nil) })
+	commandBuffers, err = vkutil.AllocateCommandBuffers(logicalDevice, commandPool, 2)
+	vkutil.OrPanic(err)
+	vkutil.OrPanic(vkutil.BeginCommandBuffers(commandBuffers, 0))
 
 	// Window creation related
-	window = createWindow()
-	listRequiredExtensions(window)
-	pSurface = createWindowSurface(&instance, window)
-	surfaceCapabilities = getPhysicalDeviceSurfaceCapabilities(physicalDevices[physicalDeviceIndex], *pSurface)
+	surfaceCapabilities, err = vkutil.GetPhysicalDeviceSurfaceCapabilities(physicalDevices[physicalDeviceIndex], surface)
+	vkutil.OrPanic(err)
+	vkutil.PrintSurfaceCapabilities(surfaceCapabilities)
 	// List the all the Images Formats supported by GPU
-	formats = getPhysicalDeviceSurfaceFormats(physicalDevices[physicalDeviceIndex], *pSurface)
-	swapChains = createSwapChain(*pLogicalDevice, pSurface, surfaceCapabilities, formats)
-	acquireNextImage(*pLogicalDevice, swapChains)
+	formats, err = vkutil.GetPhysicalDeviceSurfaceFormats(physicalDevices[physicalDeviceIndex], surface)
+	vkutil.OrPanic(err)
+	for _, format := range formats {
+		fmt.Println("\t\t* Format = ", format.Format, " ColorSpace = ", format.ColorSpace)
+	}
+	swapchainConfig, err := vkutil.ResolveSwapchainConfig(physicalDevices[physicalDeviceIndex], surface, vkutil.SwapchainPreferences{
+		Extent: window.FramebufferExtent(glfwWindow),
+	})
+	vkutil.OrPanic(err)
+	vkutil.PrintSwapchainConfig(swapchainConfig)
+	swapChain, err := vkutil.CreateSwapChain(logicalDevice, surface, swapchainConfig, queueFamilies, vk.NullSwapchain)
+	vkutil.OrPanic(err)
+	swapChains = []vk.Swapchain{swapChain}
+	resources.Push("swapchain", func() { vk.DestroySwapchain(logicalDevice, swapChain, nil) })
+	acquireNextImage(logicalDevice, swapChains)
 
 	// Verbose - Please don't remove, ignore
-	physicalDeviceProperties.Deref()
 	fmt.Println("\n===========================\n    OUTPUTS    \n===========================")
 	fmt.Println("Physical Devices present....", physicalDevices)
 	//Physical Device name
@@ -121,167 +196,32 @@ func main() {
 	fmt.Println(physicalDeviceFeatures)
 	fmt.Println(memoryProperties)
 	fmt.Println(pQueueFamilyProperties)
-	printDeviceQueueFamilyProperties(pQueueFamilyProperties)
-	fmt.Printf("%T, %v", pLogicalDevice, pLogicalDevice)
-	fmt.Println(pBuffer, dstBuffer)
+	vkutil.PrintDeviceQueueFamilyProperties(pQueueFamilyProperties)
+	fmt.Printf("%T, %v", logicalDevice, logicalDevice)
+	fmt.Println("Vertex Buffer ", vertexBuffer.Buffer, " Vertices ", vertexBuffer.Count, " Stride ", vertexBuffer.Stride)
+	fmt.Println("Vertex Buffer Memory ", vertexBuffer.Allocation.Memory, " Offset ", vertexBuffer.Allocation.Offset)
+	fmt.Println("Index Buffer ", indexBuffer.Buffer, " Indices ", indexBuffer.Count, " Type ", indexBuffer.IndexType)
+	fmt.Println("Uploads ran on queue family ", uploader.TransferFamily())
 	fmt.Println(&imageFormatProperties)
 	fmt.Println(commandPool)
 	fmt.Println(commandBuffers)
-	fmt.Println(pImageBuffer)
-	fmt.Println("Host Memory Pointer ", pHostMemory)
-	fmt.Println("Image Buffer View Pointer ", pImageView)
-	fmt.Println("Device Queue......", pQueue)
+	fmt.Println("Texture ", texture.Image, " ", texture.Extent.Width, "x", texture.Extent.Height, " ", vkutil.FormatName(texture.Format), " Mip levels ", texture.MipLevels)
+	fmt.Println("Texture View Pointer ", texture.View, " Sampler ", texture.Sampler)
+	fmt.Println("Cube Map ", cubeMap.Image, " ", cubeMap.Extent.Width, "x", cubeMap.Extent.Height, " Layers ", cubeMap.Layers, " View Type ", cubeMap.ViewType, " Mip levels ", cubeMap.MipLevels)
+	fmt.Println("Device Queue......", queue)
 	fmt.Println("SwapChain Pointer........", swapChains)
 
-	//Cleaningup code
-	vk.FreeCommandBuffers(*pLogicalDevice, *commandPool, 1, commandBuffers)
-	vk.DestroyCommandPool(*pLogicalDevice, *commandPool, nil)
-	for _, sc := range swapChains {
-		vk.DestroySwapchain(*pLogicalDevice, sc, nil)
-	}
-	vk.DestroySurface(instance, *pSurface, nil)
-	window.Destroy()
-	vk.DestroyImageView(*pLogicalDevice, *pImageView, nil)
-	//vk.DestroyCommandPool(*pLogicalDevice, *commandPool, nil)
-
-	vk.DestroyBuffer(*pLogicalDevice, *pBuffer, nil)
-	vk.DestroyDevice(*pLogicalDevice, nil)
-	vk.DestroyInstance(instance, nil)
-	// var instance vk.Instance
-	// var physicalDevices []vk.PhysicalDevice
-	// var physicalDeviceProperties vk.PhysicalDeviceProperties
-	// var physicalDeviceFeatures vk.PhysicalDeviceFeatures
-	// var memoryProperties vk.PhysicalDeviceMemoryProperties
-	// var pQueueFamilyProperties []vk.QueueFamilyProperties
-	// var pLogicalDevice *vk.Device
-	// var commandPool *vk.CommandPool
-	// var commandBuffers []vk.CommandBuffer
-	// var pBuffer, dstBuffer *vk.Buffer
-	// var imageFormatProperties vk.ImageFormatProperties
-	// var pImageBuffer *vk.Image
-	// var pHostMemory unsafe.Pointer
-	// var pDeviceMemory *vk.DeviceMemory
-	// var pImageView *vk.ImageView
-
+	//Cleaningup code, in the reverse order of creation
+	vkutil.OrPanic(vkutil.DeviceWaitTillComplete(logicalDevice))
+	allocator.PrintStats()
+	resources.PrintAlive()
+	vkutil.OrPanic(resources.Close())
 }
 
 //Windows Creation related Begins
 
-func createWindow() *glfw.Window {
-	fmt.Println("Creating Window......")
-	glfw.WindowHint(glfw.ClientAPI, glfw.NoAPI)
-	window, err := glfw.CreateWindow(640, 480, "Vulkan Info", nil, nil)
-	if err != nil {
-		fmt.Println("Failed to create window with error :", err)
-	}
-	return window
-}
-
-func listRequiredExtensions(window *glfw.Window) {
-	fmt.Println("Listing Vulkan instance extension required by GLFW for creating Vulkan surfaces for GLFW windows..........")
-	var extensions []string = window.GetRequiredInstanceExtensions()
-	for idx, e := range extensions {
-		fmt.Printf("\t* [%v] %v\n", idx, e)
-	}
-}
-
-func createWindowSurface(instance *vk.Instance, window *glfw.Window) *vk.Surface {
-	fmt.Println("Creating window surface.......")
-	pSurface, err := window.CreateWindowSurface(*instance, nil)
-	if err != nil {
-		fmt.Println("Failed to create window surface with error :", err)
-	}
-	var surface = vk.SurfaceFromPointer(pSurface)
-	return &surface
-}
-
-func getPhysicalDeviceSurfaceCapabilities(physicalDevice vk.PhysicalDevice, surface vk.Surface) vk.SurfaceCapabilities {
-	fmt.Println("Listing Device Surface Capabilities..........")
-	var surfaceCapabilities vk.SurfaceCapabilities
-	result := vk.GetPhysicalDeviceSurfaceCapabilities(physicalDevice, surface, &surfaceCapabilities)
-	if result != vk.Success {
-		fmt.Printf("Failed to get physical device properties with error : %v", result)
-	}
-	surfaceCapabilities.Deref()
-	fmt.Printf("\t* MinImageCount : \t%v\n", surfaceCapabilities.MinImageCount)
-	fmt.Printf("\t* MaxImageCount : \t%v\n", surfaceCapabilities.MaxImageCount)
-	fmt.Printf("\t* CurrentExtent : \t%v\n", surfaceCapabilities.CurrentExtent)
-	fmt.Printf("\t* MinImageExtent : \t%v\n", surfaceCapabilities.MinImageExtent)
-	fmt.Printf("\t* MaxImageExtent  : \t%v\n", surfaceCapabilities.MaxImageExtent)
-	fmt.Printf("\t* MaxImageArrayLayers : \t%v\n", surfaceCapabilities.MaxImageArrayLayers)
-	fmt.Printf("\t* SurfaceTransformFlags : \t%v\n", surfaceCapabilities.SupportedTransforms)
-	fmt.Printf("\t* CurrentTransform : \t%v\n", surfaceCapabilities.CurrentTransform)
-	fmt.Printf("\t* SupportedCompositeAlpha : \t%v\n", surfaceCapabilities.SupportedCompositeAlpha)
-	fmt.Printf("\t* SupportedUsageFlags : \t%v\n", surfaceCapabilities.SupportedUsageFlags)
-	return surfaceCapabilities
-}
-
-// How to list all supported Image formats by the Physical Device GPU
-func getPhysicalDeviceSurfaceFormats(physicalDevice vk.PhysicalDevice, surface vk.Surface) []vk.SurfaceFormat {
-	fmt.Println("Listing all supported Image formats and ColorSpaces by the Physical Device [GPU].............")
-	var formatCount uint32
-	vk.GetPhysicalDeviceSurfaceFormats(physicalDevice, surface, &formatCount, nil)
-	fmt.Println("\t Total ", formatCount, " Image Format(s) supported by the GPU...")
-	formatsPacked := make([]vk.SurfaceFormat, formatCount)
-	formatsUnpacked := make([]vk.SurfaceFormat, formatCount)
-	vk.GetPhysicalDeviceSurfaceFormats(physicalDevice, surface, &formatCount, formatsPacked)
-
-	for _, format := range formatsPacked {
-		format.Deref()
-		formatsUnpacked = append(formatsUnpacked, format)
-		fmt.Println("\t\t* Format = ", format.Format, " ColorSpace = ", format.ColorSpace)
-	}
-	return formatsUnpacked
-
-	// chosenFormat := -1
-	// for i := 0; i < int(formatCount); i++ {
-	// 	formats[i].Deref()
-	// 	if formats[i].Format == vk.FormatB8g8r8a8Unorm ||
-	// 		formats[i].Format == vk.FormatR8g8b8a8Unorm {
-	// 		chosenFormat = i
-	// 		break
-	// 	}
-	// }
-	// if chosenFormat < 0 {
-	// 	err := fmt.Errorf("vk.GetPhysicalDeviceSurfaceFormats not found suitable format")
-	// 	return s, err
-	// }
-}
-
 //func getPhysicalDeviceSurfaceSupport(physicalDevice PhysicalDevice) {}
 
-func createSwapChain(logicalDevice vk.Device, surface *vk.Surface, surfaceCapabilities vk.SurfaceCapabilities, formats []vk.SurfaceFormat) []vk.Swapchain {
-	fmt.Println("Creating swap chain..............")
-	queueFamily := []uint32{0}
-	var swapChains []vk.Swapchain
-	swapChains = make([]vk.Swapchain, 1)
-	var swapChainCreateInfo = vk.SwapchainCreateInfo{
-		SType:                 vk.StructureTypeSwapchainCreateInfo,
-		Surface:               *surface,
-		MinImageCount:         surfaceCapabilities.MinImageCount,
-		ImageFormat:           formats[0].Format,     //vk.FormatB8g8r8a8Srgb,
-		ImageColorSpace:       formats[0].ColorSpace, //vk.ColorspaceSrgbNonlinear,
-		ImageExtent:           surfaceCapabilities.CurrentExtent,
-		ImageUsage:            vk.ImageUsageFlags(vk.ImageUsageColorAttachmentBit),
-		PreTransform:          vk.SurfaceTransformIdentityBit,
-		ImageArrayLayers:      1,
-		ImageSharingMode:      vk.SharingModeExclusive,
-		QueueFamilyIndexCount: 1,
-		PQueueFamilyIndices:   queueFamily,
-		PresentMode:           vk.PresentModeFifo,
-		OldSwapchain:          vk.NullSwapchain,
-		Clipped:               vk.False,
-		//CompositeAlpha:        vk.CompositeAlphaFlagBits(vk.CompositeAlphaOpaqueBit | vk.CompositeAlphaInheritBit),
-		CompositeAlpha: vk.CompositeAlphaOpaqueBit,
-	}
-	result := vk.CreateSwapchain(logicalDevice, &swapChainCreateInfo, nil, &swapChains[0])
-	if result != vk.Success {
-		fmt.Printf("Failed to create command buffer with error : %v", result)
-		return nil
-	}
-	return swapChains
-}
-
 func acquireNextImage(logicalDevice vk.Device, pSwapChain []vk.Swapchain) {
 	fmt.Printf("%v", time.Second)
 	// result := vk.AcquireNextImage(logicalDevice, *pSwapChain, timeout uint64, semaphore Semaphore, fence Fence, pImageIndex *uint32)
@@ -293,102 +233,39 @@ func acquireNextImage(logicalDevice vk.Device, pSwapChain []vk.Swapchain) {
 
 //Windows Creation related Ends
 
-func recordCommandIntoCommandBuffer(commandBuffer vk.CommandBuffer) {}
-func beginCommandBuffer(commandBuffer []vk.CommandBuffer) {
-	fmt.Println("Begin Command Buffers.................")
-	var commandBufferBeginInfo = vk.CommandBufferBeginInfo{
-		SType: vk.StructureTypeCommandBufferBeginInfo,
-		Flags: 0x0,
-	}
-	for _, cmdBuffer := range commandBuffer {
-		result := vk.BeginCommandBuffer(cmdBuffer, &commandBufferBeginInfo)
-		if result != vk.Success {
-			fmt.Printf("Failed to begin command buffer with error : %v", result)
+// checkerboard returns a width x height image of white and grey squares of size pixels.
+func checkerboard(width, height, size int) image.Image {
+	img := image.NewNRGBA(image.Rect(0, 0, width, height))
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			c := color.NRGBA{R: 255, G: 255, B: 255, A: 255}
+			if (x/size+y/size)%2 == 1 {
+				c = color.NRGBA{R: 96, G: 96, B: 96, A: 255}
+			}
+			img.SetNRGBA(x, y, c)
 		}
 	}
+	return img
 }
 
-func allocateCommandBuffers(pLogicalDevice vk.Device, pCommandPool vk.CommandPool, count uint32) []vk.CommandBuffer {
-	fmt.Println("Allocating command buffer...............")
-	var commandBuffers []vk.CommandBuffer
-	commandBuffers = make([]vk.CommandBuffer, count)
-	var commandBufferAllocateInfo = vk.CommandBufferAllocateInfo{
-		SType:              vk.StructureTypeCommandBufferAllocateInfo,
-		CommandPool:        pCommandPool,
-		Level:              vk.CommandBufferLevelPrimary,
-		CommandBufferCount: count,
-	}
-	result := vk.AllocateCommandBuffers(pLogicalDevice, &commandBufferAllocateInfo, commandBuffers)
-	if result != vk.Success {
-		fmt.Printf("Failed to create command buffer with error : %v", result)
+// coloredFaces returns six size x size faces of a cube map, red, cyan,
+// green, magenta, blue and yellow, so it is easy to tell which is which.
+func coloredFaces(size int) []image.Image {
+	colors := []color.NRGBA{
+		{R: 255, A: 255}, {G: 255, B: 255, A: 255},
+		{G: 255, A: 255}, {R: 255, B: 255, A: 255},
+		{B: 255, A: 255}, {R: 255, G: 255, A: 255},
 	}
-	return commandBuffers
-}
-
-func createCommandPool(pLogicalDevice vk.Device) *vk.CommandPool {
-	fmt.Println("Creating Command Pool........")
-	// Create CommandPool first
-	var commandPool vk.CommandPool
-	var commandPollCreateInfo = vk.CommandPoolCreateInfo{
-		SType:            vk.StructureTypeCommandPoolCreateInfo,
-		Flags:            vk.CommandPoolCreateFlags(vk.CommandPoolCreateResetCommandBufferBit | vk.CommandPoolCreateTransientBit),
-		QueueFamilyIndex: 0,
+	faces := make([]image.Image, len(colors))
+	for idx, c := range colors {
+		face := image.NewNRGBA(image.Rect(0, 0, size, size))
+		draw.Draw(face, face.Bounds(), &image.Uniform{C: c}, image.Point{}, draw.Src)
+		faces[idx] = face
 	}
-	result := vk.CreateCommandPool(pLogicalDevice, &commandPollCreateInfo, nil, &commandPool)
-	if result != vk.Success {
-		fmt.Printf("Failed to create command pool with error : %v", result)
-	}
-	return &commandPool
-}
-
-func getDeviceQueue(pLogicalDevice vk.Device) *vk.Queue {
-	fmt.Println("Getting Device Queue................")
-	var queue vk.Queue
-	vk.GetDeviceQueue(pLogicalDevice, 0, 0, &queue)
-	return &queue
+	return faces
 }
 
-func createImageView(pLogicalDevice vk.Device, imageBuffer vk.Image) *vk.ImageView {
-	fmt.Println("Creating Image View........")
-	var imageView vk.ImageView
-	var imageViewCreateInfo = vk.ImageViewCreateInfo{
-		SType:    vk.StructureTypeImageViewCreateInfo,
-		Image:    imageBuffer,
-		ViewType: vk.ImageViewType2d,     // It must be compatible with Image Buffer's ImageType in ImageCreateInfo struct
-		Format:   vk.FormatR8g8b8a8Unorm, // It's same as Format in Image Buffer's ImageCreateInfo struct
-		Components: vk.ComponentMapping{
-			R: vk.ComponentSwizzleR,
-			G: vk.ComponentSwizzleG,
-			B: vk.ComponentSwizzleB,
-			A: vk.ComponentSwizzleA,
-		},
-		SubresourceRange: vk.ImageSubresourceRange{
-			AspectMask: vk.ImageAspectFlags(vk.ImageAspectColorBit),
-			LevelCount: 1,
-			LayerCount: 1,
-		},
-	}
-	result := vk.CreateImageView(pLogicalDevice, &imageViewCreateInfo, nil, &imageView)
-	if result != vk.Success {
-		fmt.Printf("Failed to map memory to image buffer with error : %v", result)
-		return nil
-	}
-	return &imageView
-}
-
-func bindImageMemory(pLogicalDevice vk.Device, imageBuffer vk.Image, pHostMemory *vk.DeviceMemory) {
-	// Before a resource such as a buffer or image can be used by Vulkan to store data, memory must be
-	// bound to it. Before memory is bound to a resource, you should determine what type of memory and
-	// how much of it the resource requires. There is a different function for buffers and for textures.
-	// They are vkGetBufferMemoryRequirements() and vkGetImageMemoryRequirements()
-	// The only difference between these two functions is that vkGetBufferMemoryRequirements() takes a
-	// handle to a buffer object and vkGetImageMemoryRequirements() takes a handle to an image object.
-	fmt.Println("Binding Device Memory............")
-	result := vk.BindImageMemory(pLogicalDevice, imageBuffer, *pHostMemory, vk.DeviceSize(0))
-	if result != vk.Success {
-		fmt.Printf("Failed to failed to bind memory to image with error : %v", result)
-	}
-}
+func recordCommandIntoCommandBuffer(commandBuffer vk.CommandBuffer) {}
 
 // func createBufferView(pLogicalDevice vk.Device, buffer vk.Buffer) *vk.BufferView {
 // 	var bufferView vk.BufferView
@@ -407,376 +284,10 @@ func bindImageMemory(pLogicalDevice vk.Device, imageBuffer vk.Image, pHostMemory
 // 	return &bufferView
 // }
 
-// func bindMemoryToBuffer() {
-// 	vertexData := linmath.ArrayFloat32([]float32{
-// 		-1, -1, 0,
-// 		1, -1, 0,
-// 		0, 1, 0,
-// 	})
-
-// }
-
-func mapHostMemoryForImage(pLogicalDevice vk.Device, memoryProperties vk.PhysicalDeviceMemoryProperties, imageBuffer vk.Image) (unsafe.Pointer, *vk.DeviceMemory) {
-	// Access to this memory object must be externally synchronized
-	// A flush is necessary if the host has written to a mapped memory
-	// region and needs the device to see the effect of those writes.
-	// However, if the device writes to a mapped memory region and you
-	// need the host to see the effect of the device’s writes, you need
-	// to invalidate any caches on the host that might now hold stale data.
-	// To do this, call vkInvalidateMappedMemoryRanges()
-	var memReqs vk.MemoryRequirements
-	vk.GetImageMemoryRequirements(pLogicalDevice, imageBuffer, &memReqs)
-	memReqs.Deref()
-	memoryProperties.Deref()
-
-	var pData unsafe.Pointer
-	memAlloc := &vk.MemoryAllocateInfo{
-		SType:           vk.StructureTypeMemoryAllocateInfo,
-		AllocationSize:  memReqs.Size,
-		MemoryTypeIndex: 0, //MemoryTypeIndex is an index into the memory type array returned from a call to vkGetPhysicalDeviceMemoryProperties()
-		// I found index of memory contaning 'MemoryPropertyDeviceLocalBit' and 'MemoryPropertyHostVisibleBit', from the output of printPhysicalDeviceMemoryProperties() function
-	}
-	var mem vk.DeviceMemory
-	result := vk.AllocateMemory(pLogicalDevice, memAlloc, nil, &mem)
-	if result != vk.Success {
-		fmt.Printf("Failed to map memory to image buffer with error : %v", result)
-		return nil, nil
-	}
-	vk.MapMemory(pLogicalDevice, mem, vk.DeviceSize(0), vk.DeviceSize(vk.WholeSize), 0, &pData)
-	return pData, &mem
-}
-
 // func mapHostMemoryForBuffer() {
 
 // }
 
-func createImageBuffer(pLogicalDevice *vk.Device) *vk.Image {
-	fmt.Println("Creating image buffer...........")
-	var imageBuffer vk.Image
-	var extent = vk.Extent3D{
-		Width:  1024,
-		Height: 1024,
-		Depth:  1,
-	}
-	//=================
-	// CUBE MAPS (cube map & cube-map array image)
-	//=================
-	// Set following(top 3) in ImageCreateInfo struct and below 4,5,6 in ImageViewCreateInfo struct and below 7,8,9 inside SubresourceRange section of ImageViewCreateInfo to create a Cube Map:
-	// ImageCreateInfo------------------------
-	// ArrayLayers: 6
-	// TimageType to vk.ImageType2d
-	// Flags: vk.ImageCreateCubeCompatibleBit OR
-	// ImageViewCreateInfo --------------------
-	// ViewType: vk.ImageViewTypeCubeArray
-	// ViewType: vk.ImageViewTypeCube // we create a view of the 2D array parent, but rather than creating a normal 2D (array) view of the image, we create a cube-map view
-	// layerCount : 6 #Cube maps can also form arrays of their own. This is simply a concatenation of an integer multiple of six faces, with each group of six forming a separate cube. To create a cube-map array image, set the viewType field of VkImageViewCreateInfo to VK_IMAGE_VIEW_TYPE_CUBE_ARRAY
-	// baseArrayLayer: #numberOfCubesToMake
-	// layerCount: 6, //To create a single cube, layerCount should be set to 6
-
-	var imageCreateInfo = vk.ImageCreateInfo{
-		SType:                 vk.StructureTypeImageCreateInfo,
-		ImageType:             vk.ImageType2d,
-		Format:                vk.FormatR8g8b8a8Unorm, //vk.FormatR8g8b8a8Srgb,
-		Extent:                extent,
-		MipLevels:             10,
-		ArrayLayers:           1,
-		Samples:               vk.SampleCount1Bit,
-		Tiling:                vk.ImageTilingOptimal,
-		Usage:                 vk.ImageUsageFlags(vk.ImageUsageSampledBit),
-		SharingMode:           vk.SharingModeExclusive,
-		QueueFamilyIndexCount: 0,
-		InitialLayout:         vk.ImageLayoutUndefined,
-	}
-	result := vk.CreateImage(*pLogicalDevice, &imageCreateInfo, nil, &imageBuffer)
-	if result != vk.Success {
-		fmt.Printf("Failed to create image buffer with error : %v", result)
-	}
-	return &imageBuffer
-}
-
-func getBufferMemoryRequirements(pLogicalDevice vk.Device, buffer vk.Buffer) {
-	var memoryProperties = map[string]vk.MemoryPropertyFlagBits{
-		"MemoryPropertyDeviceLocalBit":     vk.MemoryPropertyDeviceLocalBit,
-		"MemoryPropertyHostVisibleBit":     vk.MemoryPropertyHostVisibleBit,
-		"MemoryPropertyHostCoherentBit":    vk.MemoryPropertyHostCoherentBit,
-		"MemoryPropertyHostCachedBit":      vk.MemoryPropertyHostCachedBit,
-		"MemoryPropertyLazilyAllocatedBit": vk.MemoryPropertyLazilyAllocatedBit,
-		"MemoryPropertyProtectedBit":       vk.MemoryPropertyProtectedBit,
-		"MemoryPropertyFlagBitsMaxEnum":    vk.MemoryPropertyFlagBitsMaxEnum,
-	}
-	fmt.Println("Printing Buffer memory requirements..........")
-	var memoryRequirements vk.MemoryRequirements
-	vk.GetBufferMemoryRequirements(pLogicalDevice, buffer, &memoryRequirements)
-	memoryRequirements.Deref()
-	fmt.Println("\t\t* Size Required ...", memoryRequirements.Size, " Bytes")
-	fmt.Println("\t\t* Alignment Required ...", memoryRequirements.Alignment, " Bytes")
-	fmt.Println("\t\t* MemoryTypes Required ...")
-	for key, mp := range memoryProperties {
-		if mp&vk.MemoryPropertyFlagBits(memoryRequirements.MemoryTypeBits) != 0x00000000 {
-			fmt.Println("\t\t\t\t* ", key)
-		}
-	}
-}
-
-func getPhysicalDeviceImageProperties(physicalDevice vk.PhysicalDevice) vk.ImageFormatProperties {
-	fmt.Println("Physical device supported image format for 3D data of sRGB FormatB8g8r8Unorm Format........") // Another popular format vk.FormatR8g8b8Unorm
-	var imageType vk.ImageType = vk.ImageType3d
-	var imageTiling vk.ImageTiling = vk.ImageTilingLinear
-	//var imageUsageFlags vk.ImageUsageFlagBits
-	var imageCreateFlags vk.ImageCreateFlags
-	var imageFormatProperties vk.ImageFormatProperties
-	result := vk.GetPhysicalDeviceImageFormatProperties(physicalDevice, vk.FormatR8g8b8a8Unorm, imageType, imageTiling, vk.ImageUsageFlags(vk.ImageUsageColorAttachmentBit), imageCreateFlags, &imageFormatProperties)
-	if result != vk.Success {
-		fmt.Printf("Failed to get Physical device supported image formats with error : %v", result)
-	}
-	fmt.Printf("\t*\tImageType: %v\t\t \t\t\n", imageType)
-	fmt.Printf("\t*\tImageTiling: %v\t\t \t\t\n", imageTiling)
-	imageFormatProperties.Deref()
-	extent := imageFormatProperties.MaxExtent
-	maxmipmaplevel := imageFormatProperties.MaxMipLevels
-	maxarraylayers := imageFormatProperties.MaxArrayLayers
-	maxResourceSize := imageFormatProperties.MaxResourceSize
-	extent.Deref()
-	fmt.Printf("\t*\tExtent: \t\t \t\t\n")
-	fmt.Printf("\t\t\tWidth: %v\t\t\n", extent.Width)
-	fmt.Printf("\t\t\tHeight: %v\t\t\n", extent.Height)
-	fmt.Printf("\t\t\tDepth: %v\t\t\n", extent.Depth)
-	fmt.Printf("\t*\tMaxMipMap Levels: %v\t\t \t\t\n", maxmipmaplevel)
-	fmt.Printf("\t*\tMaxArrayLayers: %v\t\t \t\t\n", maxarraylayers)
-	fmt.Printf("\t*\tMaxResourceSize: %v\t\t \t\t\n", maxResourceSize)
-	return imageFormatProperties
-}
-
-func createBuffer(device vk.Device) *vk.Buffer {
-	fmt.Println("Creating buffer..........")
-	queueFamilyIdx := []uint32{0}
-	var buffer vk.Buffer
-	var bufferCreateInfo = vk.BufferCreateInfo{
-		SType:                 vk.StructureTypeBufferCreateInfo,
-		Flags:                 0x0,
-		Size:                  1024 * 1024, // 1Mb or vk.DeviceSize(vertexData.Sizeof()) in cause 'Usage' is set vk.BufferUsageVertexBufferBit
-		Usage:                 vk.BufferUsageFlags(vk.BufferUsageTransferSrcBit | vk.BufferUsageTransferDstBit),
-		SharingMode:           vk.SharingModeExclusive,
-		QueueFamilyIndexCount: 1,
-		PQueueFamilyIndices:   queueFamilyIdx,
-	}
-	result := vk.CreateBuffer(device, &bufferCreateInfo, nil, &buffer)
-	if result != vk.Success {
-		fmt.Printf("Failed to create buffer : %v", result)
-	}
-	return &buffer
-}
-
-func deviceWaitTillComplete(pLogicalDevice *vk.Device) {
-	// Wait till the device is finished executing any work on behalf of your application
-	// Wait on the host for the completion of outstanding queue operations for all queues on a given logical device
-	fmt.Println("Waiting for the device to complete all pending queue operations.....")
-	result := vk.DeviceWaitIdle(*pLogicalDevice)
-	if result != vk.Success {
-		fmt.Printf("Failed to wait for device to complete the execution with error : %v", result)
-	}
-	fmt.Println("All the queue operations are complete, device is free now....")
-}
-func getDeviceExtensionProperties(physicalDevice vk.PhysicalDevice) {
-	fmt.Println("Listing available Extensions for device only..............")
-	var propertyCount uint32
-	vk.EnumerateDeviceExtensionProperties(physicalDevice, "", &propertyCount, nil)
-	var pProperties []vk.ExtensionProperties = make([]vk.ExtensionProperties, propertyCount)
-	vk.EnumerateDeviceExtensionProperties(physicalDevice, "", &propertyCount, pProperties)
-	for i := 0; i < int(propertyCount); i++ {
-		p := pProperties[i]
-		p.Deref()
-		fmt.Printf("\t*\tExtensionName: %v\n\t\tSpecVersion: %v\n", vk.ToString(p.ExtensionName[:]), p.SpecVersion)
-	}
-}
-
-func getInstanceExtensionProperties() {
-	fmt.Println("Listing available Extensions for instance only..............")
-	var propertyCount uint32
-	//vk.EnumerateInstanceLayerProperties(&propertyCount, nil)
-	vk.EnumerateInstanceExtensionProperties("", &propertyCount, nil)
-	var pProperties []vk.ExtensionProperties = make([]vk.ExtensionProperties, propertyCount)
-	//vk.EnumerateInstanceLayerProperties(&propertyCount, pProperties)
-	vk.EnumerateInstanceExtensionProperties("", &propertyCount, pProperties)
-	for i := 0; i < int(propertyCount); i++ {
-		p := pProperties[i]
-		p.Deref()
-		fmt.Printf("\t*\tExtensionName: %v\n\t\tSpecVersion: %v\n", vk.ToString(p.ExtensionName[:]), p.SpecVersion)
-	}
-}
-
-func getDeviceLayerProperties(physicalDevice vk.PhysicalDevice) {
-	fmt.Println("Listing available layers for device only..............")
-	var propertyCount uint32
-	vk.EnumerateDeviceLayerProperties(physicalDevice, &propertyCount, nil)
-	var pProperties []vk.LayerProperties = make([]vk.LayerProperties, propertyCount)
-	vk.EnumerateDeviceLayerProperties(physicalDevice, &propertyCount, pProperties)
-	for i := 0; i < int(propertyCount); i++ {
-		p := pProperties[i]
-		p.Deref()
-		fmt.Printf("\t*\tName: %v\n\t\tDescription: %v\n\t\tSpecVersion: %v\n\t\tImplementationVersion: %v\n", vk.ToString(p.LayerName[:]), vk.ToString(p.Description[:]), p.SpecVersion, p.ImplementationVersion)
-	}
-}
-
-func getInstanceLayerProperties() {
-	fmt.Println("Listing available layers for instance only..............")
-	var propertyCount uint32
-	vk.EnumerateInstanceLayerProperties(&propertyCount, nil)
-	var pProperties []vk.LayerProperties = make([]vk.LayerProperties, propertyCount)
-	vk.EnumerateInstanceLayerProperties(&propertyCount, pProperties)
-	for i := 0; i < int(propertyCount); i++ {
-		p := pProperties[i]
-		p.Deref()
-		fmt.Printf("\t*\tName: %v\n\t\tDescription: %v\n\t\tSpecVersion: %v\n\t\tImplementationVersion: %v\n", vk.ToString(p.LayerName[:]), vk.ToString(p.Description[:]), p.SpecVersion, p.ImplementationVersion)
-	}
-}
-
-func createDevice(physicalDevice vk.PhysicalDevice, physicalDeviceFeatures vk.PhysicalDeviceFeatures) *vk.Device {
-	fmt.Println("Creating Logical device..............")
-	var logicalDevice vk.Device
-	// var physicalDeviceFeatures []vk.PhysicalDeviceFeatures = []vk.PhysicalDeviceFeatures{
-	// 	physicalDeviceFeatures,
-	// }
-	var pEnabledDeviceFeatures []vk.PhysicalDeviceFeatures = make([]vk.PhysicalDeviceFeatures, 1)
-	deviceQueueCreateInfoSlice := []vk.DeviceQueueCreateInfo{{
-		SType: vk.StructureTypeDeviceQueueCreateInfo,
-		//QueueCount:       16,
-		QueueCount:       1,
-		QueueFamilyIndex: 0,
-		PQueuePriorities: []float32{1.0},
-	}}
-	//var deviceExtensions = []string{"VK_KHR_surface\x00"}
-	//var deviceExtensions = []string{"VK_KHR_swapchain\x00"}
-	//var deviceLayers = []string{"VK_LAYER_KHRONOS_validation\x00"}
-	deviceExtensions := []string{
-		"VK_KHR_swapchain\x00",
-	}
-
-	var deviceCreateInfo *vk.DeviceCreateInfo = &vk.DeviceCreateInfo{
-		SType:                   vk.StructureTypeDeviceCreateInfo,
-		QueueCreateInfoCount:    uint32(len(deviceQueueCreateInfoSlice)),
-		PQueueCreateInfos:       deviceQueueCreateInfoSlice,
-		EnabledExtensionCount:   uint32(len(deviceExtensions)),
-		PpEnabledExtensionNames: deviceExtensions,
-		// EnabledLayerCount:       uint32(len(deviceLayers)),
-		// PpEnabledLayerNames:     deviceLayers,
-		// EnabledExtensionCount:   uint32(len(deviceExtensions)),
-		// PpEnabledExtensionNames: deviceExtensions,
-		// Following will enable given features from slice
-		//PEnabledFeatures:        physicalDeviceFeatures,
-		// Following will return structure of enable features into the slice
-		PEnabledFeatures: pEnabledDeviceFeatures,
-	}
-	result := vk.CreateDevice(physicalDevice, deviceCreateInfo, nil, &logicalDevice)
-	if result != vk.Success {
-		fmt.Println(fmt.Errorf("Error creating Logical device: %v", result))
-		return nil
-	}
-	fmt.Println("Created Logical device..............")
-	return &logicalDevice
-}
-
-func printDeviceQueueFamilyProperties(qfs []vk.QueueFamilyProperties) {
-	fmt.Println("Print device queue properties..............")
-	fmt.Printf("\tFound %v families\n", len(qfs))
-	for idx, qf := range qfs {
-		qf.Deref()
-		fmt.Printf("\tFamily %v, Number of queues in the family (vk.QueueCount): %v\n", idx, qf.QueueCount)
-		flagBits := vk.QueueFlagBits(qf.QueueFlags)
-		if flagBits&vk.QueueGraphicsBit != 0x00000000 {
-			fmt.Printf("\t\tVK_QUEUE_GRAPHICS_BIT \t\t[vk.QueueGraphicsBit] \t\t[0x00000001\\1]\n")
-		}
-		if flagBits&vk.QueueComputeBit != 0x00000000 {
-			fmt.Printf("\t\tVK_QUEUE_COMPUTE_BIT \t\t[vk.QueueComputeBit] \t\t[0x00000002\\2]\n")
-		}
-		if flagBits&vk.QueueTransferBit != 0x00000000 {
-			fmt.Printf("\t\tVK_QUEUE_TRANSFER_BIT \t\t[vk.QueueTransferBit] \t\t[0x00000004\\4]\n")
-		}
-		if flagBits&vk.QueueSparseBindingBit != 0x00000000 {
-			fmt.Printf("\t\tVK_QUEUE_SPARSE_BINDING_BIT \t[vk.QueueSparseBindingBit] \t[0x00000008\\8]\n")
-		}
-		if flagBits&vk.QueueProtectedBit != 0x00000000 {
-			fmt.Printf("\t\tVK_QUEUE_PROTECTED_BIT \t\t[vk.QueueProtectedBit] \t\t[0x00000010\\16]\n")
-		}
-		if flagBits&vk.QueueFlagBitsMaxEnum != 0x00000000 {
-			fmt.Printf("\t\tVK_QUEUE_FLAG_BITS_MAX_ENUM \t[QueueFlagBitsMaxEnumt] \t[0x7FFFFFFF\\2147483647]\n")
-		}
-	}
-}
-
-func getPhysicalDeviceQueueFamilyProperties(physicalDevice vk.PhysicalDevice) []vk.QueueFamilyProperties {
-	var pQueueFamilyPropertyCount uint32
-	vk.GetPhysicalDeviceQueueFamilyProperties(physicalDevice, &pQueueFamilyPropertyCount, nil)
-	var pQueueFamilyProperties []vk.QueueFamilyProperties
-	pQueueFamilyProperties = make([]vk.QueueFamilyProperties, pQueueFamilyPropertyCount)
-	vk.GetPhysicalDeviceQueueFamilyProperties(physicalDevice, &pQueueFamilyPropertyCount, pQueueFamilyProperties)
-	return pQueueFamilyProperties
-}
-
-func printPhysicalDeviceMemoryProperties(memoryProperties vk.PhysicalDeviceMemoryProperties) {
-	fmt.Println("Printing Device memory properties.......................")
-	memoryProperties.Deref()
-	fmt.Printf("\t*\tMemoryTypeCount: %v\t\t \t\t\n", memoryProperties.MemoryTypeCount)
-	fmt.Printf("\t*\tMemoryHeapCount: %v\t\t \t\t\n", memoryProperties.MemoryHeapCount)
-	var memPropertiesFlags = map[string]vk.MemoryPropertyFlagBits{
-		"MemoryPropertyDeviceLocalBit":     vk.MemoryPropertyDeviceLocalBit,
-		"MemoryPropertyHostVisibleBit":     vk.MemoryPropertyHostVisibleBit,
-		"MemoryPropertyHostCoherentBit":    vk.MemoryPropertyHostCoherentBit,
-		"MemoryPropertyHostCachedBit":      vk.MemoryPropertyHostCachedBit,
-		"MemoryPropertyLazilyAllocatedBit": vk.MemoryPropertyLazilyAllocatedBit,
-		"MemoryPropertyProtectedBit":       vk.MemoryPropertyProtectedBit,
-		"MemoryPropertyFlagBitsMaxEnum":    vk.MemoryPropertyFlagBitsMaxEnum,
-	}
-	fmt.Println("Total MemoryTypes Found :", len(memoryProperties.MemoryTypes))
-	for idx, memoryType := range memoryProperties.MemoryTypes { //HeapIndex
-		memoryType.Deref()
-		fmt.Println("\t* MemoryTypes index: ", idx)
-		fmt.Printf("\t\t*\tMemoryType HeapIndex: %v\t\t \t\t\n", memoryType.HeapIndex)
-		fmt.Printf("\t\t*\tMemoryType PropertyFlags: \t\t \t\t\n")
-		for key, memoryProperty := range memPropertiesFlags {
-			if memoryProperty&vk.MemoryPropertyFlagBits(memoryType.PropertyFlags) != 0x00000000 {
-				fmt.Printf("\t\t\t* Memory Property Flags: %v\t\t \t\t\n", key)
-			}
-		}
-	}
-}
-
-func getPhysicalDeviceMemoryProperties(physicalDevice vk.PhysicalDevice) vk.PhysicalDeviceMemoryProperties {
-	var pMemoryProperties vk.PhysicalDeviceMemoryProperties
-	vk.GetPhysicalDeviceMemoryProperties(physicalDevice, &pMemoryProperties)
-	return pMemoryProperties
-}
-
-func getPhysicalDeviceFeatures(physicalDevice vk.PhysicalDevice) vk.PhysicalDeviceFeatures {
-	var pFeatures vk.PhysicalDeviceFeatures
-	vk.GetPhysicalDeviceFeatures(physicalDevice, &pFeatures)
-	return pFeatures
-}
-
-func getPhysicalDeviceProperties(physicalDevice vk.PhysicalDevice) vk.PhysicalDeviceProperties {
-	var pProperties vk.PhysicalDeviceProperties
-	vk.GetPhysicalDeviceProperties(physicalDevice, &pProperties)
-	return pProperties
-}
-
-func getPhysicalDevices(instance vk.Instance) []vk.PhysicalDevice {
-	fmt.Println("[PhysicalDevice] Getting information....")
-	var deviceCount uint32
-	result := vk.EnumeratePhysicalDevices(instance, &deviceCount, nil)
-	if result != vk.Success {
-		fmt.Println(fmt.Errorf("Error getting physical device count: %v", result))
-		return nil
-	}
-	fmt.Printf("\tFound total %v Physical Device(s).....\n", deviceCount)
-	var physicalDevices = make([]vk.PhysicalDevice, deviceCount)
-	result = vk.EnumeratePhysicalDevices(instance, &deviceCount, physicalDevices)
-	if result != vk.Success {
-		fmt.Println(fmt.Errorf("Error getting physical device count: %v", result))
-		return nil
-	}
-	return physicalDevices
-}
-
 func checkSupportedImageFormat(physicalDevice vk.PhysicalDevice) {
 	fmt.Println("Listing all the supported image formats.............................")
 	var formats = map[string]vk.Format{
@@ -1,63 +1,82 @@
 package main
 
 import (
+	"errors"
+	"flag"
 	"fmt"
-	"log"
-	"os"
+	"time"
 
+	"github.com/goodshailesh/My-Vulkan-Projects/shaders"
+	"github.com/goodshailesh/My-Vulkan-Projects/vkutil"
+	vkwindow "github.com/goodshailesh/My-Vulkan-Projects/vkutil/window"
 	"github.com/vulkan-go/glfw/v3.3/glfw"
 	vk "github.com/vulkan-go/vulkan"
 )
 
+// The CPU records up to this many frames while the GPU draws the previous ones
+var framesInFlight = flag.Int("frames", vkutil.DefaultFramesInFlight, "number of frames in flight")
+
+// MSAA samples per pixel, lowered to what the GPU supports; 1 turns MSAA off
+var samplesFlag = flag.Int("samples", 4, "MSAA samples per pixel: 1, 2, 4, 8, 16, 32 or 64")
+
+// frameData is the uniform block of set 0, binding 0, laid out as std140:
+//
+//	layout(set = 0, binding = 0) uniform Frame { float time; vec2 extent; };
+type frameData struct {
+	Time   float32 // seconds since the start
+	_      float32
+	Extent [2]float32 // framebuffer size in pixels
+}
+
 func main() {
-	glfw.Init()
-	vk.SetGetInstanceProcAddr(glfw.GetVulkanGetInstanceProcAddress())
-	vk.Init()
+	flag.Parse()
+	vkutil.OrPanic(glfw.Init())
+	vkutil.SetGetInstanceProcAddr(glfw.GetVulkanGetInstanceProcAddress())
+	vkutil.OrPanic(vk.Init())
+	// Every handle is registered right after it is created and destroyed in reverse order by Close
+	resources := vkutil.NewDeletionQueue()
+	resources.Verbose = true
+	resources.Push("glfw", glfw.Terminate)
 
 	//1. Instance
 	//	1. Create Application Struct
-	//	2. Create Instance
-	//	3. Create Window
+	//	2. Create Window
+	//	3. Create Instance
 	//	4. Create Surface
 	var instance vk.Instance
-	var appInfo vk.ApplicationInfo = vk.ApplicationInfo{
-		SType:              vk.StructureTypeApplicationInfo,
-		PNext:              nil,
-		PApplicationName:   "myVulkan Application\x00",
-		ApiVersion:         vk.ApiVersion10,
-		ApplicationVersion: vk.MakeVersion(1, 0, 0),
-		PEngineName:        "My Game Engine\x00",
-		EngineVersion:      vk.MakeVersion(0, 1, 0),
+	var appInfo = vkutil.NewApplicationInfo("myVulkan Application", "My Game Engine")
+	var window *glfw.Window
+	// Only the layers actually installed are requested, otherwise vkCreateInstance fails
+	layers, err := vkutil.SelectInstanceLayers(vkutil.ValidationLayer, "VK_LAYER_NV_optimus")
+	if err != nil {
+		panic(err)
 	}
-	var layers = []string{"VK_LAYER_NV_optimus\x00"}
-	var extensions = []string{"VK_KHR_surface\x00", "VK_KHR_win32_surface\x00"}
-	var instanceInfo = vk.InstanceCreateInfo{
-		SType:                   vk.StructureTypeInstanceCreateInfo,
-		PNext:                   nil,
-		Flags:                   0,
-		PApplicationInfo:        &appInfo,
-		EnabledLayerCount:       uint32(len(layers)),
-		PpEnabledLayerNames:     layers,
-		EnabledExtensionCount:   uint32(len(extensions)),
-		PpEnabledExtensionNames: extensions,
+	window, err = vkwindow.CreateWindow(640, 480, "Vulkan Info")
+	if err != nil {
+		panic(err)
 	}
-	result := vk.CreateInstance(&instanceInfo, nil, &instance)
-	if result != vk.Success {
-		fmt.Println(fmt.Errorf("Error creating instance: %v", result))
+	resources.Push("window", window.Destroy)
+	// Surface extensions differ per platform (win32, xcb, wayland...), GLFW tells us which ones it needs
+	extensions, err := vkwindow.InstanceExtensions(window, vkutil.DebugUtilsExtension)
+	if err != nil {
+		panic(err)
 	}
-	var window *glfw.Window
-	var err error
-	glfw.WindowHint(glfw.ClientAPI, glfw.NoAPI)
-	window, err = glfw.CreateWindow(640, 480, "Vulkan Info", nil, nil)
+	instance, err = vkutil.CreateInstance(appInfo, layers, extensions)
 	if err != nil {
-		fmt.Println("Failed to create window with error :", err)
+		panic(err)
 	}
+	resources.Push("instance", func() { vk.DestroyInstance(instance, nil) })
+	debugMessenger, err := vkutil.CreateValidationMessenger(instance, layers, extensions, vkutil.DebugSeverityWarning, vkutil.PrintDebugMessage)
+	if err != nil {
+		panic(err)
+	}
+	resources.Push("debug messenger", debugMessenger.Destroy)
 	var surface vk.Surface
-	pSurface, glfwError := window.CreateWindowSurface(instance, nil)
-	if glfwError != nil {
-		fmt.Println("Failed to create window surface with error :", glfwError)
+	surface, err = vkwindow.CreateWindowSurface(instance, window)
+	if err != nil {
+		panic(err)
 	}
-	surface = vk.SurfaceFromPointer(pSurface)
+	resources.Push("surface", func() { vk.DestroySurface(instance, surface, nil) })
 	fmt.Println(surface)
 	//2. Logical Device
 	//	1. Get All Physical Devices GPU
@@ -66,27 +85,36 @@ func main() {
 	//	4. Get Device Extension Properties
 	//	5. Create Logical Device
 	var deviceCount uint32
-	result = vk.EnumeratePhysicalDevices(instance, &deviceCount, nil)
-	if result != vk.Success {
-		fmt.Println(fmt.Errorf("Error getting physical device count: %v", result))
-		panic(result)
+	if err := vkutil.Check("vkEnumeratePhysicalDevices", vk.EnumeratePhysicalDevices(instance, &deviceCount, nil)); err != nil {
+		panic(err)
 	}
 	fmt.Printf("\tFound total %v Physical Device(s).....\n", deviceCount)
 	var physicalDevices = make([]vk.PhysicalDevice, deviceCount)
-	result = vk.EnumeratePhysicalDevices(instance, &deviceCount, physicalDevices)
-	if result != vk.Success {
-		fmt.Println(fmt.Errorf("Error getting physical device count: %v", result))
-		panic(result)
+	if err := vkutil.Check("vkEnumeratePhysicalDevices", vk.EnumeratePhysicalDevices(instance, &deviceCount, physicalDevices)); err != nil {
+		panic(err)
+	}
+	// Score the GPUs instead of taking the first one, it has to draw and present to our surface
+	selectedDevice, deviceCandidates, err := vkutil.SelectPhysicalDevice(instance, vkutil.DeviceRequirements{
+		QueueFlags: vk.QueueGraphicsBit,
+		Surface:    surface,
+		Extensions: []string{"VK_KHR_swapchain"},
+	}, vkutil.DevicePreferences{})
+	vkutil.PrintDeviceCandidates(deviceCandidates)
+	if err != nil {
+		panic(err)
 	}
-	var physicalDevice = physicalDevices[0]
+	var physicalDevice = selectedDevice.PhysicalDevice
 	var physicalDeviceMemoryProperties vk.PhysicalDeviceMemoryProperties
 	vk.GetPhysicalDeviceMemoryProperties(physicalDevice, &physicalDeviceMemoryProperties)
-	deviceQueueCreateInfo := []vk.DeviceQueueCreateInfo{{
-		SType:            vk.StructureTypeDeviceQueueCreateInfo,
-		QueueFamilyIndex: 0,
-		QueueCount:       1,
-		PQueuePriorities: []float32{1.0},
-	}}
+	// Graphics and present may live in different families, ask for one queue from each family we use
+	queueFamilies, err := vkutil.FindQueueFamilies(physicalDevice, surface)
+	if err != nil {
+		panic(err)
+	}
+	if err := queueFamilies.Require(true); err != nil {
+		panic(err)
+	}
+	fmt.Println("Queue families:", queueFamilies)
 	var deviceProperties vk.PhysicalDeviceProperties
 	vk.GetPhysicalDeviceProperties(physicalDevice, &deviceProperties)
 	deviceProperties.Deref()
@@ -95,252 +123,378 @@ func main() {
 	fmt.Println("\t* Type:\t\t", deviceProperties.DeviceType)
 	fmt.Printf("\t* Version: \t%v.%v.%v\n", (deviceProperties.ApiVersion>>22)&0x3FF, (deviceProperties.ApiVersion>>22)&0x3FF, deviceProperties.ApiVersion&0xFFF)
 	fmt.Println("\t* Name:\t", string(deviceProperties.DeviceName[:]))
-	var deviceExtensionCount uint32
-	result = vk.EnumerateDeviceExtensionProperties(physicalDevice, "", &deviceExtensionCount, nil)
-	if result != vk.Success {
-		fmt.Println(fmt.Errorf("Error getting physical device count: %v", result))
-		panic(result)
-	}
-	var deviceExtensions = make([]vk.ExtensionProperties, deviceExtensionCount)
-	var deviceExtensionNames = make([]string, deviceExtensionCount)
-	for idx, extension := range deviceExtensions {
-		extension.Deref()
-		deviceExtensionNames[idx] = fmt.Sprintf("%v\x00", extension.ExtensionName[:])
-	}
-	result = vk.EnumerateDeviceExtensionProperties(physicalDevice, "", &deviceExtensionCount, deviceExtensions)
-	var deviceFeatures = make([]vk.PhysicalDeviceFeatures, 1)
-	deviceFeatures[0].ShaderClipDistance = vk.True
-	var deviceCreateInfo vk.DeviceCreateInfo = vk.DeviceCreateInfo{
-		SType:                   vk.StructureTypeDeviceCreateInfo,
-		QueueCreateInfoCount:    uint32(len(deviceQueueCreateInfo)),
-		PQueueCreateInfos:       deviceQueueCreateInfo,
-		EnabledLayerCount:       uint32(len(layers)),
-		PpEnabledLayerNames:     layers,
-		EnabledExtensionCount:   uint32(len(deviceExtensionNames)),
-		PpEnabledExtensionNames: deviceExtensionNames,
-		PEnabledFeatures:        deviceFeatures,
+	// Clip distances only when the GPU has them, asking for a missing feature fails vkCreateDevice
+	var deviceFeatures vk.PhysicalDeviceFeatures
+	deviceFeatures.ShaderClipDistance = vkutil.GetPhysicalDeviceFeatures(physicalDevice).ShaderClipDistance
+	// One queue from every family we use, device layers are deprecated and the instance layers apply
+	logicalDevice, err := vkutil.CreateDevice(physicalDevice, queueFamilies, []string{"VK_KHR_swapchain"}, &deviceFeatures)
+	if err != nil {
+		panic(err)
 	}
-	var logicalDevice vk.Device
-	result = vk.CreateDevice(physicalDevice, &deviceCreateInfo, nil, &logicalDevice)
+	resources.Push("logical device", func() { vk.DestroyDevice(logicalDevice, nil) })
+	// The depth buffer gets its memory from here
+	allocator := vkutil.NewAllocator(logicalDevice, physicalDevice, vkutil.AllocatorConfig{})
+	resources.PushErr("allocator", allocator.Destroy)
 
 	//3. Create SwapChain
-	//	1. Get surface capabilities
+	//	1. Resolve format, present mode, image count and extent against what the surface supports
 	//  2. Create Swapchain
-	var physicalDeviceSurfaceCapabilities vk.SurfaceCapabilities
-	result = vk.GetPhysicalDeviceSurfaceCapabilities(physicalDevice, surface, &physicalDeviceSurfaceCapabilities)
-	if result != vk.Success {
-		fmt.Println(fmt.Errorf("Error getting physical device surface capabilities: %v", result))
-		panic(result)
-	}
-	var formatCount uint32
-	vk.GetPhysicalDeviceSurfaceFormats(physicalDevice, surface, &formatCount, nil)
-	formats := make([]vk.SurfaceFormat, formatCount)
-	vk.GetPhysicalDeviceSurfaceFormats(physicalDevice, surface, &formatCount, formats)
-
-	chosenFormat := -1
-	for i := 0; i < int(formatCount); i++ {
-		formats[i].Deref()
-		log.Printf("physical device surface formats and colorspace available %v : % v\n", formats[0].Format, formats[1].Format)
-		if formats[i].Format == vk.FormatB8g8r8a8Unorm ||
-			formats[i].Format == vk.FormatR8g8b8a8Unorm {
-			chosenFormat = i
-			break
-		}
-	}
-	if chosenFormat < 0 {
-		fmt.Println("vk.GetPhysicalDeviceSurfaceFormats not found suitable format")
-	}
-	physicalDeviceSurfaceCapabilities.Deref()
-	var surfaceResuloution vk.Extent2D
-	surfaceResuloution = physicalDeviceSurfaceCapabilities.CurrentExtent
-	surfaceResuloution.Deref()
-	formats[chosenFormat].Deref()
-	width := surfaceResuloution.Width
-	height := surfaceResuloution.Height
-	var swapChainInfo = vk.SwapchainCreateInfo{
-		SType:                 vk.StructureTypeSwapchainCreateInfo,
-		Surface:               surface,
-		MinImageCount:         2,
-		ImageFormat:           vk.FormatB8g8r8a8Unorm,     //,
-		ImageColorSpace:       vk.ColorspaceSrgbNonlinear, //,
-		ImageExtent:           surfaceResuloution,
-		ImageArrayLayers:      1,
-		ImageUsage:            vk.ImageUsageFlags(vk.ImageUsageColorAttachmentBit),
-		ImageSharingMode:      vk.SharingModeExclusive,
-		QueueFamilyIndexCount: 1,
-		PQueueFamilyIndices:   []uint32{0},
-		PreTransform:          vk.SurfaceTransformIdentityBit,
-		CompositeAlpha:        vk.CompositeAlphaOpaqueBit,
-		PresentMode:           vk.PresentModeMailbox, //vk.PresentModeFifo, //
-		Clipped:               vk.True,
-		OldSwapchain:          vk.NullSwapchain,
+	swapchainPreferences := vkutil.SwapchainPreferences{
+		Formats: []vk.SurfaceFormat{
+			{Format: vk.FormatB8g8r8a8Unorm, ColorSpace: vk.ColorSpaceSrgbNonlinear},
+			{Format: vk.FormatR8g8b8a8Unorm, ColorSpace: vk.ColorSpaceSrgbNonlinear},
+		},
+		PresentModes: []vk.PresentMode{vk.PresentModeMailbox, vk.PresentModeImmediate, vk.PresentModeFifo},
+		Extent:       vkwindow.FramebufferExtent(window),
+	}
+	swapchainConfig, err := vkutil.ResolveSwapchainConfig(physicalDevice, surface, swapchainPreferences)
+	if err != nil {
+		panic(err)
 	}
+	vkutil.PrintSwapchainConfig(swapchainConfig)
+	width := swapchainConfig.Extent.Width
+	height := swapchainConfig.Extent.Height
 	var swapChain = make([]vk.Swapchain, 1)
-	result = vk.CreateSwapchain(logicalDevice, &swapChainInfo, nil, &swapChain[0])
-	if result != vk.Success {
-		fmt.Println(fmt.Errorf("Error creating swapchain: %v", result))
-		panic(result)
+	if swapChain[0], err = vkutil.CreateSwapChain(logicalDevice, surface, swapchainConfig, queueFamilies, vk.NullSwapchain); err != nil {
+		panic(err)
 	}
+	firstSwapchain := swapChain[0]
+	swapchainDeletion := resources.Push("swapchain", func() { vk.DestroySwapchain(logicalDevice, firstSwapchain, nil) })
 	fmt.Println("XXXXXXXXXXXXXXXXXXXXXXXXXXXXXXXXXXXXXXXXXXXXXXXXXXXXXXXX")
 	//4. Create Image and ImageView
 	//	1. Get coount of images required by swapchain
 	//  2. Create Swapchain
-	var imageCount uint32
-	result = vk.GetSwapchainImages(logicalDevice, swapChain[0], &imageCount, nil)
-	if result != vk.Success {
-		fmt.Println(fmt.Errorf("Error getting image count from swapchain: %v", result))
-		panic(result)
-	}
-	fmt.Println("Querying Number of Images required by swapchain....", imageCount)
-	var images []vk.Image
-	images = make([]vk.Image, imageCount)
-	result = vk.GetSwapchainImages(logicalDevice, swapChain[0], &imageCount, images)
-	if result != vk.Success {
-		fmt.Println(fmt.Errorf("Error getting images for swapchain: %v", result))
-		panic(result)
-	}
-	var imageViews = make([]vk.ImageView, 2)
-	for idx := range imageViews {
-		var imageViewCreateInfo = vk.ImageViewCreateInfo{
-			SType:    vk.StructureTypeImageViewCreateInfo,
-			ViewType: vk.ImageViewType2d,
-			Format:   vk.FormatB8g8r8a8Unorm,
-			Components: vk.ComponentMapping{
-				R: vk.ComponentSwizzleR,
-				G: vk.ComponentSwizzleG,
-				B: vk.ComponentSwizzleB,
-				A: vk.ComponentSwizzleA,
-			},
-			SubresourceRange: vk.ImageSubresourceRange{
-				AspectMask:     vk.ImageAspectFlags(vk.ImageAspectColorBit),
-				BaseMipLevel:   0,
-				LevelCount:     1,
-				BaseArrayLayer: 0,
-				LayerCount:     1,
-			},
-			Image: images[idx],
-		}
-		result = vk.CreateImageView(logicalDevice, &imageViewCreateInfo, nil, &imageViews[idx])
-		if result != vk.Success {
-			fmt.Println(fmt.Errorf("Failed to create image view : %v", result))
-			panic(result)
+	var imageViews []vk.ImageView
+	var imageViewDeletions []*vkutil.Deletion
+	// Run again for every new swapchain, see recreateSwapchain
+	createImageViews := func() {
+		var imageCount uint32
+		if err := vkutil.Check("vkGetSwapchainImagesKHR", vk.GetSwapchainImages(logicalDevice, swapChain[0], &imageCount, nil)); err != nil {
+			panic(err)
+		}
+		fmt.Println("Querying Number of Images required by swapchain....", imageCount)
+		var images []vk.Image
+		images = make([]vk.Image, imageCount)
+		if err := vkutil.Check("vkGetSwapchainImagesKHR", vk.GetSwapchainImages(logicalDevice, swapChain[0], &imageCount, images)); err != nil {
+			panic(err)
+		}
+		// The swapchain owns its images, they go away with it and must not be passed to vk.DestroyImage
+		imageViews = make([]vk.ImageView, len(images))
+		imageViewDeletions = nil
+		for idx := range imageViews {
+			var imageViewCreateInfo = vk.ImageViewCreateInfo{
+				SType:    vk.StructureTypeImageViewCreateInfo,
+				ViewType: vk.ImageViewType2d,
+				Format:   swapchainConfig.Format.Format,
+				Components: vk.ComponentMapping{
+					R: vk.ComponentSwizzleR,
+					G: vk.ComponentSwizzleG,
+					B: vk.ComponentSwizzleB,
+					A: vk.ComponentSwizzleA,
+				},
+				SubresourceRange: vk.ImageSubresourceRange{
+					AspectMask:     vk.ImageAspectFlags(vk.ImageAspectColorBit),
+					BaseMipLevel:   0,
+					LevelCount:     1,
+					BaseArrayLayer: 0,
+					LayerCount:     1,
+				},
+				Image: images[idx],
+			}
+			if err := vkutil.Check("vkCreateImageView", vk.CreateImageView(logicalDevice, &imageViewCreateInfo, nil, &imageViews[idx])); err != nil {
+				panic(err)
+			}
+			imageView := imageViews[idx]
+			imageViewDeletions = append(imageViewDeletions, resources.Push(fmt.Sprintf("image view %v", idx), func() { vk.DestroyImageView(logicalDevice, imageView, nil) }))
 		}
 	}
-	//5. Create and Allocate CommandBuffer
-	//	1. Get first Queue
-	//  2. Create commmand pool
-	//  3. Allocate commmand buffer
-
-	var queue vk.Queue
-	vk.GetDeviceQueue(logicalDevice, 0, 0, &queue)
-	var commandPool vk.CommandPool
-	var commandPoolCreateInfo = vk.CommandPoolCreateInfo{
-		SType:            vk.StructureTypeQueryPoolCreateInfo,
-		Flags:            vk.CommandPoolCreateFlags(vk.CommandPoolCreateResetCommandBufferBit),
-		QueueFamilyIndex: 0,
-	}
-	result = vk.CreateCommandPool(logicalDevice, &commandPoolCreateInfo, nil, &commandPool)
-	if result != vk.Success {
-		fmt.Println(fmt.Errorf("Failed to create command pool : %v", result))
-		panic(result)
-	}
-	var commandBuffer []vk.CommandBuffer
-	commandBuffer = make([]vk.CommandBuffer, 1)
-	var commandBufferAllocateInfo = vk.CommandBufferAllocateInfo{
-		SType:              vk.StructureTypeCommandBufferAllocateInfo,
-		CommandPool:        commandPool,
-		Level:              vk.CommandBufferLevelPrimary,
-		CommandBufferCount: 1,
-	}
-	result = vk.AllocateCommandBuffers(logicalDevice, &commandBufferAllocateInfo, commandBuffer)
-	if result != vk.Success {
-		fmt.Println(fmt.Errorf("Failed to allocate command buffer : %v", result))
-		panic(result)
+	createImageViews()
+	// One depth buffer shared by the frames in flight, in the best depth format the GPU supports
+	depthFormat, err := vkutil.FindDepthFormat(physicalDevice, false)
+	if err != nil {
+		panic(err)
+	}
+	fmt.Println("Depth format:", vkutil.FormatName(depthFormat))
+	samples := vkutil.SelectSampleCount(physicalDevice, vk.SampleCountFlagBits(*samplesFlag))
+	fmt.Println("MSAA samples:", samples)
+	var depthBuffer, msaaColor *vkutil.Attachment
+	var depthBufferDeletion, msaaColorDeletion *vkutil.Deletion
+	// Run again for every new swapchain, the depth buffer and the multisampled
+	// color image have the size of its images
+	createAttachments := func() {
+		var err error
+		extent := vk.Extent2D{Width: width, Height: height}
+		if depthBuffer, err = vkutil.NewDepthBuffer(logicalDevice, allocator, depthFormat, extent, samples); err != nil {
+			panic(err)
+		}
+		depthBufferDeletion = resources.Push("depth buffer", depthBuffer.Destroy)
+		if samples == vk.SampleCount1Bit {
+			return
+		}
+		if msaaColor, err = vkutil.NewAttachment(logicalDevice, allocator, vkutil.AttachmentConfig{
+			Format:    swapchainConfig.Format.Format,
+			Extent:    extent,
+			Samples:   samples,
+			Transient: true,
+			Name:      "msaa color",
+		}); err != nil {
+			panic(err)
+		}
+		msaaColorDeletion = resources.Push("msaa color", msaaColor.Destroy)
+	}
+	createAttachments()
+	//5. Create the frame loop
+	//	1. Get the graphics and present queues
+	//  2. Command buffer, semaphores and fence for every frame in flight
+	queues := vkutil.GetDeviceQueues(logicalDevice, queueFamilies)
+	frameLoop, err := vkutil.NewFrameLoop(logicalDevice, queueFamilies, queues, swapChain[0], uint32(len(imageViews)), vkutil.FrameLoopConfig{
+		FramesInFlight: *framesInFlight,
+	})
+	if err != nil {
+		panic(err)
 	}
+	resources.Push("frame loop", frameLoop.Destroy)
+	fmt.Println("Frames in flight:", frameLoop.FramesInFlight())
 	//6. Create FrameBuffer
 	//	1. Create Attachment Description and Attachment Reference
 	//  2. Create Subpass
 	//  3. Create Render Pass
 	//  4. Create FrameBuffer
-	var attachmentDescriptions []vk.AttachmentDescription
-	attachmentDescriptions = make([]vk.AttachmentDescription, 1)
-	attachmentDescriptions[0] = vk.AttachmentDescription{
-		Format:         vk.FormatB8g8r8a8Unorm,
-		Samples:        vk.SampleCount1Bit,
-		LoadOp:         vk.AttachmentLoadOpClear,
-		StoreOp:        vk.AttachmentStoreOpStore,
-		StencilLoadOp:  vk.AttachmentLoadOpDontCare,
-		StencilStoreOp: vk.AttachmentStoreOpDontCare,
-		InitialLayout:  vk.ImageLayoutColorAttachmentOptimal,
-		FinalLayout:    vk.ImageLayoutColorAttachmentOptimal,
-	}
-	var attachmentReference = make([]vk.AttachmentReference, 1)
-	attachmentReference[0].Attachment = 0
-	attachmentReference[0].Layout = vk.ImageLayoutColorAttachmentOptimal
-	var subpass = make([]vk.SubpassDescription, 1)
-	subpass[0] = vk.SubpassDescription{
-		PipelineBindPoint:       vk.PipelineBindPointGraphics,
-		ColorAttachmentCount:    1,
-		PColorAttachments:       attachmentReference,
-		PDepthStencilAttachment: nil,
-	}
-	var renderPassCreateInfo = vk.RenderPassCreateInfo{
-		SType:           vk.StructureTypeRenderPassCreateInfo,
-		AttachmentCount: 1,
-		PAttachments:    attachmentDescriptions,
-		SubpassCount:    1,
-		PSubpasses:      subpass,
-	}
 	var renderPass vk.RenderPass
-	result = vk.CreateRenderPass(logicalDevice, &renderPassCreateInfo, nil, &renderPass)
-	if result != vk.Success {
-		fmt.Println(fmt.Errorf("Failed to create render pass : %v", result))
-		panic(result)
+	var renderPassDeletion *vkutil.Deletion
+	// Run again when a new swapchain comes in another format, see recreateSwapchain
+	createRenderPass := func() {
+		var attachmentDescriptions []vk.AttachmentDescription
+		attachmentDescriptions = make([]vk.AttachmentDescription, 2)
+		// Swapchain images come undefined from the acquire and are left ready to present
+		attachmentDescriptions[0] = vkutil.ColorAttachmentDescription(swapchainConfig.Format.Format, vk.SampleCount1Bit, vkutil.AttachmentPresent)
+		attachmentDescriptions[1] = vkutil.DepthAttachmentDescription(depthFormat, samples)
+		var attachmentReference = make([]vk.AttachmentReference, 1)
+		attachmentReference[0].Attachment = 0
+		attachmentReference[0].Layout = vk.ImageLayoutColorAttachmentOptimal
+		var depthAttachmentReference = vk.AttachmentReference{
+			Attachment: 1,
+			Layout:     vk.ImageLayoutDepthStencilAttachmentOptimal,
+		}
+		var subpass = make([]vk.SubpassDescription, 1)
+		subpass[0] = vk.SubpassDescription{
+			PipelineBindPoint:       vk.PipelineBindPointGraphics,
+			ColorAttachmentCount:    1,
+			PColorAttachments:       attachmentReference,
+			PDepthStencilAttachment: &depthAttachmentReference,
+		}
+		// With MSAA the triangle is drawn into the multisampled attachment 0, which
+		// lives during the pass only, and resolved into the swapchain image, attachment 2
+		if samples != vk.SampleCount1Bit {
+			attachmentDescriptions[0] = vkutil.ColorAttachmentDescription(swapchainConfig.Format.Format, samples, vkutil.AttachmentOnly)
+			attachmentDescriptions = append(attachmentDescriptions, vkutil.ResolveAttachmentDescription(swapchainConfig.Format.Format, vk.ImageLayoutPresentSrc))
+			subpass[0].PResolveAttachments = []vk.AttachmentReference{{
+				Attachment: 2,
+				Layout:     vk.ImageLayoutColorAttachmentOptimal,
+			}}
+		}
+		// The frames in flight share the depth buffer and MSAA image, the clear of one waits for the depth tests
+		// and color writes of the previous, and the swapchain image for the acquire
+		var dependencies = vkutil.SubpassDependencies(attachmentDescriptions)
+		var renderPassCreateInfo = vk.RenderPassCreateInfo{
+			SType:           vk.StructureTypeRenderPassCreateInfo,
+			AttachmentCount: uint32(len(attachmentDescriptions)),
+			PAttachments:    attachmentDescriptions,
+			SubpassCount:    1,
+			PSubpasses:      subpass,
+			DependencyCount: uint32(len(dependencies)),
+			PDependencies:   dependencies,
+		}
+		if err := vkutil.Check("vkCreateRenderPass", vk.CreateRenderPass(logicalDevice, &renderPassCreateInfo, nil, &renderPass)); err != nil {
+			panic(err)
+		}
+		pass := renderPass
+		renderPassDeletion = resources.Push("render pass", func() { vk.DestroyRenderPass(logicalDevice, pass, nil) })
 	}
-	var frameBufferAttachments = make([]vk.ImageView, 1)
-	var frameBufferCreateInfo = vk.FramebufferCreateInfo{
-		SType:           vk.StructureTypeFramebufferCreateInfo,
-		PNext:           nil,
-		RenderPass:      renderPass,
-		AttachmentCount: 1,
-		PAttachments:    frameBufferAttachments,
-		Width:           width,
-		Height:          height,
-		Layers:          1,
+	createRenderPass()
+	// The pipeline draws the colored triangle of shaders/triangle.vert, its viewport and scissor
+	// are dynamic so it outlives swapchain recreation, unless the format changes
+	vertexShader, err := vkutil.LoadSPIRVFS(shaders.FS, "triangle.vert.spv")
+	if err != nil {
+		panic(err)
 	}
-	var frameBuffers = make([]vk.Framebuffer, 2)
-	for idx := range frameBuffers {
-		result = vk.CreateFramebuffer(logicalDevice, &frameBufferCreateInfo, nil, &frameBuffers[idx])
-		if result != vk.Success {
-			fmt.Println(fmt.Errorf("Failed to create frame buffers : %v", result))
-			panic(result)
+	fragmentShader, err := vkutil.LoadSPIRVFS(shaders.FS, "triangle.frag.spv")
+	if err != nil {
+		panic(err)
+	}
+	// The per-frame uniforms are bound as set 0. The triangle shaders do not read them yet,
+	// a pipeline layout may declare more than its shaders use
+	descriptorLayouts := vkutil.NewDescriptorLayoutCache(logicalDevice)
+	resources.Push("descriptor set layouts", descriptorLayouts.Destroy)
+	frameSetLayout, err := descriptorLayouts.Get(vkutil.DescriptorBinding(0, vk.DescriptorTypeUniformBuffer, vk.ShaderStageVertexBit|vk.ShaderStageFragmentBit))
+	if err != nil {
+		panic(err)
+	}
+	// One uniform buffer per frame in flight: BeginFrame waited for the GPU to be done with the slot's
+	frameUniforms := make([]*vkutil.UniformBuffer[frameData], frameLoop.FramesInFlight())
+	for idx := range frameUniforms {
+		name := fmt.Sprintf("frame uniforms %v", idx)
+		if frameUniforms[idx], err = vkutil.NewUniformBuffer[frameData](logicalDevice, allocator, name); err != nil {
+			panic(err)
+		}
+		resources.Push(name, frameUniforms[idx].Destroy)
+	}
+	// The sets are allocated anew every frame from pools per swapchain image, reset when the image comes round again
+	frameDescriptors := vkutil.NewFrameDescriptors(logicalDevice, uint32(len(imageViews)), vkutil.DescriptorAllocatorConfig{})
+	resources.Push("frame descriptors", frameDescriptors.Destroy)
+	pipelineBuilder := vkutil.NewGraphicsPipelineBuilder(renderPass)
+	pipelineBuilder.AddShader(vertexShader, vk.ShaderStageVertexBit)
+	pipelineBuilder.AddShader(fragmentShader, vk.ShaderStageFragmentBit)
+	pipelineBuilder.DepthTest, pipelineBuilder.DepthWrite = true, true
+	pipelineBuilder.Samples = samples
+	pipelineBuilder.SetLayouts = []vk.DescriptorSetLayout{frameSetLayout}
+	var pipeline *vkutil.GraphicsPipeline
+	var pipelineDeletion *vkutil.Deletion
+	// Run again with the render pass, the pipeline is tied to its formats
+	createPipeline := func() {
+		var err error
+		pipelineBuilder.RenderPass = renderPass
+		if pipeline, err = pipelineBuilder.Build(logicalDevice); err != nil {
+			panic(err)
+		}
+		pipelineDeletion = resources.Push("graphics pipeline", pipeline.Destroy)
+	}
+	createPipeline()
+	var frameBuffers []vk.Framebuffer
+	var frameBufferDeletions []*vkutil.Deletion
+	// Run again for every new swapchain, see recreateSwapchain
+	createFrameBuffers := func() {
+		// The color view changes per framebuffer, the depth view is the same for all
+		var frameBufferAttachments = []vk.ImageView{vk.NullImageView, depthBuffer.View}
+		// With MSAA the swapchain image is the resolve target instead
+		swapchainAttachment := 0
+		if msaaColor != nil {
+			frameBufferAttachments = []vk.ImageView{msaaColor.View, depthBuffer.View, vk.NullImageView}
+			swapchainAttachment = 2
+		}
+		var frameBufferCreateInfo = vk.FramebufferCreateInfo{
+			SType:           vk.StructureTypeFramebufferCreateInfo,
+			PNext:           nil,
+			RenderPass:      renderPass,
+			AttachmentCount: uint32(len(frameBufferAttachments)),
+			PAttachments:    frameBufferAttachments,
+			Width:           width,
+			Height:          height,
+			Layers:          1,
+		}
+		// One framebuffer per swapchain image, AcquireNextImage picks which one is drawn to
+		frameBuffers = make([]vk.Framebuffer, len(imageViews))
+		frameBufferDeletions = nil
+		for idx := range frameBuffers {
+			frameBufferAttachments[swapchainAttachment] = imageViews[idx]
+			if err := vkutil.Check("vkCreateFramebuffer", vk.CreateFramebuffer(logicalDevice, &frameBufferCreateInfo, nil, &frameBuffers[idx])); err != nil {
+				panic(err)
+			}
+			frameBuffer := frameBuffers[idx]
+			frameBufferDeletions = append(frameBufferDeletions, resources.Push(fmt.Sprintf("framebuffer %v", idx), func() { vk.DestroyFramebuffer(logicalDevice, frameBuffer, nil) }))
 		}
 	}
-	for {
+	createFrameBuffers()
+
+	// A resized window needs a new swapchain, the old one is passed as OldSwapchain so the
+	// presentation engine can hand its images over, then everything sized after it is rebuilt
+	recreateSwapchain := func() {
+		// A minimized window has a 0x0 framebuffer, no swapchain can be created for it
+		vkwindow.WaitWhileMinimized(window)
+		if window.ShouldClose() {
+			return
+		}
+		// The old images may still be read by the GPU
+		if err := vkutil.DeviceWaitTillComplete(logicalDevice); err != nil {
+			panic(err)
+		}
+		for _, d := range append(append(frameBufferDeletions, depthBufferDeletion, msaaColorDeletion), imageViewDeletions...) {
+			if err := d.Destroy(); err != nil {
+				panic(err)
+			}
+		}
+		// The render pass was made for the current format, ask for it again first
+		swapchainPreferences.Formats = []vk.SurfaceFormat{swapchainConfig.Format}
+		swapchainPreferences.Extent = vkwindow.FramebufferExtent(window)
+		config, err := vkutil.ResolveSwapchainConfig(physicalDevice, surface, swapchainPreferences)
+		if err != nil {
+			panic(err)
+		}
+		newSwapchain, err := vkutil.CreateSwapChain(logicalDevice, surface, config, queueFamilies, swapChain[0])
+		if err != nil {
+			panic(err)
+		}
+		// The old swapchain is retired now but still has to be destroyed
+		if err := swapchainDeletion.Destroy(); err != nil {
+			panic(err)
+		}
+		swapChain[0] = newSwapchain
+		swapchainDeletion = resources.Push("swapchain", func() { vk.DestroySwapchain(logicalDevice, newSwapchain, nil) })
+		oldFormat := swapchainConfig.Format
+		swapchainConfig = config
+		width = config.Extent.Width
+		height = config.Extent.Height
+		createImageViews()
+		createAttachments()
+		// Some platforms hand out a different format after a resize, the pipeline is tied to the render pass
+		if config.Format != oldFormat {
+			fmt.Printf("Swapchain format changed from %v to %v\n", vkutil.FormatName(oldFormat.Format), vkutil.FormatName(config.Format.Format))
+			if err := errors.Join(pipelineDeletion.Destroy(), renderPassDeletion.Destroy()); err != nil {
+				panic(err)
+			}
+			createRenderPass()
+			createPipeline()
+		}
+		createFrameBuffers()
+		frameLoop.SetSwapchain(newSwapchain, uint32(len(imageViews)))
+		frameDescriptors.SetImageCount(uint32(len(imageViews)))
+		fmt.Printf("Recreated swapchain %vx%v\n", width, height)
+	}
+	resize := vkwindow.TrackResize(window)
+	startTime := time.Now()
+	for !window.ShouldClose() {
+		glfw.PollEvents()
+		// Nothing can be presented to a minimized window, sleep until it is restored
+		if vkwindow.Minimized(window) {
+			vkwindow.WaitWhileMinimized(window)
+			continue
+		}
 		//7. Display Output
-		//	1. Create AcquireNextImage
-		//  2. Command Buffer Begin info
-		//  3. Create Render Pass
-		//  4. Create FrameBuffer
-		var nextImageIdx uint32
-		result = vk.AcquireNextImage(logicalDevice, swapChain[0], vk.MaxUint64, vk.NullSemaphore, vk.NullFence, &nextImageIdx)
-		if result != vk.Success {
-			fmt.Println(fmt.Errorf("Failed to acquire next image : %v", result))
-			panic(result)
-		}
-		var commandBufferBeginInfo = vk.CommandBufferBeginInfo{
-			SType: vk.StructureTypeCommandBufferBeginInfo,
-			Flags: vk.CommandBufferUsageFlags(vk.CommandBufferUsageOneTimeSubmitBit),
-		}
-		vk.BeginCommandBuffer(commandBuffer[0], &commandBufferBeginInfo)
+		//	1. Wait for the oldest frame in flight and acquire the next image
+		//  2. Record the render pass into the framebuffer of that image
+		//  3. Submit and present
+		frame, err := frameLoop.BeginFrame()
+		if errors.Is(err, vkutil.ErrOutOfDate) {
+			// No image was acquired, the frame is skipped. The new swapchain covers a pending resize too
+			fmt.Println("Swapchain no longer matches the surface:", err)
+			resize.Resized()
+			recreateSwapchain()
+			continue
+		} else if err != nil {
+			panic(err)
+		}
+		descriptors, err := frameDescriptors.Begin(frame.ImageIndex)
+		if err != nil {
+			panic(err)
+		}
+		frameSet, err := descriptors.Allocate(frameSetLayout)
+		if err != nil {
+			panic(err)
+		}
+		uniforms := frameUniforms[frame.Slot]
+		uniforms.Write(frameData{
+			Time:   float32(time.Since(startTime).Seconds()),
+			Extent: [2]float32{float32(width), float32(height)},
+		})
+		vkutil.NewDescriptorWriter().UniformBuffer(frameSet, 0, uniforms.Buffer, 0, uniforms.Size).Update(logicalDevice)
 		var clearValue = []vk.ClearValue{
 			vk.NewClearValue([]float32{1.0, 0.0, 0.0, 1.0}),
-			vk.NewClearValue([]float32{1.0, 0.0}),
+			vk.NewClearDepthStencil(1.0, 0), // the far plane
 		}
 		var RenderPassBeginInfo = vk.RenderPassBeginInfo{
 			SType:       vk.StructureTypeRenderPassBeginInfo,
 			RenderPass:  renderPass,
-			Framebuffer: frameBuffers[nextImageIdx],
+			Framebuffer: frameBuffers[frame.ImageIndex],
 		}
 
 		var start = vk.Offset2D{
@@ -357,59 +511,37 @@ func main() {
 		RenderPassBeginInfo.RenderArea = rect
 		RenderPassBeginInfo.ClearValueCount = 2
 		RenderPassBeginInfo.PClearValues = clearValue
-		vk.CmdBeginRenderPass(commandBuffer[0], &RenderPassBeginInfo, vk.SubpassContentsBeginRange)
-		vk.CmdEndRenderPass(commandBuffer[0])
-		vk.EndCommandBuffer(commandBuffer[0])
+		vk.CmdBeginRenderPass(frame.CommandBuffer, &RenderPassBeginInfo, vk.SubpassContentsInline)
+		vk.CmdBindPipeline(frame.CommandBuffer, vk.PipelineBindPointGraphics, pipeline.Pipeline)
+		vkutil.CmdBindDescriptorSets(frame.CommandBuffer, pipeline.Layout, 0, frameSet)
+		vkutil.CmdSetViewportAndScissor(frame.CommandBuffer, dim)
+		vk.CmdDraw(frame.CommandBuffer, 3, 1, 0, 0)
+		vk.CmdEndRenderPass(frame.CommandBuffer)
 
-		var renderFence = make([]vk.Fence, 1)
-		var fenceCreateInfo = vk.FenceCreateInfo{
-			SType: vk.StructureTypeFenceCreateInfo,
-		}
-		vk.CreateFence(logicalDevice, &fenceCreateInfo, nil, &renderFence[0])
-		var submitInfo = make([]vk.SubmitInfo, 1)
-		submitInfo[0] = vk.SubmitInfo{
-			SType:                vk.StructureTypeSubmitInfo,
-			WaitSemaphoreCount:   0,
-			PWaitSemaphores:      nil,
-			PWaitDstStageMask:    nil,
-			CommandBufferCount:   1,
-			PCommandBuffers:      commandBuffer,
-			SignalSemaphoreCount: 0,
-			PSignalSemaphores:    nil,
-		}
-		vk.QueueSubmit(queue, 1, submitInfo, renderFence[0])
-		const timeoutNano = 10 * 1000 * 1000 * 1000
-		vk.WaitForFences(logicalDevice, 1, renderFence, vk.True, timeoutNano)
-		vk.DestroyFence(logicalDevice, renderFence[0], nil)
-		var presentInfo = vk.PresentInfo{
-			SType:              vk.StructureTypePresentInfo,
-			WaitSemaphoreCount: 0,
-			PWaitSemaphores:    nil,
-			PSwapchains:        swapChain,
-			PImageIndices:      []uint32{nextImageIdx},
-			PResults:           nil,
-		}
-		vk.QueuePresent(queue, &presentInfo)
-		if window.ShouldClose() {
-			os.Exit(0)
+		err = frameLoop.EndFrame()
+		if err != nil && !vkutil.SwapchainOutOfDate(err) {
+			panic(err)
+		}
+		// Drivers need not report OUT_OF_DATE after a resize, the framebuffer size callback tells us as well.
+		// Asked every frame, so a resize seen alongside an error is not reported again next frame
+		resized := resize.Resized()
+		if err != nil || frame.Suboptimal || resized {
+			reason := "the window was resized"
+			if err != nil {
+				reason = err.Error()
+			} else if frame.Suboptimal {
+				reason = "the image was acquired suboptimal"
+			}
+			fmt.Println("Swapchain no longer matches the surface:", reason)
+			recreateSwapchain()
 		}
-		glfw.PollEvents()
-	}
-	//Cleanup
-	for idx := range frameBuffers {
-		vk.DestroyFramebuffer(logicalDevice, frameBuffers[idx], nil)
 	}
-	vk.DestroyRenderPass(logicalDevice, renderPass, nil)
-	vk.DestroyCommandPool(logicalDevice, commandPool, nil)
-	for _, imageView := range imageViews {
-		vk.DestroyImageView(logicalDevice, imageView, nil)
+	//Cleanup, in the reverse order of creation once the GPU is done with the last frame
+	if err := vkutil.DeviceWaitTillComplete(logicalDevice); err != nil {
+		panic(err)
 	}
-	for _, image := range images {
-		vk.DestroyImage(logicalDevice, image, nil)
+	resources.PrintAlive()
+	if err := resources.Close(); err != nil {
+		panic(err)
 	}
-	vk.DestroySwapchain(logicalDevice, swapChain[0], nil)
-	vk.DestroySurface(instance, surface, nil)
-	vk.DestroyInstance(instance, nil)
-	window.Destroy()
-	glfw.Terminate()
 }
@@ -0,0 +1,90 @@
+// Excercise010 draws the triangle of the earlier exercises without a window:
+// into an offscreen color image that is copied back and saved as PNG or PPM.
+// It runs on hosts without a display, e.g. with lavapipe:
+//
+//	VK_ICD_FILENAMES=/usr/share/vulkan/icd.d/lvp_icd.x86_64.json go run ./Excercise010 -o triangle.png
+package main
+
+import (
+	"flag"
+	"fmt"
+
+	"github.com/goodshailesh/My-Vulkan-Projects/shaders"
+	"github.com/goodshailesh/My-Vulkan-Projects/vkutil"
+	vk "github.com/vulkan-go/vulkan"
+)
+
+var (
+	output = flag.String("o", "triangle.png", "image to write, PPM when it ends in .ppm")
+	width  = flag.Uint("width", 256, "image width")
+	height = flag.Uint("height", 256, "image height")
+	device = flag.String("device", "", "part of the name of the device to use, e.g. llvmpipe")
+)
+
+func main() {
+	flag.Parse()
+	// No GLFW here, the Vulkan loader is opened directly
+	vkutil.OrPanic(vkutil.LoadVulkanLibrary())
+	vkutil.OrPanic(vk.Init())
+	// Everything created is registered here and destroyed in reverse order at the end
+	resources := vkutil.NewDeletionQueue()
+
+	// No surface extensions: nothing is presented
+	layers, err := vkutil.SelectInstanceLayers(vkutil.ValidationLayer)
+	vkutil.OrPanic(err)
+	extensions, err := vkutil.SelectInstanceExtensions(nil, []string{vkutil.DebugUtilsExtension})
+	vkutil.OrPanic(err)
+	instance, err := vkutil.CreateInstance(vkutil.NewApplicationInfo("myVulkan Headless", "My Game Engine"), layers, extensions)
+	vkutil.OrPanic(err)
+	resources.Push("instance", func() { vk.DestroyInstance(instance, nil) })
+	debugMessenger, err := vkutil.CreateValidationMessenger(instance, layers, extensions, vkutil.DebugSeverityWarning, vkutil.PrintDebugMessage)
+	vkutil.OrPanic(err)
+	resources.Push("debug messenger", debugMessenger.Destroy)
+
+	// Any device that can draw will do, CPU implementations included
+	selectedDevice, deviceCandidates, err := vkutil.SelectPhysicalDevice(instance, vkutil.DeviceRequirements{
+		QueueFlags: vk.QueueGraphicsBit,
+	}, vkutil.DevicePreferences{Name: *device})
+	vkutil.PrintDeviceCandidates(deviceCandidates)
+	vkutil.OrPanic(err)
+	physicalDevice := selectedDevice.PhysicalDevice
+	queueFamilies, err := vkutil.FindQueueFamilies(physicalDevice, vk.NullSurface)
+	vkutil.OrPanic(err)
+	vkutil.OrPanic(queueFamilies.Require(false))
+	fmt.Println("Queue families:", queueFamilies)
+	logicalDevice, err := vkutil.CreateDevice(physicalDevice, queueFamilies, nil, nil)
+	vkutil.OrPanic(err)
+	resources.Push("logical device", func() { vk.DestroyDevice(logicalDevice, nil) })
+	queues := vkutil.GetDeviceQueues(logicalDevice, queueFamilies)
+	allocator := vkutil.NewAllocator(logicalDevice, physicalDevice, vkutil.AllocatorConfig{})
+	resources.PushErr("allocator", allocator.Destroy)
+
+	target, err := vkutil.NewOffscreenTarget(logicalDevice, allocator, queueFamilies.Graphics, queues.Graphics, vkutil.OffscreenConfig{
+		Extent:     vk.Extent2D{Width: uint32(*width), Height: uint32(*height)},
+		ClearColor: [4]float32{0, 0, 0, 1},
+	})
+	vkutil.OrPanic(err)
+	resources.Push("offscreen target", target.Destroy)
+
+	vertexShader, err := vkutil.LoadSPIRVFS(shaders.FS, "triangle.vert.spv")
+	vkutil.OrPanic(err)
+	fragmentShader, err := vkutil.LoadSPIRVFS(shaders.FS, "triangle.frag.spv")
+	vkutil.OrPanic(err)
+	builder := vkutil.NewGraphicsPipelineBuilder(target.RenderPass)
+	builder.AddShader(vertexShader, vk.ShaderStageVertexBit)
+	builder.AddShader(fragmentShader, vk.ShaderStageFragmentBit)
+	pipeline, err := builder.Build(logicalDevice)
+	vkutil.OrPanic(err)
+	resources.Push("graphics pipeline", pipeline.Destroy)
+
+	img, err := target.Render(func(commandBuffer vk.CommandBuffer) {
+		vk.CmdBindPipeline(commandBuffer, vk.PipelineBindPointGraphics, pipeline.Pipeline)
+		vk.CmdDraw(commandBuffer, 3, 1, 0, 0)
+	})
+	vkutil.OrPanic(err)
+	vkutil.OrPanic(vkutil.SaveImage(*output, img))
+	fmt.Printf("Wrote %vx%v image to %v\n", img.Bounds().Dx(), img.Bounds().Dy(), *output)
+
+	vkutil.OrPanic(vkutil.DeviceWaitTillComplete(logicalDevice))
+	vkutil.OrPanic(resources.Close())
+}
@@ -0,0 +1,129 @@
+// Excercise011 renders scenes headless and compares them against the golden
+// PNGs in testdata, writing <scene>.got.png and <scene>.diff.png on failure.
+// Run it from the repository root, -update rewrites the goldens:
+//
+//	go run ./Excercise011
+//	go run ./Excercise011 -update
+//
+// go test ./Excercise011 checks the same scenes, -update works there too.
+// The test is skipped when no Vulkan loader is installed.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"image"
+	"os"
+
+	"github.com/goodshailesh/My-Vulkan-Projects/vkutil"
+	"github.com/goodshailesh/My-Vulkan-Projects/vkutil/golden"
+	vk "github.com/vulkan-go/vulkan"
+)
+
+var (
+	update   = flag.Bool("update", false, "rewrite the goldens with the rendered frames")
+	goldens  = flag.String("goldens", "Excercise011/testdata", "directory of the golden PNGs")
+	failures = flag.String("failures", "", "directory for the images of failed scenes, defaults to -goldens")
+	device   = flag.String("device", "", "part of the name of the device to use, e.g. llvmpipe")
+)
+
+// headless is what every scene renders with.
+type headless struct {
+	device        vk.Device
+	allocator     *vkutil.Allocator
+	queueFamilies vkutil.QueueFamilyIndices
+	queues        vkutil.Queues
+}
+
+// render draws with record into a new offscreen target cleared to clearColor.
+func (h *headless) render(extent vk.Extent2D, clearColor [4]float32, record func(commandBuffer vk.CommandBuffer)) (image.Image, error) {
+	target, err := vkutil.NewOffscreenTarget(h.device, h.allocator, h.queueFamilies.Graphics, h.queues.Graphics, vkutil.OffscreenConfig{
+		Extent:     extent,
+		ClearColor: clearColor,
+	})
+	if err != nil {
+		return nil, err
+	}
+	defer target.Destroy()
+	return target.Render(record)
+}
+
+// scenes lists what is checked, the golden of each is testdata/<Name>.png.
+func scenes(h *headless) []golden.Scene {
+	return []golden.Scene{{
+		// The clear of xDrawFrameToDevice in Excercise002
+		Name: "red-clear",
+		Render: func() (image.Image, error) {
+			return h.render(vk.Extent2D{Width: 256, Height: 256}, [4]float32{1.0, 0.0, 0.0, 1.0}, nil)
+		},
+		Options: golden.Options{Tolerance: 1},
+	}}
+}
+
+// newHeadless loads Vulkan and creates a device with a graphics queue on the
+// device whose name contains name, any when empty. What it creates is
+// pushed onto resources.
+func newHeadless(name string, resources *vkutil.DeletionQueue) (*headless, error) {
+	if err := vkutil.LoadVulkanLibrary(); err != nil {
+		return nil, err
+	}
+	if err := vk.Init(); err != nil {
+		return nil, err
+	}
+	instance, err := vkutil.CreateInstance(vkutil.NewApplicationInfo("myVulkan Goldens", "My Game Engine"), nil, nil)
+	if err != nil {
+		return nil, err
+	}
+	resources.Push("instance", func() { vk.DestroyInstance(instance, nil) })
+	selectedDevice, deviceCandidates, err := vkutil.SelectPhysicalDevice(instance, vkutil.DeviceRequirements{
+		QueueFlags: vk.QueueGraphicsBit,
+	}, vkutil.DevicePreferences{Name: name})
+	if err != nil {
+		vkutil.PrintDeviceCandidates(deviceCandidates)
+		return nil, err
+	}
+	fmt.Println("Rendering on", selectedDevice.Name)
+	queueFamilies, err := vkutil.FindQueueFamilies(selectedDevice.PhysicalDevice, vk.NullSurface)
+	if err != nil {
+		return nil, err
+	}
+	if err := queueFamilies.Require(false); err != nil {
+		return nil, err
+	}
+	logicalDevice, err := vkutil.CreateDevice(selectedDevice.PhysicalDevice, queueFamilies, nil, nil)
+	if err != nil {
+		return nil, err
+	}
+	resources.Push("logical device", func() { vk.DestroyDevice(logicalDevice, nil) })
+	allocator := vkutil.NewAllocator(logicalDevice, selectedDevice.PhysicalDevice, vkutil.AllocatorConfig{})
+	resources.PushErr("allocator", allocator.Destroy)
+
+	return &headless{
+		device:        logicalDevice,
+		allocator:     allocator,
+		queueFamilies: queueFamilies,
+		queues:        vkutil.GetDeviceQueues(logicalDevice, queueFamilies),
+	}, nil
+}
+
+func main() {
+	flag.Parse()
+	// Everything created is registered here and destroyed in reverse order at the end
+	resources := vkutil.NewDeletionQueue()
+	h, err := newHeadless(*device, resources)
+	vkutil.OrPanic(err)
+	harness := &golden.Harness{
+		Dir:        *goldens,
+		Update:     *update,
+		FailureDir: *failures,
+		Log:        os.Stdout,
+	}
+	runErr := harness.Run(scenes(h))
+
+	vkutil.OrPanic(vkutil.DeviceWaitTillComplete(h.device))
+	vkutil.OrPanic(resources.Close())
+	if runErr != nil {
+		fmt.Fprintln(os.Stderr, runErr)
+		os.Exit(1)
+	}
+}
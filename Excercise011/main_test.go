@@ -0,0 +1,61 @@
+package main
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/goodshailesh/My-Vulkan-Projects/vkutil"
+	"github.com/goodshailesh/My-Vulkan-Projects/vkutil/golden"
+)
+
+// testLog sends the lines of the harness to the test log.
+type testLog struct{ t *testing.T }
+
+func (l testLog) Write(p []byte) (int, error) {
+	l.t.Log(strings.TrimSuffix(string(p), "\n"))
+	return len(p), nil
+}
+
+// TestScenes checks the scenes of main against testdata, go test
+// ./Excercise011 -update rewrites the goldens. -device and -failures work
+// as for go run.
+func TestScenes(t *testing.T) {
+	if err := vkutil.LoadVulkanLibrary(); err != nil {
+		t.Skip(err)
+	}
+	resources := vkutil.NewDeletionQueue()
+	defer func() {
+		if err := resources.Close(); err != nil {
+			t.Error(err)
+		}
+	}()
+	h, err := newHeadless(*device, resources)
+	var noDevice *vkutil.NoSuitableDeviceError
+	if errors.As(err, &noDevice) {
+		t.Skip(err)
+	}
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		if err := vkutil.DeviceWaitTillComplete(h.device); err != nil {
+			t.Error(err)
+		}
+	}()
+
+	// Tests run in the package directory
+	harness := &golden.Harness{
+		Dir:        "testdata",
+		Update:     *update,
+		FailureDir: *failures,
+		Log:        testLog{t},
+	}
+	for _, scene := range scenes(h) {
+		t.Run(scene.Name, func(t *testing.T) {
+			if err := harness.Check(scene); err != nil {
+				t.Error(err)
+			}
+		})
+	}
+}
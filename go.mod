@@ -0,0 +1,8 @@
+module github.com/goodshailesh/My-Vulkan-Projects
+
+go 1.21
+
+require (
+	github.com/vulkan-go/glfw v0.0.0-20210402172934-58379a80228d
+	github.com/vulkan-go/vulkan v0.0.0-20210402152248-956e3850d8f9
+)
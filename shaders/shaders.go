@@ -0,0 +1,17 @@
+// Package shaders embeds the SPIR-V of the GLSL shaders next to it, so the
+// exercises do not depend on the directory they are started from. Load them
+// with vkutil.LoadSPIRVFS(shaders.FS, "triangle.vert.spv").
+//
+// After editing a shader regenerate the SPIR-V with the glslc of the Vulkan
+// SDK: go generate ./shaders
+package shaders
+
+import "embed"
+
+//go:generate glslc triangle.vert -o triangle.vert.spv
+//go:generate glslc triangle.frag -o triangle.frag.spv
+
+// FS holds every compiled *.spv shader of this directory.
+//
+//go:embed *.spv
+var FS embed.FS
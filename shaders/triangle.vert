@@ -0,0 +1,13 @@
+#version 450
+
+// A triangle without vertex buffers, the vertices are picked with gl_VertexIndex
+// https://www.khronos.org/opengl/wiki/Vertex_Shader#Other_inputs
+
+layout(location = 0) out vec3 fragColor;
+
+void main() {
+    vec2 positions[3] = vec2[](vec2(0.0, -0.5), vec2(0.5, 0.5), vec2(-0.5, 0.5));
+    vec3 colors[3] = vec3[](vec3(1.0, 0.0, 0.0), vec3(0.0, 1.0, 0.0), vec3(0.0, 0.0, 1.0));
+    gl_Position = vec4(positions[gl_VertexIndex], 0.0, 1.0);
+    fragColor = colors[gl_VertexIndex];
+}
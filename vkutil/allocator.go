@@ -0,0 +1,378 @@
+package vkutil
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"unsafe"
+
+	vk "github.com/vulkan-go/vulkan"
+)
+
+// AllocatorConfig tunes NewAllocator. Zero values pick the defaults.
+type AllocatorConfig struct {
+	// BlockSize is the size of the vkAllocateMemory blocks resources are
+	// sub-allocated from. Defaults to 64 MiB, or 1/8 of the heap for heaps
+	// smaller than 512 MiB.
+	BlockSize vk.DeviceSize
+	// DedicatedThreshold is the size from which a resource gets its own
+	// vkAllocateMemory instead of a range of a block. Defaults to half the
+	// block size, and is capped at the block size.
+	DedicatedThreshold vk.DeviceSize
+}
+
+const defaultBlockSize = vk.DeviceSize(64 << 20)
+
+// Allocator sub-allocates device memory for buffers and images out of a
+// few big blocks per memory type, so a program does not run into
+// maxMemoryAllocationCount (as low as 4096) with one vkAllocateMemory per
+// resource. It is safe for concurrent use.
+type Allocator struct {
+	device           vk.Device
+	memoryProperties vk.PhysicalDeviceMemoryProperties
+	granularity      vk.DeviceSize
+	maxAllocations   uint32
+	config           AllocatorConfig
+
+	mu          sync.Mutex
+	blocks      [vk.MaxMemoryTypes][]*memoryBlock
+	live        map[*Allocation]struct{}
+	allocations uint32 // vkAllocateMemory calls alive
+}
+
+// memoryBlock is one vkAllocateMemory sub-allocated by a blockFreeList.
+type memoryBlock struct {
+	memory vk.DeviceMemory
+	list   *blockFreeList
+	mapped unsafe.Pointer
+}
+
+// Allocation is a range of device memory bound to one resource.
+type Allocation struct {
+	Memory          vk.DeviceMemory
+	Offset          vk.DeviceSize
+	Size            vk.DeviceSize
+	MemoryTypeIndex uint32
+	// Dedicated is true when the allocation owns Memory.
+	Dedicated bool
+	// Name shows up in the leak report.
+	Name string
+
+	allocator *Allocator
+	block     *memoryBlock
+	mapped    unsafe.Pointer // dedicated allocations only
+}
+
+// NewAllocator creates an allocator for device, which was created from physicalDevice.
+func NewAllocator(device vk.Device, physicalDevice vk.PhysicalDevice, config AllocatorConfig) *Allocator {
+	properties := GetPhysicalDeviceProperties(physicalDevice)
+	return &Allocator{
+		device:           device,
+		memoryProperties: GetPhysicalDeviceMemoryProperties(physicalDevice),
+		granularity:      properties.Limits.BufferImageGranularity,
+		maxAllocations:   properties.Limits.MaxMemoryAllocationCount,
+		config:           config,
+		live:             make(map[*Allocation]struct{}),
+	}
+}
+
+func (a *Allocator) blockSize(memoryTypeIndex uint32) vk.DeviceSize {
+	if a.config.BlockSize != 0 {
+		return a.config.BlockSize
+	}
+	heap := a.memoryProperties.MemoryHeaps[a.memoryProperties.MemoryTypes[memoryTypeIndex].HeapIndex]
+	if heap.Size < 512<<20 {
+		return alignUp(heap.Size/8, 1<<20)
+	}
+	return defaultBlockSize
+}
+
+func (a *Allocator) dedicatedThreshold(memoryTypeIndex uint32) vk.DeviceSize {
+	blockSize := a.blockSize(memoryTypeIndex)
+	if a.config.DedicatedThreshold == 0 {
+		return blockSize / 2
+	}
+	// Anything larger than a block would not fit a new block either
+	return min(a.config.DedicatedThreshold, blockSize)
+}
+
+// Allocate finds memory for a resource with the given requirements, see
+// FindMemoryType for required and preferred. linear is true for buffers and
+// linearly tiled images, false for optimally tiled images.
+func (a *Allocator) Allocate(requirements vk.MemoryRequirements, required, preferred vk.MemoryPropertyFlags, linear bool, name string) (*Allocation, error) {
+	memoryTypeIndex, err := FindMemoryType(a.memoryProperties, requirements.MemoryTypeBits, required, preferred)
+	if err != nil {
+		return nil, fmt.Errorf("allocating %q: %w", name, err)
+	}
+	kind := resourceOptimal
+	if linear {
+		kind = resourceLinear
+	}
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	alloc := &Allocation{
+		Size:            requirements.Size,
+		MemoryTypeIndex: memoryTypeIndex,
+		Name:            name,
+		allocator:       a,
+	}
+	if requirements.Size >= a.dedicatedThreshold(memoryTypeIndex) {
+		alloc.Memory, err = a.allocateMemory(requirements.Size, memoryTypeIndex)
+		if err != nil {
+			return nil, fmt.Errorf("allocating %q: %w", name, err)
+		}
+		alloc.Dedicated = true
+		a.live[alloc] = struct{}{}
+		return alloc, nil
+	}
+	for _, block := range a.blocks[memoryTypeIndex] {
+		if offset, ok := block.list.allocate(requirements.Size, requirements.Alignment, kind); ok {
+			alloc.Memory, alloc.Offset, alloc.block = block.memory, offset, block
+			a.live[alloc] = struct{}{}
+			return alloc, nil
+		}
+	}
+	size := a.blockSize(memoryTypeIndex)
+	memory, err := a.allocateMemory(size, memoryTypeIndex)
+	if err != nil {
+		return nil, fmt.Errorf("allocating %q: %w", name, err)
+	}
+	block := &memoryBlock{memory: memory, list: newBlockFreeList(size, a.granularity)}
+	offset, ok := block.list.allocate(requirements.Size, requirements.Alignment, kind)
+	if !ok {
+		// Cannot happen as the size is below the dedicated threshold, which is at most the block size
+		driver.FreeMemory(a.device, memory)
+		a.allocations--
+		return nil, fmt.Errorf("allocating %q: %v bytes do not fit a new %v bytes block", name, requirements.Size, size)
+	}
+	a.blocks[memoryTypeIndex] = append(a.blocks[memoryTypeIndex], block)
+	alloc.Memory, alloc.Offset, alloc.block = memory, offset, block
+	a.live[alloc] = struct{}{}
+	return alloc, nil
+}
+
+func (a *Allocator) allocateMemory(size vk.DeviceSize, memoryTypeIndex uint32) (vk.DeviceMemory, error) {
+	if a.maxAllocations != 0 && a.allocations >= a.maxAllocations {
+		// What the driver would most likely answer, but it is not required to check the limit
+		return vk.NullDeviceMemory, fmt.Errorf("maxMemoryAllocationCount (%v) reached: %w", a.maxAllocations,
+			&ResultError{Call: "vkAllocateMemory", Result: vk.ErrorTooManyObjects})
+	}
+	memAlloc := &vk.MemoryAllocateInfo{
+		SType:           vk.StructureTypeMemoryAllocateInfo,
+		AllocationSize:  size,
+		MemoryTypeIndex: memoryTypeIndex,
+	}
+	memory, err := driver.AllocateMemory(a.device, memAlloc)
+	if err != nil {
+		return vk.NullDeviceMemory, err
+	}
+	a.allocations++
+	return memory, nil
+}
+
+// AllocateForBuffer allocates memory for buffer and binds it.
+func (a *Allocator) AllocateForBuffer(buffer vk.Buffer, required, preferred vk.MemoryPropertyFlags, name string) (*Allocation, error) {
+	alloc, err := a.Allocate(GetBufferMemoryRequirements(a.device, buffer), required, preferred, true, name)
+	if err != nil {
+		return nil, err
+	}
+	if err := driver.BindBufferMemory(a.device, buffer, alloc.Memory, alloc.Offset); err != nil {
+		a.Free(alloc)
+		return nil, err
+	}
+	return alloc, nil
+}
+
+// AllocateForImage allocates memory for image, created with tiling, and binds it.
+func (a *Allocator) AllocateForImage(image vk.Image, tiling vk.ImageTiling, required, preferred vk.MemoryPropertyFlags, name string) (*Allocation, error) {
+	alloc, err := a.Allocate(GetImageMemoryRequirements(a.device, image), required, preferred, tiling == vk.ImageTilingLinear, name)
+	if err != nil {
+		return nil, err
+	}
+	if err := driver.BindImageMemory(a.device, image, alloc.Memory, alloc.Offset); err != nil {
+		a.Free(alloc)
+		return nil, err
+	}
+	return alloc, nil
+}
+
+// Map returns a host pointer to the start of the allocation. The memory type
+// must be host visible. Blocks stay mapped until the allocator is destroyed,
+// as the same vk.DeviceMemory cannot be mapped twice.
+func (alloc *Allocation) Map() (unsafe.Pointer, error) {
+	a := alloc.allocator
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if alloc.Dedicated {
+		if alloc.mapped == nil {
+			mapped, err := driver.MapMemory(a.device, alloc.Memory, 0, vk.DeviceSize(vk.WholeSize))
+			if err != nil {
+				return nil, err
+			}
+			alloc.mapped = mapped
+		}
+		return alloc.mapped, nil
+	}
+	if alloc.block.mapped == nil {
+		mapped, err := driver.MapMemory(a.device, alloc.Memory, 0, vk.DeviceSize(vk.WholeSize))
+		if err != nil {
+			return nil, err
+		}
+		alloc.block.mapped = mapped
+	}
+	return unsafe.Pointer(uintptr(alloc.block.mapped) + uintptr(alloc.Offset)), nil
+}
+
+// Free gives the allocation back. The resource bound to it must have been
+// destroyed, or at least no longer be in use by the device.
+func (a *Allocator) Free(alloc *Allocation) {
+	if alloc == nil {
+		return
+	}
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if _, ok := a.live[alloc]; !ok {
+		return
+	}
+	delete(a.live, alloc)
+	if alloc.Dedicated {
+		driver.FreeMemory(a.device, alloc.Memory)
+		a.allocations--
+		return
+	}
+	alloc.block.list.free(alloc.Offset)
+	// Give empty blocks back to the driver, but keep one per memory type to
+	// avoid allocating and freeing a block over and over
+	blocks := a.blocks[alloc.MemoryTypeIndex]
+	if alloc.block.list.empty() && len(blocks) > 1 {
+		for idx, b := range blocks {
+			if b == alloc.block {
+				a.blocks[alloc.MemoryTypeIndex] = append(blocks[:idx], blocks[idx+1:]...)
+				break
+			}
+		}
+		driver.FreeMemory(a.device, alloc.block.memory)
+		a.allocations--
+	}
+}
+
+// AllocatorStats is a snapshot of what the allocator holds.
+type AllocatorStats struct {
+	// Blocks and BlockBytes are the sub-allocated vkAllocateMemory blocks.
+	Blocks     int
+	BlockBytes vk.DeviceSize
+	// UsedBytes is the part of BlockBytes handed out.
+	UsedBytes vk.DeviceSize
+	// LargestFree is the biggest range still free in any block.
+	LargestFree vk.DeviceSize
+	// Dedicated and DedicatedBytes are the allocations owning their memory.
+	Dedicated      int
+	DedicatedBytes vk.DeviceSize
+	// Allocations is the number of live Allocations, VkAllocations the
+	// number of vkAllocateMemory calls they need.
+	Allocations   int
+	VkAllocations uint32
+}
+
+// Stats returns the current statistics.
+func (a *Allocator) Stats() AllocatorStats {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	var stats AllocatorStats
+	for _, blocks := range a.blocks {
+		for _, b := range blocks {
+			stats.Blocks++
+			stats.BlockBytes += b.list.size
+			stats.UsedBytes += b.list.used
+			if free := b.list.largestFree(); free > stats.LargestFree {
+				stats.LargestFree = free
+			}
+		}
+	}
+	for alloc := range a.live {
+		if alloc.Dedicated {
+			stats.Dedicated++
+			stats.DedicatedBytes += alloc.Size
+		}
+	}
+	stats.Allocations = len(a.live)
+	stats.VkAllocations = a.allocations
+	return stats
+}
+
+// PrintStats prints Stats.
+func (a *Allocator) PrintStats() {
+	stats := a.Stats()
+	fmt.Println("Allocator statistics..............")
+	fmt.Printf("\t* Allocations: %v in %v vkAllocateMemory (max %v)\n", stats.Allocations, stats.VkAllocations, a.maxAllocations)
+	fmt.Printf("\t* Blocks: %v, %v of %v bytes used, largest free range %v bytes\n", stats.Blocks, stats.UsedBytes, stats.BlockBytes, stats.LargestFree)
+	fmt.Printf("\t* Dedicated: %v, %v bytes\n", stats.Dedicated, stats.DedicatedBytes)
+}
+
+// LeakError is returned by Allocator.Destroy when allocations were never freed.
+type LeakError struct {
+	Leaks []string
+}
+
+func (e *LeakError) Error() string {
+	return fmt.Sprintf("%v allocation(s) leaked: %v", len(e.Leaks), strings.Join(e.Leaks, ", "))
+}
+
+// Destroy frees every block and dedicated allocation. Allocations still
+// live are leaks: they are freed as well and reported in a *LeakError.
+func (a *Allocator) Destroy() error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	var leaks []string
+	for alloc := range a.live {
+		leaks = append(leaks, fmt.Sprintf("%q (%v bytes, memory type %v)", alloc.Name, alloc.Size, alloc.MemoryTypeIndex))
+		if alloc.Dedicated {
+			driver.FreeMemory(a.device, alloc.Memory)
+		}
+	}
+	for idx, blocks := range a.blocks {
+		for _, b := range blocks {
+			driver.FreeMemory(a.device, b.memory)
+		}
+		a.blocks[idx] = nil
+	}
+	a.live = make(map[*Allocation]struct{})
+	a.allocations = 0
+	if len(leaks) > 0 {
+		sort.Strings(leaks)
+		return &LeakError{Leaks: leaks}
+	}
+	return nil
+}
+
+// CreateBufferWithMemory creates an exclusive buffer of size bytes with
+// memory from allocator bound to it.
+func CreateBufferWithMemory(device vk.Device, allocator *Allocator, size vk.DeviceSize, usage vk.BufferUsageFlags, required, preferred vk.MemoryPropertyFlags, name string) (vk.Buffer, *Allocation, error) {
+	buffer, err := CreateBuffer(device, size, usage)
+	if err != nil {
+		return vk.NullBuffer, nil, err
+	}
+	alloc, err := allocator.AllocateForBuffer(buffer, required, preferred, name)
+	if err != nil {
+		driver.DestroyBuffer(device, buffer)
+		return vk.NullBuffer, nil, err
+	}
+	return buffer, alloc, nil
+}
+
+// CreateImageWithMemory creates an image like CreateImageBuffer with
+// device local memory from allocator bound to it.
+func CreateImageWithMemory(device vk.Device, allocator *Allocator, format vk.Format, extent vk.Extent3D, mipLevels uint32, usage vk.ImageUsageFlags, name string) (vk.Image, *Allocation, error) {
+	image, err := CreateImageBuffer(device, format, extent, mipLevels, usage)
+	if err != nil {
+		return vk.NullImage, nil, err
+	}
+	alloc, err := allocator.AllocateForImage(image, vk.ImageTilingOptimal, vk.MemoryPropertyFlags(vk.MemoryPropertyDeviceLocalBit), 0, name)
+	if err != nil {
+		driver.DestroyImage(device, image)
+		return vk.NullImage, nil, err
+	}
+	return image, alloc, nil
+}
@@ -0,0 +1,159 @@
+package vkutil
+
+import (
+	vk "github.com/vulkan-go/vulkan"
+)
+
+// resourceKind tells linear resources (buffers, linear images) from
+// optimally tiled images. Neighbours of different kinds must not share a
+// bufferImageGranularity page.
+// https://www.khronos.org/registry/vulkan/specs/1.2-extensions/html/vkspec.html#resources-bufferimagegranularity
+type resourceKind int
+
+const (
+	resourceLinear resourceKind = iota
+	resourceOptimal
+)
+
+// blockRegion is a free or used byte range of a blockFreeList.
+type blockRegion struct {
+	offset vk.DeviceSize
+	size   vk.DeviceSize
+	free   bool
+	kind   resourceKind
+}
+
+func (r blockRegion) end() vk.DeviceSize {
+	return r.offset + r.size
+}
+
+// blockFreeList hands out ranges of a memory block with a first-fit free
+// list. Regions are sorted by offset and cover the whole block; adjacent
+// free regions are always merged, so the neighbours of a free region are
+// used ones.
+type blockFreeList struct {
+	size        vk.DeviceSize
+	granularity vk.DeviceSize
+	regions     []blockRegion
+	used        vk.DeviceSize
+	count       int
+}
+
+func newBlockFreeList(size, granularity vk.DeviceSize) *blockFreeList {
+	if granularity == 0 {
+		granularity = 1
+	}
+	return &blockFreeList{
+		size:        size,
+		granularity: granularity,
+		regions:     []blockRegion{{offset: 0, size: size, free: true}},
+	}
+}
+
+func alignUp(value, alignment vk.DeviceSize) vk.DeviceSize {
+	if alignment <= 1 {
+		return value
+	}
+	return (value + alignment - 1) / alignment * alignment
+}
+
+// samePage reports whether the byte at endOfA-1 and the byte at startOfB
+// fall in the same granularity page.
+func samePage(endOfA, startOfB, granularity vk.DeviceSize) bool {
+	if endOfA == 0 {
+		return false
+	}
+	return (endOfA-1)/granularity == startOfB/granularity
+}
+
+// allocate reserves size bytes aligned to alignment and returns the offset.
+// ok is false when no free region can take it.
+func (l *blockFreeList) allocate(size, alignment vk.DeviceSize, kind resourceKind) (offset vk.DeviceSize, ok bool) {
+	if size == 0 {
+		size = 1
+	}
+	for idx, r := range l.regions {
+		if !r.free || r.size < size {
+			continue
+		}
+		offset = alignUp(r.offset, alignment)
+		if idx > 0 {
+			prev := l.regions[idx-1]
+			if prev.kind != kind && samePage(prev.end(), offset, l.granularity) {
+				offset = alignUp(offset, l.granularity)
+			}
+		}
+		end := offset + size
+		if end > r.end() {
+			continue
+		}
+		if idx+1 < len(l.regions) {
+			next := l.regions[idx+1]
+			if next.kind != kind && samePage(end, next.offset, l.granularity) {
+				continue
+			}
+		}
+		l.split(idx, offset, size, kind)
+		l.used += size
+		l.count++
+		return offset, true
+	}
+	return 0, false
+}
+
+// split carves the used range [offset, offset+size) out of free region idx.
+func (l *blockFreeList) split(idx int, offset, size vk.DeviceSize, kind resourceKind) {
+	r := l.regions[idx]
+	var parts []blockRegion
+	if offset > r.offset {
+		parts = append(parts, blockRegion{offset: r.offset, size: offset - r.offset, free: true})
+	}
+	parts = append(parts, blockRegion{offset: offset, size: size, kind: kind})
+	if end := offset + size; end < r.end() {
+		parts = append(parts, blockRegion{offset: end, size: r.end() - end, free: true})
+	}
+	regions := make([]blockRegion, 0, len(l.regions)+len(parts)-1)
+	regions = append(regions, l.regions[:idx]...)
+	regions = append(regions, parts...)
+	regions = append(regions, l.regions[idx+1:]...)
+	l.regions = regions
+}
+
+// free releases the range allocated at offset. It returns false if no
+// allocation starts there.
+func (l *blockFreeList) free(offset vk.DeviceSize) bool {
+	for idx, r := range l.regions {
+		if r.offset != offset || r.free {
+			continue
+		}
+		l.used -= r.size
+		l.count--
+		l.regions[idx].free = true
+		// Merge with the following then the preceding region when free
+		if idx+1 < len(l.regions) && l.regions[idx+1].free {
+			l.regions[idx].size += l.regions[idx+1].size
+			l.regions = append(l.regions[:idx+1], l.regions[idx+2:]...)
+		}
+		if idx > 0 && l.regions[idx-1].free {
+			l.regions[idx-1].size += l.regions[idx].size
+			l.regions = append(l.regions[:idx], l.regions[idx+1:]...)
+		}
+		return true
+	}
+	return false
+}
+
+// largestFree returns the size of the biggest free region.
+func (l *blockFreeList) largestFree() vk.DeviceSize {
+	var largest vk.DeviceSize
+	for _, r := range l.regions {
+		if r.free && r.size > largest {
+			largest = r.size
+		}
+	}
+	return largest
+}
+
+func (l *blockFreeList) empty() bool {
+	return l.count == 0
+}
@@ -0,0 +1,148 @@
+package vkutil
+
+import (
+	"testing"
+
+	vk "github.com/vulkan-go/vulkan"
+)
+
+// allocation is one blockFreeList.allocate call and the offset it must return.
+type allocation struct {
+	size, alignment vk.DeviceSize
+	kind            resourceKind
+	want            vk.DeviceSize
+}
+
+func allocateAll(t *testing.T, l *blockFreeList, allocations []allocation) {
+	t.Helper()
+	for idx, a := range allocations {
+		offset, ok := l.allocate(a.size, a.alignment, a.kind)
+		if !ok {
+			t.Fatalf("allocation %v of %v bytes failed", idx, a.size)
+		}
+		if offset != a.want {
+			t.Fatalf("allocation %v of %v bytes aligned to %v at %v, want %v", idx, a.size, a.alignment, offset, a.want)
+		}
+	}
+}
+
+func TestBlockFreeListAlignment(t *testing.T) {
+	l := newBlockFreeList(1024, 1)
+	allocateAll(t, l, []allocation{
+		{size: 10, alignment: 1, want: 0},
+		{size: 16, alignment: 256, want: 256},
+		{size: 4, alignment: 4, want: 12},
+		// Zero sized allocations still take a byte, first fit in the
+		// padding in front of the last one
+		{size: 0, alignment: 1, want: 10},
+	})
+	if l.used != 31 || l.count != 4 {
+		t.Errorf("used %v bytes in %v allocations, want 31 in 4", l.used, l.count)
+	}
+	// 272 to 1024 is the largest hole
+	if got := l.largestFree(); got != 752 {
+		t.Errorf("largestFree = %v, want 752", got)
+	}
+	if _, ok := l.allocate(753, 1, resourceLinear); ok {
+		t.Error("allocated more than the largest hole")
+	}
+}
+
+func TestBlockFreeListFreeMerges(t *testing.T) {
+	l := newBlockFreeList(1024, 1)
+	allocateAll(t, l, []allocation{
+		{size: 100, alignment: 1, want: 0},
+		{size: 100, alignment: 1, want: 100},
+		{size: 100, alignment: 1, want: 200},
+	})
+	if l.free(50) {
+		t.Error("freed an offset no allocation starts at")
+	}
+	// Freeing the middle then the first leaves one hole of 200 in front
+	for _, offset := range []vk.DeviceSize{100, 0} {
+		if !l.free(offset) {
+			t.Fatalf("free(%v) failed", offset)
+		}
+	}
+	if l.free(0) {
+		t.Error("freed the same offset twice")
+	}
+	allocateAll(t, l, []allocation{{size: 200, alignment: 1, want: 0}})
+	for _, offset := range []vk.DeviceSize{0, 200} {
+		l.free(offset)
+	}
+	if !l.empty() || l.used != 0 {
+		t.Errorf("empty = %v with %v bytes used after freeing everything", l.empty(), l.used)
+	}
+	if len(l.regions) != 1 || l.largestFree() != 1024 {
+		t.Errorf("regions = %+v, want the whole block free", l.regions)
+	}
+}
+
+func TestBlockFreeListGranularity(t *testing.T) {
+	l := newBlockFreeList(8192, 1024)
+	allocateAll(t, l, []allocation{
+		{size: 100, alignment: 4, kind: resourceLinear, want: 0},
+		// Same kind packs tightly
+		{size: 100, alignment: 4, kind: resourceLinear, want: 100},
+		// An image after buffers starts on the next page
+		{size: 100, alignment: 16, kind: resourceOptimal, want: 1024},
+		{size: 100, alignment: 16, kind: resourceOptimal, want: 1136},
+		// A buffer fits in front of the image page
+		{size: 100, alignment: 4, kind: resourceLinear, want: 200},
+		// and one after the images starts on the next page
+		{size: 900, alignment: 4, kind: resourceLinear, want: 2048},
+		// An image would share the page of the buffer in front of the hole
+		// at 300, it goes after the other images
+		{size: 100, alignment: 16, kind: resourceOptimal, want: 1248},
+	})
+	// An image must not end in the page of the buffer following it either
+	if !l.free(0) {
+		t.Fatal("free(0) failed")
+	}
+	allocateAll(t, l, []allocation{
+		{size: 50, alignment: 1, kind: resourceOptimal, want: 1348},
+		{size: 50, alignment: 1, kind: resourceLinear, want: 0},
+	})
+}
+
+func TestSamePage(t *testing.T) {
+	for _, tc := range []struct {
+		endOfA, startOfB vk.DeviceSize
+		want             bool
+	}{
+		{0, 0, false},
+		{100, 200, true},
+		{1024, 1024, false},
+		{1025, 1100, true},
+		{1000, 2048, false},
+	} {
+		if got := samePage(tc.endOfA, tc.startOfB, 1024); got != tc.want {
+			t.Errorf("samePage(%v, %v, 1024) = %v, want %v", tc.endOfA, tc.startOfB, got, tc.want)
+		}
+	}
+}
+
+func TestAllocatorDedicatedThreshold(t *testing.T) {
+	const blockSize = 1 << 20
+	tests := []struct {
+		name      string
+		threshold vk.DeviceSize
+		want      vk.DeviceSize
+	}{
+		{"defaults to half a block", 0, blockSize / 2},
+		{"below the block size", 4 << 10, 4 << 10},
+		{"capped at the block size", 4 << 20, blockSize},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a := &Allocator{
+				memoryProperties: memoryTable(deviceLocal),
+				config:           AllocatorConfig{BlockSize: blockSize, DedicatedThreshold: tt.threshold},
+			}
+			if got := a.dedicatedThreshold(0); got != tt.want {
+				t.Errorf("dedicatedThreshold = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
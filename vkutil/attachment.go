@@ -0,0 +1,103 @@
+package vkutil
+
+import (
+	"fmt"
+
+	vk "github.com/vulkan-go/vulkan"
+)
+
+// AttachmentConfig tunes NewAttachment. Zero values pick the defaults.
+type AttachmentConfig struct {
+	Format vk.Format
+	Extent vk.Extent2D
+	// Samples per pixel. Defaults to vk.SampleCount1Bit.
+	Samples vk.SampleCountFlagBits
+	// Usage is added to COLOR_ATTACHMENT, or DEPTH_STENCIL_ATTACHMENT for
+	// depth formats, e.g. vk.ImageUsageSampledBit to read it afterwards.
+	Usage vk.ImageUsageFlags
+	// Transient attachments live within a render pass only, like a
+	// multisampled image resolved at the end of it or a depth buffer. They
+	// get lazily allocated memory where the GPU has some, tile based GPUs
+	// then never back them with real memory.
+	Transient bool
+	// Name shows up in the allocator statistics.
+	Name string
+}
+
+// Attachment is a device local image made to be a framebuffer attachment,
+// and a view over all of its aspects. Recreate it with the swapchain it is
+// sized after.
+type Attachment struct {
+	Format     vk.Format
+	Extent     vk.Extent2D
+	Samples    vk.SampleCountFlagBits
+	Image      vk.Image
+	View       vk.ImageView
+	Allocation *Allocation
+
+	device vk.Device
+}
+
+// NewAttachment creates the image with memory from allocator and its view.
+func NewAttachment(device vk.Device, allocator *Allocator, config AttachmentConfig) (*Attachment, error) {
+	if config.Samples == 0 {
+		config.Samples = vk.SampleCount1Bit
+	}
+	if config.Name == "" {
+		config.Name = "attachment"
+	}
+	usage, aspect := vk.ImageUsageFlags(vk.ImageUsageColorAttachmentBit), vk.ImageAspectFlags(vk.ImageAspectColorBit)
+	if IsDepthFormat(config.Format) {
+		usage, aspect = vk.ImageUsageFlags(vk.ImageUsageDepthStencilAttachmentBit), DepthAspect(config.Format)
+	}
+	usage |= config.Usage
+	var preferred vk.MemoryPropertyFlags
+	if config.Transient {
+		// Only attachment usages may go with TRANSIENT_ATTACHMENT
+		usage |= vk.ImageUsageFlags(vk.ImageUsageTransientAttachmentBit)
+		preferred = vk.MemoryPropertyFlags(vk.MemoryPropertyLazilyAllocatedBit)
+	}
+	a := &Attachment{Format: config.Format, Extent: config.Extent, Samples: config.Samples, device: device}
+	var err error
+	a.Image, err = CreateAttachmentImage(device, config.Format, config.Extent, config.Samples, usage)
+	if err != nil {
+		return nil, fmt.Errorf("%v: %w", config.Name, err)
+	}
+	a.Allocation, err = allocator.AllocateForImage(a.Image, vk.ImageTilingOptimal, vk.MemoryPropertyFlags(vk.MemoryPropertyDeviceLocalBit), preferred, config.Name)
+	if err != nil {
+		a.Destroy()
+		return nil, err
+	}
+	a.View, err = CreateImageViewAspect(device, a.Image, config.Format, aspect)
+	if err != nil {
+		a.Destroy()
+		return nil, fmt.Errorf("%v: %w", config.Name, err)
+	}
+	return a, nil
+}
+
+// Destroy destroys the view and image and frees the memory. The GPU must be done with them.
+func (a *Attachment) Destroy() {
+	if a.View != vk.NullImageView {
+		driver.DestroyImageView(a.device, a.View)
+		a.View = vk.NullImageView
+	}
+	if a.Image != vk.NullImage {
+		driver.DestroyImage(a.device, a.Image)
+		a.Image = vk.NullImage
+	}
+	if a.Allocation != nil {
+		a.Allocation.allocator.Free(a.Allocation)
+		a.Allocation = nil
+	}
+}
+
+// IsDepthFormat reports whether format has a depth or a stencil aspect.
+func IsDepthFormat(format vk.Format) bool {
+	switch format {
+	case vk.FormatD16Unorm, vk.FormatX8D24UnormPack32, vk.FormatD32Sfloat, vk.FormatS8Uint,
+		vk.FormatD16UnormS8Uint, vk.FormatD24UnormS8Uint, vk.FormatD32SfloatS8Uint:
+		return true
+	}
+	return false
+}
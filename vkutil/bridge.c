@@ -0,0 +1,105 @@
+#include <stddef.h>
+#include <string.h>
+#include "bridge.h"
+
+#if defined(_WIN32)
+#include <windows.h>
+#else
+#include <dlfcn.h>
+#endif
+
+typedef vkutil_PFN_voidFunction(VKUTIL_CALL* vkutil_PFN_getInstanceProcAddr)(void* instance, const char* pName);
+
+static vkutil_PFN_getInstanceProcAddr vkutil_getInstanceProcAddr = NULL;
+
+void vkutil_setProcAddr(void* getProcAddr) {
+    vkutil_getInstanceProcAddr = (vkutil_PFN_getInstanceProcAddr)getProcAddr;
+}
+
+int vkutil_isProcAddrSet(void) {
+    return vkutil_getInstanceProcAddr != NULL;
+}
+
+void* vkutil_loadGetInstanceProcAddr(void) {
+#if defined(_WIN32)
+    HMODULE library = LoadLibraryA("vulkan-1.dll");
+    if (library == NULL) {
+        return NULL;
+    }
+    return (void*)GetProcAddress(library, "vkGetInstanceProcAddr");
+#else
+    // The unversioned name only exists with the development package installed
+    static const char* names[] = {"libvulkan.so.1", "libvulkan.so", "libvulkan.1.dylib", "libMoltenVK.dylib"};
+    for (size_t idx = 0; idx < sizeof(names) / sizeof(names[0]); idx++) {
+        void* library = dlopen(names[idx], RTLD_NOW | RTLD_LOCAL);
+        if (library == NULL) {
+            continue;
+        }
+        void* getProcAddr = dlsym(library, "vkGetInstanceProcAddr");
+        if (getProcAddr != NULL) {
+            return getProcAddr;
+        }
+        dlclose(library);
+    }
+    return NULL;
+#endif
+}
+
+vkutil_PFN_voidFunction vkutil_getInstanceProc(void* instance, const char* name) {
+    if (vkutil_getInstanceProcAddr == NULL) {
+        return NULL;
+    }
+    return vkutil_getInstanceProcAddr(instance, name);
+}
+
+// Layouts follow vulkan_core.h.
+// https://www.khronos.org/registry/vulkan/specs/1.2-extensions/man/html/VkPhysicalDeviceIDProperties.html
+#define VKUTIL_STRUCTURE_TYPE_PHYSICAL_DEVICE_PROPERTIES_2 1000059001
+#define VKUTIL_STRUCTURE_TYPE_PHYSICAL_DEVICE_ID_PROPERTIES 1000071004
+#define VKUTIL_UUID_SIZE 16
+#define VKUTIL_LUID_SIZE 8
+// sizeof(VkPhysicalDeviceProperties) is 824 on every platform, the extra room
+// keeps us safe should the layout ever be padded differently.
+#define VKUTIL_PHYSICAL_DEVICE_PROPERTIES_SIZE 1024
+
+typedef struct vkutil_PhysicalDeviceIDProperties {
+    int32_t sType;
+    void* pNext;
+    uint8_t deviceUUID[VKUTIL_UUID_SIZE];
+    uint8_t driverUUID[VKUTIL_UUID_SIZE];
+    uint8_t deviceLUID[VKUTIL_LUID_SIZE];
+    uint32_t deviceNodeMask;
+    uint32_t deviceLUIDValid;
+} vkutil_PhysicalDeviceIDProperties;
+
+typedef struct vkutil_PhysicalDeviceProperties2 {
+    int32_t sType;
+    void* pNext;
+    // VkPhysicalDeviceProperties starts with uint32_t apiVersion, so it is 8 byte
+    // aligned at most; a uint64_t array keeps the same alignment.
+    uint64_t properties[VKUTIL_PHYSICAL_DEVICE_PROPERTIES_SIZE / 8];
+} vkutil_PhysicalDeviceProperties2;
+
+typedef void(VKUTIL_CALL* vkutil_PFN_getPhysicalDeviceProperties2)(void* physicalDevice, vkutil_PhysicalDeviceProperties2* pProperties);
+
+int vkutil_getPhysicalDeviceUUID(void* instance, void* physicalDevice, uint8_t* uuid) {
+    vkutil_PFN_getPhysicalDeviceProperties2 getProperties2 = (vkutil_PFN_getPhysicalDeviceProperties2)
+        (vkutil_getInstanceProc(instance, "vkGetPhysicalDeviceProperties2KHR"));
+    if (getProperties2 == NULL) {
+        getProperties2 = (vkutil_PFN_getPhysicalDeviceProperties2)
+            (vkutil_getInstanceProc(instance, "vkGetPhysicalDeviceProperties2"));
+    }
+    if (getProperties2 == NULL) {
+        return 0;
+    }
+    vkutil_PhysicalDeviceIDProperties idProperties;
+    memset(&idProperties, 0, sizeof(idProperties));
+    idProperties.sType = VKUTIL_STRUCTURE_TYPE_PHYSICAL_DEVICE_ID_PROPERTIES;
+    vkutil_PhysicalDeviceProperties2 properties2;
+    memset(&properties2, 0, sizeof(properties2));
+    properties2.sType = VKUTIL_STRUCTURE_TYPE_PHYSICAL_DEVICE_PROPERTIES_2;
+    properties2.pNext = &idProperties;
+    getProperties2(physicalDevice, &properties2);
+    memcpy(uuid, idProperties.deviceUUID, VKUTIL_UUID_SIZE);
+    return 1;
+}
@@ -0,0 +1,59 @@
+package vkutil
+
+/*
+#cgo linux LDFLAGS: -ldl
+#include "bridge.h"
+*/
+import "C"
+
+import (
+	"errors"
+	"unsafe"
+
+	vk "github.com/vulkan-go/vulkan"
+)
+
+// PhysicalDeviceProperties2Extension is the instance extension providing
+// vkGetPhysicalDeviceProperties2KHR on Vulkan 1.0 instances.
+const PhysicalDeviceProperties2Extension = "VK_KHR_get_physical_device_properties2"
+
+// ErrGetInstanceProcAddrNotSet is returned by the helpers calling entry points
+// vulkan-go does not wrap when SetGetInstanceProcAddr was not used to load Vulkan.
+var ErrGetInstanceProcAddrNotSet = errors.New("vkutil: GetInstanceProcAddr is not set, use vkutil.SetGetInstanceProcAddr")
+
+// SetGetInstanceProcAddr calls vk.SetGetInstanceProcAddr and keeps the
+// pointer for the entry points vulkan-go does not wrap (VK_EXT_debug_utils,
+// vkGetPhysicalDeviceProperties2). Use it in place of vk.SetGetInstanceProcAddr,
+// before vk.Init().
+func SetGetInstanceProcAddr(getProcAddr unsafe.Pointer) {
+	vk.SetGetInstanceProcAddr(getProcAddr)
+	C.vkutil_setProcAddr(getProcAddr)
+}
+
+// LoadVulkanLibrary opens the Vulkan loader (libvulkan.so.1, vulkan-1.dll)
+// and hands its vkGetInstanceProcAddr to SetGetInstanceProcAddr. Headless
+// programs use it in place of glfw.GetVulkanGetInstanceProcAddress, before vk.Init().
+func LoadVulkanLibrary() error {
+	getProcAddr := C.vkutil_loadGetInstanceProcAddr()
+	if getProcAddr == nil {
+		return errors.New("vkutil: the Vulkan loader library was not found")
+	}
+	SetGetInstanceProcAddr(getProcAddr)
+	return nil
+}
+
+// GetPhysicalDeviceUUID returns the deviceUUID of VkPhysicalDeviceIDProperties,
+// which stays the same for a GPU across processes and driver restarts. ok is
+// false when the instance exposes neither vkGetPhysicalDeviceProperties2KHR
+// (enable VK_KHR_get_physical_device_properties2) nor the Vulkan 1.1 entry point.
+func GetPhysicalDeviceUUID(instance vk.Instance, physicalDevice vk.PhysicalDevice) (uuid [vk.UuidSize]byte, ok bool) {
+	return driver.GetPhysicalDeviceUUID(instance, physicalDevice)
+}
+
+func getPhysicalDeviceUUID(instance vk.Instance, physicalDevice vk.PhysicalDevice) (uuid [vk.UuidSize]byte, ok bool) {
+	if C.vkutil_isProcAddrSet() == 0 {
+		return uuid, false
+	}
+	ret := C.vkutil_getPhysicalDeviceUUID(unsafe.Pointer(instance), unsafe.Pointer(physicalDevice), (*C.uint8_t)(unsafe.Pointer(&uuid[0])))
+	return uuid, ret != 0
+}
@@ -0,0 +1,32 @@
+#ifndef VKUTIL_BRIDGE_H_
+#define VKUTIL_BRIDGE_H_ 1
+
+// Entry points vulkan-go does not wrap are loaded through the same
+// vkGetInstanceProcAddr handed to vk.SetGetInstanceProcAddr.
+
+#include <stdint.h>
+
+#if defined(_WIN32)
+#define VKUTIL_CALL __stdcall
+#else
+#define VKUTIL_CALL
+#endif
+
+#define VKUTIL_ERROR_EXTENSION_NOT_PRESENT -7
+
+typedef void(VKUTIL_CALL* vkutil_PFN_voidFunction)(void);
+
+void vkutil_setProcAddr(void* getProcAddr);
+int vkutil_isProcAddrSet(void);
+// vkutil_loadGetInstanceProcAddr opens the Vulkan loader library and returns
+// its vkGetInstanceProcAddr, or NULL when it is not installed.
+void* vkutil_loadGetInstanceProcAddr(void);
+vkutil_PFN_voidFunction vkutil_getInstanceProc(void* instance, const char* name);
+
+// vkutil_getPhysicalDeviceUUID fills uuid (VK_UUID_SIZE bytes) with the
+// deviceUUID of VkPhysicalDeviceIDProperties. It returns 0 when neither
+// vkGetPhysicalDeviceProperties2KHR nor vkGetPhysicalDeviceProperties2 is
+// available on instance.
+int vkutil_getPhysicalDeviceUUID(void* instance, void* physicalDevice, uint8_t* uuid);
+
+#endif // VKUTIL_BRIDGE_H_
@@ -0,0 +1,33 @@
+package vkutil
+
+import (
+	"fmt"
+
+	vk "github.com/vulkan-go/vulkan"
+)
+
+// CreateBuffer creates an exclusive buffer of size bytes. No memory is bound to it.
+func CreateBuffer(device vk.Device, size vk.DeviceSize, usage vk.BufferUsageFlags) (vk.Buffer, error) {
+	var bufferCreateInfo = vk.BufferCreateInfo{
+		SType:       vk.StructureTypeBufferCreateInfo,
+		Size:        size,
+		Usage:       usage,
+		SharingMode: vk.SharingModeExclusive,
+	}
+	return driver.CreateBuffer(device, &bufferCreateInfo)
+}
+
+// GetBufferMemoryRequirements returns the size, alignment and memory type
+// bits the buffer needs before memory can be bound to it.
+func GetBufferMemoryRequirements(device vk.Device, buffer vk.Buffer) vk.MemoryRequirements {
+	return driver.GetBufferMemoryRequirements(device, buffer)
+}
+
+// PrintMemoryRequirements prints what GetBufferMemoryRequirements or
+// GetImageMemoryRequirements returned.
+func PrintMemoryRequirements(memoryRequirements vk.MemoryRequirements) {
+	fmt.Println("Printing memory requirements..........")
+	fmt.Println("\t\t* Size Required ...", memoryRequirements.Size, " Bytes")
+	fmt.Println("\t\t* Alignment Required ...", memoryRequirements.Alignment, " Bytes")
+	fmt.Printf("\t\t* MemoryTypeBits ... %#032b\n", memoryRequirements.MemoryTypeBits)
+}
@@ -0,0 +1,40 @@
+package vkutil
+
+import (
+	vk "github.com/vulkan-go/vulkan"
+)
+
+// CreateCommandPool creates a command pool for queues of queueFamilyIndex.
+func CreateCommandPool(device vk.Device, queueFamilyIndex uint32, flags vk.CommandPoolCreateFlags) (vk.CommandPool, error) {
+	var commandPoolCreateInfo = vk.CommandPoolCreateInfo{
+		SType:            vk.StructureTypeCommandPoolCreateInfo,
+		Flags:            flags,
+		QueueFamilyIndex: queueFamilyIndex,
+	}
+	return driver.CreateCommandPool(device, &commandPoolCreateInfo)
+}
+
+// AllocateCommandBuffers allocates count primary command buffers from commandPool.
+func AllocateCommandBuffers(device vk.Device, commandPool vk.CommandPool, count uint32) ([]vk.CommandBuffer, error) {
+	var commandBufferAllocateInfo = vk.CommandBufferAllocateInfo{
+		SType:              vk.StructureTypeCommandBufferAllocateInfo,
+		CommandPool:        commandPool,
+		Level:              vk.CommandBufferLevelPrimary,
+		CommandBufferCount: count,
+	}
+	return driver.AllocateCommandBuffers(device, &commandBufferAllocateInfo)
+}
+
+// BeginCommandBuffers puts every command buffer into the recording state.
+func BeginCommandBuffers(commandBuffers []vk.CommandBuffer, flags vk.CommandBufferUsageFlags) error {
+	var commandBufferBeginInfo = vk.CommandBufferBeginInfo{
+		SType: vk.StructureTypeCommandBufferBeginInfo,
+		Flags: flags,
+	}
+	for _, commandBuffer := range commandBuffers {
+		if err := driver.BeginCommandBuffer(commandBuffer, &commandBufferBeginInfo); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,152 @@
+package vkutil
+
+import (
+	"fmt"
+	"image"
+	"image/draw"
+
+	vk "github.com/vulkan-go/vulkan"
+)
+
+// CubeLayout is how the six faces of a cube map are laid out in one image.
+type CubeLayout int
+
+const (
+	// CubeLayoutAuto picks the layout from the aspect ratio of the image.
+	CubeLayoutAuto CubeLayout = iota
+	// CubeLayoutHorizontalCross is 4x3 faces:
+	//
+	//	    +Y
+	//	-X  +Z  +X  -Z
+	//	    -Y
+	CubeLayoutHorizontalCross
+	// CubeLayoutVerticalCross is 3x4 faces, -Z upside down below -Y:
+	//
+	//	    +Y
+	//	-X  +Z  +X
+	//	    -Y
+	//	    -Z
+	CubeLayoutVerticalCross
+	// CubeLayoutHorizontalStrip is +X, -X, +Y, -Y, +Z, -Z from left to right.
+	CubeLayoutHorizontalStrip
+	// CubeLayoutVerticalStrip is +X, -X, +Y, -Y, +Z, -Z from top to bottom.
+	CubeLayoutVerticalStrip
+)
+
+func (l CubeLayout) String() string {
+	switch l {
+	case CubeLayoutAuto:
+		return "auto"
+	case CubeLayoutHorizontalCross:
+		return "horizontal cross"
+	case CubeLayoutVerticalCross:
+		return "vertical cross"
+	case CubeLayoutHorizontalStrip:
+		return "horizontal strip"
+	case CubeLayoutVerticalStrip:
+		return "vertical strip"
+	}
+	return fmt.Sprintf("CubeLayout(%d)", int(l))
+}
+
+// cubeCells are the columns and rows of a layout, and the cell of each face
+// in +X, -X, +Y, -Y, +Z, -Z order.
+var cubeCells = map[CubeLayout]struct {
+	columns, rows int
+	faces         [6]image.Point
+}{
+	CubeLayoutHorizontalCross: {4, 3, [6]image.Point{{2, 1}, {0, 1}, {1, 0}, {1, 2}, {1, 1}, {3, 1}}},
+	CubeLayoutVerticalCross:   {3, 4, [6]image.Point{{2, 1}, {0, 1}, {1, 0}, {1, 2}, {1, 1}, {1, 3}}},
+	CubeLayoutHorizontalStrip: {6, 1, [6]image.Point{{0, 0}, {1, 0}, {2, 0}, {3, 0}, {4, 0}, {5, 0}}},
+	CubeLayoutVerticalStrip:   {1, 6, [6]image.Point{{0, 0}, {0, 1}, {0, 2}, {0, 3}, {0, 4}, {0, 5}}},
+}
+
+// CubeFaces cuts the six faces of a cube map out of img, laid out as layout,
+// in the +X, -X, +Y, -Y, +Z, -Z order CreateTextureArray wants them for
+// vk.ImageViewTypeCube.
+func CubeFaces(img image.Image, layout CubeLayout) ([6]image.Image, error) {
+	var faces [6]image.Image
+	size := img.Bounds().Size()
+	if layout == CubeLayoutAuto {
+		for candidate, cells := range cubeCells {
+			if size.X*cells.rows == size.Y*cells.columns {
+				layout = candidate
+			}
+		}
+		if layout == CubeLayoutAuto {
+			return faces, fmt.Errorf("cube map of %vx%v: no layout of that aspect ratio", size.X, size.Y)
+		}
+	}
+	cells, ok := cubeCells[layout]
+	if !ok {
+		return faces, fmt.Errorf("unknown %v", layout)
+	}
+	face := size.X / cells.columns
+	if face == 0 || face*cells.columns != size.X || face*cells.rows != size.Y {
+		return faces, fmt.Errorf("cube map of %vx%v: not %vx%v square faces", size.X, size.Y, cells.columns, cells.rows)
+	}
+	corner := img.Bounds().Min
+	for idx, cell := range cells.faces {
+		origin := corner.Add(cell.Mul(face))
+		// Copied, so the faces are tightly packed for TexturePixels
+		faces[idx] = cropImage(img, image.Rectangle{Min: origin, Max: origin.Add(image.Pt(face, face))})
+	}
+	if layout == CubeLayoutVerticalCross {
+		// Folding the cross into a cube turns the face below -Y upside down
+		faces[5] = rotate180(faces[5].(*image.NRGBA))
+	}
+	return faces, nil
+}
+
+// LoadCubeMap is CreateTextureArray of the cube map in the PNG or JPEG file
+// at path, laid out as layout.
+func (u *Uploader) LoadCubeMap(path string, layout CubeLayout, config TextureConfig) (*Texture, error) {
+	img, err := LoadImage(path)
+	if err != nil {
+		return nil, err
+	}
+	faces, err := CubeFaces(img, layout)
+	if err != nil {
+		return nil, fmt.Errorf("%v: %w", path, err)
+	}
+	if config.Name == "" {
+		config.Name = path
+	}
+	return u.CreateTextureArray(faces[:], vk.ImageViewTypeCube, config)
+}
+
+// LoadCubeMapFaces is CreateTextureArray of a cube map of six PNG or JPEG
+// files, the faces +X, -X, +Y, -Y, +Z, -Z. It is named after the +X face
+// unless config has a Name.
+func (u *Uploader) LoadCubeMapFaces(paths [6]string, config TextureConfig) (*Texture, error) {
+	faces := make([]image.Image, len(paths))
+	for idx, path := range paths {
+		var err error
+		if faces[idx], err = LoadImage(path); err != nil {
+			return nil, err
+		}
+	}
+	if config.Name == "" {
+		config.Name = paths[0]
+	}
+	return u.CreateTextureArray(faces, vk.ImageViewTypeCube, config)
+}
+
+// cropImage copies r of img to a new image at the origin.
+func cropImage(img image.Image, r image.Rectangle) *image.NRGBA {
+	cropped := image.NewNRGBA(image.Rect(0, 0, r.Dx(), r.Dy()))
+	draw.Draw(cropped, cropped.Bounds(), img, r.Min, draw.Src)
+	return cropped
+}
+
+// rotate180 returns img turned upside down.
+func rotate180(img *image.NRGBA) *image.NRGBA {
+	bounds := img.Bounds()
+	rotated := image.NewNRGBA(bounds)
+	for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
+		for x := bounds.Min.X; x < bounds.Max.X; x++ {
+			rotated.SetNRGBA(bounds.Max.X-1-(x-bounds.Min.X), bounds.Max.Y-1-(y-bounds.Min.Y), img.NRGBAAt(x, y))
+		}
+	}
+	return rotated
+}
@@ -0,0 +1,243 @@
+package vkutil
+
+/*
+#include "debug_utils.h"
+*/
+import "C"
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"unsafe"
+
+	vk "github.com/vulkan-go/vulkan"
+)
+
+// ValidationLayer is the Khronos validation layer shipped with the Vulkan SDK.
+const ValidationLayer = "VK_LAYER_KHRONOS_validation"
+
+// DebugUtilsExtension is the instance extension providing debug messengers.
+const DebugUtilsExtension = "VK_EXT_debug_utils"
+
+// DebugSeverity mirrors VkDebugUtilsMessageSeverityFlagBitsEXT.
+type DebugSeverity uint32
+
+const (
+	DebugSeverityVerbose DebugSeverity = 0x00000001
+	DebugSeverityInfo    DebugSeverity = 0x00000010
+	DebugSeverityWarning DebugSeverity = 0x00000100
+	DebugSeverityError   DebugSeverity = 0x00001000
+)
+
+// SeverityAtLeast returns a mask with min and every more severe level set.
+func SeverityAtLeast(min DebugSeverity) DebugSeverity {
+	var mask DebugSeverity
+	for _, s := range []DebugSeverity{DebugSeverityVerbose, DebugSeverityInfo, DebugSeverityWarning, DebugSeverityError} {
+		if s >= min {
+			mask |= s
+		}
+	}
+	return mask
+}
+
+func (s DebugSeverity) String() string {
+	switch {
+	case s&DebugSeverityError != 0:
+		return "ERROR"
+	case s&DebugSeverityWarning != 0:
+		return "WARNING"
+	case s&DebugSeverityInfo != 0:
+		return "INFO"
+	case s&DebugSeverityVerbose != 0:
+		return "VERBOSE"
+	}
+	return fmt.Sprintf("DebugSeverity(%#x)", uint32(s))
+}
+
+// DebugMessageType mirrors VkDebugUtilsMessageTypeFlagBitsEXT.
+type DebugMessageType uint32
+
+const (
+	DebugMessageGeneral     DebugMessageType = 0x00000001
+	DebugMessageValidation  DebugMessageType = 0x00000002
+	DebugMessagePerformance DebugMessageType = 0x00000004
+	DebugMessageAll                          = DebugMessageGeneral | DebugMessageValidation | DebugMessagePerformance
+)
+
+func (t DebugMessageType) String() string {
+	var names []string
+	if t&DebugMessageGeneral != 0 {
+		names = append(names, "general")
+	}
+	if t&DebugMessageValidation != 0 {
+		names = append(names, "validation")
+	}
+	if t&DebugMessagePerformance != 0 {
+		names = append(names, "performance")
+	}
+	return strings.Join(names, "|")
+}
+
+// DebugObject is one of the Vulkan objects a debug message refers to.
+type DebugObject struct {
+	Type   vk.ObjectType
+	Handle uint64
+	Name   string
+}
+
+// DebugMessage is the Go copy of VkDebugUtilsMessengerCallbackDataEXT handed
+// to a DebugCallback. It stays valid after the callback returns.
+type DebugMessage struct {
+	Severity        DebugSeverity
+	Type            DebugMessageType
+	MessageIDName   string
+	MessageIDNumber int32
+	Message         string
+	QueueLabels     []string
+	CmdBufLabels    []string
+	Objects         []DebugObject
+}
+
+// DebugCallback receives the messages of a DebugMessenger. It is called on
+// whatever thread made the Vulkan call that triggered the message.
+type DebugCallback func(msg DebugMessage)
+
+// PrintDebugMessage is a DebugCallback printing msg on stdout.
+func PrintDebugMessage(msg DebugMessage) {
+	fmt.Printf("[%v][%v] %v\n", msg.Severity, msg.Type, msg.Message)
+	for _, o := range msg.Objects {
+		fmt.Printf("\t* Object: type %v handle %#x %v\n", o.Type, o.Handle, o.Name)
+	}
+}
+
+// DebugMessenger is a VK_EXT_debug_utils messenger routing messages into a
+// Go callback. Destroy it before the instance.
+type DebugMessenger struct {
+	instance vk.Instance
+	handle   C.uint64_t
+	id       uintptr
+}
+
+var (
+	debugCallbacksMu sync.Mutex
+	debugCallbacks   = map[uintptr]DebugCallback{}
+	debugCallbackID  uintptr
+)
+
+// SelectInstanceLayers returns the layers out of wanted that the Vulkan
+// installation provides, in the order given. Missing layers are skipped, so
+// the instance can still be created on machines without the SDK.
+func SelectInstanceLayers(wanted ...string) ([]string, error) {
+	available, err := GetInstanceLayerProperties()
+	if err != nil {
+		return nil, err
+	}
+	present := make(map[string]bool, len(available))
+	for _, p := range available {
+		present[vk.ToString(p.LayerName[:])] = true
+	}
+	var layers []string
+	for _, name := range wanted {
+		if present[strings.TrimSuffix(name, "\x00")] {
+			layers = append(layers, name)
+		}
+	}
+	return layers, nil
+}
+
+// CreateDebugMessenger creates a VK_EXT_debug_utils messenger reporting the
+// messages matching both severities and types to callback. The instance must
+// have been created with DebugUtilsExtension enabled.
+func CreateDebugMessenger(instance vk.Instance, severities DebugSeverity, types DebugMessageType, callback DebugCallback) (*DebugMessenger, error) {
+	if C.vkutil_isProcAddrSet() == 0 {
+		return nil, ErrGetInstanceProcAddrNotSet
+	}
+	debugCallbacksMu.Lock()
+	debugCallbackID++
+	id := debugCallbackID
+	debugCallbacks[id] = callback
+	debugCallbacksMu.Unlock()
+
+	m := &DebugMessenger{instance: instance, id: id}
+	ret := C.vkutil_createDebugUtilsMessenger(unsafe.Pointer(instance), C.uint32_t(severities), C.uint32_t(types), C.uintptr_t(id), &m.handle)
+	if err := Check("vkCreateDebugUtilsMessengerEXT", vk.Result(ret)); err != nil {
+		debugCallbacksMu.Lock()
+		delete(debugCallbacks, id)
+		debugCallbacksMu.Unlock()
+		return nil, err
+	}
+	return m, nil
+}
+
+// CreateValidationMessenger creates a messenger reporting every message type
+// of severity min or above to callback, but only when layers holds
+// ValidationLayer and extensions holds DebugUtilsExtension. Otherwise it
+// returns a nil messenger and no error, so the result of SelectInstanceLayers
+// and SelectInstanceExtensions can be passed without checking them first.
+func CreateValidationMessenger(instance vk.Instance, layers, extensions []string, min DebugSeverity, callback DebugCallback) (*DebugMessenger, error) {
+	if !Contains(layers, ValidationLayer) || !Contains(extensions, DebugUtilsExtension) {
+		return nil, nil
+	}
+	return CreateDebugMessenger(instance, SeverityAtLeast(min), DebugMessageAll, callback)
+}
+
+// Destroy destroys the messenger. It is safe to call on a nil messenger, so
+// callers can destroy unconditionally when validation was not available.
+func (m *DebugMessenger) Destroy() {
+	if m == nil {
+		return
+	}
+	C.vkutil_destroyDebugUtilsMessenger(unsafe.Pointer(m.instance), m.handle)
+	debugCallbacksMu.Lock()
+	delete(debugCallbacks, m.id)
+	debugCallbacksMu.Unlock()
+}
+
+//export vkutilDebugUtilsCallback
+func vkutilDebugUtilsCallback(severity, types C.uint32_t, data *C.vkutil_DebugUtilsMessengerCallbackData, userData C.uintptr_t) {
+	debugCallbacksMu.Lock()
+	callback := debugCallbacks[uintptr(userData)]
+	debugCallbacksMu.Unlock()
+	if callback == nil || data == nil {
+		return
+	}
+	msg := DebugMessage{
+		Severity:        DebugSeverity(severity),
+		Type:            DebugMessageType(types),
+		MessageIDName:   goString(data.pMessageIdName),
+		MessageIDNumber: int32(data.messageIdNumber),
+		Message:         goString(data.pMessage),
+		QueueLabels:     debugLabels(data.pQueueLabels, data.queueLabelCount),
+		CmdBufLabels:    debugLabels(data.pCmdBufLabels, data.cmdBufLabelCount),
+	}
+	if data.objectCount > 0 {
+		objects := unsafe.Slice(data.pObjects, int(data.objectCount))
+		for _, o := range objects {
+			msg.Objects = append(msg.Objects, DebugObject{
+				Type:   vk.ObjectType(o.objectType),
+				Handle: uint64(o.objectHandle),
+				Name:   goString(o.pObjectName),
+			})
+		}
+	}
+	callback(msg)
+}
+
+func debugLabels(labels *C.vkutil_DebugUtilsLabel, count C.uint32_t) []string {
+	if count == 0 || labels == nil {
+		return nil
+	}
+	var names []string
+	for _, l := range unsafe.Slice(labels, int(count)) {
+		names = append(names, goString(l.pLabelName))
+	}
+	return names
+}
+
+func goString(s *C.char) string {
+	if s == nil {
+		return ""
+	}
+	return C.GoString(s)
+}
@@ -0,0 +1,59 @@
+#include <stddef.h>
+#include "debug_utils.h"
+#include "_cgo_export.h"
+
+#define VKUTIL_STRUCTURE_TYPE_DEBUG_UTILS_MESSENGER_CREATE_INFO 1000128004
+
+typedef uint32_t(VKUTIL_CALL* vkutil_PFN_debugUtilsMessengerCallback)(
+    uint32_t messageSeverity, uint32_t messageTypes,
+    const vkutil_DebugUtilsMessengerCallbackData* pCallbackData, void* pUserData);
+
+typedef struct vkutil_DebugUtilsMessengerCreateInfo {
+    int32_t sType;
+    const void* pNext;
+    uint32_t flags;
+    uint32_t messageSeverity;
+    uint32_t messageType;
+    vkutil_PFN_debugUtilsMessengerCallback pfnUserCallback;
+    void* pUserData;
+} vkutil_DebugUtilsMessengerCreateInfo;
+
+typedef int32_t(VKUTIL_CALL* vkutil_PFN_createDebugUtilsMessenger)(
+    void* instance, const vkutil_DebugUtilsMessengerCreateInfo* pCreateInfo,
+    const void* pAllocator, uint64_t* pMessenger);
+typedef void(VKUTIL_CALL* vkutil_PFN_destroyDebugUtilsMessenger)(
+    void* instance, uint64_t messenger, const void* pAllocator);
+
+static uint32_t VKUTIL_CALL vkutil_debugUtilsCallback(
+    uint32_t messageSeverity, uint32_t messageTypes,
+    const vkutil_DebugUtilsMessengerCallbackData* pCallbackData, void* pUserData) {
+    vkutilDebugUtilsCallback(messageSeverity, messageTypes,
+                             (vkutil_DebugUtilsMessengerCallbackData*)pCallbackData,
+                             (uintptr_t)pUserData);
+    // The application should always return VK_FALSE.
+    return 0;
+}
+
+int32_t vkutil_createDebugUtilsMessenger(void* instance, uint32_t severities, uint32_t types, uintptr_t userData, uint64_t* messenger) {
+    vkutil_PFN_createDebugUtilsMessenger create = (vkutil_PFN_createDebugUtilsMessenger)
+        (vkutil_getInstanceProc(instance, "vkCreateDebugUtilsMessengerEXT"));
+    if (create == NULL) {
+        return VKUTIL_ERROR_EXTENSION_NOT_PRESENT;
+    }
+    vkutil_DebugUtilsMessengerCreateInfo createInfo = {
+        .sType = VKUTIL_STRUCTURE_TYPE_DEBUG_UTILS_MESSENGER_CREATE_INFO,
+        .messageSeverity = severities,
+        .messageType = types,
+        .pfnUserCallback = vkutil_debugUtilsCallback,
+        .pUserData = (void*)userData,
+    };
+    return create(instance, &createInfo, NULL, messenger);
+}
+
+void vkutil_destroyDebugUtilsMessenger(void* instance, uint64_t messenger) {
+    vkutil_PFN_destroyDebugUtilsMessenger destroy = (vkutil_PFN_destroyDebugUtilsMessenger)
+        (vkutil_getInstanceProc(instance, "vkDestroyDebugUtilsMessengerEXT"));
+    if (destroy != NULL) {
+        destroy(instance, messenger, NULL);
+    }
+}
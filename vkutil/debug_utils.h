@@ -0,0 +1,46 @@
+#ifndef VKUTIL_DEBUG_UTILS_H_
+#define VKUTIL_DEBUG_UTILS_H_ 1
+
+// vulkan-go does not wrap VK_EXT_debug_utils, so the few types the messenger
+// needs are declared here, following vulkan_core.h.
+// https://www.khronos.org/registry/vulkan/specs/1.2-extensions/man/html/VK_EXT_debug_utils.html
+
+#include <stdint.h>
+#include "bridge.h"
+
+typedef struct vkutil_DebugUtilsLabel {
+    int32_t sType;
+    const void* pNext;
+    const char* pLabelName;
+    float color[4];
+} vkutil_DebugUtilsLabel;
+
+typedef struct vkutil_DebugUtilsObjectNameInfo {
+    int32_t sType;
+    const void* pNext;
+    int32_t objectType;
+    uint64_t objectHandle;
+    const char* pObjectName;
+} vkutil_DebugUtilsObjectNameInfo;
+
+typedef struct vkutil_DebugUtilsMessengerCallbackData {
+    int32_t sType;
+    const void* pNext;
+    uint32_t flags;
+    const char* pMessageIdName;
+    int32_t messageIdNumber;
+    const char* pMessage;
+    uint32_t queueLabelCount;
+    const vkutil_DebugUtilsLabel* pQueueLabels;
+    uint32_t cmdBufLabelCount;
+    const vkutil_DebugUtilsLabel* pCmdBufLabels;
+    uint32_t objectCount;
+    const vkutil_DebugUtilsObjectNameInfo* pObjects;
+} vkutil_DebugUtilsMessengerCallbackData;
+
+// vkutil_createDebugUtilsMessenger returns a VkResult. VK_ERROR_EXTENSION_NOT_PRESENT
+// is returned when the instance does not expose vkCreateDebugUtilsMessengerEXT.
+int32_t vkutil_createDebugUtilsMessenger(void* instance, uint32_t severities, uint32_t types, uintptr_t userData, uint64_t* messenger);
+void vkutil_destroyDebugUtilsMessenger(void* instance, uint64_t messenger);
+
+#endif // VKUTIL_DEBUG_UTILS_H_
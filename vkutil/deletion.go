@@ -0,0 +1,136 @@
+package vkutil
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+)
+
+// DeletionQueue records how to destroy every handle a program creates and
+// tears them down in reverse order on Close. Handles are pushed right after
+// they are created, so reverse order is reverse dependency order: views go
+// before their images, the device before the instance, and so on. It is safe
+// for concurrent use.
+type DeletionQueue struct {
+	// Verbose prints the name of every resource as it is destroyed.
+	Verbose bool
+
+	mu      sync.Mutex
+	entries []*Deletion
+	closed  bool
+}
+
+// Deletion is one resource of a DeletionQueue.
+type Deletion struct {
+	Name string
+
+	queue   *DeletionQueue
+	destroy func() error
+}
+
+// NewDeletionQueue returns an empty queue.
+func NewDeletionQueue() *DeletionQueue {
+	return &DeletionQueue{}
+}
+
+// Push registers destroy for the resource called name.
+func (q *DeletionQueue) Push(name string, destroy func()) *Deletion {
+	return q.PushErr(name, func() error {
+		destroy()
+		return nil
+	})
+}
+
+// PushErr is Push for destroyers that can fail, like Allocator.Destroy.
+func (q *DeletionQueue) PushErr(name string, destroy func() error) *Deletion {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if q.closed {
+		panic(fmt.Sprintf("vkutil: %v pushed to a closed DeletionQueue", name))
+	}
+	d := &Deletion{Name: name, queue: q, destroy: destroy}
+	q.entries = append(q.entries, d)
+	return d
+}
+
+// Destroy destroys the resource now, out of order, e.g. a swapchain that is
+// being recreated. Calling it again, after Close or on nil does nothing.
+func (d *Deletion) Destroy() error {
+	if d == nil {
+		return nil
+	}
+	q := d.queue
+	q.mu.Lock()
+	found := false
+	for idx, e := range q.entries {
+		if e == d {
+			q.entries = append(q.entries[:idx], q.entries[idx+1:]...)
+			found = true
+			break
+		}
+	}
+	q.mu.Unlock()
+	if !found {
+		return nil
+	}
+	return q.run(d)
+}
+
+func (q *DeletionQueue) run(d *Deletion) error {
+	if q.Verbose {
+		fmt.Printf("\t* Destroying %v\n", d.Name)
+	}
+	if err := d.destroy(); err != nil {
+		return fmt.Errorf("destroying %v: %w", d.Name, err)
+	}
+	return nil
+}
+
+// Alive returns the names of the resources not destroyed yet, oldest first.
+func (q *DeletionQueue) Alive() []string {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	names := make([]string, 0, len(q.entries))
+	for _, d := range q.entries {
+		names = append(names, d.Name)
+	}
+	return names
+}
+
+// PrintAlive prints Alive.
+func (q *DeletionQueue) PrintAlive() {
+	fmt.Println("Resources alive..............")
+	for _, name := range q.Alive() {
+		fmt.Printf("\t* %v\n", name)
+	}
+}
+
+// Flush destroys every resource, newest first, and leaves the queue usable.
+// Errors of the destroyers are joined, a failing destroyer does not stop the
+// others.
+func (q *DeletionQueue) Flush() error {
+	q.mu.Lock()
+	entries := q.entries
+	q.entries = nil
+	q.mu.Unlock()
+	var errs []error
+	for idx := len(entries) - 1; idx >= 0; idx-- {
+		if err := q.run(entries[idx]); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// Close flushes the queue and refuses any further Push. The device should be
+// idle, see DeviceWaitTillComplete.
+func (q *DeletionQueue) Close() error {
+	q.mu.Lock()
+	if q.closed {
+		q.mu.Unlock()
+		return nil
+	}
+	q.closed = true
+	q.mu.Unlock()
+	return q.Flush()
+}
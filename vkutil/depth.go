@@ -0,0 +1,123 @@
+package vkutil
+
+import (
+	"fmt"
+	"strings"
+
+	vk "github.com/vulkan-go/vulkan"
+)
+
+// DepthFormats are the depth formats FindDepthFormat tries, best first.
+// Every implementation supports D16 or D32 as a depth attachment, and one
+// of D24S8 or D32S8 when stencil is needed.
+// https://www.khronos.org/registry/vulkan/specs/1.2-extensions/html/vkspec.html#features-required-format-support
+var DepthFormats = []vk.Format{
+	vk.FormatD32Sfloat,
+	vk.FormatD32SfloatS8Uint,
+	vk.FormatD24UnormS8Uint,
+	vk.FormatD16Unorm,
+}
+
+// GetPhysicalDeviceFormatProperties returns the features format supports
+// for linear and optimal tiling and for buffers.
+func GetPhysicalDeviceFormatProperties(physicalDevice vk.PhysicalDevice, format vk.Format) vk.FormatProperties {
+	return driver.GetPhysicalDeviceFormatProperties(physicalDevice, format)
+}
+
+// FindSupportedFormat returns the first of candidates supporting features
+// with tiling.
+func FindSupportedFormat(physicalDevice vk.PhysicalDevice, candidates []vk.Format, tiling vk.ImageTiling, features vk.FormatFeatureFlagBits) (vk.Format, error) {
+	for _, format := range candidates {
+		properties := driver.GetPhysicalDeviceFormatProperties(physicalDevice, format)
+		supported := properties.OptimalTilingFeatures
+		if tiling == vk.ImageTilingLinear {
+			supported = properties.LinearTilingFeatures
+		}
+		if supported&vk.FormatFeatureFlags(features) == vk.FormatFeatureFlags(features) {
+			return format, nil
+		}
+	}
+	names := make([]string, len(candidates))
+	for idx, format := range candidates {
+		names[idx] = FormatName(format)
+	}
+	return vk.FormatUndefined, fmt.Errorf("none of the formats %v supports features %#x with %v tiling", strings.Join(names, ", "), uint32(features), tilingName(tiling))
+}
+
+// FindDepthFormat returns the first of DepthFormats usable as an optimally
+// tiled depth attachment. With stencil true only formats with a stencil
+// aspect are considered.
+func FindDepthFormat(physicalDevice vk.PhysicalDevice, stencil bool) (vk.Format, error) {
+	candidates := DepthFormats
+	if stencil {
+		candidates = nil
+		for _, format := range DepthFormats {
+			if HasStencil(format) {
+				candidates = append(candidates, format)
+			}
+		}
+	}
+	return FindSupportedFormat(physicalDevice, candidates, vk.ImageTilingOptimal, vk.FormatFeatureDepthStencilAttachmentBit)
+}
+
+// HasStencil reports whether format has a stencil aspect.
+func HasStencil(format vk.Format) bool {
+	switch format {
+	case vk.FormatS8Uint, vk.FormatD16UnormS8Uint, vk.FormatD24UnormS8Uint, vk.FormatD32SfloatS8Uint:
+		return true
+	}
+	return false
+}
+
+// DepthAspect returns the aspects of the depth format: depth, plus stencil
+// for the combined formats.
+func DepthAspect(format vk.Format) vk.ImageAspectFlags {
+	if format == vk.FormatS8Uint {
+		return vk.ImageAspectFlags(vk.ImageAspectStencilBit)
+	}
+	if HasStencil(format) {
+		return vk.ImageAspectFlags(vk.ImageAspectDepthBit | vk.ImageAspectStencilBit)
+	}
+	return vk.ImageAspectFlags(vk.ImageAspectDepthBit)
+}
+
+// NewDepthBuffer creates a transient depth attachment of format, e.g. from
+// FindDepthFormat, with memory from allocator, and a view over its depth
+// (and stencil) aspects. samples must match the color attachments it is
+// used with. Recreate it with the swapchain it is sized after.
+func NewDepthBuffer(device vk.Device, allocator *Allocator, format vk.Format, extent vk.Extent2D, samples vk.SampleCountFlagBits) (*Attachment, error) {
+	return NewAttachment(device, allocator, AttachmentConfig{
+		Format:    format,
+		Extent:    extent,
+		Samples:   samples,
+		Transient: true,
+		Name:      "depth buffer",
+	})
+}
+
+// DepthAttachmentDescription describes a depth buffer of format for a render
+// pass: cleared at the start, its content thrown away at the end as
+// nothing reads it after the pass.
+func DepthAttachmentDescription(format vk.Format, samples vk.SampleCountFlagBits) vk.AttachmentDescription {
+	stencilLoadOp := vk.AttachmentLoadOpDontCare
+	if HasStencil(format) {
+		stencilLoadOp = vk.AttachmentLoadOpClear
+	}
+	return vk.AttachmentDescription{
+		Format:         format,
+		Samples:        samples,
+		LoadOp:         vk.AttachmentLoadOpClear,
+		StoreOp:        vk.AttachmentStoreOpDontCare,
+		StencilLoadOp:  stencilLoadOp,
+		StencilStoreOp: vk.AttachmentStoreOpDontCare,
+		InitialLayout:  vk.ImageLayoutUndefined, // cleared anyway
+		FinalLayout:    vk.ImageLayoutDepthStencilAttachmentOptimal,
+	}
+}
+
+func tilingName(tiling vk.ImageTiling) string {
+	if tiling == vk.ImageTilingLinear {
+		return "linear"
+	}
+	return "optimal"
+}
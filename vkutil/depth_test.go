@@ -0,0 +1,56 @@
+package vkutil_test
+
+import (
+	"testing"
+
+	"github.com/goodshailesh/My-Vulkan-Projects/vkutil"
+	"github.com/goodshailesh/My-Vulkan-Projects/vkutil/vkfake"
+	vk "github.com/vulkan-go/vulkan"
+)
+
+func TestFindDepthFormat(t *testing.T) {
+	const (
+		d32   = vk.FormatD32Sfloat
+		d32s8 = vk.FormatD32SfloatS8Uint
+		d24s8 = vk.FormatD24UnormS8Uint
+		d16   = vk.FormatD16Unorm
+	)
+	attachment := vk.FormatProperties{OptimalTilingFeatures: vk.FormatFeatureFlags(vk.FormatFeatureDepthStencilAttachmentBit)}
+	linearOnly := vk.FormatProperties{LinearTilingFeatures: vk.FormatFeatureFlags(vk.FormatFeatureDepthStencilAttachmentBit)}
+	for _, tc := range []struct {
+		name    string
+		formats map[vk.Format]vk.FormatProperties
+		stencil bool
+		want    vk.Format // vk.FormatUndefined for an error
+	}{
+		{"D32 first", map[vk.Format]vk.FormatProperties{d32: attachment, d32s8: attachment, d24s8: attachment, d16: attachment}, false, d32},
+		{"D32S8 without D32", map[vk.Format]vk.FormatProperties{d32s8: attachment, d24s8: attachment, d16: attachment}, false, d32s8},
+		{"D24S8 without D32S8", map[vk.Format]vk.FormatProperties{d24s8: attachment, d16: attachment}, false, d24s8},
+		{"D16 last", map[vk.Format]vk.FormatProperties{d16: attachment}, false, d16},
+		{"optimal tiling only", map[vk.Format]vk.FormatProperties{d32: linearOnly, d16: attachment}, false, d16},
+		{"stencil skips D32", map[vk.Format]vk.FormatProperties{d32: attachment, d32s8: attachment, d24s8: attachment, d16: attachment}, true, d32s8},
+		{"stencil takes D24S8", map[vk.Format]vk.FormatProperties{d32: attachment, d24s8: attachment, d16: attachment}, true, d24s8},
+		{"stencil without a stencil format", map[vk.Format]vk.FormatProperties{d32: attachment, d16: attachment}, true, vk.FormatUndefined},
+		{"no depth format", nil, false, vk.FormatUndefined},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			dev := vkfake.NewDevice("Fake dGPU", vk.PhysicalDeviceTypeDiscreteGpu)
+			for _, format := range vkutil.DepthFormats {
+				delete(dev.Formats, format)
+			}
+			for format, properties := range tc.formats {
+				dev.Formats[format] = properties
+			}
+			_, physicalDevices := useFake(t, dev)
+			format, err := vkutil.FindDepthFormat(physicalDevices[0], tc.stencil)
+			switch {
+			case tc.want == vk.FormatUndefined && err == nil:
+				t.Errorf("FindDepthFormat = %v, want an error", vkutil.FormatName(format))
+			case tc.want != vk.FormatUndefined && err != nil:
+				t.Errorf("FindDepthFormat: %v", err)
+			case format != tc.want:
+				t.Errorf("FindDepthFormat = %v, want %v", vkutil.FormatName(format), vkutil.FormatName(tc.want))
+			}
+		})
+	}
+}
@@ -0,0 +1,372 @@
+package vkutil
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	vk "github.com/vulkan-go/vulkan"
+)
+
+// DescriptorBinding describes binding as a single descriptor of
+// descriptorType read by stages.
+func DescriptorBinding(binding uint32, descriptorType vk.DescriptorType, stages vk.ShaderStageFlagBits) vk.DescriptorSetLayoutBinding {
+	return vk.DescriptorSetLayoutBinding{
+		Binding:         binding,
+		DescriptorType:  descriptorType,
+		DescriptorCount: 1,
+		StageFlags:      vk.ShaderStageFlags(stages),
+	}
+}
+
+// DescriptorLayoutCache creates one descriptor set layout per binding
+// signature, so pipelines and materials asking for the same bindings share
+// it and the pipeline layouts built from it are compatible.
+// https://www.khronos.org/registry/vulkan/specs/1.2-extensions/html/vkspec.html#descriptorsets-compatibility
+type DescriptorLayoutCache struct {
+	device  vk.Device
+	mu      sync.Mutex
+	layouts map[string]vk.DescriptorSetLayout
+}
+
+// NewDescriptorLayoutCache returns an empty cache creating layouts on device.
+func NewDescriptorLayoutCache(device vk.Device) *DescriptorLayoutCache {
+	return &DescriptorLayoutCache{device: device, layouts: make(map[string]vk.DescriptorSetLayout)}
+}
+
+// Get returns the layout of bindings, creating it on first use. The order
+// of bindings does not matter. The cache owns the layout, do not destroy it.
+func (c *DescriptorLayoutCache) Get(bindings ...vk.DescriptorSetLayoutBinding) (vk.DescriptorSetLayout, error) {
+	sorted := append([]vk.DescriptorSetLayoutBinding(nil), bindings...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].Binding < sorted[j].Binding })
+	key := bindingSignature(sorted)
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if layout, ok := c.layouts[key]; ok {
+		return layout, nil
+	}
+	layoutCreateInfo := vk.DescriptorSetLayoutCreateInfo{
+		SType:        vk.StructureTypeDescriptorSetLayoutCreateInfo,
+		BindingCount: uint32(len(sorted)),
+		PBindings:    sorted,
+	}
+	layout, err := driver.CreateDescriptorSetLayout(c.device, &layoutCreateInfo)
+	if err != nil {
+		return vk.NullDescriptorSetLayout, err
+	}
+	c.layouts[key] = layout
+	return layout, nil
+}
+
+// Len returns the number of layouts created.
+func (c *DescriptorLayoutCache) Len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return len(c.layouts)
+}
+
+// Destroy destroys every layout. Pipeline layouts built from them may outlive them.
+func (c *DescriptorLayoutCache) Destroy() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for key, layout := range c.layouts {
+		driver.DestroyDescriptorSetLayout(c.device, layout)
+		delete(c.layouts, key)
+	}
+}
+
+// bindingSignature identifies sorted bindings, immutable samplers included.
+func bindingSignature(sorted []vk.DescriptorSetLayoutBinding) string {
+	var key strings.Builder
+	for _, b := range sorted {
+		fmt.Fprintf(&key, "%v:%v:%v:%#x", b.Binding, b.DescriptorType, b.DescriptorCount, b.StageFlags)
+		for _, sampler := range b.PImmutableSamplers {
+			fmt.Fprintf(&key, ":%p", sampler)
+		}
+		key.WriteByte(';')
+	}
+	return key.String()
+}
+
+// DefaultDescriptorRatios are the descriptors of each type a pool holds per
+// set when DescriptorAllocatorConfig.Ratios is nil.
+var DefaultDescriptorRatios = map[vk.DescriptorType]float32{
+	vk.DescriptorTypeUniformBuffer:        2,
+	vk.DescriptorTypeUniformBufferDynamic: 1,
+	vk.DescriptorTypeStorageBuffer:        1,
+	vk.DescriptorTypeCombinedImageSampler: 4,
+}
+
+// DescriptorAllocatorConfig tunes NewDescriptorAllocator. Zero values pick the defaults.
+type DescriptorAllocatorConfig struct {
+	// SetsPerPool is the maxSets of every pool. Defaults to 64.
+	SetsPerPool uint32
+	// Ratios are the descriptors of each type a pool holds, per set. Defaults
+	// to DefaultDescriptorRatios.
+	Ratios map[vk.DescriptorType]float32
+}
+
+// DescriptorAllocator hands out descriptor sets from pools it creates as
+// needed: when a pool runs out (VK_ERROR_OUT_OF_POOL_MEMORY or
+// VK_ERROR_FRAGMENTED_POOL) the allocation is retried in a new one. Sets are
+// not freed one by one, Reset gives all of them back at once, keeping the
+// pools for the next round.
+type DescriptorAllocator struct {
+	device vk.Device
+	config DescriptorAllocatorConfig
+	mu     sync.Mutex
+	// current is the pool allocated from, full were given up on until Reset,
+	// free are reset and ready to become current
+	current vk.DescriptorPool
+	full    []vk.DescriptorPool
+	free    []vk.DescriptorPool
+}
+
+// NewDescriptorAllocator returns an allocator creating pools on device.
+// The first pool is created with the first Allocate.
+func NewDescriptorAllocator(device vk.Device, config DescriptorAllocatorConfig) *DescriptorAllocator {
+	if config.SetsPerPool == 0 {
+		config.SetsPerPool = 64
+	}
+	if config.Ratios == nil {
+		config.Ratios = DefaultDescriptorRatios
+	}
+	return &DescriptorAllocator{device: device, config: config}
+}
+
+// Allocate returns a set of layout.
+func (a *DescriptorAllocator) Allocate(layout vk.DescriptorSetLayout) (vk.DescriptorSet, error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if a.current == vk.NullDescriptorPool {
+		if err := a.nextPool(); err != nil {
+			return vk.NullDescriptorSet, err
+		}
+	}
+	set, err := a.allocate(layout)
+	if errors.Is(err, ErrOutOfPoolMemory) || errors.Is(err, ErrFragmentedPool) {
+		a.full = append(a.full, a.current)
+		a.current = vk.NullDescriptorPool
+		if err := a.nextPool(); err != nil {
+			return vk.NullDescriptorSet, err
+		}
+		// A fresh pool failing too means a single set needs more than a pool holds
+		if set, err = a.allocate(layout); err != nil {
+			return vk.NullDescriptorSet, fmt.Errorf("a new descriptor pool of %v sets is too small: %w", a.config.SetsPerPool, err)
+		}
+	}
+	return set, err
+}
+
+func (a *DescriptorAllocator) allocate(layout vk.DescriptorSetLayout) (vk.DescriptorSet, error) {
+	allocateInfo := vk.DescriptorSetAllocateInfo{
+		SType:              vk.StructureTypeDescriptorSetAllocateInfo,
+		DescriptorPool:     a.current,
+		DescriptorSetCount: 1,
+		PSetLayouts:        []vk.DescriptorSetLayout{layout},
+	}
+	sets, err := driver.AllocateDescriptorSets(a.device, &allocateInfo)
+	if err != nil {
+		return vk.NullDescriptorSet, err
+	}
+	return sets[0], nil
+}
+
+// nextPool makes a reset pool, or a new one, current. The caller holds a.mu.
+func (a *DescriptorAllocator) nextPool() error {
+	if n := len(a.free); n > 0 {
+		a.current, a.free = a.free[n-1], a.free[:n-1]
+		return nil
+	}
+	var poolSizes []vk.DescriptorPoolSize
+	for descriptorType, ratio := range a.config.Ratios {
+		count := uint32(ratio * float32(a.config.SetsPerPool))
+		if count == 0 {
+			count = 1
+		}
+		poolSizes = append(poolSizes, vk.DescriptorPoolSize{Type: descriptorType, DescriptorCount: count})
+	}
+	// Map order is random, sorted sizes make every pool alike
+	sort.Slice(poolSizes, func(i, j int) bool { return poolSizes[i].Type < poolSizes[j].Type })
+	poolCreateInfo := vk.DescriptorPoolCreateInfo{
+		SType:         vk.StructureTypeDescriptorPoolCreateInfo,
+		MaxSets:       a.config.SetsPerPool,
+		PoolSizeCount: uint32(len(poolSizes)),
+		PPoolSizes:    poolSizes,
+	}
+	pool, err := driver.CreateDescriptorPool(a.device, &poolCreateInfo)
+	if err != nil {
+		return err
+	}
+	a.current = pool
+	return nil
+}
+
+// Pools returns the number of pools created.
+func (a *DescriptorAllocator) Pools() int {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	n := len(a.full) + len(a.free)
+	if a.current != vk.NullDescriptorPool {
+		n++
+	}
+	return n
+}
+
+// Reset frees every set allocated so far. The GPU must be done with them,
+// e.g. once the fence of the frame that used them is signaled.
+func (a *DescriptorAllocator) Reset() error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if a.current != vk.NullDescriptorPool {
+		a.full = append(a.full, a.current)
+		a.current = vk.NullDescriptorPool
+	}
+	for len(a.full) > 0 {
+		pool := a.full[len(a.full)-1]
+		if err := driver.ResetDescriptorPool(a.device, pool); err != nil {
+			return err
+		}
+		a.full = a.full[:len(a.full)-1]
+		a.free = append(a.free, pool)
+	}
+	return nil
+}
+
+// Destroy destroys the pools, and with them every set. The GPU must be done with them.
+func (a *DescriptorAllocator) Destroy() {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if a.current != vk.NullDescriptorPool {
+		a.full = append(a.full, a.current)
+		a.current = vk.NullDescriptorPool
+	}
+	for _, pool := range append(a.full, a.free...) {
+		driver.DestroyDescriptorPool(a.device, pool)
+	}
+	a.full, a.free = nil, nil
+}
+
+// FrameDescriptors is a DescriptorAllocator per swapchain image, for the
+// sets written every frame. Begin resets the allocator of the acquired
+// image: FrameLoop.BeginFrame waited for the last frame drawn to that image,
+// so none of its sets is still in use.
+type FrameDescriptors struct {
+	device vk.Device
+	config DescriptorAllocatorConfig
+	frames []*DescriptorAllocator
+}
+
+// NewFrameDescriptors returns allocators for imageCount swapchain images.
+func NewFrameDescriptors(device vk.Device, imageCount uint32, config DescriptorAllocatorConfig) *FrameDescriptors {
+	f := &FrameDescriptors{device: device, config: config}
+	f.SetImageCount(imageCount)
+	return f
+}
+
+// SetImageCount follows a recreated swapchain with imageCount images, see
+// FrameLoop.SetSwapchain. The device must be idle.
+func (f *FrameDescriptors) SetImageCount(imageCount uint32) {
+	for uint32(len(f.frames)) > imageCount {
+		f.frames[len(f.frames)-1].Destroy()
+		f.frames = f.frames[:len(f.frames)-1]
+	}
+	for uint32(len(f.frames)) < imageCount {
+		f.frames = append(f.frames, NewDescriptorAllocator(f.device, f.config))
+	}
+}
+
+// Begin resets and returns the allocator of swapchain image imageIndex,
+// Frame.ImageIndex of the frame being recorded.
+func (f *FrameDescriptors) Begin(imageIndex uint32) (*DescriptorAllocator, error) {
+	if imageIndex >= uint32(len(f.frames)) {
+		return nil, fmt.Errorf("image %v out of the %v of the swapchain", imageIndex, len(f.frames))
+	}
+	allocator := f.frames[imageIndex]
+	if err := allocator.Reset(); err != nil {
+		return nil, err
+	}
+	return allocator, nil
+}
+
+// Destroy destroys every allocator. The GPU must be done with all frames.
+func (f *FrameDescriptors) Destroy() {
+	for _, allocator := range f.frames {
+		allocator.Destroy()
+	}
+	f.frames = nil
+}
+
+// DescriptorWriter collects writes to descriptor sets and applies them with
+// a single vkUpdateDescriptorSets. The methods chain:
+//
+//	vkutil.NewDescriptorWriter().
+//		UniformBuffer(set, 0, ubo.Buffer, 0, ubo.Size).
+//		CombinedImageSampler(set, 1, texture.View, sampler).
+//		Update(device)
+type DescriptorWriter struct {
+	writes []vk.WriteDescriptorSet
+}
+
+// NewDescriptorWriter returns a writer without writes.
+func NewDescriptorWriter() *DescriptorWriter {
+	return &DescriptorWriter{}
+}
+
+// UniformBuffer points binding of set at size bytes of buffer from offset,
+// vk.WholeSize for the rest of the buffer.
+func (w *DescriptorWriter) UniformBuffer(set vk.DescriptorSet, binding uint32, buffer vk.Buffer, offset, size vk.DeviceSize) *DescriptorWriter {
+	return w.buffer(set, binding, vk.DescriptorTypeUniformBuffer, buffer, offset, size)
+}
+
+// StorageBuffer is UniformBuffer for a storage buffer binding.
+func (w *DescriptorWriter) StorageBuffer(set vk.DescriptorSet, binding uint32, buffer vk.Buffer, offset, size vk.DeviceSize) *DescriptorWriter {
+	return w.buffer(set, binding, vk.DescriptorTypeStorageBuffer, buffer, offset, size)
+}
+
+func (w *DescriptorWriter) buffer(set vk.DescriptorSet, binding uint32, descriptorType vk.DescriptorType, buffer vk.Buffer, offset, size vk.DeviceSize) *DescriptorWriter {
+	w.writes = append(w.writes, vk.WriteDescriptorSet{
+		SType:           vk.StructureTypeWriteDescriptorSet,
+		DstSet:          set,
+		DstBinding:      binding,
+		DescriptorCount: 1,
+		DescriptorType:  descriptorType,
+		PBufferInfo:     []vk.DescriptorBufferInfo{{Buffer: buffer, Offset: offset, Range: size}},
+	})
+	return w
+}
+
+// CombinedImageSampler points binding of set at view sampled with sampler,
+// the image being in SHADER_READ_ONLY_OPTIMAL layout when it is read.
+func (w *DescriptorWriter) CombinedImageSampler(set vk.DescriptorSet, binding uint32, view vk.ImageView, sampler vk.Sampler) *DescriptorWriter {
+	w.writes = append(w.writes, vk.WriteDescriptorSet{
+		SType:           vk.StructureTypeWriteDescriptorSet,
+		DstSet:          set,
+		DstBinding:      binding,
+		DescriptorCount: 1,
+		DescriptorType:  vk.DescriptorTypeCombinedImageSampler,
+		PImageInfo: []vk.DescriptorImageInfo{{
+			Sampler:     sampler,
+			ImageView:   view,
+			ImageLayout: vk.ImageLayoutShaderReadOnlyOptimal,
+		}},
+	})
+	return w
+}
+
+// Update applies the writes and forgets them, so the writer can be reused.
+// The sets must not be in use by the GPU.
+func (w *DescriptorWriter) Update(device vk.Device) {
+	if len(w.writes) > 0 {
+		driver.UpdateDescriptorSets(device, w.writes)
+	}
+	w.writes = w.writes[:0]
+}
+
+// CmdBindDescriptorSets binds sets to the graphics bind point of commandBuffer,
+// starting at set firstSet of layout.
+func CmdBindDescriptorSets(commandBuffer vk.CommandBuffer, layout vk.PipelineLayout, firstSet uint32, sets ...vk.DescriptorSet) {
+	vk.CmdBindDescriptorSets(commandBuffer, vk.PipelineBindPointGraphics, layout, firstSet, uint32(len(sets)), sets, 0, nil)
+}
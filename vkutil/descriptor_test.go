@@ -0,0 +1,179 @@
+package vkutil_test
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/goodshailesh/My-Vulkan-Projects/vkutil"
+	"github.com/goodshailesh/My-Vulkan-Projects/vkutil/vkfake"
+	vk "github.com/vulkan-go/vulkan"
+)
+
+// newDescriptorLayout returns the layout of a single uniform buffer, the
+// cache is destroyed when the test ends.
+func newDescriptorLayout(t *testing.T) (*vkfake.Driver, vk.Device, vk.DescriptorSetLayout) {
+	t.Helper()
+	fake, _, _, device, _ := newDevice(t)
+	cache := vkutil.NewDescriptorLayoutCache(device)
+	t.Cleanup(cache.Destroy)
+	layout, err := cache.Get(vkutil.DescriptorBinding(0, vk.DescriptorTypeUniformBuffer, vk.ShaderStageVertexBit))
+	if err != nil {
+		t.Fatalf("Get: %v", err)
+	}
+	return fake, device, layout
+}
+
+func countCalls(calls []string, command string) int {
+	n := 0
+	for _, call := range calls {
+		if call == command {
+			n++
+		}
+	}
+	return n
+}
+
+// poolRunsOut fails the next fail vkAllocateDescriptorSets with
+// VK_ERROR_OUT_OF_POOL_MEMORY, whatever is left in the pool.
+type poolRunsOut struct {
+	*vkfake.Driver
+	fail int
+}
+
+func (d *poolRunsOut) AllocateDescriptorSets(device vk.Device, allocateInfo *vk.DescriptorSetAllocateInfo) ([]vk.DescriptorSet, error) {
+	if d.fail > 0 {
+		d.fail--
+		return nil, vkutil.Check("vkAllocateDescriptorSets", vk.ErrorOutOfPoolMemory)
+	}
+	return d.Driver.AllocateDescriptorSets(device, allocateInfo)
+}
+
+func TestDescriptorAllocatorOutOfPoolMemory(t *testing.T) {
+	fake, device, layout := newDescriptorLayout(t)
+	a := vkutil.NewDescriptorAllocator(device, vkutil.DescriptorAllocatorConfig{})
+	defer a.Destroy()
+	if _, err := a.Allocate(layout); err != nil {
+		t.Fatalf("Allocate: %v", err)
+	}
+	driver := &poolRunsOut{Driver: fake, fail: 1}
+	previous := vkutil.SetDriver(driver)
+	defer vkutil.SetDriver(previous)
+	if _, err := a.Allocate(layout); err != nil {
+		t.Fatalf("Allocate from a pool out of memory: %v", err)
+	}
+	if a.Pools() != 2 || countCalls(fake.Calls, "vkCreateDescriptorPool") != 2 {
+		t.Fatalf("%v pools after the first ran out, %v created, want 2", a.Pools(), countCalls(fake.Calls, "vkCreateDescriptorPool"))
+	}
+
+	// Both pools are reset and allocated from again, none is created
+	if err := a.Reset(); err != nil {
+		t.Fatalf("Reset: %v", err)
+	}
+	if n := countCalls(fake.Calls, "vkResetDescriptorPool"); n != 2 {
+		t.Errorf("%v pools reset, want 2", n)
+	}
+	driver.fail = 1
+	for idx := 0; idx < 3; idx++ {
+		if _, err := a.Allocate(layout); err != nil {
+			t.Fatalf("Allocate %v after Reset: %v", idx, err)
+		}
+	}
+	if a.Pools() != 2 || countCalls(fake.Calls, "vkCreateDescriptorPool") != 2 {
+		t.Errorf("%v pools after Reset, %v created, want the 2 recycled", a.Pools(), countCalls(fake.Calls, "vkCreateDescriptorPool"))
+	}
+}
+
+func TestDescriptorAllocatorPoolTooSmall(t *testing.T) {
+	fake, device, layout := newDescriptorLayout(t)
+	a := vkutil.NewDescriptorAllocator(device, vkutil.DescriptorAllocatorConfig{})
+	defer a.Destroy()
+	fake.Results["vkAllocateDescriptorSets"] = vk.ErrorOutOfPoolMemory
+	_, err := a.Allocate(layout)
+	if !errors.Is(err, vkutil.ErrOutOfPoolMemory) || !strings.Contains(err.Error(), "too small") {
+		t.Errorf("Allocate error = %v, want a new pool too small", err)
+	}
+	if a.Pools() != 2 {
+		t.Errorf("%v pools, want 2", a.Pools())
+	}
+}
+
+func TestDescriptorAllocatorFillsPools(t *testing.T) {
+	fake, device, layout := newDescriptorLayout(t)
+	a := vkutil.NewDescriptorAllocator(device, vkutil.DescriptorAllocatorConfig{SetsPerPool: 2})
+	defer a.Destroy()
+	for idx := 0; idx < 5; idx++ {
+		if _, err := a.Allocate(layout); err != nil {
+			t.Fatalf("Allocate %v: %v", idx, err)
+		}
+	}
+	if a.Pools() != 3 {
+		t.Errorf("%v pools for 5 sets of 2 per pool, want 3", a.Pools())
+	}
+	a.Destroy()
+	if n := liveKinds(fake.Live())["VkDescriptorPool"]; n != 0 {
+		t.Errorf("%v pools left after Destroy", n)
+	}
+}
+
+func TestFrameDescriptorsSetImageCount(t *testing.T) {
+	fake, device, layout := newDescriptorLayout(t)
+	f := vkutil.NewFrameDescriptors(device, 3, vkutil.DescriptorAllocatorConfig{})
+	defer f.Destroy()
+	for image := uint32(0); image < 3; image++ {
+		a, err := f.Begin(image)
+		if err != nil {
+			t.Fatalf("Begin(%v): %v", image, err)
+		}
+		if _, err := a.Allocate(layout); err != nil {
+			t.Fatalf("Allocate: %v", err)
+		}
+	}
+	f.SetImageCount(2)
+	if n := liveKinds(fake.Live())["VkDescriptorPool"]; n != 2 {
+		t.Errorf("%v pools after shrinking to 2 images, want 2", n)
+	}
+	if _, err := f.Begin(2); err == nil {
+		t.Error("Begin(2) succeeded with 2 images")
+	}
+	f.SetImageCount(4)
+	for image := uint32(0); image < 4; image++ {
+		if _, err := f.Begin(image); err != nil {
+			t.Errorf("Begin(%v) after growing to 4 images: %v", image, err)
+		}
+	}
+	if _, err := f.Begin(4); err == nil {
+		t.Error("Begin(4) succeeded with 4 images")
+	}
+}
+
+func TestDescriptorLayoutCache(t *testing.T) {
+	fake, _, _, device, _ := newDevice(t)
+	cache := vkutil.NewDescriptorLayoutCache(device)
+	ubo := vkutil.DescriptorBinding(0, vk.DescriptorTypeUniformBuffer, vk.ShaderStageVertexBit)
+	texture := vkutil.DescriptorBinding(1, vk.DescriptorTypeCombinedImageSampler, vk.ShaderStageFragmentBit)
+	first, err := cache.Get(ubo, texture)
+	if err != nil {
+		t.Fatalf("Get: %v", err)
+	}
+	second, err := cache.Get(texture, ubo)
+	if err != nil {
+		t.Fatalf("Get: %v", err)
+	}
+	if first != second || cache.Len() != 1 {
+		t.Errorf("bindings in another order made another layout, %v layouts", cache.Len())
+	}
+	// Another stage is another signature
+	texture.StageFlags |= vk.ShaderStageFlags(vk.ShaderStageVertexBit)
+	third, err := cache.Get(ubo, texture)
+	if err != nil {
+		t.Fatalf("Get: %v", err)
+	}
+	if third == first || cache.Len() != 2 {
+		t.Errorf("other stages shared a layout, %v layouts", cache.Len())
+	}
+	cache.Destroy()
+	if n := liveKinds(fake.Live())["VkDescriptorSetLayout"]; n != 0 {
+		t.Errorf("%v layouts left after Destroy", n)
+	}
+}
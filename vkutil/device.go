@@ -0,0 +1,112 @@
+package vkutil
+
+import (
+	"fmt"
+
+	vk "github.com/vulkan-go/vulkan"
+)
+
+// GetPhysicalDevices returns every physical device (GPU) visible to the instance.
+func GetPhysicalDevices(instance vk.Instance) ([]vk.PhysicalDevice, error) {
+	return driver.EnumeratePhysicalDevices(instance)
+}
+
+// GetPhysicalDeviceProperties returns the properties of the device, limits included.
+func GetPhysicalDeviceProperties(physicalDevice vk.PhysicalDevice) vk.PhysicalDeviceProperties {
+	return driver.GetPhysicalDeviceProperties(physicalDevice)
+}
+
+// GetPhysicalDeviceFeatures returns the features supported by the device.
+func GetPhysicalDeviceFeatures(physicalDevice vk.PhysicalDevice) vk.PhysicalDeviceFeatures {
+	return driver.GetPhysicalDeviceFeatures(physicalDevice)
+}
+
+// GetPhysicalDeviceMemoryProperties returns the memory types and heaps of the device.
+func GetPhysicalDeviceMemoryProperties(physicalDevice vk.PhysicalDevice) vk.PhysicalDeviceMemoryProperties {
+	return driver.GetPhysicalDeviceMemoryProperties(physicalDevice)
+}
+
+// GetPhysicalDeviceQueueFamilyProperties returns the queue families of the device,
+// the slice index is the queue family index.
+func GetPhysicalDeviceQueueFamilyProperties(physicalDevice vk.PhysicalDevice) []vk.QueueFamilyProperties {
+	return driver.GetPhysicalDeviceQueueFamilyProperties(physicalDevice)
+}
+
+// GetDeviceExtensionProperties lists the extensions the physical device supports.
+func GetDeviceExtensionProperties(physicalDevice vk.PhysicalDevice) ([]vk.ExtensionProperties, error) {
+	return driver.EnumerateDeviceExtensionProperties(physicalDevice)
+}
+
+// GetDeviceLayerProperties lists the device layers. Device layers are
+// deprecated, but older loaders still report them.
+func GetDeviceLayerProperties(physicalDevice vk.PhysicalDevice) ([]vk.LayerProperties, error) {
+	return driver.EnumerateDeviceLayerProperties(physicalDevice)
+}
+
+// PrintDeviceExtensionProperties prints the extensions the physical device supports.
+func PrintDeviceExtensionProperties(physicalDevice vk.PhysicalDevice) error {
+	fmt.Println("Listing available Extensions for device only..............")
+	properties, err := GetDeviceExtensionProperties(physicalDevice)
+	if err != nil {
+		return err
+	}
+	printExtensionProperties(properties)
+	return nil
+}
+
+// PrintDeviceLayerProperties prints the layers of the physical device.
+func PrintDeviceLayerProperties(physicalDevice vk.PhysicalDevice) error {
+	fmt.Println("Listing available layers for device only..............")
+	properties, err := GetDeviceLayerProperties(physicalDevice)
+	if err != nil {
+		return err
+	}
+	printLayerProperties(properties)
+	return nil
+}
+
+// CreateDevice creates a logical device with one queue from each of the
+// families in queueFamilies (see FindQueueFamilies) and the given device
+// extensions enabled. features may be nil to enable none.
+func CreateDevice(physicalDevice vk.PhysicalDevice, queueFamilies QueueFamilyIndices, extensions []string, features *vk.PhysicalDeviceFeatures) (vk.Device, error) {
+	var enabledFeatures = make([]vk.PhysicalDeviceFeatures, 1)
+	if features != nil {
+		enabledFeatures[0] = *features
+	}
+	// A family may only appear once in pQueueCreateInfos
+	var deviceQueueCreateInfos []vk.DeviceQueueCreateInfo
+	for _, family := range queueFamilies.Unique() {
+		deviceQueueCreateInfos = append(deviceQueueCreateInfos, vk.DeviceQueueCreateInfo{
+			SType:            vk.StructureTypeDeviceQueueCreateInfo,
+			QueueCount:       1,
+			QueueFamilyIndex: family,
+			PQueuePriorities: []float32{1.0},
+		})
+	}
+	extensions = safeStrings(extensions)
+	var deviceCreateInfo = vk.DeviceCreateInfo{
+		SType:                   vk.StructureTypeDeviceCreateInfo,
+		QueueCreateInfoCount:    uint32(len(deviceQueueCreateInfos)),
+		PQueueCreateInfos:       deviceQueueCreateInfos,
+		EnabledExtensionCount:   uint32(len(extensions)),
+		PpEnabledExtensionNames: extensions,
+		PEnabledFeatures:        enabledFeatures,
+	}
+	return driver.CreateDevice(physicalDevice, &deviceCreateInfo)
+}
+
+// DestroyDevice destroys a device made by CreateDevice.
+func DestroyDevice(device vk.Device) {
+	driver.DestroyDevice(device)
+}
+
+// GetDeviceQueue returns queue queueIndex of family queueFamilyIndex.
+func GetDeviceQueue(device vk.Device, queueFamilyIndex, queueIndex uint32) vk.Queue {
+	return driver.GetDeviceQueue(device, queueFamilyIndex, queueIndex)
+}
+
+// DeviceWaitTillComplete waits on the host for the completion of outstanding
+// queue operations for all queues of the logical device.
+func DeviceWaitTillComplete(device vk.Device) error {
+	return driver.DeviceWaitIdle(device)
+}
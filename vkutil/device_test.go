@@ -0,0 +1,63 @@
+package vkutil_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/goodshailesh/My-Vulkan-Projects/vkutil"
+	"github.com/goodshailesh/My-Vulkan-Projects/vkutil/vkfake"
+	vk "github.com/vulkan-go/vulkan"
+)
+
+// newDevice creates a device on a fake GPU with a surface, with a queue from
+// every family FindQueueFamilies picks. Once the test ends and the cleanups
+// registered later ran, the device is destroyed and nothing else may be left.
+func newDevice(t *testing.T) (*vkfake.Driver, vk.PhysicalDevice, vk.Surface, vk.Device, vkutil.QueueFamilyIndices) {
+	t.Helper()
+	fake, physicalDevices := useFake(t, vkfake.NewDevice("GPU", vk.PhysicalDeviceTypeDiscreteGpu))
+	surface := fake.NewSurface()
+	queueFamilies, err := vkutil.FindQueueFamilies(physicalDevices[0], surface)
+	if err != nil {
+		t.Fatalf("FindQueueFamilies: %v", err)
+	}
+	device, err := vkutil.CreateDevice(physicalDevices[0], queueFamilies, []string{"VK_KHR_swapchain"}, nil)
+	if err != nil {
+		t.Fatalf("CreateDevice: %v", err)
+	}
+	t.Cleanup(func() {
+		fake.DestroyDevice(device)
+		// The fake surface lives as long as the fake
+		if live := fake.Live(); len(live) != 1 {
+			t.Errorf("not destroyed: %v", live)
+		}
+	})
+	return fake, physicalDevices[0], surface, device, queueFamilies
+}
+
+func TestCreateDevice(t *testing.T) {
+	_, _, _, device, queueFamilies := newDevice(t)
+	queues := vkutil.GetDeviceQueues(device, queueFamilies)
+	if queues.Graphics == nil || queues.Present == nil || queues.Compute == nil || queues.Transfer == nil {
+		t.Errorf("queues = %+v, want one from every family", queues)
+	}
+	// Families shared by several roles hand out the same queue
+	if (queueFamilies.Graphics == queueFamilies.Present) != (queues.Graphics == queues.Present) {
+		t.Errorf("graphics queue %v, present queue %v of families %v", queues.Graphics, queues.Present, queueFamilies)
+	}
+}
+
+func TestCreateDeviceUnsupported(t *testing.T) {
+	_, physicalDevices := useFake(t, vkfake.NewDevice("GPU", vk.PhysicalDeviceTypeDiscreteGpu))
+	queueFamilies, err := vkutil.FindQueueFamilies(physicalDevices[0], vk.NullSurface)
+	if err != nil {
+		t.Fatalf("FindQueueFamilies: %v", err)
+	}
+	if _, err := vkutil.CreateDevice(physicalDevices[0], queueFamilies, []string{"VK_KHR_ray_query"}, nil); !errors.Is(err, vkutil.ErrExtensionNotPresent) {
+		t.Errorf("CreateDevice with an unknown extension = %v, want VK_ERROR_EXTENSION_NOT_PRESENT", err)
+	}
+	// The fake GPU has no geometry shaders
+	features := vk.PhysicalDeviceFeatures{GeometryShader: vk.True}
+	if _, err := vkutil.CreateDevice(physicalDevices[0], queueFamilies, nil, &features); !errors.Is(err, vkutil.ErrFeatureNotPresent) {
+		t.Errorf("CreateDevice with geometry shaders = %v, want VK_ERROR_FEATURE_NOT_PRESENT", err)
+	}
+}
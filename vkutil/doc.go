@@ -0,0 +1,24 @@
+// Package vkutil collects the Vulkan setup helpers that used to be copied
+// between the Excercise00N programs (instance and device creation, command
+// pools and buffers, buffers, images, image views, swapchains, the frame loop
+// drawing to them, depth and multisampled attachments, image layout tracking
+// and render pass dependencies, SPIR-V shaders and graphics pipelines,
+// descriptor sets and uniform buffers, staged uploads, mipmapped textures from
+// PNG and JPEG files, texture arrays and cube maps, cached samplers and
+// offscreen render targets) so every exercise builds against one
+// implementation.
+//
+// Nothing in this package depends on a windowing library; the GLFW window
+// and surface helpers live in the vkutil/window sub-package. Headless
+// programs load Vulkan with LoadVulkanLibrary and draw to an OffscreenTarget.
+//
+// Every helper returns an error instead of printing and handing back a zero
+// handle. A failed Vulkan call is a *ResultError carrying the call name and
+// the vk.Result, test it with errors.Is against ErrOutOfDate, ErrDeviceLost
+// and friends. Structures returned by the Get* helpers are already Deref'ed.
+//
+// The helpers reach Vulkan through a Driver. SetDriver swaps in the fake of
+// the vkutil/vkfake sub-package to run device selection, queue family,
+// swapchain, memory, descriptor, pipeline, command buffer and frame loop
+// code without a GPU.
+package vkutil
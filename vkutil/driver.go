@@ -0,0 +1,667 @@
+package vkutil
+
+import (
+	"fmt"
+	"unsafe"
+
+	vk "github.com/vulkan-go/vulkan"
+)
+
+// Driver is the part of the Vulkan API the setup helpers of this package go
+// through: instance creation, device selection, queue families, swapchains,
+// memory, samplers, descriptors, render passes, shaders, pipelines, command
+// buffers, synchronization and submission. Slices come back whole (no
+// count-then-fill) and already Deref'ed, and failures are *ResultError
+// values naming the Vulkan command.
+//
+// The default Driver calls the vk package. SetDriver swaps in another one,
+// e.g. the scriptable fake of the vkfake package, so the helpers can run on
+// a machine without a GPU.
+type Driver interface {
+	// CreateInstance also loads the instance level commands the vk package
+	// calls through.
+	CreateInstance(createInfo *vk.InstanceCreateInfo) (vk.Instance, error)
+	DestroyInstance(instance vk.Instance)
+	EnumerateInstanceLayerProperties() ([]vk.LayerProperties, error)
+	EnumerateInstanceExtensionProperties(layerName string) ([]vk.ExtensionProperties, error)
+
+	EnumeratePhysicalDevices(instance vk.Instance) ([]vk.PhysicalDevice, error)
+	GetPhysicalDeviceProperties(physicalDevice vk.PhysicalDevice) vk.PhysicalDeviceProperties
+	GetPhysicalDeviceFeatures(physicalDevice vk.PhysicalDevice) vk.PhysicalDeviceFeatures
+	GetPhysicalDeviceMemoryProperties(physicalDevice vk.PhysicalDevice) vk.PhysicalDeviceMemoryProperties
+	GetPhysicalDeviceQueueFamilyProperties(physicalDevice vk.PhysicalDevice) []vk.QueueFamilyProperties
+	GetPhysicalDeviceFormatProperties(physicalDevice vk.PhysicalDevice, format vk.Format) vk.FormatProperties
+	GetPhysicalDeviceImageFormatProperties(physicalDevice vk.PhysicalDevice, format vk.Format, imageType vk.ImageType, tiling vk.ImageTiling, usage vk.ImageUsageFlags, flags vk.ImageCreateFlags) (vk.ImageFormatProperties, error)
+	EnumerateDeviceExtensionProperties(physicalDevice vk.PhysicalDevice) ([]vk.ExtensionProperties, error)
+	EnumerateDeviceLayerProperties(physicalDevice vk.PhysicalDevice) ([]vk.LayerProperties, error)
+	// GetPhysicalDeviceUUID is the deviceUUID of VkPhysicalDeviceIDProperties,
+	// ok is false when the implementation cannot tell.
+	GetPhysicalDeviceUUID(instance vk.Instance, physicalDevice vk.PhysicalDevice) (uuid [vk.UuidSize]byte, ok bool)
+
+	GetPhysicalDeviceSurfaceSupport(physicalDevice vk.PhysicalDevice, queueFamilyIndex uint32, surface vk.Surface) (bool, error)
+	GetPhysicalDeviceSurfaceCapabilities(physicalDevice vk.PhysicalDevice, surface vk.Surface) (vk.SurfaceCapabilities, error)
+	GetPhysicalDeviceSurfaceFormats(physicalDevice vk.PhysicalDevice, surface vk.Surface) ([]vk.SurfaceFormat, error)
+	GetPhysicalDeviceSurfacePresentModes(physicalDevice vk.PhysicalDevice, surface vk.Surface) ([]vk.PresentMode, error)
+
+	CreateDevice(physicalDevice vk.PhysicalDevice, createInfo *vk.DeviceCreateInfo) (vk.Device, error)
+	DestroyDevice(device vk.Device)
+	GetDeviceQueue(device vk.Device, queueFamilyIndex, queueIndex uint32) vk.Queue
+	DeviceWaitIdle(device vk.Device) error
+
+	CreateSwapchain(device vk.Device, createInfo *vk.SwapchainCreateInfo) (vk.Swapchain, error)
+	DestroySwapchain(device vk.Device, swapchain vk.Swapchain)
+	GetSwapchainImages(device vk.Device, swapchain vk.Swapchain) ([]vk.Image, error)
+
+	AllocateMemory(device vk.Device, allocateInfo *vk.MemoryAllocateInfo) (vk.DeviceMemory, error)
+	FreeMemory(device vk.Device, memory vk.DeviceMemory)
+	MapMemory(device vk.Device, memory vk.DeviceMemory, offset, size vk.DeviceSize) (unsafe.Pointer, error)
+	UnmapMemory(device vk.Device, memory vk.DeviceMemory)
+
+	CreateBuffer(device vk.Device, createInfo *vk.BufferCreateInfo) (vk.Buffer, error)
+	DestroyBuffer(device vk.Device, buffer vk.Buffer)
+	GetBufferMemoryRequirements(device vk.Device, buffer vk.Buffer) vk.MemoryRequirements
+	BindBufferMemory(device vk.Device, buffer vk.Buffer, memory vk.DeviceMemory, offset vk.DeviceSize) error
+
+	CreateImage(device vk.Device, createInfo *vk.ImageCreateInfo) (vk.Image, error)
+	DestroyImage(device vk.Device, image vk.Image)
+	GetImageMemoryRequirements(device vk.Device, image vk.Image) vk.MemoryRequirements
+	BindImageMemory(device vk.Device, image vk.Image, memory vk.DeviceMemory, offset vk.DeviceSize) error
+	CreateImageView(device vk.Device, createInfo *vk.ImageViewCreateInfo) (vk.ImageView, error)
+	DestroyImageView(device vk.Device, imageView vk.ImageView)
+	CreateSampler(device vk.Device, createInfo *vk.SamplerCreateInfo) (vk.Sampler, error)
+	DestroySampler(device vk.Device, sampler vk.Sampler)
+
+	CreateDescriptorSetLayout(device vk.Device, createInfo *vk.DescriptorSetLayoutCreateInfo) (vk.DescriptorSetLayout, error)
+	DestroyDescriptorSetLayout(device vk.Device, layout vk.DescriptorSetLayout)
+	CreateDescriptorPool(device vk.Device, createInfo *vk.DescriptorPoolCreateInfo) (vk.DescriptorPool, error)
+	DestroyDescriptorPool(device vk.Device, pool vk.DescriptorPool)
+	ResetDescriptorPool(device vk.Device, pool vk.DescriptorPool) error
+	AllocateDescriptorSets(device vk.Device, allocateInfo *vk.DescriptorSetAllocateInfo) ([]vk.DescriptorSet, error)
+	UpdateDescriptorSets(device vk.Device, writes []vk.WriteDescriptorSet)
+
+	CreateRenderPass(device vk.Device, createInfo *vk.RenderPassCreateInfo) (vk.RenderPass, error)
+	DestroyRenderPass(device vk.Device, renderPass vk.RenderPass)
+	CreateFramebuffer(device vk.Device, createInfo *vk.FramebufferCreateInfo) (vk.Framebuffer, error)
+	DestroyFramebuffer(device vk.Device, framebuffer vk.Framebuffer)
+
+	CreateShaderModule(device vk.Device, createInfo *vk.ShaderModuleCreateInfo) (vk.ShaderModule, error)
+	DestroyShaderModule(device vk.Device, module vk.ShaderModule)
+	CreatePipelineLayout(device vk.Device, createInfo *vk.PipelineLayoutCreateInfo) (vk.PipelineLayout, error)
+	DestroyPipelineLayout(device vk.Device, layout vk.PipelineLayout)
+	// CreateGraphicsPipelines creates one pipeline per create info, without a pipeline cache.
+	CreateGraphicsPipelines(device vk.Device, createInfos []vk.GraphicsPipelineCreateInfo) ([]vk.Pipeline, error)
+	DestroyPipeline(device vk.Device, pipeline vk.Pipeline)
+
+	CreateCommandPool(device vk.Device, createInfo *vk.CommandPoolCreateInfo) (vk.CommandPool, error)
+	DestroyCommandPool(device vk.Device, pool vk.CommandPool)
+	AllocateCommandBuffers(device vk.Device, allocateInfo *vk.CommandBufferAllocateInfo) ([]vk.CommandBuffer, error)
+	FreeCommandBuffers(device vk.Device, pool vk.CommandPool, commandBuffers []vk.CommandBuffer)
+	BeginCommandBuffer(commandBuffer vk.CommandBuffer, beginInfo *vk.CommandBufferBeginInfo) error
+	EndCommandBuffer(commandBuffer vk.CommandBuffer) error
+
+	CreateSemaphore(device vk.Device, createInfo *vk.SemaphoreCreateInfo) (vk.Semaphore, error)
+	DestroySemaphore(device vk.Device, semaphore vk.Semaphore)
+	CreateFence(device vk.Device, createInfo *vk.FenceCreateInfo) (vk.Fence, error)
+	DestroyFence(device vk.Device, fence vk.Fence)
+	WaitForFences(device vk.Device, fences []vk.Fence, waitAll bool, timeout uint64) error
+	ResetFences(device vk.Device, fences []vk.Fence) error
+
+	QueueSubmit(queue vk.Queue, submits []vk.SubmitInfo, fence vk.Fence) error
+	QueueWaitIdle(queue vk.Queue) error
+	// AcquireNextImage returns the index of the acquired image along with an
+	// error matching ErrSuboptimal, the image may still be drawn to.
+	AcquireNextImage(device vk.Device, swapchain vk.Swapchain, timeout uint64, semaphore vk.Semaphore, fence vk.Fence) (uint32, error)
+	QueuePresent(queue vk.Queue, presentInfo *vk.PresentInfo) error
+}
+
+var driver Driver = vulkanDriver{}
+
+// SetDriver makes the helpers call d and returns the Driver used so far, so
+// a test can put it back. A nil d restores the vk package driver.
+func SetDriver(d Driver) (previous Driver) {
+	previous = driver
+	if d == nil {
+		d = vulkanDriver{}
+	}
+	driver = d
+	return previous
+}
+
+// CurrentDriver returns the Driver the helpers call.
+func CurrentDriver() Driver {
+	return driver
+}
+
+// vulkanDriver is the Driver calling the real implementation through the vk package.
+type vulkanDriver struct{}
+
+func (vulkanDriver) CreateInstance(createInfo *vk.InstanceCreateInfo) (vk.Instance, error) {
+	var instance vk.Instance
+	if err := Check("vkCreateInstance", vk.CreateInstance(createInfo, nil, &instance)); err != nil {
+		return nil, err
+	}
+	// InitInstance obtains instance PFNs for Vulkan API functions, this is necessary on OS X
+	// using MoltenVK, but for the other platforms it's an option.
+	if err := vk.InitInstance(instance); err != nil {
+		vk.DestroyInstance(instance, nil)
+		return nil, fmt.Errorf("vk.InitInstance failed with %w", err)
+	}
+	return instance, nil
+}
+
+func (vulkanDriver) DestroyInstance(instance vk.Instance) {
+	vk.DestroyInstance(instance, nil)
+}
+
+func (vulkanDriver) EnumerateInstanceLayerProperties() ([]vk.LayerProperties, error) {
+	var propertyCount uint32
+	if err := Check("vkEnumerateInstanceLayerProperties", vk.EnumerateInstanceLayerProperties(&propertyCount, nil)); err != nil {
+		return nil, err
+	}
+	properties := make([]vk.LayerProperties, propertyCount)
+	if err := Check("vkEnumerateInstanceLayerProperties", vk.EnumerateInstanceLayerProperties(&propertyCount, properties)); err != nil {
+		return nil, err
+	}
+	for idx := range properties {
+		properties[idx].Deref()
+	}
+	return properties[:propertyCount], nil
+}
+
+func (vulkanDriver) EnumerateInstanceExtensionProperties(layerName string) ([]vk.ExtensionProperties, error) {
+	var propertyCount uint32
+	if layerName != "" {
+		layerName = safeString(layerName)
+	}
+	if err := Check("vkEnumerateInstanceExtensionProperties", vk.EnumerateInstanceExtensionProperties(layerName, &propertyCount, nil)); err != nil {
+		return nil, err
+	}
+	properties := make([]vk.ExtensionProperties, propertyCount)
+	if err := Check("vkEnumerateInstanceExtensionProperties", vk.EnumerateInstanceExtensionProperties(layerName, &propertyCount, properties)); err != nil {
+		return nil, err
+	}
+	for idx := range properties {
+		properties[idx].Deref()
+	}
+	return properties[:propertyCount], nil
+}
+
+func (vulkanDriver) EnumeratePhysicalDevices(instance vk.Instance) ([]vk.PhysicalDevice, error) {
+	var deviceCount uint32
+	if err := Check("vkEnumeratePhysicalDevices", vk.EnumeratePhysicalDevices(instance, &deviceCount, nil)); err != nil {
+		return nil, err
+	}
+	var physicalDevices = make([]vk.PhysicalDevice, deviceCount)
+	if err := Check("vkEnumeratePhysicalDevices", vk.EnumeratePhysicalDevices(instance, &deviceCount, physicalDevices)); err != nil {
+		return nil, err
+	}
+	return physicalDevices[:deviceCount], nil
+}
+
+func (vulkanDriver) GetPhysicalDeviceProperties(physicalDevice vk.PhysicalDevice) vk.PhysicalDeviceProperties {
+	var properties vk.PhysicalDeviceProperties
+	vk.GetPhysicalDeviceProperties(physicalDevice, &properties)
+	properties.Deref()
+	properties.Limits.Deref()
+	properties.SparseProperties.Deref()
+	return properties
+}
+
+func (vulkanDriver) GetPhysicalDeviceFeatures(physicalDevice vk.PhysicalDevice) vk.PhysicalDeviceFeatures {
+	var features vk.PhysicalDeviceFeatures
+	vk.GetPhysicalDeviceFeatures(physicalDevice, &features)
+	features.Deref()
+	return features
+}
+
+func (vulkanDriver) GetPhysicalDeviceMemoryProperties(physicalDevice vk.PhysicalDevice) vk.PhysicalDeviceMemoryProperties {
+	var memoryProperties vk.PhysicalDeviceMemoryProperties
+	vk.GetPhysicalDeviceMemoryProperties(physicalDevice, &memoryProperties)
+	memoryProperties.Deref()
+	for idx := range memoryProperties.MemoryTypes {
+		memoryProperties.MemoryTypes[idx].Deref()
+	}
+	for idx := range memoryProperties.MemoryHeaps {
+		memoryProperties.MemoryHeaps[idx].Deref()
+	}
+	return memoryProperties
+}
+
+func (vulkanDriver) GetPhysicalDeviceQueueFamilyProperties(physicalDevice vk.PhysicalDevice) []vk.QueueFamilyProperties {
+	var familyCount uint32
+	vk.GetPhysicalDeviceQueueFamilyProperties(physicalDevice, &familyCount, nil)
+	var families = make([]vk.QueueFamilyProperties, familyCount)
+	vk.GetPhysicalDeviceQueueFamilyProperties(physicalDevice, &familyCount, families)
+	for idx := range families {
+		families[idx].Deref()
+		families[idx].MinImageTransferGranularity.Deref()
+	}
+	return families[:familyCount]
+}
+
+func (vulkanDriver) GetPhysicalDeviceFormatProperties(physicalDevice vk.PhysicalDevice, format vk.Format) vk.FormatProperties {
+	var formatProperties vk.FormatProperties
+	vk.GetPhysicalDeviceFormatProperties(physicalDevice, format, &formatProperties)
+	formatProperties.Deref()
+	return formatProperties
+}
+
+func (vulkanDriver) GetPhysicalDeviceImageFormatProperties(physicalDevice vk.PhysicalDevice, format vk.Format, imageType vk.ImageType, tiling vk.ImageTiling, usage vk.ImageUsageFlags, flags vk.ImageCreateFlags) (vk.ImageFormatProperties, error) {
+	var imageFormatProperties vk.ImageFormatProperties
+	if err := Check("vkGetPhysicalDeviceImageFormatProperties", vk.GetPhysicalDeviceImageFormatProperties(physicalDevice, format, imageType, tiling, usage, flags, &imageFormatProperties)); err != nil {
+		return imageFormatProperties, err
+	}
+	imageFormatProperties.Deref()
+	imageFormatProperties.MaxExtent.Deref()
+	return imageFormatProperties, nil
+}
+
+func (vulkanDriver) EnumerateDeviceExtensionProperties(physicalDevice vk.PhysicalDevice) ([]vk.ExtensionProperties, error) {
+	var propertyCount uint32
+	if err := Check("vkEnumerateDeviceExtensionProperties", vk.EnumerateDeviceExtensionProperties(physicalDevice, "", &propertyCount, nil)); err != nil {
+		return nil, err
+	}
+	properties := make([]vk.ExtensionProperties, propertyCount)
+	if err := Check("vkEnumerateDeviceExtensionProperties", vk.EnumerateDeviceExtensionProperties(physicalDevice, "", &propertyCount, properties)); err != nil {
+		return nil, err
+	}
+	for idx := range properties {
+		properties[idx].Deref()
+	}
+	return properties[:propertyCount], nil
+}
+
+func (vulkanDriver) EnumerateDeviceLayerProperties(physicalDevice vk.PhysicalDevice) ([]vk.LayerProperties, error) {
+	var propertyCount uint32
+	if err := Check("vkEnumerateDeviceLayerProperties", vk.EnumerateDeviceLayerProperties(physicalDevice, &propertyCount, nil)); err != nil {
+		return nil, err
+	}
+	properties := make([]vk.LayerProperties, propertyCount)
+	if err := Check("vkEnumerateDeviceLayerProperties", vk.EnumerateDeviceLayerProperties(physicalDevice, &propertyCount, properties)); err != nil {
+		return nil, err
+	}
+	for idx := range properties {
+		properties[idx].Deref()
+	}
+	return properties[:propertyCount], nil
+}
+
+func (vulkanDriver) GetPhysicalDeviceUUID(instance vk.Instance, physicalDevice vk.PhysicalDevice) ([vk.UuidSize]byte, bool) {
+	return getPhysicalDeviceUUID(instance, physicalDevice)
+}
+
+func (vulkanDriver) GetPhysicalDeviceSurfaceSupport(physicalDevice vk.PhysicalDevice, queueFamilyIndex uint32, surface vk.Surface) (bool, error) {
+	var supported vk.Bool32
+	if err := Check("vkGetPhysicalDeviceSurfaceSupportKHR", vk.GetPhysicalDeviceSurfaceSupport(physicalDevice, queueFamilyIndex, surface, &supported)); err != nil {
+		return false, err
+	}
+	return supported == vk.True, nil
+}
+
+func (vulkanDriver) GetPhysicalDeviceSurfaceCapabilities(physicalDevice vk.PhysicalDevice, surface vk.Surface) (vk.SurfaceCapabilities, error) {
+	var surfaceCapabilities vk.SurfaceCapabilities
+	if err := Check("vkGetPhysicalDeviceSurfaceCapabilitiesKHR", vk.GetPhysicalDeviceSurfaceCapabilities(physicalDevice, surface, &surfaceCapabilities)); err != nil {
+		return surfaceCapabilities, err
+	}
+	surfaceCapabilities.Deref()
+	surfaceCapabilities.CurrentExtent.Deref()
+	surfaceCapabilities.MinImageExtent.Deref()
+	surfaceCapabilities.MaxImageExtent.Deref()
+	return surfaceCapabilities, nil
+}
+
+func (vulkanDriver) GetPhysicalDeviceSurfaceFormats(physicalDevice vk.PhysicalDevice, surface vk.Surface) ([]vk.SurfaceFormat, error) {
+	var formatCount uint32
+	if err := Check("vkGetPhysicalDeviceSurfaceFormatsKHR", vk.GetPhysicalDeviceSurfaceFormats(physicalDevice, surface, &formatCount, nil)); err != nil {
+		return nil, err
+	}
+	formats := make([]vk.SurfaceFormat, formatCount)
+	if err := Check("vkGetPhysicalDeviceSurfaceFormatsKHR", vk.GetPhysicalDeviceSurfaceFormats(physicalDevice, surface, &formatCount, formats)); err != nil {
+		return nil, err
+	}
+	for idx := range formats {
+		formats[idx].Deref()
+	}
+	return formats[:formatCount], nil
+}
+
+func (vulkanDriver) GetPhysicalDeviceSurfacePresentModes(physicalDevice vk.PhysicalDevice, surface vk.Surface) ([]vk.PresentMode, error) {
+	var modeCount uint32
+	if err := Check("vkGetPhysicalDeviceSurfacePresentModesKHR", vk.GetPhysicalDeviceSurfacePresentModes(physicalDevice, surface, &modeCount, nil)); err != nil {
+		return nil, err
+	}
+	modes := make([]vk.PresentMode, modeCount)
+	if err := Check("vkGetPhysicalDeviceSurfacePresentModesKHR", vk.GetPhysicalDeviceSurfacePresentModes(physicalDevice, surface, &modeCount, modes)); err != nil {
+		return nil, err
+	}
+	return modes[:modeCount], nil
+}
+
+func (vulkanDriver) CreateDevice(physicalDevice vk.PhysicalDevice, createInfo *vk.DeviceCreateInfo) (vk.Device, error) {
+	var device vk.Device
+	if err := Check("vkCreateDevice", vk.CreateDevice(physicalDevice, createInfo, nil, &device)); err != nil {
+		return nil, err
+	}
+	return device, nil
+}
+
+func (vulkanDriver) DestroyDevice(device vk.Device) {
+	vk.DestroyDevice(device, nil)
+}
+
+func (vulkanDriver) GetDeviceQueue(device vk.Device, queueFamilyIndex, queueIndex uint32) vk.Queue {
+	var queue vk.Queue
+	vk.GetDeviceQueue(device, queueFamilyIndex, queueIndex, &queue)
+	return queue
+}
+
+func (vulkanDriver) DeviceWaitIdle(device vk.Device) error {
+	return Check("vkDeviceWaitIdle", vk.DeviceWaitIdle(device))
+}
+
+func (vulkanDriver) CreateSwapchain(device vk.Device, createInfo *vk.SwapchainCreateInfo) (vk.Swapchain, error) {
+	var swapchain vk.Swapchain
+	if err := Check("vkCreateSwapchainKHR", vk.CreateSwapchain(device, createInfo, nil, &swapchain)); err != nil {
+		return vk.NullSwapchain, err
+	}
+	return swapchain, nil
+}
+
+func (vulkanDriver) DestroySwapchain(device vk.Device, swapchain vk.Swapchain) {
+	vk.DestroySwapchain(device, swapchain, nil)
+}
+
+func (vulkanDriver) GetSwapchainImages(device vk.Device, swapchain vk.Swapchain) ([]vk.Image, error) {
+	var imageCount uint32
+	if err := Check("vkGetSwapchainImagesKHR", vk.GetSwapchainImages(device, swapchain, &imageCount, nil)); err != nil {
+		return nil, err
+	}
+	images := make([]vk.Image, imageCount)
+	if err := Check("vkGetSwapchainImagesKHR", vk.GetSwapchainImages(device, swapchain, &imageCount, images)); err != nil {
+		return nil, err
+	}
+	return images[:imageCount], nil
+}
+
+func (vulkanDriver) AllocateMemory(device vk.Device, allocateInfo *vk.MemoryAllocateInfo) (vk.DeviceMemory, error) {
+	var memory vk.DeviceMemory
+	if err := Check("vkAllocateMemory", vk.AllocateMemory(device, allocateInfo, nil, &memory)); err != nil {
+		return vk.NullDeviceMemory, err
+	}
+	return memory, nil
+}
+
+func (vulkanDriver) FreeMemory(device vk.Device, memory vk.DeviceMemory) {
+	vk.FreeMemory(device, memory, nil)
+}
+
+func (vulkanDriver) MapMemory(device vk.Device, memory vk.DeviceMemory, offset, size vk.DeviceSize) (unsafe.Pointer, error) {
+	var data unsafe.Pointer
+	if err := Check("vkMapMemory", vk.MapMemory(device, memory, offset, size, 0, &data)); err != nil {
+		return nil, err
+	}
+	return data, nil
+}
+
+func (vulkanDriver) UnmapMemory(device vk.Device, memory vk.DeviceMemory) {
+	vk.UnmapMemory(device, memory)
+}
+
+func (vulkanDriver) CreateBuffer(device vk.Device, createInfo *vk.BufferCreateInfo) (vk.Buffer, error) {
+	var buffer vk.Buffer
+	if err := Check("vkCreateBuffer", vk.CreateBuffer(device, createInfo, nil, &buffer)); err != nil {
+		return vk.NullBuffer, err
+	}
+	return buffer, nil
+}
+
+func (vulkanDriver) DestroyBuffer(device vk.Device, buffer vk.Buffer) {
+	vk.DestroyBuffer(device, buffer, nil)
+}
+
+func (vulkanDriver) GetBufferMemoryRequirements(device vk.Device, buffer vk.Buffer) vk.MemoryRequirements {
+	var memoryRequirements vk.MemoryRequirements
+	vk.GetBufferMemoryRequirements(device, buffer, &memoryRequirements)
+	memoryRequirements.Deref()
+	return memoryRequirements
+}
+
+func (vulkanDriver) BindBufferMemory(device vk.Device, buffer vk.Buffer, memory vk.DeviceMemory, offset vk.DeviceSize) error {
+	return Check("vkBindBufferMemory", vk.BindBufferMemory(device, buffer, memory, offset))
+}
+
+func (vulkanDriver) CreateImage(device vk.Device, createInfo *vk.ImageCreateInfo) (vk.Image, error) {
+	var image vk.Image
+	if err := Check("vkCreateImage", vk.CreateImage(device, createInfo, nil, &image)); err != nil {
+		return vk.NullImage, err
+	}
+	return image, nil
+}
+
+func (vulkanDriver) DestroyImage(device vk.Device, image vk.Image) {
+	vk.DestroyImage(device, image, nil)
+}
+
+func (vulkanDriver) GetImageMemoryRequirements(device vk.Device, image vk.Image) vk.MemoryRequirements {
+	var memoryRequirements vk.MemoryRequirements
+	vk.GetImageMemoryRequirements(device, image, &memoryRequirements)
+	memoryRequirements.Deref()
+	return memoryRequirements
+}
+
+func (vulkanDriver) BindImageMemory(device vk.Device, image vk.Image, memory vk.DeviceMemory, offset vk.DeviceSize) error {
+	return Check("vkBindImageMemory", vk.BindImageMemory(device, image, memory, offset))
+}
+
+func (vulkanDriver) CreateImageView(device vk.Device, createInfo *vk.ImageViewCreateInfo) (vk.ImageView, error) {
+	var imageView vk.ImageView
+	if err := Check("vkCreateImageView", vk.CreateImageView(device, createInfo, nil, &imageView)); err != nil {
+		return vk.NullImageView, err
+	}
+	return imageView, nil
+}
+
+func (vulkanDriver) DestroyImageView(device vk.Device, imageView vk.ImageView) {
+	vk.DestroyImageView(device, imageView, nil)
+}
+
+func (vulkanDriver) CreateSampler(device vk.Device, createInfo *vk.SamplerCreateInfo) (vk.Sampler, error) {
+	var sampler vk.Sampler
+	if err := Check("vkCreateSampler", vk.CreateSampler(device, createInfo, nil, &sampler)); err != nil {
+		return vk.NullSampler, err
+	}
+	return sampler, nil
+}
+
+func (vulkanDriver) DestroySampler(device vk.Device, sampler vk.Sampler) {
+	vk.DestroySampler(device, sampler, nil)
+}
+
+func (vulkanDriver) CreateDescriptorSetLayout(device vk.Device, createInfo *vk.DescriptorSetLayoutCreateInfo) (vk.DescriptorSetLayout, error) {
+	var layout vk.DescriptorSetLayout
+	if err := Check("vkCreateDescriptorSetLayout", vk.CreateDescriptorSetLayout(device, createInfo, nil, &layout)); err != nil {
+		return vk.NullDescriptorSetLayout, err
+	}
+	return layout, nil
+}
+
+func (vulkanDriver) DestroyDescriptorSetLayout(device vk.Device, layout vk.DescriptorSetLayout) {
+	vk.DestroyDescriptorSetLayout(device, layout, nil)
+}
+
+func (vulkanDriver) CreateDescriptorPool(device vk.Device, createInfo *vk.DescriptorPoolCreateInfo) (vk.DescriptorPool, error) {
+	var pool vk.DescriptorPool
+	if err := Check("vkCreateDescriptorPool", vk.CreateDescriptorPool(device, createInfo, nil, &pool)); err != nil {
+		return vk.NullDescriptorPool, err
+	}
+	return pool, nil
+}
+
+func (vulkanDriver) DestroyDescriptorPool(device vk.Device, pool vk.DescriptorPool) {
+	vk.DestroyDescriptorPool(device, pool, nil)
+}
+
+func (vulkanDriver) ResetDescriptorPool(device vk.Device, pool vk.DescriptorPool) error {
+	return Check("vkResetDescriptorPool", vk.ResetDescriptorPool(device, pool, 0))
+}
+
+func (vulkanDriver) AllocateDescriptorSets(device vk.Device, allocateInfo *vk.DescriptorSetAllocateInfo) ([]vk.DescriptorSet, error) {
+	if allocateInfo.DescriptorSetCount == 0 {
+		return nil, nil
+	}
+	sets := make([]vk.DescriptorSet, allocateInfo.DescriptorSetCount)
+	if err := Check("vkAllocateDescriptorSets", vk.AllocateDescriptorSets(device, allocateInfo, &sets[0])); err != nil {
+		return nil, err
+	}
+	return sets, nil
+}
+
+func (vulkanDriver) UpdateDescriptorSets(device vk.Device, writes []vk.WriteDescriptorSet) {
+	vk.UpdateDescriptorSets(device, uint32(len(writes)), writes, 0, nil)
+}
+
+func (vulkanDriver) CreateRenderPass(device vk.Device, createInfo *vk.RenderPassCreateInfo) (vk.RenderPass, error) {
+	var renderPass vk.RenderPass
+	if err := Check("vkCreateRenderPass", vk.CreateRenderPass(device, createInfo, nil, &renderPass)); err != nil {
+		return vk.NullRenderPass, err
+	}
+	return renderPass, nil
+}
+
+func (vulkanDriver) DestroyRenderPass(device vk.Device, renderPass vk.RenderPass) {
+	vk.DestroyRenderPass(device, renderPass, nil)
+}
+
+func (vulkanDriver) CreateFramebuffer(device vk.Device, createInfo *vk.FramebufferCreateInfo) (vk.Framebuffer, error) {
+	var framebuffer vk.Framebuffer
+	if err := Check("vkCreateFramebuffer", vk.CreateFramebuffer(device, createInfo, nil, &framebuffer)); err != nil {
+		return vk.NullFramebuffer, err
+	}
+	return framebuffer, nil
+}
+
+func (vulkanDriver) DestroyFramebuffer(device vk.Device, framebuffer vk.Framebuffer) {
+	vk.DestroyFramebuffer(device, framebuffer, nil)
+}
+
+func (vulkanDriver) CreateShaderModule(device vk.Device, createInfo *vk.ShaderModuleCreateInfo) (vk.ShaderModule, error) {
+	var module vk.ShaderModule
+	if err := Check("vkCreateShaderModule", vk.CreateShaderModule(device, createInfo, nil, &module)); err != nil {
+		return vk.NullShaderModule, err
+	}
+	return module, nil
+}
+
+func (vulkanDriver) DestroyShaderModule(device vk.Device, module vk.ShaderModule) {
+	vk.DestroyShaderModule(device, module, nil)
+}
+
+func (vulkanDriver) CreatePipelineLayout(device vk.Device, createInfo *vk.PipelineLayoutCreateInfo) (vk.PipelineLayout, error) {
+	var layout vk.PipelineLayout
+	if err := Check("vkCreatePipelineLayout", vk.CreatePipelineLayout(device, createInfo, nil, &layout)); err != nil {
+		return vk.NullPipelineLayout, err
+	}
+	return layout, nil
+}
+
+func (vulkanDriver) DestroyPipelineLayout(device vk.Device, layout vk.PipelineLayout) {
+	vk.DestroyPipelineLayout(device, layout, nil)
+}
+
+func (vulkanDriver) CreateGraphicsPipelines(device vk.Device, createInfos []vk.GraphicsPipelineCreateInfo) ([]vk.Pipeline, error) {
+	pipelines := make([]vk.Pipeline, len(createInfos))
+	if err := Check("vkCreateGraphicsPipelines", vk.CreateGraphicsPipelines(device, vk.NullPipelineCache, uint32(len(createInfos)), createInfos, nil, pipelines)); err != nil {
+		return nil, err
+	}
+	return pipelines, nil
+}
+
+func (vulkanDriver) DestroyPipeline(device vk.Device, pipeline vk.Pipeline) {
+	vk.DestroyPipeline(device, pipeline, nil)
+}
+
+func (vulkanDriver) CreateCommandPool(device vk.Device, createInfo *vk.CommandPoolCreateInfo) (vk.CommandPool, error) {
+	var pool vk.CommandPool
+	if err := Check("vkCreateCommandPool", vk.CreateCommandPool(device, createInfo, nil, &pool)); err != nil {
+		return vk.NullCommandPool, err
+	}
+	return pool, nil
+}
+
+func (vulkanDriver) DestroyCommandPool(device vk.Device, pool vk.CommandPool) {
+	vk.DestroyCommandPool(device, pool, nil)
+}
+
+func (vulkanDriver) AllocateCommandBuffers(device vk.Device, allocateInfo *vk.CommandBufferAllocateInfo) ([]vk.CommandBuffer, error) {
+	commandBuffers := make([]vk.CommandBuffer, allocateInfo.CommandBufferCount)
+	if err := Check("vkAllocateCommandBuffers", vk.AllocateCommandBuffers(device, allocateInfo, commandBuffers)); err != nil {
+		return nil, err
+	}
+	return commandBuffers, nil
+}
+
+func (vulkanDriver) FreeCommandBuffers(device vk.Device, pool vk.CommandPool, commandBuffers []vk.CommandBuffer) {
+	vk.FreeCommandBuffers(device, pool, uint32(len(commandBuffers)), commandBuffers)
+}
+
+func (vulkanDriver) BeginCommandBuffer(commandBuffer vk.CommandBuffer, beginInfo *vk.CommandBufferBeginInfo) error {
+	return Check("vkBeginCommandBuffer", vk.BeginCommandBuffer(commandBuffer, beginInfo))
+}
+
+func (vulkanDriver) EndCommandBuffer(commandBuffer vk.CommandBuffer) error {
+	return Check("vkEndCommandBuffer", vk.EndCommandBuffer(commandBuffer))
+}
+
+func (vulkanDriver) CreateSemaphore(device vk.Device, createInfo *vk.SemaphoreCreateInfo) (vk.Semaphore, error) {
+	var semaphore vk.Semaphore
+	if err := Check("vkCreateSemaphore", vk.CreateSemaphore(device, createInfo, nil, &semaphore)); err != nil {
+		return vk.NullSemaphore, err
+	}
+	return semaphore, nil
+}
+
+func (vulkanDriver) DestroySemaphore(device vk.Device, semaphore vk.Semaphore) {
+	vk.DestroySemaphore(device, semaphore, nil)
+}
+
+func (vulkanDriver) CreateFence(device vk.Device, createInfo *vk.FenceCreateInfo) (vk.Fence, error) {
+	var fence vk.Fence
+	if err := Check("vkCreateFence", vk.CreateFence(device, createInfo, nil, &fence)); err != nil {
+		return vk.NullFence, err
+	}
+	return fence, nil
+}
+
+func (vulkanDriver) DestroyFence(device vk.Device, fence vk.Fence) {
+	vk.DestroyFence(device, fence, nil)
+}
+
+func (vulkanDriver) WaitForFences(device vk.Device, fences []vk.Fence, waitAll bool, timeout uint64) error {
+	all := vk.Bool32(vk.False)
+	if waitAll {
+		all = vk.True
+	}
+	return Check("vkWaitForFences", vk.WaitForFences(device, uint32(len(fences)), fences, all, timeout))
+}
+
+func (vulkanDriver) ResetFences(device vk.Device, fences []vk.Fence) error {
+	return Check("vkResetFences", vk.ResetFences(device, uint32(len(fences)), fences))
+}
+
+func (vulkanDriver) QueueSubmit(queue vk.Queue, submits []vk.SubmitInfo, fence vk.Fence) error {
+	return Check("vkQueueSubmit", vk.QueueSubmit(queue, uint32(len(submits)), submits, fence))
+}
+
+func (vulkanDriver) QueueWaitIdle(queue vk.Queue) error {
+	return Check("vkQueueWaitIdle", vk.QueueWaitIdle(queue))
+}
+
+func (vulkanDriver) AcquireNextImage(device vk.Device, swapchain vk.Swapchain, timeout uint64, semaphore vk.Semaphore, fence vk.Fence) (uint32, error) {
+	var imageIndex uint32
+	err := Check("vkAcquireNextImageKHR", vk.AcquireNextImage(device, swapchain, timeout, semaphore, fence, &imageIndex))
+	return imageIndex, err
+}
+
+func (vulkanDriver) QueuePresent(queue vk.Queue, presentInfo *vk.PresentInfo) error {
+	return Check("vkQueuePresentKHR", vk.QueuePresent(queue, presentInfo))
+}
@@ -0,0 +1,143 @@
+package vkutil
+
+import (
+	"errors"
+	"fmt"
+
+	vk "github.com/vulkan-go/vulkan"
+)
+
+// ResultError is returned by every helper whose Vulkan call did not return
+// VK_SUCCESS. Call is the failing Vulkan function, e.g. "vkCreateDevice".
+//
+// Branch on the result with errors.Is and the Err* values below, which match
+// any call:
+//
+//	if errors.Is(err, vkutil.ErrOutOfDate) {
+//		// recreate the swapchain
+//	}
+type ResultError struct {
+	Call   string
+	Result vk.Result
+}
+
+func (e *ResultError) Error() string {
+	if e.Call == "" {
+		return ResultString(e.Result)
+	}
+	return fmt.Sprintf("%v failed with %v", e.Call, ResultString(e.Result))
+}
+
+// Is matches the Err* values: a target without Call matches on Result alone.
+func (e *ResultError) Is(target error) bool {
+	t, ok := target.(*ResultError)
+	if !ok {
+		return false
+	}
+	return t.Result == e.Result && (t.Call == "" || t.Call == e.Call)
+}
+
+// Results to branch on with errors.Is.
+var (
+	ErrNotReady             = &ResultError{Result: vk.NotReady}
+	ErrTimeout              = &ResultError{Result: vk.Timeout}
+	ErrIncomplete           = &ResultError{Result: vk.Incomplete}
+	ErrSuboptimal           = &ResultError{Result: vk.Suboptimal}
+	ErrOutOfHostMemory      = &ResultError{Result: vk.ErrorOutOfHostMemory}
+	ErrOutOfDeviceMemory    = &ResultError{Result: vk.ErrorOutOfDeviceMemory}
+	ErrInitializationFailed = &ResultError{Result: vk.ErrorInitializationFailed}
+	ErrDeviceLost           = &ResultError{Result: vk.ErrorDeviceLost}
+	ErrMemoryMapFailed      = &ResultError{Result: vk.ErrorMemoryMapFailed}
+	ErrLayerNotPresent      = &ResultError{Result: vk.ErrorLayerNotPresent}
+	ErrExtensionNotPresent  = &ResultError{Result: vk.ErrorExtensionNotPresent}
+	ErrFeatureNotPresent    = &ResultError{Result: vk.ErrorFeatureNotPresent}
+	ErrIncompatibleDriver   = &ResultError{Result: vk.ErrorIncompatibleDriver}
+	ErrTooManyObjects       = &ResultError{Result: vk.ErrorTooManyObjects}
+	ErrFormatNotSupported   = &ResultError{Result: vk.ErrorFormatNotSupported}
+	ErrSurfaceLost          = &ResultError{Result: vk.ErrorSurfaceLost}
+	ErrNativeWindowInUse    = &ResultError{Result: vk.ErrorNativeWindowInUse}
+	ErrOutOfDate            = &ResultError{Result: vk.ErrorOutOfDate}
+	ErrOutOfPoolMemory      = &ResultError{Result: vk.ErrorOutOfPoolMemory}
+	ErrFragmentedPool       = &ResultError{Result: vk.ErrorFragmentedPool}
+)
+
+// Check returns nil when result is VK_SUCCESS and a *ResultError naming call
+// otherwise. Positive codes like VK_SUBOPTIMAL_KHR or VK_TIMEOUT are errors
+// as well, callers that expect them test for them with errors.Is.
+func Check(call string, result vk.Result) error {
+	if result == vk.Success {
+		return nil
+	}
+	return &ResultError{Call: call, Result: result}
+}
+
+// ResultOf returns the vk.Result wrapped somewhere in err.
+func ResultOf(err error) (vk.Result, bool) {
+	var re *ResultError
+	if errors.As(err, &re) {
+		return re.Result, true
+	}
+	return vk.Success, false
+}
+
+// ResultString returns the C name of result, e.g. "VK_ERROR_OUT_OF_DATE_KHR".
+// https://www.khronos.org/registry/vulkan/specs/1.2-extensions/man/html/VkResult.html
+func ResultString(result vk.Result) string {
+	switch result {
+	case vk.Success:
+		return "VK_SUCCESS"
+	case vk.NotReady:
+		return "VK_NOT_READY"
+	case vk.Timeout:
+		return "VK_TIMEOUT"
+	case vk.EventSet:
+		return "VK_EVENT_SET"
+	case vk.EventReset:
+		return "VK_EVENT_RESET"
+	case vk.Incomplete:
+		return "VK_INCOMPLETE"
+	case vk.ErrorOutOfHostMemory:
+		return "VK_ERROR_OUT_OF_HOST_MEMORY"
+	case vk.ErrorOutOfDeviceMemory:
+		return "VK_ERROR_OUT_OF_DEVICE_MEMORY"
+	case vk.ErrorInitializationFailed:
+		return "VK_ERROR_INITIALIZATION_FAILED"
+	case vk.ErrorDeviceLost:
+		return "VK_ERROR_DEVICE_LOST"
+	case vk.ErrorMemoryMapFailed:
+		return "VK_ERROR_MEMORY_MAP_FAILED"
+	case vk.ErrorLayerNotPresent:
+		return "VK_ERROR_LAYER_NOT_PRESENT"
+	case vk.ErrorExtensionNotPresent:
+		return "VK_ERROR_EXTENSION_NOT_PRESENT"
+	case vk.ErrorFeatureNotPresent:
+		return "VK_ERROR_FEATURE_NOT_PRESENT"
+	case vk.ErrorIncompatibleDriver:
+		return "VK_ERROR_INCOMPATIBLE_DRIVER"
+	case vk.ErrorTooManyObjects:
+		return "VK_ERROR_TOO_MANY_OBJECTS"
+	case vk.ErrorFormatNotSupported:
+		return "VK_ERROR_FORMAT_NOT_SUPPORTED"
+	case vk.ErrorFragmentedPool:
+		return "VK_ERROR_FRAGMENTED_POOL"
+	case vk.ErrorOutOfPoolMemory:
+		return "VK_ERROR_OUT_OF_POOL_MEMORY"
+	case vk.ErrorInvalidExternalHandle:
+		return "VK_ERROR_INVALID_EXTERNAL_HANDLE"
+	case vk.ErrorSurfaceLost:
+		return "VK_ERROR_SURFACE_LOST_KHR"
+	case vk.ErrorNativeWindowInUse:
+		return "VK_ERROR_NATIVE_WINDOW_IN_USE_KHR"
+	case vk.Suboptimal:
+		return "VK_SUBOPTIMAL_KHR"
+	case vk.ErrorOutOfDate:
+		return "VK_ERROR_OUT_OF_DATE_KHR"
+	case vk.ErrorIncompatibleDisplay:
+		return "VK_ERROR_INCOMPATIBLE_DISPLAY_KHR"
+	case vk.ErrorValidationFailed:
+		return "VK_ERROR_VALIDATION_FAILED_EXT"
+	case vk.ErrorInvalidShaderNv:
+		return "VK_ERROR_INVALID_SHADER_NV"
+	}
+	return fmt.Sprintf("VkResult(%v)", int32(result))
+}
@@ -0,0 +1,94 @@
+package vkutil
+
+import (
+	"fmt"
+	"runtime"
+	"strings"
+
+	vk "github.com/vulkan-go/vulkan"
+)
+
+// MissingExtensionError is returned when one or more required extensions are
+// not offered by the Vulkan implementation.
+type MissingExtensionError struct {
+	// Scope is "instance" or "device".
+	Scope   string
+	Missing []string
+}
+
+func (e *MissingExtensionError) Error() string {
+	return fmt.Sprintf("required %s extension(s) not available: %s", e.Scope, strings.Join(e.Missing, ", "))
+}
+
+// PlatformSurfaceExtensions returns the instance extensions needed to create
+// a surface on the current OS. Programs with a GLFW window should prefer
+// window.GetRequiredInstanceExtensions, which asks GLFW directly.
+// On Linux any of the window system extensions will do, so they are meant to
+// be passed as optional.
+func PlatformSurfaceExtensions() []string {
+	switch runtime.GOOS {
+	case "windows":
+		return []string{"VK_KHR_surface", "VK_KHR_win32_surface"}
+	case "darwin":
+		return []string{"VK_KHR_surface", "VK_EXT_metal_surface", "VK_MVK_macos_surface"}
+	case "android":
+		return []string{"VK_KHR_surface", "VK_KHR_android_surface"}
+	default:
+		//https://software.intel.com/en-us/articles/api-without-secrets-introduction-to-vulkan-part-2
+		return []string{"VK_KHR_surface", "VK_KHR_xcb_surface", "VK_KHR_xlib_surface", "VK_KHR_wayland_surface"}
+	}
+}
+
+// SelectInstanceExtensions checks required and optional against the
+// extensions the instance offers and returns the list to enable: every
+// required extension plus the optional ones that are present. If a required
+// extension is missing a *MissingExtensionError is returned.
+func SelectInstanceExtensions(required, optional []string) ([]string, error) {
+	available, err := GetInstanceExtensionProperties("")
+	if err != nil {
+		return nil, err
+	}
+	return selectExtensions("instance", available, required, optional)
+}
+
+// SelectDeviceExtensions is SelectInstanceExtensions for the extensions of physicalDevice.
+func SelectDeviceExtensions(physicalDevice vk.PhysicalDevice, required, optional []string) ([]string, error) {
+	available, err := GetDeviceExtensionProperties(physicalDevice)
+	if err != nil {
+		return nil, err
+	}
+	return selectExtensions("device", available, required, optional)
+}
+
+func selectExtensions(scope string, available []vk.ExtensionProperties, required, optional []string) ([]string, error) {
+	present := make(map[string]bool, len(available))
+	for _, p := range available {
+		present[vk.ToString(p.ExtensionName[:])] = true
+	}
+	var enabled, missing []string
+	seen := make(map[string]bool)
+	for _, name := range required {
+		name = strings.TrimSuffix(name, "\x00")
+		if seen[name] {
+			continue
+		}
+		seen[name] = true
+		if !present[name] {
+			missing = append(missing, name)
+			continue
+		}
+		enabled = append(enabled, name)
+	}
+	if len(missing) > 0 {
+		return nil, &MissingExtensionError{Scope: scope, Missing: missing}
+	}
+	for _, name := range optional {
+		name = strings.TrimSuffix(name, "\x00")
+		if seen[name] || !present[name] {
+			continue
+		}
+		seen[name] = true
+		enabled = append(enabled, name)
+	}
+	return enabled, nil
+}
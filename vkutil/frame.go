@@ -0,0 +1,274 @@
+package vkutil
+
+import (
+	"errors"
+	"fmt"
+
+	vk "github.com/vulkan-go/vulkan"
+)
+
+// DefaultFramesInFlight is the number of frames the CPU may record ahead of
+// the GPU when FrameLoopConfig.FramesInFlight is 0. Two keeps both busy
+// without adding much latency.
+const DefaultFramesInFlight = 2
+
+// FrameLoopConfig tunes NewFrameLoop. Zero values pick the defaults.
+type FrameLoopConfig struct {
+	// FramesInFlight is the number of frames recorded and submitted before
+	// BeginFrame waits for the oldest one to finish. Defaults to DefaultFramesInFlight.
+	FramesInFlight int
+	// Timeout in nanoseconds for acquiring an image and waiting for a frame
+	// fence, after which BeginFrame fails with ErrTimeout. Defaults to no timeout.
+	Timeout uint64
+}
+
+// FrameLoop hides the acquire, submit and present dance of drawing to a
+// swapchain behind BeginFrame and EndFrame. Each frame in flight has its own
+// command buffer, an image-available and a render-finished semaphore and a
+// fence, so the CPU records frame N+1 while the GPU still draws frame N.
+// https://www.khronos.org/registry/vulkan/specs/1.2-extensions/html/vkspec.html#synchronization
+type FrameLoop struct {
+	device         vk.Device
+	graphics       vk.Queue
+	present        vk.Queue
+	swapchain      vk.Swapchain
+	commandPool    vk.CommandPool
+	timeout        uint64
+	frames         []frameSync
+	current        int
+	recording      bool
+	imageIndex     uint32
+	imagesInFlight []vk.Fence
+}
+
+// frameSync is what one frame in flight owns.
+type frameSync struct {
+	commandBuffer  vk.CommandBuffer
+	imageAvailable vk.Semaphore
+	renderFinished vk.Semaphore
+	inFlight       vk.Fence
+}
+
+// Frame is the frame BeginFrame started.
+type Frame struct {
+	// Slot is the frame in flight, 0 to FramesInFlight-1. Per-frame resources
+	// like uniform buffers are indexed with it.
+	Slot int
+	// ImageIndex is the acquired swapchain image, pick the framebuffer with it.
+	ImageIndex uint32
+	// CommandBuffer is in the recording state, EndFrame ends and submits it.
+	CommandBuffer vk.CommandBuffer
+	// Suboptimal is true when the image was acquired but the swapchain no
+	// longer matches the surface exactly. The frame can be finished, the
+	// swapchain should be recreated afterwards.
+	Suboptimal bool
+}
+
+// NewFrameLoop creates the command buffers, semaphores and fences to draw
+// to swapchain, which has imageCount images. Commands are submitted to
+// queues.Graphics, created from queueFamilies.Graphics, and presented on
+// queues.Present.
+func NewFrameLoop(device vk.Device, queueFamilies QueueFamilyIndices, queues Queues, swapchain vk.Swapchain, imageCount uint32, config FrameLoopConfig) (*FrameLoop, error) {
+	if config.FramesInFlight == 0 {
+		config.FramesInFlight = DefaultFramesInFlight
+	}
+	if config.FramesInFlight < 0 {
+		return nil, fmt.Errorf("FramesInFlight %v is negative", config.FramesInFlight)
+	}
+	if config.Timeout == 0 {
+		config.Timeout = vk.MaxUint64
+	}
+	l := &FrameLoop{
+		device:         device,
+		graphics:       queues.Graphics,
+		present:        queues.Present,
+		swapchain:      swapchain,
+		timeout:        config.Timeout,
+		imagesInFlight: make([]vk.Fence, imageCount),
+	}
+	// The command buffers are reset one by one each time their frame comes round again
+	var err error
+	l.commandPool, err = CreateCommandPool(device, queueFamilies.Graphics, vk.CommandPoolCreateFlags(vk.CommandPoolCreateResetCommandBufferBit))
+	if err != nil {
+		return nil, err
+	}
+	commandBuffers, err := AllocateCommandBuffers(device, l.commandPool, uint32(config.FramesInFlight))
+	if err != nil {
+		l.Destroy()
+		return nil, err
+	}
+	semaphoreCreateInfo := vk.SemaphoreCreateInfo{
+		SType: vk.StructureTypeSemaphoreCreateInfo,
+	}
+	// Signaled, so the first BeginFrame of every slot does not wait for a frame that never ran
+	fenceCreateInfo := vk.FenceCreateInfo{
+		SType: vk.StructureTypeFenceCreateInfo,
+		Flags: vk.FenceCreateFlags(vk.FenceCreateSignaledBit),
+	}
+	for idx, commandBuffer := range commandBuffers {
+		// Appended first so Destroy cleans up what was created when a later call fails
+		l.frames = append(l.frames, frameSync{commandBuffer: commandBuffer})
+		f := &l.frames[idx]
+		if f.imageAvailable, err = driver.CreateSemaphore(device, &semaphoreCreateInfo); err != nil {
+			l.Destroy()
+			return nil, err
+		}
+		if f.renderFinished, err = driver.CreateSemaphore(device, &semaphoreCreateInfo); err != nil {
+			l.Destroy()
+			return nil, err
+		}
+		if f.inFlight, err = driver.CreateFence(device, &fenceCreateInfo); err != nil {
+			l.Destroy()
+			return nil, err
+		}
+	}
+	return l, nil
+}
+
+// FramesInFlight returns the number of frames the loop cycles through.
+func (l *FrameLoop) FramesInFlight() int {
+	return len(l.frames)
+}
+
+// SetSwapchain makes the loop draw to a recreated swapchain with imageCount
+// images. The device must be idle, e.g. after DeviceWaitTillComplete.
+func (l *FrameLoop) SetSwapchain(swapchain vk.Swapchain, imageCount uint32) {
+	l.swapchain = swapchain
+	l.imagesInFlight = make([]vk.Fence, imageCount)
+}
+
+// BeginFrame waits until the GPU is done with the oldest frame in flight,
+// acquires the next swapchain image and begins the command buffer of the
+// frame. The caller records into Frame.CommandBuffer then calls EndFrame.
+//
+// When the swapchain is out of date the error matches ErrOutOfDate, no
+// image was acquired and the caller recreates the swapchain, calls
+// SetSwapchain and tries again.
+func (l *FrameLoop) BeginFrame() (Frame, error) {
+	if l.recording {
+		return Frame{}, errors.New("BeginFrame called twice without EndFrame")
+	}
+	f := &l.frames[l.current]
+	if err := driver.WaitForFences(l.device, []vk.Fence{f.inFlight}, true, l.timeout); err != nil {
+		return Frame{}, err
+	}
+	// The fence is only reset once work is sure to be submitted, an out of
+	// date swapchain would otherwise leave it unsignaled forever
+	imageIndex, err := driver.AcquireNextImage(l.device, l.swapchain, l.timeout, f.imageAvailable, vk.NullFence)
+	suboptimal := errors.Is(err, ErrSuboptimal)
+	if err != nil && !suboptimal {
+		return Frame{}, err
+	}
+	// With more images than frames in flight, or images handed out of order,
+	// the image may still be drawn by another frame
+	if inFlight := l.imagesInFlight[imageIndex]; inFlight != vk.NullFence && inFlight != f.inFlight {
+		if err := driver.WaitForFences(l.device, []vk.Fence{inFlight}, true, l.timeout); err != nil {
+			return Frame{}, err
+		}
+	}
+	l.imagesInFlight[imageIndex] = f.inFlight
+
+	// Beginning a command buffer of a pool created with RESET_COMMAND_BUFFER resets it
+	commandBufferBeginInfo := vk.CommandBufferBeginInfo{
+		SType: vk.StructureTypeCommandBufferBeginInfo,
+		Flags: vk.CommandBufferUsageFlags(vk.CommandBufferUsageOneTimeSubmitBit),
+	}
+	if err := driver.BeginCommandBuffer(f.commandBuffer, &commandBufferBeginInfo); err != nil {
+		return Frame{}, l.giveBack(f, vk.NullFence, err)
+	}
+	l.recording = true
+	l.imageIndex = imageIndex
+	return Frame{
+		Slot:          l.current,
+		ImageIndex:    imageIndex,
+		CommandBuffer: f.commandBuffer,
+		Suboptimal:    suboptimal,
+	}, nil
+}
+
+// EndFrame ends the command buffer of the frame and submits it once the
+// image is available, then presents the image once rendering finished.
+// The next BeginFrame moves on to the next frame in flight.
+//
+// An error matching ErrOutOfDate or ErrSuboptimal (see SwapchainOutOfDate)
+// comes from presenting: the frame was submitted, the swapchain should be
+// recreated before the next one. Any other error means the frame was not
+// submitted, the next BeginFrame starts the same frame in flight again.
+func (l *FrameLoop) EndFrame() error {
+	if !l.recording {
+		return errors.New("EndFrame called without BeginFrame")
+	}
+	l.recording = false
+	f := &l.frames[l.current]
+	if err := driver.EndCommandBuffer(f.commandBuffer); err != nil {
+		return l.giveBack(f, vk.NullFence, err)
+	}
+	// Only the color output waits for the image, vertex work can start before
+	submitInfo := []vk.SubmitInfo{{
+		SType:                vk.StructureTypeSubmitInfo,
+		WaitSemaphoreCount:   1,
+		PWaitSemaphores:      []vk.Semaphore{f.imageAvailable},
+		PWaitDstStageMask:    []vk.PipelineStageFlags{vk.PipelineStageFlags(vk.PipelineStageColorAttachmentOutputBit)},
+		CommandBufferCount:   1,
+		PCommandBuffers:      []vk.CommandBuffer{f.commandBuffer},
+		SignalSemaphoreCount: 1,
+		PSignalSemaphores:    []vk.Semaphore{f.renderFinished},
+	}}
+	// Reset right before the submit that signals it again
+	if err := driver.ResetFences(l.device, []vk.Fence{f.inFlight}); err != nil {
+		return l.giveBack(f, vk.NullFence, err)
+	}
+	if err := driver.QueueSubmit(l.graphics, submitInfo, f.inFlight); err != nil {
+		return l.giveBack(f, f.inFlight, err)
+	}
+	l.current = (l.current + 1) % len(l.frames)
+	presentInfo := vk.PresentInfo{
+		SType:              vk.StructureTypePresentInfo,
+		WaitSemaphoreCount: 1,
+		PWaitSemaphores:    []vk.Semaphore{f.renderFinished},
+		SwapchainCount:     1,
+		PSwapchains:        []vk.Swapchain{l.swapchain},
+		PImageIndices:      []uint32{l.imageIndex},
+	}
+	return driver.QueuePresent(l.present, &presentInfo)
+}
+
+// giveBack undoes what BeginFrame left pending for a frame that will not be
+// submitted, then returns err. An empty submit waits on the image-available
+// semaphore, an acquire must not signal it again before, and signals fence,
+// the frame fence when it was already reset, so BeginFrame does not wait for
+// it forever.
+func (l *FrameLoop) giveBack(f *frameSync, fence vk.Fence, err error) error {
+	submitInfo := []vk.SubmitInfo{{
+		SType:              vk.StructureTypeSubmitInfo,
+		WaitSemaphoreCount: 1,
+		PWaitSemaphores:    []vk.Semaphore{f.imageAvailable},
+		PWaitDstStageMask:  []vk.PipelineStageFlags{vk.PipelineStageFlags(vk.PipelineStageAllCommandsBit)},
+	}}
+	if submitErr := driver.QueueSubmit(l.graphics, submitInfo, fence); submitErr != nil {
+		return fmt.Errorf("%w (giving the frame back failed too: %v)", err, submitErr)
+	}
+	return err
+}
+
+// Destroy destroys the semaphores, fences and command pool. The GPU must be
+// done with every frame, e.g. after DeviceWaitTillComplete.
+func (l *FrameLoop) Destroy() {
+	for _, f := range l.frames {
+		if f.imageAvailable != vk.NullSemaphore {
+			driver.DestroySemaphore(l.device, f.imageAvailable)
+		}
+		if f.renderFinished != vk.NullSemaphore {
+			driver.DestroySemaphore(l.device, f.renderFinished)
+		}
+		if f.inFlight != vk.NullFence {
+			driver.DestroyFence(l.device, f.inFlight)
+		}
+	}
+	l.frames = nil
+	if l.commandPool != vk.NullCommandPool {
+		// Frees the command buffers as well
+		driver.DestroyCommandPool(l.device, l.commandPool)
+		l.commandPool = vk.NullCommandPool
+	}
+}
@@ -0,0 +1,234 @@
+package vkutil_test
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/goodshailesh/My-Vulkan-Projects/vkutil"
+	"github.com/goodshailesh/My-Vulkan-Projects/vkutil/vkfake"
+	vk "github.com/vulkan-go/vulkan"
+)
+
+// newFrameLoop returns a frame loop drawing to a 3 image swapchain of a fake
+// device, everything is destroyed when the test ends.
+func newFrameLoop(t *testing.T) (*vkfake.Driver, *vkutil.FrameLoop) {
+	t.Helper()
+	fake, physicalDevice, surface, device, queueFamilies := newDevice(t)
+	config, err := vkutil.ResolveSwapchainConfig(physicalDevice, surface, vkutil.SwapchainPreferences{})
+	if err != nil {
+		t.Fatalf("ResolveSwapchainConfig: %v", err)
+	}
+	swapchain, err := vkutil.CreateSwapChain(device, surface, config, queueFamilies, vk.NullSwapchain)
+	if err != nil {
+		t.Fatalf("CreateSwapChain: %v", err)
+	}
+	l, err := vkutil.NewFrameLoop(device, queueFamilies, vkutil.GetDeviceQueues(device, queueFamilies), swapchain, config.ImageCount, vkutil.FrameLoopConfig{})
+	if err != nil {
+		t.Fatalf("NewFrameLoop: %v", err)
+	}
+	t.Cleanup(func() {
+		l.Destroy()
+		fake.DestroySwapchain(device, swapchain)
+	})
+	return fake, l
+}
+
+// drawFrame begins and ends a frame, recording nothing.
+func drawFrame(t *testing.T, l *vkutil.FrameLoop) vkutil.Frame {
+	t.Helper()
+	frame, err := l.BeginFrame()
+	if err != nil {
+		t.Fatalf("BeginFrame: %v", err)
+	}
+	if err := l.EndFrame(); err != nil {
+		t.Fatalf("EndFrame: %v", err)
+	}
+	return frame
+}
+
+func TestFrameLoop(t *testing.T) {
+	_, l := newFrameLoop(t)
+	var slots []int
+	var images []uint32
+	for idx := 0; idx < 5; idx++ {
+		frame := drawFrame(t, l)
+		slots = append(slots, frame.Slot)
+		images = append(images, frame.ImageIndex)
+	}
+	if want := []int{0, 1, 0, 1, 0}; !reflect.DeepEqual(slots, want) {
+		t.Errorf("slots = %v, want %v", slots, want)
+	}
+	// The fake hands out the first image presented back
+	if want := []uint32{0, 0, 0, 0, 0}; !reflect.DeepEqual(images, want) {
+		t.Errorf("images = %v, want %v", images, want)
+	}
+}
+
+func TestFrameLoopMisuse(t *testing.T) {
+	_, l := newFrameLoop(t)
+	if err := l.EndFrame(); err == nil {
+		t.Error("EndFrame succeeded without BeginFrame")
+	}
+	if _, err := l.BeginFrame(); err != nil {
+		t.Fatalf("BeginFrame: %v", err)
+	}
+	if _, err := l.BeginFrame(); err == nil {
+		t.Error("BeginFrame succeeded twice")
+	}
+	if err := l.EndFrame(); err != nil {
+		t.Errorf("EndFrame: %v", err)
+	}
+}
+
+// failingSubmit fails the first fail frame submits, the empty submits giving
+// a frame back still go through.
+type failingSubmit struct {
+	*vkfake.Driver
+	fail int
+}
+
+func (d *failingSubmit) QueueSubmit(queue vk.Queue, submits []vk.SubmitInfo, fence vk.Fence) error {
+	if d.fail > 0 && len(submits) == 1 && submits[0].CommandBufferCount > 0 {
+		d.fail--
+		return vkutil.Check("vkQueueSubmit", vk.ErrorOutOfDeviceMemory)
+	}
+	return d.Driver.QueueSubmit(queue, submits, fence)
+}
+
+func TestFrameLoopSubmitFails(t *testing.T) {
+	fake, l := newFrameLoop(t)
+	drawFrame(t, l)
+	previous := vkutil.SetDriver(&failingSubmit{Driver: fake, fail: 2})
+	t.Cleanup(func() { vkutil.SetDriver(previous) })
+	for idx := 0; idx < 2; idx++ {
+		frame, err := l.BeginFrame()
+		if err != nil {
+			t.Fatalf("BeginFrame after %v failed submits: %v", idx, err)
+		}
+		if frame.Slot != 1 {
+			t.Errorf("Slot = %v, the failed frame is started again", frame.Slot)
+		}
+		if err := l.EndFrame(); !errors.Is(err, vkutil.ErrOutOfDeviceMemory) {
+			t.Fatalf("EndFrame error = %v, want VK_ERROR_OUT_OF_DEVICE_MEMORY", err)
+		}
+	}
+	// Fence signaled and semaphore waited on, BeginFrame neither times out nor acquires with a pending semaphore
+	if frame := drawFrame(t, l); frame.Slot != 1 {
+		t.Errorf("Slot = %v after the failed submits, want 1", frame.Slot)
+	}
+	if frame := drawFrame(t, l); frame.Slot != 0 {
+		t.Errorf("Slot = %v, want 0", frame.Slot)
+	}
+}
+
+func TestFrameLoopEndCommandBufferFails(t *testing.T) {
+	fake, l := newFrameLoop(t)
+	if _, err := l.BeginFrame(); err != nil {
+		t.Fatalf("BeginFrame: %v", err)
+	}
+	fake.Results["vkEndCommandBuffer"] = vk.ErrorOutOfHostMemory
+	if err := l.EndFrame(); !errors.Is(err, vkutil.ErrOutOfHostMemory) {
+		t.Fatalf("EndFrame error = %v, want VK_ERROR_OUT_OF_HOST_MEMORY", err)
+	}
+	delete(fake.Results, "vkEndCommandBuffer")
+	if frame := drawFrame(t, l); frame.Slot != 0 {
+		t.Errorf("Slot = %v, the failed frame is started again", frame.Slot)
+	}
+}
+
+func TestFrameLoopBeginCommandBufferFails(t *testing.T) {
+	fake, l := newFrameLoop(t)
+	fake.Results["vkBeginCommandBuffer"] = vk.ErrorOutOfHostMemory
+	if _, err := l.BeginFrame(); !errors.Is(err, vkutil.ErrOutOfHostMemory) {
+		t.Fatalf("BeginFrame error = %v, want VK_ERROR_OUT_OF_HOST_MEMORY", err)
+	}
+	delete(fake.Results, "vkBeginCommandBuffer")
+	if frame := drawFrame(t, l); frame.Slot != 0 {
+		t.Errorf("Slot = %v, want 0", frame.Slot)
+	}
+}
+
+func TestFrameLoopOutOfDate(t *testing.T) {
+	fake, l := newFrameLoop(t)
+	fake.Results["vkAcquireNextImageKHR"] = vk.ErrorOutOfDate
+	if _, err := l.BeginFrame(); !errors.Is(err, vkutil.ErrOutOfDate) {
+		t.Fatalf("BeginFrame error = %v, want VK_ERROR_OUT_OF_DATE_KHR", err)
+	}
+	delete(fake.Results, "vkAcquireNextImageKHR")
+	fake.Results["vkQueuePresentKHR"] = vk.Suboptimal
+	frame, err := l.BeginFrame()
+	if err != nil {
+		t.Fatalf("BeginFrame: %v", err)
+	}
+	if err := l.EndFrame(); !vkutil.SwapchainOutOfDate(err) {
+		t.Fatalf("EndFrame error = %v, want VK_SUBOPTIMAL_KHR", err)
+	}
+	delete(fake.Results, "vkQueuePresentKHR")
+	// The frame was submitted and presented all the same
+	if next := drawFrame(t, l); next.Slot != frame.Slot+1 {
+		t.Errorf("Slot = %v after %v", next.Slot, frame.Slot)
+	}
+}
+
+// fenceLog logs the fences frames are submitted with and waited on.
+type fenceLog struct {
+	*vkfake.Driver
+	submitted []vk.Fence
+	waited    [][]vk.Fence
+}
+
+func (d *fenceLog) QueueSubmit(queue vk.Queue, submits []vk.SubmitInfo, fence vk.Fence) error {
+	d.submitted = append(d.submitted, fence)
+	return d.Driver.QueueSubmit(queue, submits, fence)
+}
+
+func (d *fenceLog) WaitForFences(device vk.Device, fences []vk.Fence, waitAll bool, timeout uint64) error {
+	d.waited = append(d.waited, append([]vk.Fence(nil), fences...))
+	return d.Driver.WaitForFences(device, fences, waitAll, timeout)
+}
+
+func TestFrameLoopImagesOutOfOrder(t *testing.T) {
+	fake, l := newFrameLoop(t)
+	log := &fenceLog{Driver: fake}
+	previous := vkutil.SetDriver(log)
+	t.Cleanup(func() { vkutil.SetDriver(previous) })
+	// Frame 2 gets the image of frame 1, frame 3 the one of frame 0, each from the other slot
+	fake.AcquireOrder = []uint32{0, 1, 1, 0}
+	slotFences := make(map[int]vk.Fence)
+	var waited [][][]vk.Fence
+	for idx := 0; idx < 4; idx++ {
+		waits := len(log.waited)
+		frame := drawFrame(t, l)
+		slotFences[frame.Slot] = log.submitted[len(log.submitted)-1]
+		waited = append(waited, log.waited[waits:])
+	}
+	var got [][]string
+	for _, calls := range waited {
+		var names []string
+		for _, fences := range calls {
+			for _, fence := range fences {
+				switch fence {
+				case slotFences[0]:
+					names = append(names, "slot 0")
+				case slotFences[1]:
+					names = append(names, "slot 1")
+				default:
+					names = append(names, "unknown")
+				}
+			}
+		}
+		got = append(got, names)
+	}
+	want := [][]string{
+		{"slot 0"},
+		{"slot 1"},
+		// Slot 0 draws to image 1, which slot 1 may still be drawing
+		{"slot 0", "slot 1"},
+		// Slot 1 draws to image 0, last drawn by slot 0
+		{"slot 1", "slot 0"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("fences waited on = %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,269 @@
+// Package golden compares rendered frames against stored golden PNGs.
+//
+// A Harness renders named scenes, typically into a vkutil.OffscreenTarget,
+// and compares every one with <Dir>/<name>.png. A pixel counts as different
+// when a channel is off by more than Options.Tolerance and, if
+// Options.Perceptual is set, the colors are also perceptually apart. On
+// failure the rendered frame and a diff image are written next to the
+// golden. With Harness.Update the goldens are rewritten instead, review
+// them before committing.
+//
+// Nothing in this package uses Vulkan, scenes only hand back an image.Image.
+package golden
+
+import (
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/png"
+	"io"
+	"math"
+	"os"
+	"path/filepath"
+)
+
+// Options tune Compare. Zero values ask for an exact match.
+type Options struct {
+	// Tolerance is the largest difference of a channel, 0 to 255, that is
+	// still counted as equal. GPUs and drivers round differently, 2 or 3
+	// absorbs that without hiding real changes.
+	Tolerance uint8
+	// Perceptual, between 0 and 1, forgives pixels whose colors are closer
+	// than this in the YIQ space, where brightness weighs more than hue. 0.1
+	// is a good start; 0 turns the check off.
+	// https://github.com/mapbox/pixelmatch
+	Perceptual float64
+	// MaxDiffPixels is the number of different pixels still accepted, for
+	// anti-aliased edges that move by a pixel between implementations.
+	MaxDiffPixels int
+}
+
+// Result is what Compare found.
+type Result struct {
+	// DiffPixels is the number of pixels counted as different.
+	DiffPixels int
+	// MaxChannelDelta is the largest channel difference over all pixels.
+	MaxChannelDelta uint8
+	// Diff shows the golden faded to gray with the different pixels in red.
+	Diff *image.NRGBA
+}
+
+// Passed reports whether the frames match within opts.
+func (r *Result) Passed(opts Options) bool {
+	return r.DiffPixels <= opts.MaxDiffPixels
+}
+
+// maxYIQDelta is the largest YIQ distance two colors can have.
+const maxYIQDelta = 35215
+
+// Compare compares got with want pixel by pixel. It fails when the sizes differ.
+func Compare(got, want image.Image, opts Options) (*Result, error) {
+	if got.Bounds().Size() != want.Bounds().Size() {
+		return nil, fmt.Errorf("size %v does not match the golden %v", got.Bounds().Size(), want.Bounds().Size())
+	}
+	if opts.Perceptual < 0 || opts.Perceptual > 1 {
+		return nil, fmt.Errorf("perceptual threshold %v is not between 0 and 1", opts.Perceptual)
+	}
+	g, w := toNRGBA(got), toNRGBA(want)
+	size := g.Bounds().Size()
+	r := &Result{Diff: image.NewNRGBA(image.Rect(0, 0, size.X, size.Y))}
+	threshold := maxYIQDelta * opts.Perceptual * opts.Perceptual
+	for y := 0; y < size.Y; y++ {
+		for x := 0; x < size.X; x++ {
+			a, b := g.NRGBAAt(x, y), w.NRGBAAt(x, y)
+			delta := maxUint8(absDiff(a.R, b.R), absDiff(a.G, b.G), absDiff(a.B, b.B), absDiff(a.A, b.A))
+			if delta > r.MaxChannelDelta {
+				r.MaxChannelDelta = delta
+			}
+			different := delta > opts.Tolerance
+			if different && opts.Perceptual > 0 {
+				different = yiqDelta(a, b) > threshold
+			}
+			if different {
+				r.DiffPixels++
+				r.Diff.SetNRGBA(x, y, color.NRGBA{R: 255, A: 255})
+				continue
+			}
+			// Faded gray of the golden, so the red stands out but the scene is recognizable
+			gray := uint8(255 - (255-luma(b))/10)
+			r.Diff.SetNRGBA(x, y, color.NRGBA{R: gray, G: gray, B: gray, A: 255})
+		}
+	}
+	return r, nil
+}
+
+// yiqDelta is the squared, weighted YIQ distance of a and b blended on white.
+func yiqDelta(a, b color.NRGBA) float64 {
+	ya, ia, qa := yiq(a)
+	yb, ib, qb := yiq(b)
+	dy, di, dq := ya-yb, ia-ib, qa-qb
+	return 0.5053*dy*dy + 0.299*di*di + 0.1957*dq*dq
+}
+
+func yiq(c color.NRGBA) (y, i, q float64) {
+	alpha := float64(c.A) / 255
+	blend := func(v uint8) float64 { return 255 + (float64(v)-255)*alpha }
+	r, g, b := blend(c.R), blend(c.G), blend(c.B)
+	y = r*0.29889531 + g*0.58662247 + b*0.11448223
+	i = r*0.59597799 - g*0.27417610 - b*0.32180189
+	q = r*0.21147017 - g*0.52261711 + b*0.31114694
+	return y, i, q
+}
+
+func luma(c color.NRGBA) uint8 {
+	y, _, _ := yiq(c)
+	return uint8(math.Max(0, math.Min(255, math.Round(y))))
+}
+
+func absDiff(a, b uint8) uint8 {
+	if a > b {
+		return a - b
+	}
+	return b - a
+}
+
+func maxUint8(values ...uint8) uint8 {
+	var m uint8
+	for _, v := range values {
+		if v > m {
+			m = v
+		}
+	}
+	return m
+}
+
+func toNRGBA(img image.Image) *image.NRGBA {
+	if n, ok := img.(*image.NRGBA); ok && n.Rect.Min == (image.Point{}) {
+		return n
+	}
+	n := image.NewNRGBA(image.Rect(0, 0, img.Bounds().Dx(), img.Bounds().Dy()))
+	draw.Draw(n, n.Bounds(), img, img.Bounds().Min, draw.Src)
+	return n
+}
+
+// Scene is a frame rendered by the harness.
+type Scene struct {
+	// Name is the file name of the golden, without .png.
+	Name string
+	// Render draws the frame.
+	Render func() (image.Image, error)
+	// Options the frame is compared with.
+	Options Options
+}
+
+// Harness checks scenes against their goldens.
+type Harness struct {
+	// Dir holds the goldens, <Dir>/<scene name>.png.
+	Dir string
+	// Update rewrites the goldens with the rendered frames instead of comparing.
+	Update bool
+	// FailureDir gets <name>.got.png and <name>.diff.png of failed scenes.
+	// Defaults to Dir.
+	FailureDir string
+	// Log gets one line per scene, nil for silence.
+	Log io.Writer
+}
+
+// ErrMismatch is matched by the error of a scene that does not match its golden.
+var ErrMismatch = errors.New("does not match the golden")
+
+// Check renders scene and compares it with its golden, or rewrites the golden in Update mode.
+func (h *Harness) Check(scene Scene) error {
+	got, err := scene.Render()
+	if err != nil {
+		return fmt.Errorf("scene %v: %w", scene.Name, err)
+	}
+	path := filepath.Join(h.Dir, scene.Name+".png")
+	if h.Update {
+		if err := os.MkdirAll(h.Dir, 0o755); err != nil {
+			return err
+		}
+		if err := SavePNG(path, got); err != nil {
+			return fmt.Errorf("scene %v: %w", scene.Name, err)
+		}
+		h.logf("UPDATED %v\n", path)
+		return nil
+	}
+	want, err := LoadPNG(path)
+	if err != nil {
+		return fmt.Errorf("scene %v: %w, run with -update to create it", scene.Name, err)
+	}
+	result, err := Compare(got, want, scene.Options)
+	if err != nil {
+		h.writeFailure(scene.Name, got, nil)
+		return fmt.Errorf("scene %v: %w", scene.Name, err)
+	}
+	if result.Passed(scene.Options) {
+		h.logf("PASS    %v (%v pixels differ, max channel delta %v)\n", scene.Name, result.DiffPixels, result.MaxChannelDelta)
+		return nil
+	}
+	gotPath, diffPath := h.writeFailure(scene.Name, got, result.Diff)
+	h.logf("FAIL    %v (%v pixels differ, max channel delta %v), see %v and %v\n", scene.Name, result.DiffPixels, result.MaxChannelDelta, gotPath, diffPath)
+	return fmt.Errorf("scene %v: %v pixels differ, %v allowed: %w", scene.Name, result.DiffPixels, scene.Options.MaxDiffPixels, ErrMismatch)
+}
+
+// Run checks every scene, going on after failures, and returns the errors joined.
+func (h *Harness) Run(scenes []Scene) error {
+	var errs []error
+	for _, scene := range scenes {
+		if err := h.Check(scene); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// writeFailure saves the rendered frame and the diff image, when there is
+// one, and returns their paths. Errors are logged, the mismatch matters more.
+func (h *Harness) writeFailure(name string, got image.Image, diff image.Image) (gotPath, diffPath string) {
+	dir := h.FailureDir
+	if dir == "" {
+		dir = h.Dir
+	}
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		h.logf("writing failure images: %v\n", err)
+		return "", ""
+	}
+	gotPath = filepath.Join(dir, name+".got.png")
+	if err := SavePNG(gotPath, got); err != nil {
+		h.logf("writing failure images: %v\n", err)
+	}
+	if diff != nil {
+		diffPath = filepath.Join(dir, name+".diff.png")
+		if err := SavePNG(diffPath, diff); err != nil {
+			h.logf("writing failure images: %v\n", err)
+		}
+	}
+	return gotPath, diffPath
+}
+
+func (h *Harness) logf(format string, args ...interface{}) {
+	if h.Log != nil {
+		fmt.Fprintf(h.Log, format, args...)
+	}
+}
+
+// LoadPNG reads the PNG at path.
+func LoadPNG(path string) (image.Image, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return png.Decode(f)
+}
+
+// SavePNG writes img to path as PNG.
+func SavePNG(path string, img image.Image) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if err := png.Encode(f, img); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
@@ -0,0 +1,227 @@
+package golden
+
+import (
+	"errors"
+	"image"
+	"image/color"
+	"math"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// solid returns a w x h image filled with c.
+func solid(w, h int, c color.NRGBA) *image.NRGBA {
+	img := image.NewNRGBA(image.Rect(0, 0, w, h))
+	for idx := 0; idx < len(img.Pix); idx += 4 {
+		img.Pix[idx], img.Pix[idx+1], img.Pix[idx+2], img.Pix[idx+3] = c.R, c.G, c.B, c.A
+	}
+	return img
+}
+
+var (
+	red  = color.NRGBA{R: 255, A: 255}
+	gray = color.NRGBA{R: 128, G: 128, B: 128, A: 255}
+)
+
+func TestCompareTolerance(t *testing.T) {
+	want := solid(4, 4, gray)
+	got := solid(4, 4, gray)
+	got.SetNRGBA(1, 2, color.NRGBA{R: 131, G: 128, B: 126, A: 255})
+	for _, tc := range []struct {
+		tolerance uint8
+		diff      int
+	}{
+		{0, 1},
+		{2, 1},
+		{3, 0},
+	} {
+		r, err := Compare(got, want, Options{Tolerance: tc.tolerance})
+		if err != nil {
+			t.Fatalf("Compare: %v", err)
+		}
+		if r.DiffPixels != tc.diff || r.MaxChannelDelta != 3 {
+			t.Errorf("tolerance %v: %v pixels differ by up to %v, want %v by up to 3", tc.tolerance, r.DiffPixels, r.MaxChannelDelta, tc.diff)
+		}
+	}
+}
+
+func TestCompareDiffImage(t *testing.T) {
+	want := solid(3, 1, gray)
+	got := solid(3, 1, gray)
+	got.SetNRGBA(2, 0, red)
+	r, err := Compare(got, want, Options{})
+	if err != nil {
+		t.Fatalf("Compare: %v", err)
+	}
+	if c := r.Diff.NRGBAAt(2, 0); c != red {
+		t.Errorf("different pixel is %v in the diff, want red", c)
+	}
+	// The golden faded towards white, still gray
+	if c := r.Diff.NRGBAAt(0, 0); c.R != c.G || c.G != c.B || c.R < 200 || c.R == 255 {
+		t.Errorf("matching pixel is %v in the diff, want a light gray", c)
+	}
+}
+
+func TestComparePerceptual(t *testing.T) {
+	// Off by 6 in blue only, which barely changes the brightness
+	bluish := color.NRGBA{R: 128, G: 128, B: 134, A: 255}
+	// Off by 6 in green, which weighs the most in brightness
+	greenish := color.NRGBA{R: 128, G: 134, B: 128, A: 255}
+	if blue, green := yiqDelta(bluish, gray), yiqDelta(greenish, gray); blue >= green {
+		t.Errorf("yiqDelta of a blue shift %v is not below that of a green shift %v", blue, green)
+	}
+	if d := yiqDelta(gray, gray); d != 0 {
+		t.Errorf("yiqDelta of equal colors = %v", d)
+	}
+	// The furthest apart colors are corners of the RGB cube
+	var corners []color.NRGBA
+	for idx := 0; idx < 8; idx++ {
+		corners = append(corners, color.NRGBA{R: uint8(idx&1) * 255, G: uint8(idx>>1&1) * 255, B: uint8(idx>>2&1) * 255, A: 255})
+	}
+	var largest float64
+	for _, a := range corners {
+		for _, b := range corners {
+			largest = math.Max(largest, yiqDelta(a, b))
+		}
+	}
+	if largest > maxYIQDelta || largest < maxYIQDelta-1 {
+		t.Errorf("largest yiqDelta = %v, want maxYIQDelta %v", largest, maxYIQDelta)
+	}
+	// Blended on white, transparent colors are all the same
+	if d := yiqDelta(color.NRGBA{R: 255}, color.NRGBA{B: 255}); d != 0 {
+		t.Errorf("yiqDelta of transparent colors = %v", d)
+	}
+
+	want := solid(2, 1, gray)
+	got := solid(2, 1, gray)
+	got.SetNRGBA(0, 0, bluish)
+	got.SetNRGBA(1, 0, greenish)
+	for _, tc := range []struct {
+		perceptual float64
+		diff       int
+	}{
+		{0, 2},     // off, the tolerance alone decides
+		{0.005, 2}, // both are further apart
+		{0.01, 1},  // forgives the blue shift only
+		{0.02, 0},
+	} {
+		r, err := Compare(got, want, Options{Tolerance: 2, Perceptual: tc.perceptual})
+		if err != nil {
+			t.Fatalf("Compare: %v", err)
+		}
+		if r.DiffPixels != tc.diff {
+			t.Errorf("perceptual %v: %v pixels differ, want %v", tc.perceptual, r.DiffPixels, tc.diff)
+		}
+	}
+	if _, err := Compare(got, want, Options{Perceptual: 1.5}); err == nil {
+		t.Error("Compare accepted a perceptual threshold above 1")
+	}
+}
+
+func TestCompareMaxDiffPixels(t *testing.T) {
+	want := solid(4, 4, gray)
+	got := solid(4, 4, gray)
+	got.SetNRGBA(0, 0, red)
+	got.SetNRGBA(3, 3, red)
+	r, err := Compare(got, want, Options{})
+	if err != nil {
+		t.Fatalf("Compare: %v", err)
+	}
+	for max, passed := range []bool{false, false, true, true} {
+		if r.Passed(Options{MaxDiffPixels: max}) != passed {
+			t.Errorf("MaxDiffPixels %v: Passed = %v with %v different pixels", max, !passed, r.DiffPixels)
+		}
+	}
+}
+
+func TestCompareSizeMismatch(t *testing.T) {
+	_, err := Compare(solid(4, 4, gray), solid(4, 3, gray), Options{})
+	if err == nil || !strings.Contains(err.Error(), "does not match the golden") {
+		t.Errorf("Compare error = %v", err)
+	}
+	// Only the size counts, not where the bounds start
+	offset := image.NewNRGBA(image.Rect(10, 10, 14, 14))
+	copy(offset.Pix, solid(4, 4, gray).Pix)
+	if r, err := Compare(offset, solid(4, 4, gray), Options{}); err != nil || r.DiffPixels != 0 {
+		t.Errorf("Compare of an offset image = %v, %v", r, err)
+	}
+}
+
+func TestHarness(t *testing.T) {
+	dir := t.TempDir()
+	frame := solid(8, 8, gray)
+	scene := Scene{
+		Name:   "gray",
+		Render: func() (image.Image, error) { return frame, nil },
+	}
+	var log strings.Builder
+	h := &Harness{Dir: filepath.Join(dir, "goldens"), FailureDir: filepath.Join(dir, "failures"), Log: &log}
+
+	if err := h.Check(scene); err == nil || !strings.Contains(err.Error(), "run with -update to create it") {
+		t.Errorf("Check without a golden = %v", err)
+	}
+
+	// Update creates the directory and the golden
+	h.Update = true
+	if err := h.Check(scene); err != nil {
+		t.Fatalf("Check with Update: %v", err)
+	}
+	path := filepath.Join(h.Dir, "gray.png")
+	saved, err := LoadPNG(path)
+	if err != nil {
+		t.Fatalf("LoadPNG: %v", err)
+	}
+	if r, err := Compare(saved, frame, Options{}); err != nil || r.DiffPixels != 0 {
+		t.Errorf("the golden does not hold the rendered frame: %v, %v", r, err)
+	}
+
+	h.Update = false
+	if err := h.Check(scene); err != nil {
+		t.Errorf("Check against the new golden: %v", err)
+	}
+
+	// A changed frame fails and leaves the frame and diff behind
+	frame = solid(8, 8, red)
+	err = h.Check(scene)
+	if !errors.Is(err, ErrMismatch) {
+		t.Fatalf("Check of a changed frame = %v, want ErrMismatch", err)
+	}
+	for _, name := range []string{"gray.got.png", "gray.diff.png"} {
+		if _, err := os.Stat(filepath.Join(h.FailureDir, name)); err != nil {
+			t.Errorf("failure image: %v", err)
+		}
+	}
+
+	// Update rewrites the existing golden
+	h.Update = true
+	if err := h.Check(scene); err != nil {
+		t.Fatalf("Check with Update: %v", err)
+	}
+	h.Update = false
+	if err := h.Check(scene); err != nil {
+		t.Errorf("Check against the rewritten golden: %v", err)
+	}
+	for _, want := range []string{"UPDATED " + path, "PASS    gray", "FAIL    gray"} {
+		if !strings.Contains(log.String(), want) {
+			t.Errorf("log does not have %q:\n%v", want, log.String())
+		}
+	}
+}
+
+func TestHarnessRun(t *testing.T) {
+	failed := errors.New("no device")
+	h := &Harness{Dir: t.TempDir(), Update: true}
+	err := h.Run([]Scene{
+		{Name: "broken", Render: func() (image.Image, error) { return nil, failed }},
+		{Name: "fine", Render: func() (image.Image, error) { return solid(1, 1, gray), nil }},
+	})
+	if !errors.Is(err, failed) {
+		t.Errorf("Run = %v, want the error of the broken scene", err)
+	}
+	// The scene after the failure still ran
+	if _, err := os.Stat(filepath.Join(h.Dir, "fine.png")); err != nil {
+		t.Errorf("Run stopped at the first failure: %v", err)
+	}
+}
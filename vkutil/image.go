@@ -0,0 +1,177 @@
+package vkutil
+
+import (
+	"fmt"
+	"unsafe"
+
+	vk "github.com/vulkan-go/vulkan"
+)
+
+// CreateImageBuffer creates a 2D, optimally tiled, exclusive image of one
+// layer, CreateImageArray makes arrays and cube maps. No memory is bound to it.
+func CreateImageBuffer(device vk.Device, format vk.Format, extent vk.Extent3D, mipLevels uint32, usage vk.ImageUsageFlags) (vk.Image, error) {
+	var imageCreateInfo = vk.ImageCreateInfo{
+		SType:         vk.StructureTypeImageCreateInfo,
+		ImageType:     vk.ImageType2d,
+		Format:        format,
+		Extent:        extent,
+		MipLevels:     mipLevels,
+		ArrayLayers:   1,
+		Samples:       vk.SampleCount1Bit,
+		Tiling:        vk.ImageTilingOptimal,
+		Usage:         usage,
+		SharingMode:   vk.SharingModeExclusive,
+		InitialLayout: vk.ImageLayoutUndefined,
+	}
+	return driver.CreateImage(device, &imageCreateInfo)
+}
+
+// CreateImageArray creates a 2D, optimally tiled, exclusive image of layers
+// array layers. A cube map is a 2D array of 6 square layers, the faces +X,
+// -X, +Y, -Y, +Z and -Z, created cube compatible so a vk.ImageViewTypeCube
+// view can be made of it; a cube map array concatenates multiples of 6.
+// No memory is bound to it.
+//
+// Only the shape of a cube map is checked. The caller keeps layers within the
+// MaxArrayLayers of GetPhysicalDeviceImageProperties, and makes a
+// vk.ImageViewTypeCubeArray view only when the device was created with the
+// imageCubeArray feature; Uploader.CreateTextureArray does both.
+// https://www.khronos.org/registry/vulkan/specs/1.2-extensions/html/vkspec.html#resources-image-views-compatibility
+func CreateImageArray(device vk.Device, format vk.Format, extent vk.Extent2D, mipLevels, layers uint32, cube bool, usage vk.ImageUsageFlags) (vk.Image, error) {
+	var flags vk.ImageCreateFlags
+	if cube {
+		if extent.Width != extent.Height || layers%6 != 0 {
+			return vk.NullImage, fmt.Errorf("cube map of %v %vx%v layers, faces must be square and come in sixes", layers, extent.Width, extent.Height)
+		}
+		flags = vk.ImageCreateFlags(vk.ImageCreateCubeCompatibleBit)
+	}
+	var imageCreateInfo = vk.ImageCreateInfo{
+		SType:         vk.StructureTypeImageCreateInfo,
+		Flags:         flags,
+		ImageType:     vk.ImageType2d,
+		Format:        format,
+		Extent:        vk.Extent3D{Width: extent.Width, Height: extent.Height, Depth: 1},
+		MipLevels:     mipLevels,
+		ArrayLayers:   layers,
+		Samples:       vk.SampleCount1Bit,
+		Tiling:        vk.ImageTilingOptimal,
+		Usage:         usage,
+		SharingMode:   vk.SharingModeExclusive,
+		InitialLayout: vk.ImageLayoutUndefined,
+	}
+	return driver.CreateImage(device, &imageCreateInfo)
+}
+
+// CreateAttachmentImage creates a 2D, optimally tiled, exclusive image with
+// one mip level and samples per pixel, for use as a framebuffer attachment.
+// Multisampled images are limited to the sample counts of
+// GetPhysicalDeviceImageProperties. No memory is bound to it.
+func CreateAttachmentImage(device vk.Device, format vk.Format, extent vk.Extent2D, samples vk.SampleCountFlagBits, usage vk.ImageUsageFlags) (vk.Image, error) {
+	var imageCreateInfo = vk.ImageCreateInfo{
+		SType:         vk.StructureTypeImageCreateInfo,
+		ImageType:     vk.ImageType2d,
+		Format:        format,
+		Extent:        vk.Extent3D{Width: extent.Width, Height: extent.Height, Depth: 1},
+		MipLevels:     1,
+		ArrayLayers:   1,
+		Samples:       samples,
+		Tiling:        vk.ImageTilingOptimal,
+		Usage:         usage,
+		SharingMode:   vk.SharingModeExclusive,
+		InitialLayout: vk.ImageLayoutUndefined,
+	}
+	return driver.CreateImage(device, &imageCreateInfo)
+}
+
+// CreateImageView creates a 2D color view over the first mip level and layer of image.
+// format must be the one the image was created with.
+func CreateImageView(device vk.Device, image vk.Image, format vk.Format) (vk.ImageView, error) {
+	return CreateImageViewAspect(device, image, format, vk.ImageAspectFlags(vk.ImageAspectColorBit))
+}
+
+// CreateImageViewAspect is CreateImageView for other aspects than color,
+// e.g. DepthAspect of a depth image.
+func CreateImageViewAspect(device vk.Device, image vk.Image, format vk.Format, aspect vk.ImageAspectFlags) (vk.ImageView, error) {
+	return CreateImageViewRange(device, image, format, vk.ImageViewType2d, vk.ImageSubresourceRange{
+		AspectMask: aspect,
+		LevelCount: 1,
+		LayerCount: 1,
+	})
+}
+
+// CreateImageViewRange creates a view of viewType over subresourceRange of
+// image, e.g. all the mip levels of a texture.
+func CreateImageViewRange(device vk.Device, image vk.Image, format vk.Format, viewType vk.ImageViewType, subresourceRange vk.ImageSubresourceRange) (vk.ImageView, error) {
+	var imageViewCreateInfo = vk.ImageViewCreateInfo{
+		SType:    vk.StructureTypeImageViewCreateInfo,
+		Image:    image,
+		ViewType: viewType, // It must be compatible with Image Buffer's ImageType in ImageCreateInfo struct
+		Format:   format,
+		Components: vk.ComponentMapping{
+			R: vk.ComponentSwizzleR,
+			G: vk.ComponentSwizzleG,
+			B: vk.ComponentSwizzleB,
+			A: vk.ComponentSwizzleA,
+		},
+		SubresourceRange: subresourceRange,
+	}
+	return driver.CreateImageView(device, &imageViewCreateInfo)
+}
+
+// GetImageMemoryRequirements returns the size, alignment and memory type
+// bits the image needs before memory can be bound to it.
+func GetImageMemoryRequirements(device vk.Device, image vk.Image) vk.MemoryRequirements {
+	return driver.GetImageMemoryRequirements(device, image)
+}
+
+// BindImageMemory binds memory at offset 0 to image.
+func BindImageMemory(device vk.Device, image vk.Image, memory vk.DeviceMemory) error {
+	// Before a resource such as a buffer or image can be used by Vulkan to store data, memory must be
+	// bound to it. Before memory is bound to a resource, you should determine what type of memory and
+	// how much of it the resource requires, see GetImageMemoryRequirements.
+	return driver.BindImageMemory(device, image, memory, vk.DeviceSize(0))
+}
+
+// MapHostMemoryForImage allocates a dedicated block of memory for image and
+// maps the whole block into host address space.
+func MapHostMemoryForImage(device vk.Device, memoryProperties vk.PhysicalDeviceMemoryProperties, image vk.Image) (unsafe.Pointer, vk.DeviceMemory, error) {
+	// Access to this memory object must be externally synchronized
+	// A flush is necessary if the host has written to a mapped memory
+	// region and needs the device to see the effect of those writes.
+	// However, if the device writes to a mapped memory region and you
+	// need the host to see the effect of the device’s writes, you need
+	// to invalidate any caches on the host that might now hold stale data.
+	// To do this, call vkInvalidateMappedMemoryRanges()
+	// The memory has to be mappable, device local memory is preferred when the GPU offers it host visible
+	memory, err := AllocateMemory(device, memoryProperties, GetImageMemoryRequirements(device, image),
+		vk.MemoryPropertyFlags(vk.MemoryPropertyHostVisibleBit),
+		vk.MemoryPropertyFlags(vk.MemoryPropertyDeviceLocalBit|vk.MemoryPropertyHostCoherentBit))
+	if err != nil {
+		return nil, vk.NullDeviceMemory, err
+	}
+	pData, err := driver.MapMemory(device, memory, vk.DeviceSize(0), vk.DeviceSize(vk.WholeSize))
+	if err != nil {
+		driver.FreeMemory(device, memory)
+		return nil, vk.NullDeviceMemory, err
+	}
+	return pData, memory, nil
+}
+
+// GetPhysicalDeviceImageProperties returns the limits (extent, mip levels,
+// array layers, sample counts) the device supports for an image of the
+// given format, type, tiling, usage and create flags.
+func GetPhysicalDeviceImageProperties(physicalDevice vk.PhysicalDevice, format vk.Format, imageType vk.ImageType, tiling vk.ImageTiling, usage vk.ImageUsageFlags, flags vk.ImageCreateFlags) (vk.ImageFormatProperties, error) {
+	return driver.GetPhysicalDeviceImageFormatProperties(physicalDevice, format, imageType, tiling, usage, flags)
+}
+
+// PrintImageFormatProperties prints what GetPhysicalDeviceImageProperties returned.
+func PrintImageFormatProperties(imageFormatProperties vk.ImageFormatProperties) {
+	extent := imageFormatProperties.MaxExtent
+	fmt.Printf("\t*\tExtent: \t\t \t\t\n")
+	fmt.Printf("\t\t\tWidth: %v\t\t\n", extent.Width)
+	fmt.Printf("\t\t\tHeight: %v\t\t\n", extent.Height)
+	fmt.Printf("\t\t\tDepth: %v\t\t\n", extent.Depth)
+	fmt.Printf("\t*\tMaxMipMap Levels: %v\t\t \t\t\n", imageFormatProperties.MaxMipLevels)
+	fmt.Printf("\t*\tMaxArrayLayers: %v\t\t \t\t\n", imageFormatProperties.MaxArrayLayers)
+	fmt.Printf("\t*\tMaxResourceSize: %v\t\t \t\t\n", imageFormatProperties.MaxResourceSize)
+}
@@ -0,0 +1,87 @@
+package vkutil
+
+import (
+	"fmt"
+
+	vk "github.com/vulkan-go/vulkan"
+)
+
+// NewApplicationInfo returns the ApplicationInfo the exercises pass to
+// CreateInstance. ApiVersion is kept at 1.0, a higher version throws
+// 'vulkan error: incompatible driver' on older drivers.
+func NewApplicationInfo(appName, engineName string) *vk.ApplicationInfo {
+	return &vk.ApplicationInfo{
+		SType:              vk.StructureTypeApplicationInfo,
+		PApplicationName:   safeString(appName),
+		ApiVersion:         vk.MakeVersion(1, 0, 0),
+		ApplicationVersion: vk.MakeVersion(1, 0, 0),
+		PEngineName:        safeString(engineName),
+		EngineVersion:      vk.MakeVersion(0, 1, 0),
+	}
+}
+
+// CreateInstance creates a Vulkan instance with the given layers and
+// extensions enabled. appInfo may be nil.
+func CreateInstance(appInfo *vk.ApplicationInfo, layers, extensions []string) (vk.Instance, error) {
+	layers = safeStrings(layers)
+	extensions = safeStrings(extensions)
+	var instanceInfo = vk.InstanceCreateInfo{
+		SType:                   vk.StructureTypeInstanceCreateInfo,
+		PApplicationInfo:        appInfo,
+		EnabledLayerCount:       uint32(len(layers)),
+		PpEnabledLayerNames:     layers,
+		EnabledExtensionCount:   uint32(len(extensions)),
+		PpEnabledExtensionNames: extensions,
+	}
+	return driver.CreateInstance(&instanceInfo)
+}
+
+// DestroyInstance destroys an instance made by CreateInstance.
+func DestroyInstance(instance vk.Instance) {
+	driver.DestroyInstance(instance)
+}
+
+// GetInstanceLayerProperties lists the layers available to the instance.
+func GetInstanceLayerProperties() ([]vk.LayerProperties, error) {
+	return driver.EnumerateInstanceLayerProperties()
+}
+
+// GetInstanceExtensionProperties lists the extensions available to the
+// instance, or the ones provided by layerName when it is not empty.
+func GetInstanceExtensionProperties(layerName string) ([]vk.ExtensionProperties, error) {
+	return driver.EnumerateInstanceExtensionProperties(layerName)
+}
+
+// PrintInstanceLayerProperties prints the layers available to the instance.
+func PrintInstanceLayerProperties() error {
+	fmt.Println("Listing available layers for instance only..............")
+	properties, err := GetInstanceLayerProperties()
+	if err != nil {
+		return err
+	}
+	printLayerProperties(properties)
+	return nil
+}
+
+// PrintInstanceExtensionProperties prints the extensions available to the instance.
+func PrintInstanceExtensionProperties() error {
+	fmt.Println("Listing available Extensions for instance only..............")
+	properties, err := GetInstanceExtensionProperties("")
+	if err != nil {
+		return err
+	}
+	printExtensionProperties(properties)
+	return nil
+}
+
+func printLayerProperties(properties []vk.LayerProperties) {
+	for _, p := range properties {
+		fmt.Printf("\t*\tName: %v\n\t\tDescription: %v\n\t\tSpecVersion: %v\n\t\tImplementationVersion: %v\n", vk.ToString(p.LayerName[:]), vk.ToString(p.Description[:]), p.SpecVersion, p.ImplementationVersion)
+	}
+}
+
+func printExtensionProperties(properties []vk.ExtensionProperties) {
+	for _, p := range properties {
+		fmt.Printf("\t*\tExtensionName: %v\n\t\tSpecVersion: %v\n", vk.ToString(p.ExtensionName[:]), p.SpecVersion)
+	}
+}
@@ -0,0 +1,52 @@
+package vkutil_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/goodshailesh/My-Vulkan-Projects/vkutil"
+	vk "github.com/vulkan-go/vulkan"
+)
+
+func TestCreateInstance(t *testing.T) {
+	fake, _ := useFake(t)
+	instance, err := vkutil.CreateInstance(vkutil.NewApplicationInfo("test", "vkutil"), []string{"VK_LAYER_KHRONOS_validation"}, []string{"VK_KHR_surface", "VK_EXT_debug_utils"})
+	if err != nil {
+		t.Fatalf("CreateInstance: %v", err)
+	}
+	if live := fake.Live(); len(live) != 1 {
+		t.Errorf("live objects = %v, want the instance", live)
+	}
+	vkutil.DestroyInstance(instance)
+	if live := fake.Live(); len(live) != 0 {
+		t.Errorf("live objects after DestroyInstance = %v", live)
+	}
+}
+
+func TestCreateInstanceFails(t *testing.T) {
+	for _, tc := range []struct {
+		name       string
+		layers     []string
+		extensions []string
+		result     vk.Result
+		want       error
+	}{
+		{name: "missing layer", layers: []string{"VK_LAYER_LUNARG_api_dump"}, want: vkutil.ErrLayerNotPresent},
+		{name: "missing extension", extensions: []string{"VK_KHR_android_surface"}, want: vkutil.ErrExtensionNotPresent},
+		{name: "driver failure", result: vk.ErrorIncompatibleDriver, want: vkutil.ErrIncompatibleDriver},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			fake, _ := useFake(t)
+			if tc.result != vk.Success {
+				fake.Results["vkCreateInstance"] = tc.result
+			}
+			_, err := vkutil.CreateInstance(nil, tc.layers, tc.extensions)
+			if !errors.Is(err, tc.want) {
+				t.Errorf("CreateInstance error = %v, want %v", err, tc.want)
+			}
+			if live := fake.Live(); len(live) != 0 {
+				t.Errorf("live objects = %v, want none", live)
+			}
+		})
+	}
+}
@@ -0,0 +1,357 @@
+package vkutil
+
+import (
+	"fmt"
+
+	vk "github.com/vulkan-go/vulkan"
+)
+
+// layoutScope returns the stages and accesses of the commands using an image
+// in layout. As the source of a barrier only the writes need to be made
+// available, as its destination reads and writes wait for it.
+// https://www.khronos.org/registry/vulkan/specs/1.2-extensions/html/vkspec.html#synchronization-access-types-supported
+func layoutScope(layout vk.ImageLayout, source bool) (vk.PipelineStageFlagBits, vk.AccessFlagBits) {
+	var stage vk.PipelineStageFlagBits
+	var reads, writes vk.AccessFlagBits
+	switch layout {
+	case vk.ImageLayoutUndefined:
+		stage = vk.PipelineStageTopOfPipeBit
+	case vk.ImageLayoutPreinitialized:
+		stage, writes = vk.PipelineStageHostBit, vk.AccessHostWriteBit
+	case vk.ImageLayoutColorAttachmentOptimal:
+		stage, reads, writes = vk.PipelineStageColorAttachmentOutputBit, vk.AccessColorAttachmentReadBit, vk.AccessColorAttachmentWriteBit
+	case vk.ImageLayoutDepthStencilAttachmentOptimal:
+		stage = vk.PipelineStageEarlyFragmentTestsBit | vk.PipelineStageLateFragmentTestsBit
+		reads, writes = vk.AccessDepthStencilAttachmentReadBit, vk.AccessDepthStencilAttachmentWriteBit
+	case vk.ImageLayoutDepthStencilReadOnlyOptimal:
+		stage = vk.PipelineStageEarlyFragmentTestsBit | vk.PipelineStageLateFragmentTestsBit | vk.PipelineStageFragmentShaderBit
+		reads = vk.AccessDepthStencilAttachmentReadBit | vk.AccessShaderReadBit
+	case vk.ImageLayoutShaderReadOnlyOptimal:
+		stage, reads = vk.PipelineStageVertexShaderBit|vk.PipelineStageFragmentShaderBit, vk.AccessShaderReadBit
+	case vk.ImageLayoutTransferSrcOptimal:
+		stage, reads = vk.PipelineStageTransferBit, vk.AccessTransferReadBit
+	case vk.ImageLayoutTransferDstOptimal:
+		stage, writes = vk.PipelineStageTransferBit, vk.AccessTransferWriteBit
+	case vk.ImageLayoutPresentSrc:
+		// Presentation is synchronized by semaphores. The barrier after an
+		// acquire chains to the wait of the FrameLoop submit at color output,
+		// the one before presenting only has to finish before the end.
+		stage = vk.PipelineStageBottomOfPipeBit
+		if source {
+			stage = vk.PipelineStageColorAttachmentOutputBit
+		}
+	default:
+		// GENERAL and whatever comes with extensions
+		stage, reads, writes = vk.PipelineStageAllCommandsBit, vk.AccessMemoryReadBit, vk.AccessMemoryWriteBit
+	}
+	if source {
+		return stage, writes
+	}
+	return stage, reads | writes
+}
+
+// ImageBarrier returns the barrier moving subresourceRange of image from
+// oldLayout to newLayout, with the stages it has to be recorded with. The
+// previous content is kept unless oldLayout is vk.ImageLayoutUndefined.
+func ImageBarrier(image vk.Image, subresourceRange vk.ImageSubresourceRange, oldLayout, newLayout vk.ImageLayout) (vk.ImageMemoryBarrier, vk.PipelineStageFlagBits, vk.PipelineStageFlagBits) {
+	srcStage, srcAccess := layoutScope(oldLayout, true)
+	dstStage, dstAccess := layoutScope(newLayout, false)
+	barrier := vk.ImageMemoryBarrier{
+		SType:               vk.StructureTypeImageMemoryBarrier,
+		SrcAccessMask:       vk.AccessFlags(srcAccess),
+		DstAccessMask:       vk.AccessFlags(dstAccess),
+		OldLayout:           oldLayout,
+		NewLayout:           newLayout,
+		SrcQueueFamilyIndex: vk.QueueFamilyIgnored,
+		DstQueueFamilyIndex: vk.QueueFamilyIgnored,
+		Image:               image,
+		SubresourceRange:    subresourceRange,
+	}
+	return barrier, srcStage, dstStage
+}
+
+// CmdTransitionImageLayout records the barrier of ImageBarrier. Prefer a
+// LayoutTracker, which knows oldLayout.
+func CmdTransitionImageLayout(commandBuffer vk.CommandBuffer, image vk.Image, subresourceRange vk.ImageSubresourceRange, oldLayout, newLayout vk.ImageLayout) {
+	barrier, srcStage, dstStage := ImageBarrier(image, subresourceRange, oldLayout, newLayout)
+	vk.CmdPipelineBarrier(commandBuffer, vk.PipelineStageFlags(srcStage), vk.PipelineStageFlags(dstStage), 0,
+		0, nil, 0, nil, 1, []vk.ImageMemoryBarrier{barrier})
+}
+
+// WholeRange is the subresource range over all levels and layers of an image.
+func WholeRange(aspect vk.ImageAspectFlags) vk.ImageSubresourceRange {
+	return vk.ImageSubresourceRange{
+		AspectMask: aspect,
+		LevelCount: vk.RemainingMipLevels,
+		LayerCount: vk.RemainingArrayLayers,
+	}
+}
+
+// LayoutTracker remembers the layout of every mip level and array layer of
+// the images it tracks, so transitions are recorded from the layout the
+// subresource really is in, e.g. while the levels of a mip chain move one by
+// one from TRANSFER_DST to TRANSFER_SRC to SHADER_READ_ONLY. It follows the
+// order commands are recorded in: use one per queue, and record the command
+// buffers in the order they are submitted. It is not safe for concurrent use.
+type LayoutTracker struct {
+	images map[vk.Image]*trackedImage
+}
+
+type trackedImage struct {
+	levels, layers uint32
+	layouts        []vk.ImageLayout // layer major within a level: level*layers + layer
+}
+
+// NewLayoutTracker returns a tracker without images.
+func NewLayoutTracker() *LayoutTracker {
+	return &LayoutTracker{images: make(map[vk.Image]*trackedImage)}
+}
+
+// Track starts tracking image, of levels mip levels and layers array layers
+// all in layout: vk.ImageLayoutUndefined for a new image, or
+// vk.ImageLayoutPresentSrc for swapchain images handed out by an acquire.
+// Tracking an image again starts over.
+func (t *LayoutTracker) Track(image vk.Image, levels, layers uint32, layout vk.ImageLayout) {
+	tracked := &trackedImage{levels: levels, layers: layers, layouts: make([]vk.ImageLayout, levels*layers)}
+	for idx := range tracked.layouts {
+		tracked.layouts[idx] = layout
+	}
+	t.images[image] = tracked
+}
+
+// Forget stops tracking image, call it before the image is destroyed.
+func (t *LayoutTracker) Forget(image vk.Image) {
+	delete(t.images, image)
+}
+
+// Layout returns the layout of a subresource of image. Untracked images
+// and subresources are vk.ImageLayoutUndefined.
+func (t *LayoutTracker) Layout(image vk.Image, level, layer uint32) vk.ImageLayout {
+	tracked, ok := t.images[image]
+	if !ok || level >= tracked.levels || layer >= tracked.layers {
+		return vk.ImageLayoutUndefined
+	}
+	return tracked.layouts[level*tracked.layers+layer]
+}
+
+// Assume records that subresourceRange of image was moved to layout by
+// something else than Transition, e.g. the final layout of a render pass.
+func (t *LayoutTracker) Assume(image vk.Image, subresourceRange vk.ImageSubresourceRange, layout vk.ImageLayout) error {
+	tracked, levels, layers, err := t.lookup(image, subresourceRange)
+	if err != nil {
+		return err
+	}
+	tracked.set(levels, layers, layout)
+	return nil
+}
+
+// Transition records the barriers moving subresourceRange of image to
+// layout, from the layouts the tracker knows. Subresources in layout
+// already are left alone, a single barrier covers the range when it is all
+// in one layout. The content is kept, except of subresources that were
+// vk.ImageLayoutUndefined.
+func (t *LayoutTracker) Transition(commandBuffer vk.CommandBuffer, image vk.Image, subresourceRange vk.ImageSubresourceRange, layout vk.ImageLayout) error {
+	barriers, srcStage, dstStage, err := t.barriers(image, subresourceRange, layout)
+	if err != nil || len(barriers) == 0 {
+		return err
+	}
+	vk.CmdPipelineBarrier(commandBuffer, vk.PipelineStageFlags(srcStage), vk.PipelineStageFlags(dstStage), 0,
+		0, nil, 0, nil, uint32(len(barriers)), barriers)
+	return nil
+}
+
+// barriers returns what Transition records, and updates the layouts.
+func (t *LayoutTracker) barriers(image vk.Image, subresourceRange vk.ImageSubresourceRange, layout vk.ImageLayout) ([]vk.ImageMemoryBarrier, vk.PipelineStageFlagBits, vk.PipelineStageFlagBits, error) {
+	tracked, levels, layers, err := t.lookup(image, subresourceRange)
+	if err != nil {
+		return nil, 0, 0, err
+	}
+	var barriers []vk.ImageMemoryBarrier
+	var srcStages, dstStages vk.PipelineStageFlagBits
+	add := func(oldLayout vk.ImageLayout, baseLevel, levelCount, baseLayer, layerCount uint32) {
+		if oldLayout == layout {
+			return
+		}
+		barrier, srcStage, dstStage := ImageBarrier(image, vk.ImageSubresourceRange{
+			AspectMask:     subresourceRange.AspectMask,
+			BaseMipLevel:   baseLevel,
+			LevelCount:     levelCount,
+			BaseArrayLayer: baseLayer,
+			LayerCount:     layerCount,
+		}, oldLayout, layout)
+		barriers = append(barriers, barrier)
+		srcStages, dstStages = srcStages|srcStage, dstStages|dstStage
+	}
+	if oldLayout, uniform := tracked.uniform(levels, layers); uniform {
+		add(oldLayout, levels[0], levels[1]-levels[0], layers[0], layers[1]-layers[0])
+	} else {
+		// One barrier per run of layers in the same layout, within each level
+		for level := levels[0]; level < levels[1]; level++ {
+			start := layers[0]
+			for layer := layers[0] + 1; layer <= layers[1]; layer++ {
+				if layer < layers[1] && tracked.layouts[level*tracked.layers+layer] == tracked.layouts[level*tracked.layers+start] {
+					continue
+				}
+				add(tracked.layouts[level*tracked.layers+start], level, 1, start, layer-start)
+				start = layer
+			}
+		}
+	}
+	tracked.set(levels, layers, layout)
+	return barriers, srcStages, dstStages, nil
+}
+
+// lookup returns the tracked image and the [first, end) levels and layers
+// of subresourceRange, resolving vk.RemainingMipLevels and vk.RemainingArrayLayers.
+func (t *LayoutTracker) lookup(image vk.Image, subresourceRange vk.ImageSubresourceRange) (*trackedImage, [2]uint32, [2]uint32, error) {
+	tracked, ok := t.images[image]
+	if !ok {
+		return nil, [2]uint32{}, [2]uint32{}, fmt.Errorf("image %p is not tracked", image)
+	}
+	levels, err := subrange(subresourceRange.BaseMipLevel, subresourceRange.LevelCount, tracked.levels, "mip levels")
+	if err != nil {
+		return nil, levels, [2]uint32{}, err
+	}
+	layers, err := subrange(subresourceRange.BaseArrayLayer, subresourceRange.LayerCount, tracked.layers, "array layers")
+	return tracked, levels, layers, err
+}
+
+func subrange(base, count, total uint32, what string) ([2]uint32, error) {
+	if count == vk.RemainingMipLevels && base < total {
+		count = total - base
+	}
+	if count == 0 || base >= total || count > total-base {
+		return [2]uint32{}, fmt.Errorf("%v %v to %v out of the %v of the image", what, base, uint64(base)+uint64(count), total)
+	}
+	return [2]uint32{base, base + count}, nil
+}
+
+func (tracked *trackedImage) set(levels, layers [2]uint32, layout vk.ImageLayout) {
+	for level := levels[0]; level < levels[1]; level++ {
+		for layer := layers[0]; layer < layers[1]; layer++ {
+			tracked.layouts[level*tracked.layers+layer] = layout
+		}
+	}
+}
+
+// uniform returns the layout of the subresources when they all share one.
+func (tracked *trackedImage) uniform(levels, layers [2]uint32) (vk.ImageLayout, bool) {
+	layout := tracked.layouts[levels[0]*tracked.layers+layers[0]]
+	for level := levels[0]; level < levels[1]; level++ {
+		for layer := layers[0]; layer < layers[1]; layer++ {
+			if tracked.layouts[level*tracked.layers+layer] != layout {
+				return layout, false
+			}
+		}
+	}
+	return layout, true
+}
+
+// AttachmentUse is what a render pass attachment is used for after the pass,
+// it decides the final layout.
+type AttachmentUse int
+
+const (
+	// AttachmentPresent images are handed to vkQueuePresentKHR.
+	AttachmentPresent AttachmentUse = iota
+	// AttachmentSampled images are read by shaders in later passes.
+	AttachmentSampled
+	// AttachmentTransferSrc images are copied or blitted from, e.g. read back.
+	AttachmentTransferSrc
+	// AttachmentOnly images are not used after the pass, like a multisampled
+	// image resolved in it or a depth buffer. Their content is thrown away.
+	AttachmentOnly
+)
+
+// FinalLayout returns the layout an attachment of format ends the render
+// pass in for use.
+func (use AttachmentUse) FinalLayout(format vk.Format) vk.ImageLayout {
+	depth := IsDepthFormat(format)
+	switch use {
+	case AttachmentPresent:
+		return vk.ImageLayoutPresentSrc
+	case AttachmentSampled:
+		if depth {
+			return vk.ImageLayoutDepthStencilReadOnlyOptimal
+		}
+		return vk.ImageLayoutShaderReadOnlyOptimal
+	case AttachmentTransferSrc:
+		return vk.ImageLayoutTransferSrcOptimal
+	}
+	if depth {
+		return vk.ImageLayoutDepthStencilAttachmentOptimal
+	}
+	return vk.ImageLayoutColorAttachmentOptimal
+}
+
+// ColorAttachmentDescription describes a color attachment of format cleared
+// at the start of the render pass, and left in the final layout of use.
+// Only AttachmentOnly attachments are not stored.
+func ColorAttachmentDescription(format vk.Format, samples vk.SampleCountFlagBits, use AttachmentUse) vk.AttachmentDescription {
+	storeOp := vk.AttachmentStoreOpStore
+	if use == AttachmentOnly {
+		storeOp = vk.AttachmentStoreOpDontCare
+	}
+	return vk.AttachmentDescription{
+		Format:         format,
+		Samples:        samples,
+		LoadOp:         vk.AttachmentLoadOpClear,
+		StoreOp:        storeOp,
+		StencilLoadOp:  vk.AttachmentLoadOpDontCare,
+		StencilStoreOp: vk.AttachmentStoreOpDontCare,
+		InitialLayout:  vk.ImageLayoutUndefined, // cleared anyway
+		FinalLayout:    use.FinalLayout(format),
+	}
+}
+
+// SubpassDependencies returns the dependencies of a render pass with a single
+// subpass drawing to attachments, color, resolve and depth alike.
+//
+// The first waits for the previous use of every attachment before the
+// subpass touches it: the use implied by its initial layout or, for an
+// UNDEFINED one, by its final layout, as the previous frame may still be
+// using it. Depth and transient attachments are shared by the frames in
+// flight, swapchain images wait at color output like the acquire semaphore.
+//
+// The second, only when an attachment is stored to a layout read after the
+// pass, makes the writes of the subpass visible to that use.
+func SubpassDependencies(attachments []vk.AttachmentDescription) []vk.SubpassDependency {
+	var in, out vk.SubpassDependency
+	in = vk.SubpassDependency{SrcSubpass: vk.SubpassExternal, DstSubpass: 0}
+	out = vk.SubpassDependency{SrcSubpass: 0, DstSubpass: vk.SubpassExternal}
+	stored := false
+	for _, attachment := range attachments {
+		layout := vk.ImageLayoutColorAttachmentOptimal
+		if IsDepthFormat(attachment.Format) {
+			layout = vk.ImageLayoutDepthStencilAttachmentOptimal
+		}
+		stage, access := layoutScope(layout, false)
+		_, writes := layoutScope(layout, true)
+		if attachment.LoadOp != vk.AttachmentLoadOpLoad && attachment.StencilLoadOp != vk.AttachmentLoadOpLoad {
+			access = writes
+		}
+		previous := attachment.InitialLayout
+		if previous == vk.ImageLayoutUndefined {
+			previous = attachment.FinalLayout
+		}
+		srcStage, srcAccess := layoutScope(previous, true)
+		in.SrcStageMask |= vk.PipelineStageFlags(srcStage)
+		in.SrcAccessMask |= vk.AccessFlags(srcAccess)
+		in.DstStageMask |= vk.PipelineStageFlags(stage)
+		in.DstAccessMask |= vk.AccessFlags(access)
+
+		if attachment.FinalLayout == layout || attachment.StoreOp != vk.AttachmentStoreOpStore && attachment.StencilStoreOp != vk.AttachmentStoreOpStore {
+			continue
+		}
+		dstStage, dstAccess := layoutScope(attachment.FinalLayout, false)
+		out.SrcStageMask |= vk.PipelineStageFlags(stage)
+		out.SrcAccessMask |= vk.AccessFlags(writes)
+		out.DstStageMask |= vk.PipelineStageFlags(dstStage)
+		out.DstAccessMask |= vk.AccessFlags(dstAccess)
+		stored = true
+	}
+	if !stored {
+		return []vk.SubpassDependency{in}
+	}
+	return []vk.SubpassDependency{in, out}
+}
@@ -0,0 +1,200 @@
+package vkutil
+
+import (
+	"reflect"
+	"testing"
+	"unsafe"
+
+	vk "github.com/vulkan-go/vulkan"
+)
+
+// images back the vk.Image handles of the tests. A Go allocation could live
+// on the stack and move, the tracker keeps the handles as map keys.
+var images [2]uint64
+
+func TestLayoutTrackerBarriers(t *testing.T) {
+	color := vk.ImageAspectFlags(vk.ImageAspectColorBit)
+	image := vk.Image(unsafe.Pointer(&images[0]))
+	tracker := NewLayoutTracker()
+	tracker.Track(image, 3, 4, vk.ImageLayoutUndefined)
+	// Level 1 is uploaded to, then two of its layers are read by a pass
+	barriers, _, _, err := tracker.barriers(image, vk.ImageSubresourceRange{AspectMask: color, BaseMipLevel: 1, LevelCount: 1, LayerCount: vk.RemainingArrayLayers}, vk.ImageLayoutTransferDstOptimal)
+	if err != nil {
+		t.Fatalf("barriers: %v", err)
+	}
+	if len(barriers) != 1 {
+		t.Fatalf("%v barriers for a range in one layout, want 1", len(barriers))
+	}
+	if err := tracker.Assume(image, vk.ImageSubresourceRange{AspectMask: color, BaseMipLevel: 1, LevelCount: 1, BaseArrayLayer: 1, LayerCount: 2}, vk.ImageLayoutShaderReadOnlyOptimal); err != nil {
+		t.Fatalf("Assume: %v", err)
+	}
+
+	barriers, srcStage, dstStage, err := tracker.barriers(image, WholeRange(color), vk.ImageLayoutShaderReadOnlyOptimal)
+	if err != nil {
+		t.Fatalf("barriers: %v", err)
+	}
+	type run struct {
+		oldLayout vk.ImageLayout
+		level     uint32
+		layers    [2]uint32
+	}
+	var got []run
+	for _, b := range barriers {
+		if b.NewLayout != vk.ImageLayoutShaderReadOnlyOptimal || b.SubresourceRange.LevelCount != 1 || b.SubresourceRange.AspectMask != color {
+			t.Errorf("barrier %+v", b.SubresourceRange)
+		}
+		r := b.SubresourceRange
+		got = append(got, run{b.OldLayout, r.BaseMipLevel, [2]uint32{r.BaseArrayLayer, r.BaseArrayLayer + r.LayerCount}})
+	}
+	// Layers 1 and 2 of level 1 are in the layout already
+	want := []run{
+		{vk.ImageLayoutUndefined, 0, [2]uint32{0, 4}},
+		{vk.ImageLayoutTransferDstOptimal, 1, [2]uint32{0, 1}},
+		{vk.ImageLayoutTransferDstOptimal, 1, [2]uint32{3, 4}},
+		{vk.ImageLayoutUndefined, 2, [2]uint32{0, 4}},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("barriers = %+v, want %+v", got, want)
+	}
+	if want := vk.PipelineStageTopOfPipeBit | vk.PipelineStageTransferBit; srcStage != want {
+		t.Errorf("srcStage = %#x, want %#x", srcStage, want)
+	}
+	if want := vk.PipelineStageVertexShaderBit | vk.PipelineStageFragmentShaderBit; dstStage != want {
+		t.Errorf("dstStage = %#x, want %#x", dstStage, want)
+	}
+	for level := uint32(0); level < 3; level++ {
+		for layer := uint32(0); layer < 4; layer++ {
+			if layout := tracker.Layout(image, level, layer); layout != vk.ImageLayoutShaderReadOnlyOptimal {
+				t.Errorf("level %v layer %v is in layout %v", level, layer, layout)
+			}
+		}
+	}
+	if barriers, _, _, err := tracker.barriers(image, WholeRange(color), vk.ImageLayoutShaderReadOnlyOptimal); err != nil || len(barriers) != 0 {
+		t.Errorf("barriers to the current layout = %v, %v, want none", len(barriers), err)
+	}
+}
+
+func TestLayoutTrackerOutOfRange(t *testing.T) {
+	color := vk.ImageAspectFlags(vk.ImageAspectColorBit)
+	image := vk.Image(unsafe.Pointer(&images[1]))
+	tracker := NewLayoutTracker()
+	if _, _, _, err := tracker.barriers(image, WholeRange(color), vk.ImageLayoutGeneral); err == nil {
+		t.Error("barriers succeeded for an untracked image")
+	}
+	tracker.Track(image, 2, 1, vk.ImageLayoutUndefined)
+	for _, subresourceRange := range []vk.ImageSubresourceRange{
+		{AspectMask: color, BaseMipLevel: 2, LevelCount: vk.RemainingMipLevels, LayerCount: 1},
+		{AspectMask: color, LevelCount: 1, BaseArrayLayer: 1, LayerCount: 1},
+	} {
+		if _, _, _, err := tracker.barriers(image, subresourceRange, vk.ImageLayoutGeneral); err == nil {
+			t.Errorf("barriers succeeded for %+v", subresourceRange)
+		}
+		if err := tracker.Assume(image, subresourceRange, vk.ImageLayoutGeneral); err == nil {
+			t.Errorf("Assume succeeded for %+v", subresourceRange)
+		}
+	}
+	if layout := tracker.Layout(image, 0, 0); layout != vk.ImageLayoutUndefined {
+		t.Errorf("a failed transition moved the image to %v", layout)
+	}
+}
+
+func TestSubrange(t *testing.T) {
+	for _, tc := range []struct {
+		base, count, total uint32
+		want               [2]uint32
+		ok                 bool
+	}{
+		{0, vk.RemainingMipLevels, 5, [2]uint32{0, 5}, true},
+		{2, vk.RemainingArrayLayers, 5, [2]uint32{2, 5}, true},
+		{1, 3, 5, [2]uint32{1, 4}, true},
+		{4, 1, 5, [2]uint32{4, 5}, true},
+		{5, vk.RemainingMipLevels, 5, [2]uint32{}, false},
+		{0, 0, 5, [2]uint32{}, false},
+		{3, 3, 5, [2]uint32{}, false},
+		{1, 0xFFFFFFFE, 5, [2]uint32{}, false},
+	} {
+		got, err := subrange(tc.base, tc.count, tc.total, "mip levels")
+		if (err == nil) != tc.ok || got != tc.want {
+			t.Errorf("subrange(%v, %#x, %v) = %v, %v, want %v", tc.base, tc.count, tc.total, got, err, tc.want)
+		}
+	}
+}
+
+func TestSubpassDependencies(t *testing.T) {
+	const (
+		colorOutput = vk.PipelineStageColorAttachmentOutputBit
+		colorWrite  = vk.AccessColorAttachmentWriteBit
+		tests       = vk.PipelineStageEarlyFragmentTestsBit | vk.PipelineStageLateFragmentTestsBit
+		depthWrite  = vk.AccessDepthStencilAttachmentWriteBit
+	)
+	dependency := func(src, dst uint32, srcStage vk.PipelineStageFlagBits, srcAccess vk.AccessFlagBits, dstStage vk.PipelineStageFlagBits, dstAccess vk.AccessFlagBits) vk.SubpassDependency {
+		return vk.SubpassDependency{
+			SrcSubpass:    src,
+			DstSubpass:    dst,
+			SrcStageMask:  vk.PipelineStageFlags(srcStage),
+			SrcAccessMask: vk.AccessFlags(srcAccess),
+			DstStageMask:  vk.PipelineStageFlags(dstStage),
+			DstAccessMask: vk.AccessFlags(dstAccess),
+		}
+	}
+	in := func(srcStage vk.PipelineStageFlagBits, srcAccess vk.AccessFlagBits, dstStage vk.PipelineStageFlagBits, dstAccess vk.AccessFlagBits) vk.SubpassDependency {
+		return dependency(vk.SubpassExternal, 0, srcStage, srcAccess, dstStage, dstAccess)
+	}
+	out := func(srcStage vk.PipelineStageFlagBits, srcAccess vk.AccessFlagBits, dstStage vk.PipelineStageFlagBits, dstAccess vk.AccessFlagBits) vk.SubpassDependency {
+		return dependency(0, vk.SubpassExternal, srcStage, srcAccess, dstStage, dstAccess)
+	}
+	sampledDepth := DepthAttachmentDescription(vk.FormatD32Sfloat, vk.SampleCount1Bit)
+	sampledDepth.StoreOp, sampledDepth.FinalLayout = vk.AttachmentStoreOpStore, vk.ImageLayoutDepthStencilReadOnlyOptimal
+	for _, tc := range []struct {
+		name        string
+		attachments []vk.AttachmentDescription
+		want        []vk.SubpassDependency
+	}{
+		{
+			name:        "present",
+			attachments: []vk.AttachmentDescription{ColorAttachmentDescription(vk.FormatB8g8r8a8Srgb, vk.SampleCount1Bit, AttachmentPresent)},
+			want: []vk.SubpassDependency{
+				in(colorOutput, 0, colorOutput, colorWrite),
+				out(colorOutput, colorWrite, vk.PipelineStageBottomOfPipeBit, 0),
+			},
+		},
+		{
+			name:        "transfer source",
+			attachments: []vk.AttachmentDescription{ColorAttachmentDescription(vk.FormatR8g8b8a8Unorm, vk.SampleCount1Bit, AttachmentTransferSrc)},
+			want: []vk.SubpassDependency{
+				in(vk.PipelineStageTransferBit, 0, colorOutput, colorWrite),
+				out(colorOutput, colorWrite, vk.PipelineStageTransferBit, vk.AccessTransferReadBit),
+			},
+		},
+		{
+			name:        "depth",
+			attachments: []vk.AttachmentDescription{DepthAttachmentDescription(vk.FormatD32Sfloat, vk.SampleCount1Bit)},
+			want:        []vk.SubpassDependency{in(tests, depthWrite, tests, depthWrite)},
+		},
+		{
+			name:        "sampled depth",
+			attachments: []vk.AttachmentDescription{sampledDepth},
+			want: []vk.SubpassDependency{
+				in(tests|vk.PipelineStageFragmentShaderBit, 0, tests, depthWrite),
+				out(tests, depthWrite, tests|vk.PipelineStageFragmentShaderBit, vk.AccessDepthStencilAttachmentReadBit|vk.AccessShaderReadBit),
+			},
+		},
+		{
+			name: "present with depth",
+			attachments: []vk.AttachmentDescription{
+				ColorAttachmentDescription(vk.FormatB8g8r8a8Srgb, vk.SampleCount1Bit, AttachmentPresent),
+				DepthAttachmentDescription(vk.FormatD32Sfloat, vk.SampleCount1Bit),
+			},
+			want: []vk.SubpassDependency{
+				in(colorOutput|tests, depthWrite, colorOutput|tests, colorWrite|depthWrite),
+				out(colorOutput, colorWrite, vk.PipelineStageBottomOfPipeBit, 0),
+			},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := SubpassDependencies(tc.attachments); !reflect.DeepEqual(got, tc.want) {
+				t.Errorf("SubpassDependencies =\n%+v\nwant\n%+v", got, tc.want)
+			}
+		})
+	}
+}
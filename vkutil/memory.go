@@ -0,0 +1,103 @@
+package vkutil
+
+import (
+	"fmt"
+	"math/bits"
+	"strings"
+
+	vk "github.com/vulkan-go/vulkan"
+)
+
+// NoMemoryTypeError is returned by FindMemoryType when none of the memory
+// types allowed by TypeBits has every Required property.
+type NoMemoryTypeError struct {
+	TypeBits uint32
+	Required vk.MemoryPropertyFlags
+	// Available lists the property flags of every type allowed by TypeBits.
+	Available []vk.MemoryPropertyFlags
+}
+
+func (e *NoMemoryTypeError) Error() string {
+	var available []string
+	for _, flags := range e.Available {
+		available = append(available, MemoryPropertyFlagsString(flags))
+	}
+	return fmt.Sprintf("no memory type in bits %#b has %v (allowed types have: %v)",
+		e.TypeBits, MemoryPropertyFlagsString(e.Required), strings.Join(available, "; "))
+}
+
+// MemoryPropertyFlagsString returns flags as "HostVisible|HostCoherent".
+func MemoryPropertyFlagsString(flags vk.MemoryPropertyFlags) string {
+	var names []string
+	for _, f := range memoryPropertyFlagNames {
+		if vk.MemoryPropertyFlagBits(flags)&f.flag != 0 {
+			names = append(names, strings.TrimSuffix(strings.TrimPrefix(f.name, "MemoryProperty"), "Bit"))
+		}
+	}
+	if len(names) == 0 {
+		return "none"
+	}
+	return strings.Join(names, "|")
+}
+
+// FindMemoryType returns the index of the memory type to allocate from.
+// typeBits is MemoryRequirements.MemoryTypeBits of the resource, bit i set
+// meaning type i is allowed. The type must have every required property;
+// among those the one with the most preferred properties wins, and on a tie
+// the lowest index, as implementations list their types best first.
+//
+// MemoryTypeIndex is an index into the memory type array returned from a
+// call to vkGetPhysicalDeviceMemoryProperties(), see
+// https://www.khronos.org/registry/vulkan/specs/1.2-extensions/html/vkspec.html#memory-device
+func FindMemoryType(memoryProperties vk.PhysicalDeviceMemoryProperties, typeBits uint32, required, preferred vk.MemoryPropertyFlags) (uint32, error) {
+	best := -1
+	bestScore := -1
+	var available []vk.MemoryPropertyFlags
+	for idx := uint32(0); idx < memoryProperties.MemoryTypeCount && idx < vk.MaxMemoryTypes; idx++ {
+		if typeBits&(1<<idx) == 0 {
+			continue
+		}
+		flags := memoryProperties.MemoryTypes[idx].PropertyFlags
+		available = append(available, flags)
+		if flags&required != required {
+			continue
+		}
+		score := bits.OnesCount32(uint32(flags & preferred))
+		if score > bestScore {
+			best, bestScore = int(idx), score
+		}
+	}
+	if best < 0 {
+		return 0, &NoMemoryTypeError{TypeBits: typeBits, Required: required, Available: available}
+	}
+	return uint32(best), nil
+}
+
+// AllocateMemory allocates memoryRequirements.Size bytes from the memory
+// type FindMemoryType picks for the requirements and properties.
+func AllocateMemory(device vk.Device, memoryProperties vk.PhysicalDeviceMemoryProperties, memoryRequirements vk.MemoryRequirements, required, preferred vk.MemoryPropertyFlags) (vk.DeviceMemory, error) {
+	memoryTypeIndex, err := FindMemoryType(memoryProperties, memoryRequirements.MemoryTypeBits, required, preferred)
+	if err != nil {
+		return vk.NullDeviceMemory, err
+	}
+	memAlloc := &vk.MemoryAllocateInfo{
+		SType:           vk.StructureTypeMemoryAllocateInfo,
+		AllocationSize:  memoryRequirements.Size,
+		MemoryTypeIndex: memoryTypeIndex,
+	}
+	return driver.AllocateMemory(device, memAlloc)
+}
+
+// AllocateBufferMemory allocates a dedicated block of memory for buffer and
+// binds it at offset 0.
+func AllocateBufferMemory(device vk.Device, memoryProperties vk.PhysicalDeviceMemoryProperties, buffer vk.Buffer, required, preferred vk.MemoryPropertyFlags) (vk.DeviceMemory, error) {
+	memory, err := AllocateMemory(device, memoryProperties, GetBufferMemoryRequirements(device, buffer), required, preferred)
+	if err != nil {
+		return vk.NullDeviceMemory, err
+	}
+	if err := driver.BindBufferMemory(device, buffer, memory, vk.DeviceSize(0)); err != nil {
+		driver.FreeMemory(device, memory)
+		return vk.NullDeviceMemory, err
+	}
+	return memory, nil
+}
@@ -0,0 +1,101 @@
+package vkutil
+
+import (
+	"errors"
+	"testing"
+
+	vk "github.com/vulkan-go/vulkan"
+)
+
+const (
+	deviceLocal  = vk.MemoryPropertyFlags(vk.MemoryPropertyDeviceLocalBit)
+	hostVisible  = vk.MemoryPropertyFlags(vk.MemoryPropertyHostVisibleBit)
+	hostCoherent = vk.MemoryPropertyFlags(vk.MemoryPropertyHostCoherentBit)
+	hostCached   = vk.MemoryPropertyFlags(vk.MemoryPropertyHostCachedBit)
+)
+
+// memoryTable builds memory properties with one type per flags, all in heap 0.
+func memoryTable(flags ...vk.MemoryPropertyFlags) vk.PhysicalDeviceMemoryProperties {
+	properties := vk.PhysicalDeviceMemoryProperties{
+		MemoryTypeCount: uint32(len(flags)),
+		MemoryHeapCount: 1,
+	}
+	for idx, f := range flags {
+		properties.MemoryTypes[idx] = vk.MemoryType{PropertyFlags: f}
+	}
+	return properties
+}
+
+func TestFindMemoryType(t *testing.T) {
+	// A discrete GPU: VRAM, then upload heap, then readback heap
+	discrete := memoryTable(deviceLocal, hostVisible|hostCoherent, hostVisible|hostCoherent|hostCached)
+	for _, tc := range []struct {
+		name                string
+		properties          vk.PhysicalDeviceMemoryProperties
+		typeBits            uint32
+		required, preferred vk.MemoryPropertyFlags
+		want                uint32
+	}{
+		{"required only", discrete, 0b111, hostVisible, 0, 1},
+		{"required device local", discrete, 0b111, deviceLocal, 0, 0},
+		{"preferred wins", discrete, 0b111, hostVisible, hostCached, 2},
+		{"most preferred wins", memoryTable(hostVisible, hostVisible|hostCached, hostVisible|hostCoherent|hostCached), 0b111, hostVisible, hostCoherent | hostCached, 2},
+		{"unmet preference", discrete, 0b111, deviceLocal, hostVisible, 0},
+		{"tie takes the lowest index", memoryTable(deviceLocal, deviceLocal), 0b11, deviceLocal, 0, 0},
+		{"typeBits masks the best", discrete, 0b011, hostVisible, hostCached, 1},
+		{"typeBits masks the lowest", memoryTable(deviceLocal, deviceLocal), 0b10, deviceLocal, 0, 1},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := FindMemoryType(tc.properties, tc.typeBits, tc.required, tc.preferred)
+			if err != nil {
+				t.Fatalf("FindMemoryType: %v", err)
+			}
+			if got != tc.want {
+				t.Errorf("FindMemoryType = %v, want %v", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestFindMemoryTypeNoMatch(t *testing.T) {
+	discrete := memoryTable(deviceLocal, hostVisible|hostCoherent, hostVisible|hostCoherent|hostCached)
+	for _, tc := range []struct {
+		name          string
+		typeBits      uint32
+		required      vk.MemoryPropertyFlags
+		wantAvailable []vk.MemoryPropertyFlags
+	}{
+		{"no type has it", 0b111, deviceLocal | hostVisible, []vk.MemoryPropertyFlags{deviceLocal, hostVisible | hostCoherent, hostVisible | hostCoherent | hostCached}},
+		{"masked out", 0b001, hostVisible, []vk.MemoryPropertyFlags{deviceLocal}},
+		{"no type allowed", 0, 0, nil},
+		{"bits past the type count", 0b1000, 0, nil},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := FindMemoryType(discrete, tc.typeBits, tc.required, 0)
+			var noType *NoMemoryTypeError
+			if !errors.As(err, &noType) {
+				t.Fatalf("FindMemoryType error = %v, want a *NoMemoryTypeError", err)
+			}
+			if noType.TypeBits != tc.typeBits || noType.Required != tc.required {
+				t.Errorf("error has bits %#b required %v, want %#b %v", noType.TypeBits, noType.Required, tc.typeBits, tc.required)
+			}
+			if len(noType.Available) != len(tc.wantAvailable) {
+				t.Fatalf("Available = %v, want %v", noType.Available, tc.wantAvailable)
+			}
+			for idx := range tc.wantAvailable {
+				if noType.Available[idx] != tc.wantAvailable[idx] {
+					t.Errorf("Available[%v] = %v, want %v", idx, noType.Available[idx], tc.wantAvailable[idx])
+				}
+			}
+		})
+	}
+}
+
+func TestMemoryPropertyFlagsString(t *testing.T) {
+	if got := MemoryPropertyFlagsString(hostVisible | hostCoherent); got != "HostVisible|HostCoherent" {
+		t.Errorf("MemoryPropertyFlagsString = %q", got)
+	}
+	if got := MemoryPropertyFlagsString(0); got != "none" {
+		t.Errorf("MemoryPropertyFlagsString(0) = %q", got)
+	}
+}
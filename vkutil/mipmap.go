@@ -0,0 +1,320 @@
+package vkutil
+
+import (
+	"fmt"
+	"math"
+	"math/bits"
+
+	vk "github.com/vulkan-go/vulkan"
+)
+
+// MipLevelCount returns the number of levels of the full mip chain of an
+// image of extent, halving the larger side down to 1: 11 for 1024x1024.
+func MipLevelCount(extent vk.Extent2D) uint32 {
+	size := extent.Width
+	if extent.Height > size {
+		size = extent.Height
+	}
+	if size == 0 {
+		return 1
+	}
+	return uint32(bits.Len32(size))
+}
+
+// MipExtent returns the extent of mip level of an image of extent, each
+// side halved level times but at least 1.
+func MipExtent(extent vk.Extent2D, level uint32) vk.Extent2D {
+	mip := vk.Extent2D{Width: extent.Width >> level, Height: extent.Height >> level}
+	if mip.Width == 0 {
+		mip.Width = 1
+	}
+	if mip.Height == 0 {
+		mip.Height = 1
+	}
+	return mip
+}
+
+// mipBlitFeatures are what CmdGenerateMipmaps needs of an optimally tiled format.
+const mipBlitFeatures = vk.FormatFeatureFlags(vk.FormatFeatureBlitSrcBit | vk.FormatFeatureBlitDstBit | vk.FormatFeatureSampledImageFilterLinearBit)
+
+// CanBlitMipmaps tells whether physicalDevice can generate the mip levels of
+// an optimally tiled image of format with CmdGenerateMipmaps: the format
+// must be a blit source and destination and support linear filtering.
+// https://www.khronos.org/registry/vulkan/specs/1.2-extensions/html/vkspec.html#copies-imagescaling
+func CanBlitMipmaps(physicalDevice vk.PhysicalDevice, format vk.Format) bool {
+	properties := GetPhysicalDeviceFormatProperties(physicalDevice, format)
+	return properties.OptimalTilingFeatures&mipBlitFeatures == mipBlitFeatures
+}
+
+// CmdGenerateMipmaps records the blits filling mip levels 1 to levels-1 of
+// the layers of image, a color image of extent created with TRANSFER_SRC
+// and TRANSFER_DST usage, each level from the one before. Level 0 must hold
+// the picture; tracker knows the layouts, at the end every level is
+// SHADER_READ_ONLY_OPTIMAL. The format must pass CanBlitMipmaps and the
+// command buffer must go to a graphics queue.
+func CmdGenerateMipmaps(commandBuffer vk.CommandBuffer, tracker *LayoutTracker, image vk.Image, extent vk.Extent2D, levels, layers uint32) error {
+	if levels == 0 || layers == 0 {
+		return fmt.Errorf("generating %v mip levels of %v layers", levels, layers)
+	}
+	level := func(level uint32) vk.ImageSubresourceRange {
+		return vk.ImageSubresourceRange{
+			AspectMask:   vk.ImageAspectFlags(vk.ImageAspectColorBit),
+			BaseMipLevel: level,
+			LevelCount:   1,
+			LayerCount:   layers,
+		}
+	}
+	for dst := uint32(1); dst < levels; dst++ {
+		src := dst - 1
+		if err := tracker.Transition(commandBuffer, image, level(src), vk.ImageLayoutTransferSrcOptimal); err != nil {
+			return err
+		}
+		if err := tracker.Transition(commandBuffer, image, level(dst), vk.ImageLayoutTransferDstOptimal); err != nil {
+			return err
+		}
+		srcExtent, dstExtent := MipExtent(extent, src), MipExtent(extent, dst)
+		vk.CmdBlitImage(commandBuffer, image, vk.ImageLayoutTransferSrcOptimal, image, vk.ImageLayoutTransferDstOptimal, 1, []vk.ImageBlit{{
+			SrcSubresource: vk.ImageSubresourceLayers{AspectMask: vk.ImageAspectFlags(vk.ImageAspectColorBit), MipLevel: src, LayerCount: layers},
+			SrcOffsets:     [2]vk.Offset3D{{}, {X: int32(srcExtent.Width), Y: int32(srcExtent.Height), Z: 1}},
+			DstSubresource: vk.ImageSubresourceLayers{AspectMask: vk.ImageAspectFlags(vk.ImageAspectColorBit), MipLevel: dst, LayerCount: layers},
+			DstOffsets:     [2]vk.Offset3D{{}, {X: int32(dstExtent.Width), Y: int32(dstExtent.Height), Z: 1}},
+		}}, vk.FilterLinear)
+		// The source level is done, shaders may read it
+		if err := tracker.Transition(commandBuffer, image, level(src), vk.ImageLayoutShaderReadOnlyOptimal); err != nil {
+			return err
+		}
+	}
+	// The last level was only written
+	return tracker.Transition(commandBuffer, image, level(levels-1), vk.ImageLayoutShaderReadOnlyOptimal)
+}
+
+// MipFilter is the filter MipChain downsamples with.
+type MipFilter int
+
+const (
+	// MipFilterBox averages the texels a texel of the next level covers.
+	// Cheap, but a bit blurry and prone to aliasing.
+	MipFilterBox MipFilter = iota
+	// MipFilterKaiser is a sinc filter windowed by a Kaiser window, sharper
+	// than the box with little aliasing or ringing.
+	MipFilterKaiser
+)
+
+func (f MipFilter) String() string {
+	switch f {
+	case MipFilterBox:
+		return "box"
+	case MipFilterKaiser:
+		return "Kaiser"
+	}
+	return fmt.Sprintf("MipFilter(%d)", int(f))
+}
+
+// kaiserRadius and kaiserAlpha shape MipFilterKaiser: the radius is in
+// texels of the smaller level, alpha trades sharpness for ringing.
+const (
+	kaiserRadius = 3
+	kaiserAlpha  = 4
+)
+
+// MipChain computes the mip levels of a texture on the CPU, for formats
+// CanBlitMipmaps turns down. pixels are the texels of level 0 as
+// TexturePixels returns them, extent its size. It returns levels slices,
+// the first is pixels. Srgb formats are filtered in linear space, alpha
+// always is.
+func MipChain(pixels []byte, extent vk.Extent2D, format vk.Format, levels uint32, filter MipFilter) ([][]byte, error) {
+	channels, srgb, ok := mipFormat(format)
+	if !ok {
+		return nil, fmt.Errorf("no CPU mipmaps for %v", FormatName(format))
+	}
+	if len(pixels) != int(extent.Width*extent.Height)*channels {
+		return nil, fmt.Errorf("%v bytes of pixels for %vx%v %v", len(pixels), extent.Width, extent.Height, FormatName(format))
+	}
+	if levels == 0 || levels > MipLevelCount(extent) {
+		return nil, fmt.Errorf("%v mip levels for %vx%v", levels, extent.Width, extent.Height)
+	}
+	if filter != MipFilterBox && filter != MipFilterKaiser {
+		return nil, fmt.Errorf("unknown %v", filter)
+	}
+	// Decode once to linear floats, every level is filtered from the one before
+	decode := func(channel int) bool { return srgb && channel < 3 }
+	current := make([]float32, len(pixels))
+	for idx, value := range pixels {
+		if decode(idx % channels) {
+			current[idx] = srgbToLinear[value]
+		} else {
+			current[idx] = float32(value) / 255
+		}
+	}
+	chain := [][]byte{pixels}
+	for level := uint32(1); level < levels; level++ {
+		src, dst := MipExtent(extent, level-1), MipExtent(extent, level)
+		current = resample(current, src, dst, channels, filter)
+		encoded := make([]byte, len(current))
+		for idx, value := range current {
+			if decode(idx % channels) {
+				value = linearToSrgb(value)
+			}
+			encoded[idx] = uint8(math.Round(float64(clamp01(value)) * 255))
+		}
+		chain = append(chain, encoded)
+	}
+	return chain, nil
+}
+
+// mipFormat returns the channels of format and whether its colors are sRGB
+// encoded, for the formats MipChain handles.
+func mipFormat(format vk.Format) (channels int, srgb bool, ok bool) {
+	switch format {
+	case vk.FormatR8g8b8a8Unorm, vk.FormatB8g8r8a8Unorm:
+		return 4, false, true
+	case vk.FormatR8g8b8a8Srgb, vk.FormatB8g8r8a8Srgb:
+		return 4, true, true
+	case vk.FormatR8Unorm:
+		return 1, false, true
+	case vk.FormatR8Srgb:
+		return 1, true, true
+	}
+	return 0, false, false
+}
+
+// tap is one source texel contributing to a destination texel.
+type tap struct {
+	index  int
+	weight float32
+}
+
+// resample scales the texels of src, channels floats each, to dst, a row
+// pass followed by a column pass.
+func resample(texels []float32, src, dst vk.Extent2D, channels int, filter MipFilter) []float32 {
+	columns := filterTaps(int(src.Width), int(dst.Width), filter)
+	rows := filterTaps(int(src.Height), int(dst.Height), filter)
+	wide := make([]float32, int(dst.Width*src.Height)*channels)
+	for y := 0; y < int(src.Height); y++ {
+		for x, taps := range columns {
+			out := wide[(y*int(dst.Width)+x)*channels:][:channels]
+			for _, t := range taps {
+				in := texels[(y*int(src.Width)+t.index)*channels:][:channels]
+				for c := range out {
+					out[c] += t.weight * in[c]
+				}
+			}
+		}
+	}
+	result := make([]float32, int(dst.Width*dst.Height)*channels)
+	for y, taps := range rows {
+		for x := 0; x < int(dst.Width); x++ {
+			out := result[(y*int(dst.Width)+x)*channels:][:channels]
+			for _, t := range taps {
+				in := wide[(t.index*int(dst.Width)+x)*channels:][:channels]
+				for c := range out {
+					out[c] += t.weight * in[c]
+				}
+			}
+		}
+	}
+	return result
+}
+
+// filterTaps returns, for each of dstSize texels, the source texels and
+// weights of filter along one axis. The weights add up to 1, texels past
+// the edge repeat the edge.
+func filterTaps(srcSize, dstSize int, filter MipFilter) [][]tap {
+	scale := float64(srcSize) / float64(dstSize)
+	taps := make([][]tap, dstSize)
+	for x := range taps {
+		var weights []tap
+		var total float64
+		add := func(index int, weight float64) {
+			if weight == 0 {
+				return
+			}
+			if index < 0 {
+				index = 0
+			} else if index >= srcSize {
+				index = srcSize - 1
+			}
+			weights = append(weights, tap{index: index, weight: float32(weight)})
+			total += weight
+		}
+		switch filter {
+		case MipFilterBox:
+			// The overlap of source texel i, [i, i+1), with [x*scale, (x+1)*scale)
+			start, end := float64(x)*scale, float64(x+1)*scale
+			for i := int(math.Floor(start)); float64(i) < end; i++ {
+				add(i, math.Min(end, float64(i+1))-math.Max(start, float64(i)))
+			}
+		case MipFilterKaiser:
+			// Texel centers are at +0.5, distances are in destination texels
+			center := (float64(x) + 0.5) * scale
+			radius := kaiserRadius * scale
+			for i := int(math.Floor(center - radius)); float64(i) < center+radius; i++ {
+				distance := (float64(i) + 0.5 - center) / scale
+				add(i, sinc(distance)*kaiser(distance/kaiserRadius))
+			}
+		}
+		for idx := range weights {
+			weights[idx].weight /= float32(total)
+		}
+		taps[x] = weights
+	}
+	return taps
+}
+
+func sinc(x float64) float64 {
+	if x == 0 {
+		return 1
+	}
+	return math.Sin(math.Pi*x) / (math.Pi * x)
+}
+
+// kaiser is the Kaiser window over [-1, 1].
+func kaiser(x float64) float64 {
+	if x <= -1 || x >= 1 {
+		return 0
+	}
+	return besselI0(kaiserAlpha*math.Sqrt(1-x*x)) / besselI0(kaiserAlpha)
+}
+
+// besselI0 is the zeroth order modified Bessel function of the first kind,
+// summed as a power series.
+func besselI0(x float64) float64 {
+	sum, term := 1.0, 1.0
+	for k := 1; term > 1e-12*sum; k++ {
+		half := x / (2 * float64(k))
+		term *= half * half
+		sum += term
+	}
+	return sum
+}
+
+func clamp01(x float32) float32 {
+	if x < 0 {
+		return 0
+	}
+	if x > 1 {
+		return 1
+	}
+	return x
+}
+
+// srgbToLinear decodes the 256 sRGB values.
+var srgbToLinear = func() (table [256]float32) {
+	for idx := range table {
+		c := float64(idx) / 255
+		if c <= 0.04045 {
+			table[idx] = float32(c / 12.92)
+		} else {
+			table[idx] = float32(math.Pow((c+0.055)/1.055, 2.4))
+		}
+	}
+	return table
+}()
+
+func linearToSrgb(x float32) float32 {
+	c := float64(clamp01(x))
+	if c <= 0.0031308 {
+		return float32(c * 12.92)
+	}
+	return float32(1.055*math.Pow(c, 1/2.4) - 0.055)
+}
@@ -0,0 +1,131 @@
+package vkutil
+
+import (
+	"math"
+	"testing"
+
+	vk "github.com/vulkan-go/vulkan"
+)
+
+func TestMipLevelCount(t *testing.T) {
+	for _, tc := range []struct {
+		extent vk.Extent2D
+		want   uint32
+	}{
+		{vk.Extent2D{Width: 1024, Height: 1024}, 11},
+		{vk.Extent2D{Width: 1024, Height: 512}, 11},
+		{vk.Extent2D{Width: 512, Height: 1024}, 11},
+		{vk.Extent2D{Width: 300, Height: 7}, 9},
+		{vk.Extent2D{Width: 1, Height: 17}, 5},
+		{vk.Extent2D{Width: 1, Height: 1}, 1},
+		{vk.Extent2D{}, 1},
+	} {
+		if got := MipLevelCount(tc.extent); got != tc.want {
+			t.Errorf("MipLevelCount(%vx%v) = %v, want %v", tc.extent.Width, tc.extent.Height, got, tc.want)
+		}
+	}
+}
+
+func TestMipExtent(t *testing.T) {
+	extent := vk.Extent2D{Width: 1024, Height: 256}
+	for level, want := range map[uint32]vk.Extent2D{
+		0:  {Width: 1024, Height: 256},
+		1:  {Width: 512, Height: 128},
+		8:  {Width: 4, Height: 1},
+		10: {Width: 1, Height: 1},
+	} {
+		if got := MipExtent(extent, level); got != want {
+			t.Errorf("MipExtent(1024x256, %v) = %vx%v, want %vx%v", level, got.Width, got.Height, want.Width, want.Height)
+		}
+	}
+}
+
+func TestFilterTapsSumToOne(t *testing.T) {
+	for _, filter := range []MipFilter{MipFilterBox, MipFilterKaiser} {
+		for _, size := range [][2]int{{2, 1}, {3, 1}, {4, 2}, {5, 2}, {7, 3}, {9, 4}, {1024, 512}} {
+			srcSize, dstSize := size[0], size[1]
+			for x, taps := range filterTaps(srcSize, dstSize, filter) {
+				var sum float64
+				for _, tap := range taps {
+					if tap.index < 0 || tap.index >= srcSize {
+						t.Errorf("%v %v to %v: texel %v taps %v", filter, srcSize, dstSize, x, tap.index)
+					}
+					sum += float64(tap.weight)
+				}
+				if math.Abs(sum-1) > 1e-5 {
+					t.Errorf("%v %v to %v: the weights of texel %v add up to %v", filter, srcSize, dstSize, x, sum)
+				}
+			}
+		}
+	}
+}
+
+func TestMipChainBox(t *testing.T) {
+	extent := vk.Extent2D{Width: 2, Height: 2}
+	// Red goes from black to white, green is gray, alpha is not color
+	pixels := []byte{
+		0, 100, 0, 0,
+		0, 100, 0, 100,
+		255, 100, 0, 200,
+		255, 100, 0, 100,
+	}
+	for _, tc := range []struct {
+		format vk.Format
+		want   []byte
+	}{
+		{vk.FormatR8g8b8a8Unorm, []byte{128, 100, 0, 100}},
+		// Half the light of white is 188 in sRGB, not 128, gray stays gray
+		{vk.FormatR8g8b8a8Srgb, []byte{188, 100, 0, 100}},
+	} {
+		chain, err := MipChain(pixels, extent, tc.format, 2, MipFilterBox)
+		if err != nil {
+			t.Fatalf("MipChain %v: %v", FormatName(tc.format), err)
+		}
+		if len(chain) != 2 || len(chain[1]) != 4 {
+			t.Fatalf("MipChain %v returned %v levels", FormatName(tc.format), len(chain))
+		}
+		for idx, want := range tc.want {
+			// Rounding may go either way by one
+			if got := chain[1][idx]; int(got) < int(want)-1 || int(got) > int(want)+1 {
+				t.Errorf("MipChain %v: channel %v is %v, want %v", FormatName(tc.format), idx, got, want)
+			}
+		}
+	}
+}
+
+func TestMipChainLevels(t *testing.T) {
+	extent := vk.Extent2D{Width: 5, Height: 3}
+	chain, err := MipChain(make([]byte, 5*3), extent, vk.FormatR8Unorm, MipLevelCount(extent), MipFilterKaiser)
+	if err != nil {
+		t.Fatalf("MipChain: %v", err)
+	}
+	for level, pixels := range chain {
+		mip := MipExtent(extent, uint32(level))
+		if len(pixels) != int(mip.Width*mip.Height) {
+			t.Errorf("level %v has %v bytes, want %vx%v", level, len(pixels), mip.Width, mip.Height)
+		}
+	}
+}
+
+func TestMipChainRejects(t *testing.T) {
+	extent := vk.Extent2D{Width: 4, Height: 4}
+	pixels := make([]byte, 4*4*4)
+	for _, tc := range []struct {
+		name   string
+		pixels []byte
+		format vk.Format
+		levels uint32
+		filter MipFilter
+	}{
+		{"short pixels", pixels[:4*4*4-1], vk.FormatR8g8b8a8Unorm, 3, MipFilterBox},
+		{"single channel size", pixels, vk.FormatR8Unorm, 3, MipFilterBox},
+		{"no levels", pixels, vk.FormatR8g8b8a8Unorm, 0, MipFilterBox},
+		{"more levels than the chain", pixels, vk.FormatR8g8b8a8Unorm, 4, MipFilterBox},
+		{"unknown filter", pixels, vk.FormatR8g8b8a8Unorm, 3, MipFilter(7)},
+		{"unsupported format", pixels, vk.FormatR16g16b16a16Sfloat, 3, MipFilterBox},
+	} {
+		if _, err := MipChain(tc.pixels, extent, tc.format, tc.levels, tc.filter); err == nil {
+			t.Errorf("MipChain succeeded with %v", tc.name)
+		}
+	}
+}
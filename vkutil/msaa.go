@@ -0,0 +1,59 @@
+package vkutil
+
+import (
+	vk "github.com/vulkan-go/vulkan"
+)
+
+// sampleCounts are the sample counts, highest first.
+var sampleCounts = []vk.SampleCountFlagBits{
+	vk.SampleCount64Bit,
+	vk.SampleCount32Bit,
+	vk.SampleCount16Bit,
+	vk.SampleCount8Bit,
+	vk.SampleCount4Bit,
+	vk.SampleCount2Bit,
+}
+
+// SupportedSampleCounts returns the sample counts both color and depth
+// framebuffer attachments support on physicalDevice.
+// https://www.khronos.org/registry/vulkan/specs/1.2-extensions/html/vkspec.html#limits-framebufferColorSampleCounts
+func SupportedSampleCounts(physicalDevice vk.PhysicalDevice) vk.SampleCountFlags {
+	properties := GetPhysicalDeviceProperties(physicalDevice)
+	return properties.Limits.FramebufferColorSampleCounts & properties.Limits.FramebufferDepthSampleCounts
+}
+
+// MaxSampleCount returns the highest sample count usable for color and depth
+// attachments together, vk.SampleCount1Bit without MSAA support.
+func MaxSampleCount(physicalDevice vk.PhysicalDevice) vk.SampleCountFlagBits {
+	return SelectSampleCount(physicalDevice, vk.SampleCount64Bit)
+}
+
+// SelectSampleCount returns wanted when physicalDevice supports it for color
+// and depth attachments, otherwise the next lower supported sample count.
+// Every device supports 4 samples, so asking for more may fall back.
+func SelectSampleCount(physicalDevice vk.PhysicalDevice, wanted vk.SampleCountFlagBits) vk.SampleCountFlagBits {
+	supported := SupportedSampleCounts(physicalDevice)
+	for _, count := range sampleCounts {
+		if count <= wanted && supported&vk.SampleCountFlags(count) != 0 {
+			return count
+		}
+	}
+	return vk.SampleCount1Bit
+}
+
+// ResolveAttachmentDescription describes the single sampled image a
+// multisampled color attachment of format is resolved into, e.g. the
+// swapchain image: what it held before is overwritten by the resolve, the
+// result is kept and ends in finalLayout.
+func ResolveAttachmentDescription(format vk.Format, finalLayout vk.ImageLayout) vk.AttachmentDescription {
+	return vk.AttachmentDescription{
+		Format:         format,
+		Samples:        vk.SampleCount1Bit,
+		LoadOp:         vk.AttachmentLoadOpDontCare,
+		StoreOp:        vk.AttachmentStoreOpStore,
+		StencilLoadOp:  vk.AttachmentLoadOpDontCare,
+		StencilStoreOp: vk.AttachmentStoreOpDontCare,
+		InitialLayout:  vk.ImageLayoutUndefined,
+		FinalLayout:    finalLayout,
+	}
+}
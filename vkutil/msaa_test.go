@@ -0,0 +1,52 @@
+package vkutil_test
+
+import (
+	"testing"
+
+	"github.com/goodshailesh/My-Vulkan-Projects/vkutil"
+	"github.com/goodshailesh/My-Vulkan-Projects/vkutil/vkfake"
+	vk "github.com/vulkan-go/vulkan"
+)
+
+func TestSelectSampleCount(t *testing.T) {
+	counts := func(bits ...vk.SampleCountFlagBits) vk.SampleCountFlags {
+		var flags vk.SampleCountFlags
+		for _, bit := range bits {
+			flags |= vk.SampleCountFlags(bit)
+		}
+		return flags
+	}
+	const (
+		s1  = vk.SampleCount1Bit
+		s2  = vk.SampleCount2Bit
+		s4  = vk.SampleCount4Bit
+		s8  = vk.SampleCount8Bit
+		s16 = vk.SampleCount16Bit
+		s32 = vk.SampleCount32Bit
+		s64 = vk.SampleCount64Bit
+	)
+	for _, tc := range []struct {
+		name         string
+		color, depth vk.SampleCountFlags
+		wanted       vk.SampleCountFlagBits
+		want         vk.SampleCountFlagBits
+	}{
+		{"supported", counts(s1, s2, s4, s8), counts(s1, s2, s4, s8), s4, s4},
+		{"falls back to the next lower", counts(s1, s2, s4), counts(s1, s2, s4), s8, s4},
+		{"color and depth together", counts(s1, s2, s4, s8), counts(s1, s2, s4), s8, s4},
+		{"skips unsupported counts", counts(s1, s4, s8), counts(s1, s4, s8), s2, s1},
+		{"no MSAA", counts(s1), counts(s1), s8, s1},
+		{"one sample asked for", counts(s1, s2, s4, s8), counts(s1, s2, s4, s8), s1, s1},
+		{"highest", counts(s1, s2, s4, s8, s16, s32, s64), counts(s1, s2, s4, s8, s16, s32, s64), s64, s64},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			dev := vkfake.NewDevice("Fake dGPU", vk.PhysicalDeviceTypeDiscreteGpu)
+			dev.Properties.Limits.FramebufferColorSampleCounts = tc.color
+			dev.Properties.Limits.FramebufferDepthSampleCounts = tc.depth
+			_, physicalDevices := useFake(t, dev)
+			if got := vkutil.SelectSampleCount(physicalDevices[0], tc.wanted); got != tc.want {
+				t.Errorf("SelectSampleCount(%v) = %v, want %v", tc.wanted, got, tc.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,319 @@
+package vkutil
+
+import (
+	"bufio"
+	"fmt"
+	"image"
+	"image/png"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"unsafe"
+
+	vk "github.com/vulkan-go/vulkan"
+)
+
+// DefaultOffscreenExtent is the size of an OffscreenTarget when
+// OffscreenConfig.Extent is zero.
+var DefaultOffscreenExtent = vk.Extent2D{Width: 256, Height: 256}
+
+// OffscreenConfig tunes NewOffscreenTarget. Zero values pick the defaults.
+type OffscreenConfig struct {
+	// Format of the color image: vk.FormatR8g8b8a8Unorm, vk.FormatB8g8r8a8Unorm
+	// or their Srgb variants. Defaults to vk.FormatR8g8b8a8Unorm.
+	Format vk.Format
+	// Extent of the image. Defaults to DefaultOffscreenExtent.
+	Extent vk.Extent2D
+	// ClearColor is the RGBA color the image is cleared to before drawing.
+	ClearColor [4]float32
+}
+
+// OffscreenTarget is a color image to render to without a window nor a
+// surface, e.g. on CI hosts with a CPU implementation like lavapipe. Render
+// draws into it in a single subpass render pass, then copies the image to a
+// host visible buffer and returns it as an image.Image.
+type OffscreenTarget struct {
+	Format vk.Format
+	Extent vk.Extent2D
+	// Image is the color attachment, left in TRANSFER_SRC_OPTIMAL layout by the render pass.
+	Image vk.Image
+	View  vk.ImageView
+	// RenderPass is what pipelines drawing to the target are built for.
+	RenderPass  vk.RenderPass
+	Framebuffer vk.Framebuffer
+
+	device             vk.Device
+	allocator          *Allocator
+	queue              vk.Queue
+	clearColor         [4]float32
+	imageAllocation    *Allocation
+	readback           vk.Buffer
+	readbackAllocation *Allocation
+	commandPool        vk.CommandPool
+	commandBuffer      vk.CommandBuffer
+	fence              vk.Fence
+}
+
+// NewOffscreenTarget creates the image, render pass and framebuffer to draw
+// to, and the buffer the image is read back through. Commands are submitted
+// to queue, of family graphicsFamily.
+func NewOffscreenTarget(device vk.Device, allocator *Allocator, graphicsFamily uint32, queue vk.Queue, config OffscreenConfig) (*OffscreenTarget, error) {
+	if config.Format == vk.FormatUndefined {
+		config.Format = vk.FormatR8g8b8a8Unorm
+	}
+	if config.Extent.Width == 0 || config.Extent.Height == 0 {
+		config.Extent = DefaultOffscreenExtent
+	}
+	if _, ok := offscreenSwizzle(config.Format); !ok {
+		return nil, fmt.Errorf("offscreen format %v is not an 8 bit RGBA or BGRA format", config.Format)
+	}
+	t := &OffscreenTarget{
+		Format:     config.Format,
+		Extent:     config.Extent,
+		device:     device,
+		allocator:  allocator,
+		queue:      queue,
+		clearColor: config.ClearColor,
+	}
+	var err error
+	t.Image, t.imageAllocation, err = CreateImageWithMemory(device, allocator, t.Format, vk.Extent3D{Width: t.Extent.Width, Height: t.Extent.Height, Depth: 1}, 1,
+		vk.ImageUsageFlags(vk.ImageUsageColorAttachmentBit|vk.ImageUsageTransferSrcBit), "offscreen image")
+	if err != nil {
+		return nil, err
+	}
+	if t.View, err = CreateImageView(device, t.Image, t.Format); err != nil {
+		t.Destroy()
+		return nil, err
+	}
+	if t.RenderPass, err = createOffscreenRenderPass(device, t.Format); err != nil {
+		t.Destroy()
+		return nil, err
+	}
+	framebufferCreateInfo := vk.FramebufferCreateInfo{
+		SType:           vk.StructureTypeFramebufferCreateInfo,
+		RenderPass:      t.RenderPass,
+		AttachmentCount: 1,
+		PAttachments:    []vk.ImageView{t.View},
+		Width:           t.Extent.Width,
+		Height:          t.Extent.Height,
+		Layers:          1,
+	}
+	if t.Framebuffer, err = driver.CreateFramebuffer(device, &framebufferCreateInfo); err != nil {
+		t.Destroy()
+		return nil, err
+	}
+	// Host cached memory makes reading the pixels back much faster where there is some
+	t.readback, t.readbackAllocation, err = CreateBufferWithMemory(device, allocator, vk.DeviceSize(t.Extent.Width*t.Extent.Height*4),
+		vk.BufferUsageFlags(vk.BufferUsageTransferDstBit),
+		vk.MemoryPropertyFlags(vk.MemoryPropertyHostVisibleBit|vk.MemoryPropertyHostCoherentBit),
+		vk.MemoryPropertyFlags(vk.MemoryPropertyHostCachedBit), "offscreen readback")
+	if err != nil {
+		t.Destroy()
+		return nil, err
+	}
+	if t.commandPool, err = CreateCommandPool(device, graphicsFamily, vk.CommandPoolCreateFlags(vk.CommandPoolCreateResetCommandBufferBit)); err != nil {
+		t.Destroy()
+		return nil, err
+	}
+	commandBuffers, err := AllocateCommandBuffers(device, t.commandPool, 1)
+	if err != nil {
+		t.Destroy()
+		return nil, err
+	}
+	t.commandBuffer = commandBuffers[0]
+	fenceCreateInfo := vk.FenceCreateInfo{SType: vk.StructureTypeFenceCreateInfo}
+	if t.fence, err = driver.CreateFence(device, &fenceCreateInfo); err != nil {
+		t.Destroy()
+		return nil, err
+	}
+	return t, nil
+}
+
+// createOffscreenRenderPass clears the image, draws to it and leaves it
+// ready to be copied from.
+func createOffscreenRenderPass(device vk.Device, format vk.Format) (vk.RenderPass, error) {
+	attachmentDescriptions := []vk.AttachmentDescription{
+		ColorAttachmentDescription(format, vk.SampleCount1Bit, AttachmentTransferSrc),
+	}
+	subpassDescriptions := []vk.SubpassDescription{{
+		PipelineBindPoint:    vk.PipelineBindPointGraphics,
+		ColorAttachmentCount: 1,
+		PColorAttachments: []vk.AttachmentReference{{
+			Attachment: 0,
+			Layout:     vk.ImageLayoutColorAttachmentOptimal,
+		}},
+	}}
+	// The clear waits for the copy of the previous render, the copy waits for the draws
+	dependencies := SubpassDependencies(attachmentDescriptions)
+	renderPassCreateInfo := vk.RenderPassCreateInfo{
+		SType:           vk.StructureTypeRenderPassCreateInfo,
+		AttachmentCount: uint32(len(attachmentDescriptions)),
+		PAttachments:    attachmentDescriptions,
+		SubpassCount:    uint32(len(subpassDescriptions)),
+		PSubpasses:      subpassDescriptions,
+		DependencyCount: uint32(len(dependencies)),
+		PDependencies:   dependencies,
+	}
+	return driver.CreateRenderPass(device, &renderPassCreateInfo)
+}
+
+// Render begins the render pass, sets the viewport and scissor to the
+// whole image and calls record to bind pipelines and draw. It then copies
+// the image to the readback buffer, waits for the GPU and returns the pixels.
+func (t *OffscreenTarget) Render(record func(commandBuffer vk.CommandBuffer)) (*image.NRGBA, error) {
+	cmd := t.commandBuffer
+	commandBufferBeginInfo := vk.CommandBufferBeginInfo{
+		SType: vk.StructureTypeCommandBufferBeginInfo,
+		Flags: vk.CommandBufferUsageFlags(vk.CommandBufferUsageOneTimeSubmitBit),
+	}
+	if err := driver.BeginCommandBuffer(cmd, &commandBufferBeginInfo); err != nil {
+		return nil, err
+	}
+	renderPassBeginInfo := vk.RenderPassBeginInfo{
+		SType:           vk.StructureTypeRenderPassBeginInfo,
+		RenderPass:      t.RenderPass,
+		Framebuffer:     t.Framebuffer,
+		RenderArea:      vk.Rect2D{Extent: t.Extent},
+		ClearValueCount: 1,
+		PClearValues:    []vk.ClearValue{vk.NewClearValue(t.clearColor[:])},
+	}
+	vk.CmdBeginRenderPass(cmd, &renderPassBeginInfo, vk.SubpassContentsInline)
+	CmdSetViewportAndScissor(cmd, t.Extent)
+	if record != nil {
+		record(cmd)
+	}
+	vk.CmdEndRenderPass(cmd)
+
+	// Rows are tightly packed in the buffer
+	region := vk.BufferImageCopy{
+		ImageSubresource: vk.ImageSubresourceLayers{
+			AspectMask: vk.ImageAspectFlags(vk.ImageAspectColorBit),
+			LayerCount: 1,
+		},
+		ImageExtent: vk.Extent3D{Width: t.Extent.Width, Height: t.Extent.Height, Depth: 1},
+	}
+	vk.CmdCopyImageToBuffer(cmd, t.Image, vk.ImageLayoutTransferSrcOptimal, t.readback, 1, []vk.BufferImageCopy{region})
+	// Make the copy visible to the host once the fence is signaled
+	bufferBarrier(cmd, t.readback, vk.PipelineStageTransferBit, vk.PipelineStageHostBit, vk.AccessTransferWriteBit, vk.AccessHostReadBit, vk.QueueFamilyIgnored, vk.QueueFamilyIgnored)
+	if err := driver.EndCommandBuffer(cmd); err != nil {
+		return nil, err
+	}
+	if err := driver.ResetFences(t.device, []vk.Fence{t.fence}); err != nil {
+		return nil, err
+	}
+	if err := submit(t.queue, cmd, nil, 0, nil, t.fence); err != nil {
+		return nil, err
+	}
+	if err := driver.WaitForFences(t.device, []vk.Fence{t.fence}, true, vk.MaxUint64); err != nil {
+		return nil, err
+	}
+	return t.readPixels()
+}
+
+func (t *OffscreenTarget) readPixels() (*image.NRGBA, error) {
+	mapped, err := t.readbackAllocation.Map()
+	if err != nil {
+		return nil, err
+	}
+	width, height := int(t.Extent.Width), int(t.Extent.Height)
+	img := image.NewNRGBA(image.Rect(0, 0, width, height))
+	copy(img.Pix, unsafe.Slice((*byte)(mapped), len(img.Pix)))
+	if bgra, _ := offscreenSwizzle(t.Format); bgra {
+		for idx := 0; idx < len(img.Pix); idx += 4 {
+			img.Pix[idx], img.Pix[idx+2] = img.Pix[idx+2], img.Pix[idx]
+		}
+	}
+	return img, nil
+}
+
+// offscreenSwizzle tells whether format is one OffscreenTarget can read
+// back, and whether red and blue are swapped in memory.
+func offscreenSwizzle(format vk.Format) (bgra bool, ok bool) {
+	switch format {
+	case vk.FormatR8g8b8a8Unorm, vk.FormatR8g8b8a8Srgb:
+		return false, true
+	case vk.FormatB8g8r8a8Unorm, vk.FormatB8g8r8a8Srgb:
+		return true, true
+	}
+	return false, false
+}
+
+// Destroy destroys everything NewOffscreenTarget created. The GPU must be
+// done with the target, which it is once Render returned.
+func (t *OffscreenTarget) Destroy() {
+	if t.fence != vk.NullFence {
+		driver.DestroyFence(t.device, t.fence)
+		t.fence = vk.NullFence
+	}
+	if t.commandPool != vk.NullCommandPool {
+		driver.DestroyCommandPool(t.device, t.commandPool)
+		t.commandPool = vk.NullCommandPool
+	}
+	if t.readback != vk.NullBuffer {
+		driver.DestroyBuffer(t.device, t.readback)
+		t.allocator.Free(t.readbackAllocation)
+		t.readback, t.readbackAllocation = vk.NullBuffer, nil
+	}
+	if t.Framebuffer != vk.NullFramebuffer {
+		driver.DestroyFramebuffer(t.device, t.Framebuffer)
+		t.Framebuffer = vk.NullFramebuffer
+	}
+	if t.RenderPass != vk.NullRenderPass {
+		driver.DestroyRenderPass(t.device, t.RenderPass)
+		t.RenderPass = vk.NullRenderPass
+	}
+	if t.View != vk.NullImageView {
+		driver.DestroyImageView(t.device, t.View)
+		t.View = vk.NullImageView
+	}
+	if t.Image != vk.NullImage {
+		driver.DestroyImage(t.device, t.Image)
+		t.allocator.Free(t.imageAllocation)
+		t.Image, t.imageAllocation = vk.NullImage, nil
+	}
+}
+
+// SaveImage writes img to path as PNG, or as binary PPM when path ends in
+// .ppm. PPM has no alpha channel, colors are composited on black.
+func SaveImage(path string, img image.Image) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	w := bufio.NewWriter(f)
+	if strings.EqualFold(filepath.Ext(path), ".ppm") {
+		err = WritePPM(w, img)
+	} else {
+		err = png.Encode(w, img)
+	}
+	if err == nil {
+		err = w.Flush()
+	}
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	return err
+}
+
+// WritePPM writes img as a binary (P6) PPM with 8 bits per channel.
+// http://netpbm.sourceforge.net/doc/ppm.html
+func WritePPM(w io.Writer, img image.Image) error {
+	bounds := img.Bounds()
+	if _, err := fmt.Fprintf(w, "P6\n%d %d\n255\n", bounds.Dx(), bounds.Dy()); err != nil {
+		return err
+	}
+	row := make([]byte, 0, bounds.Dx()*3)
+	for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
+		row = row[:0]
+		for x := bounds.Min.X; x < bounds.Max.X; x++ {
+			// The alpha premultiplied color of RGBA() is what a viewer would show on black
+			r, g, b, _ := img.At(x, y).RGBA()
+			row = append(row, byte(r>>8), byte(g>>8), byte(b>>8))
+		}
+		if _, err := w.Write(row); err != nil {
+			return err
+		}
+	}
+	return nil
+}
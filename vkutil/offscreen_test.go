@@ -0,0 +1,67 @@
+package vkutil_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/goodshailesh/My-Vulkan-Projects/vkutil"
+	"github.com/goodshailesh/My-Vulkan-Projects/vkutil/vkfake"
+	vk "github.com/vulkan-go/vulkan"
+)
+
+// newOffscreenTarget returns a 64x32 target on a fake device, destroyed when the test ends.
+func newOffscreenTarget(t *testing.T) (*vkfake.Driver, *vkutil.OffscreenTarget, vk.Device) {
+	t.Helper()
+	fake, physicalDevice, _, device, queueFamilies := newDevice(t)
+	allocator := vkutil.NewAllocator(device, physicalDevice, vkutil.AllocatorConfig{})
+	queues := vkutil.GetDeviceQueues(device, queueFamilies)
+	target, err := vkutil.NewOffscreenTarget(device, allocator, queueFamilies.Graphics, queues.Graphics, vkutil.OffscreenConfig{Extent: vk.Extent2D{Width: 64, Height: 32}})
+	if err != nil {
+		t.Fatalf("NewOffscreenTarget: %v", err)
+	}
+	t.Cleanup(func() {
+		target.Destroy()
+		destroyAllocator(t, allocator)
+	})
+	return fake, target, device
+}
+
+func TestOffscreenTarget(t *testing.T) {
+	_, target, _ := newOffscreenTarget(t)
+	if target.Format != vk.FormatR8g8b8a8Unorm {
+		t.Errorf("Format = %v, want R8G8B8A8_UNORM", target.Format)
+	}
+	if target.RenderPass == vk.NullRenderPass || target.Framebuffer == vk.NullFramebuffer {
+		t.Errorf("RenderPass, Framebuffer = %v, %v", target.RenderPass, target.Framebuffer)
+	}
+}
+
+func TestOffscreenTargetFormat(t *testing.T) {
+	_, physicalDevice, _, device, queueFamilies := newDevice(t)
+	allocator := vkutil.NewAllocator(device, physicalDevice, vkutil.AllocatorConfig{})
+	defer destroyAllocator(t, allocator)
+	queues := vkutil.GetDeviceQueues(device, queueFamilies)
+	_, err := vkutil.NewOffscreenTarget(device, allocator, queueFamilies.Graphics, queues.Graphics, vkutil.OffscreenConfig{Format: vk.FormatR16g16b16a16Sfloat})
+	if err == nil || !strings.Contains(err.Error(), "not an 8 bit RGBA or BGRA format") {
+		t.Errorf("NewOffscreenTarget error = %v", err)
+	}
+}
+
+func TestOffscreenTargetFramebufferFails(t *testing.T) {
+	fake, physicalDevice, _, device, queueFamilies := newDevice(t)
+	allocator := vkutil.NewAllocator(device, physicalDevice, vkutil.AllocatorConfig{})
+	defer destroyAllocator(t, allocator)
+	queues := vkutil.GetDeviceQueues(device, queueFamilies)
+	fake.Results["vkCreateFramebuffer"] = vk.ErrorOutOfDeviceMemory
+	// What was created so far is destroyed, newDevice checks nothing is left
+	if _, err := vkutil.NewOffscreenTarget(device, allocator, queueFamilies.Graphics, queues.Graphics, vkutil.OffscreenConfig{}); err == nil {
+		t.Error("NewOffscreenTarget succeeded without a framebuffer")
+	}
+}
+
+func destroyAllocator(t *testing.T, allocator *vkutil.Allocator) {
+	t.Helper()
+	if err := allocator.Destroy(); err != nil {
+		t.Errorf("Allocator.Destroy: %v", err)
+	}
+}
@@ -0,0 +1,344 @@
+package vkutil
+
+import (
+	"errors"
+	"fmt"
+
+	vk "github.com/vulkan-go/vulkan"
+)
+
+// ShaderStage is one programmable stage of a pipeline.
+type ShaderStage struct {
+	Stage vk.ShaderStageFlagBits
+	SPIRV *SPIRV
+	// EntryPoint names the function of SPIRV to run, empty for the first one
+	// declared for Stage.
+	EntryPoint string
+}
+
+// GraphicsPipelineBuilder describes a graphics pipeline.
+// NewGraphicsPipelineBuilder fills it for opaque, filled, back-face culled
+// triangles with a dynamic viewport and scissor; change the fields, add the
+// shaders with AddShader and call Build.
+// https://www.khronos.org/registry/vulkan/specs/1.2-extensions/html/vkspec.html#pipelines-graphics
+type GraphicsPipelineBuilder struct {
+	// RenderPass and Subpass the pipeline draws in. It can also be used with
+	// any render pass compatible with this one (same attachment formats and
+	// sample counts).
+	RenderPass vk.RenderPass
+	Subpass    uint32
+
+	Stages []ShaderStage
+
+	// Vertex input, leave both empty when the vertex shader makes up its
+	// vertices from gl_VertexIndex.
+	VertexBindings   []vk.VertexInputBindingDescription
+	VertexAttributes []vk.VertexInputAttributeDescription
+
+	Topology         vk.PrimitiveTopology
+	PrimitiveRestart bool
+
+	// Rasterization
+	PolygonMode vk.PolygonMode
+	CullMode    vk.CullModeFlags
+	FrontFace   vk.FrontFace
+	LineWidth   float32
+
+	// Samples must match the attachments of the subpass. MinSampleShading
+	// above 0 enables sample shading, which needs the sampleRateShading feature.
+	Samples          vk.SampleCountFlagBits
+	MinSampleShading float32
+
+	// Depth testing, the subpass needs a depth attachment when enabled.
+	DepthTest      bool
+	DepthWrite     bool
+	DepthCompareOp vk.CompareOp
+
+	// Blend has one entry per color attachment of the subpass, see OpaqueBlend and AlphaBlend.
+	Blend []vk.PipelineColorBlendAttachmentState
+
+	// DynamicStates are set while recording instead of baked in. With
+	// vk.DynamicStateViewport and vk.DynamicStateScissor the pipeline
+	// survives a swapchain resize, see CmdSetViewportAndScissor. Otherwise
+	// Viewport and Scissor are used, see SetExtent.
+	DynamicStates []vk.DynamicState
+	Viewport      vk.Viewport
+	Scissor       vk.Rect2D
+
+	// Layout is created from SetLayouts and PushConstants when it is
+	// vk.NullPipelineLayout, and destroyed with the pipeline.
+	Layout        vk.PipelineLayout
+	SetLayouts    []vk.DescriptorSetLayout
+	PushConstants []vk.PushConstantRange
+}
+
+// GraphicsPipeline is what GraphicsPipelineBuilder.Build created.
+type GraphicsPipeline struct {
+	Pipeline vk.Pipeline
+	Layout   vk.PipelineLayout
+
+	device     vk.Device
+	ownsLayout bool
+}
+
+// NewGraphicsPipelineBuilder returns a builder with the defaults described
+// on GraphicsPipelineBuilder, drawing in subpass 0 of renderPass.
+func NewGraphicsPipelineBuilder(renderPass vk.RenderPass) *GraphicsPipelineBuilder {
+	return &GraphicsPipelineBuilder{
+		RenderPass:     renderPass,
+		Topology:       vk.PrimitiveTopologyTriangleList,
+		PolygonMode:    vk.PolygonModeFill,
+		CullMode:       vk.CullModeFlags(vk.CullModeBackBit),
+		FrontFace:      vk.FrontFaceClockwise,
+		LineWidth:      1.0,
+		Samples:        vk.SampleCount1Bit,
+		DepthCompareOp: vk.CompareOpLess,
+		Blend:          []vk.PipelineColorBlendAttachmentState{OpaqueBlend()},
+		DynamicStates:  []vk.DynamicState{vk.DynamicStateViewport, vk.DynamicStateScissor},
+	}
+}
+
+// AddShader adds the stage of spirv to the pipeline, running its first
+// entry point for that stage.
+func (b *GraphicsPipelineBuilder) AddShader(spirv *SPIRV, stage vk.ShaderStageFlagBits) {
+	b.Stages = append(b.Stages, ShaderStage{Stage: stage, SPIRV: spirv})
+}
+
+// SetExtent bakes a viewport and scissor covering extent into the pipeline
+// and drops the dynamic viewport and scissor. The pipeline then has to be
+// rebuilt when the swapchain is resized.
+func (b *GraphicsPipelineBuilder) SetExtent(extent vk.Extent2D) {
+	b.Viewport = vk.Viewport{Width: float32(extent.Width), Height: float32(extent.Height), MaxDepth: 1.0}
+	b.Scissor = vk.Rect2D{Extent: extent}
+	var dynamicStates []vk.DynamicState
+	for _, d := range b.DynamicStates {
+		if d != vk.DynamicStateViewport && d != vk.DynamicStateScissor {
+			dynamicStates = append(dynamicStates, d)
+		}
+	}
+	b.DynamicStates = dynamicStates
+}
+
+// OpaqueBlend writes the fragment color as is.
+func OpaqueBlend() vk.PipelineColorBlendAttachmentState {
+	return vk.PipelineColorBlendAttachmentState{
+		ColorWriteMask: vk.ColorComponentFlags(vk.ColorComponentRBit | vk.ColorComponentGBit | vk.ColorComponentBBit | vk.ColorComponentABit),
+	}
+}
+
+// AlphaBlend mixes the fragment color over the attachment by its alpha:
+// color = src.rgb*src.a + dst.rgb*(1-src.a).
+func AlphaBlend() vk.PipelineColorBlendAttachmentState {
+	blend := OpaqueBlend()
+	blend.BlendEnable = vk.True
+	blend.SrcColorBlendFactor = vk.BlendFactorSrcAlpha
+	blend.DstColorBlendFactor = vk.BlendFactorOneMinusSrcAlpha
+	blend.ColorBlendOp = vk.BlendOpAdd
+	blend.SrcAlphaBlendFactor = vk.BlendFactorOne
+	blend.DstAlphaBlendFactor = vk.BlendFactorZero
+	blend.AlphaBlendOp = vk.BlendOpAdd
+	return blend
+}
+
+// validate catches the mistakes that would otherwise only show up as a
+// validation layer message or a crash in the driver.
+func (b *GraphicsPipelineBuilder) validate() error {
+	var errs []error
+	if b.RenderPass == vk.NullRenderPass {
+		errs = append(errs, errors.New("no render pass"))
+	}
+	seen := make(map[vk.ShaderStageFlagBits]bool)
+	for _, s := range b.Stages {
+		if s.Stage == vk.ShaderStageComputeBit {
+			errs = append(errs, errors.New("a compute shader cannot be part of a graphics pipeline"))
+		}
+		if seen[s.Stage] {
+			errs = append(errs, fmt.Errorf("%v stage added twice", ShaderStageName(s.Stage)))
+		}
+		seen[s.Stage] = true
+		if s.SPIRV == nil {
+			errs = append(errs, fmt.Errorf("%v stage has no SPIR-V", ShaderStageName(s.Stage)))
+		} else if _, err := s.SPIRV.EntryPoint(s.Stage, s.EntryPoint); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	if !seen[vk.ShaderStageVertexBit] {
+		errs = append(errs, errors.New("no vertex shader"))
+	}
+	bindings := make(map[uint32]bool)
+	for _, binding := range b.VertexBindings {
+		bindings[binding.Binding] = true
+	}
+	for _, attribute := range b.VertexAttributes {
+		if !bindings[attribute.Binding] {
+			errs = append(errs, fmt.Errorf("vertex attribute at location %v reads the undescribed binding %v", attribute.Location, attribute.Binding))
+		}
+	}
+	dynamic := make(map[vk.DynamicState]bool)
+	for _, d := range b.DynamicStates {
+		dynamic[d] = true
+	}
+	if !dynamic[vk.DynamicStateViewport] && (b.Viewport.Width == 0 || b.Viewport.Height == 0) {
+		errs = append(errs, errors.New("the viewport is neither dynamic nor set, see SetExtent"))
+	}
+	if !dynamic[vk.DynamicStateScissor] && (b.Scissor.Extent.Width == 0 || b.Scissor.Extent.Height == 0) {
+		errs = append(errs, errors.New("the scissor is neither dynamic nor set, see SetExtent"))
+	}
+	if err := errors.Join(errs...); err != nil {
+		return fmt.Errorf("invalid graphics pipeline: %w", err)
+	}
+	return nil
+}
+
+// Build creates the pipeline, and its layout when Layout is not set. The
+// shader modules only live for the duration of the call.
+func (b *GraphicsPipelineBuilder) Build(device vk.Device) (*GraphicsPipeline, error) {
+	if err := b.validate(); err != nil {
+		return nil, err
+	}
+	p := &GraphicsPipeline{device: device, Layout: b.Layout}
+	if p.Layout == vk.NullPipelineLayout {
+		var err error
+		if p.Layout, err = CreatePipelineLayout(device, b.SetLayouts, b.PushConstants); err != nil {
+			return nil, err
+		}
+		p.ownsLayout = true
+	}
+
+	// The modules can go as soon as the pipeline is created
+	var stages []vk.PipelineShaderStageCreateInfo
+	defer func() {
+		for _, stage := range stages {
+			driver.DestroyShaderModule(device, stage.Module)
+		}
+	}()
+	for _, s := range b.Stages {
+		entryPoint, _ := s.SPIRV.EntryPoint(s.Stage, s.EntryPoint)
+		module, err := CreateShaderModule(device, s.SPIRV)
+		if err != nil {
+			p.Destroy()
+			return nil, err
+		}
+		stages = append(stages, vk.PipelineShaderStageCreateInfo{
+			SType:  vk.StructureTypePipelineShaderStageCreateInfo,
+			Stage:  s.Stage,
+			Module: module,
+			PName:  safeString(entryPoint),
+		})
+	}
+
+	vertexInputState := vk.PipelineVertexInputStateCreateInfo{
+		SType:                           vk.StructureTypePipelineVertexInputStateCreateInfo,
+		VertexBindingDescriptionCount:   uint32(len(b.VertexBindings)),
+		PVertexBindingDescriptions:      b.VertexBindings,
+		VertexAttributeDescriptionCount: uint32(len(b.VertexAttributes)),
+		PVertexAttributeDescriptions:    b.VertexAttributes,
+	}
+	inputAssemblyState := vk.PipelineInputAssemblyStateCreateInfo{
+		SType:                  vk.StructureTypePipelineInputAssemblyStateCreateInfo,
+		Topology:               b.Topology,
+		PrimitiveRestartEnable: bool32(b.PrimitiveRestart),
+	}
+	// The counts matter even when the viewport and scissor are dynamic
+	viewportState := vk.PipelineViewportStateCreateInfo{
+		SType:         vk.StructureTypePipelineViewportStateCreateInfo,
+		ViewportCount: 1,
+		PViewports:    []vk.Viewport{b.Viewport},
+		ScissorCount:  1,
+		PScissors:     []vk.Rect2D{b.Scissor},
+	}
+	rasterizationState := vk.PipelineRasterizationStateCreateInfo{
+		SType:       vk.StructureTypePipelineRasterizationStateCreateInfo,
+		PolygonMode: b.PolygonMode,
+		CullMode:    b.CullMode,
+		FrontFace:   b.FrontFace,
+		LineWidth:   b.LineWidth,
+	}
+	multisampleState := vk.PipelineMultisampleStateCreateInfo{
+		SType:                vk.StructureTypePipelineMultisampleStateCreateInfo,
+		RasterizationSamples: b.Samples,
+		SampleShadingEnable:  bool32(b.MinSampleShading > 0),
+		MinSampleShading:     b.MinSampleShading,
+	}
+	depthStencilState := vk.PipelineDepthStencilStateCreateInfo{
+		SType:            vk.StructureTypePipelineDepthStencilStateCreateInfo,
+		DepthTestEnable:  bool32(b.DepthTest),
+		DepthWriteEnable: bool32(b.DepthWrite),
+		DepthCompareOp:   b.DepthCompareOp,
+		MaxDepthBounds:   1.0,
+	}
+	colorBlendState := vk.PipelineColorBlendStateCreateInfo{
+		SType:           vk.StructureTypePipelineColorBlendStateCreateInfo,
+		AttachmentCount: uint32(len(b.Blend)),
+		PAttachments:    b.Blend,
+	}
+	pipelineCreateInfo := vk.GraphicsPipelineCreateInfo{
+		SType:               vk.StructureTypeGraphicsPipelineCreateInfo,
+		StageCount:          uint32(len(stages)),
+		PStages:             stages,
+		PVertexInputState:   &vertexInputState,
+		PInputAssemblyState: &inputAssemblyState,
+		PViewportState:      &viewportState,
+		PRasterizationState: &rasterizationState,
+		PMultisampleState:   &multisampleState,
+		PDepthStencilState:  &depthStencilState,
+		PColorBlendState:    &colorBlendState,
+		Layout:              p.Layout,
+		RenderPass:          b.RenderPass,
+		Subpass:             b.Subpass,
+		BasePipelineIndex:   -1,
+	}
+	if len(b.DynamicStates) > 0 {
+		pipelineCreateInfo.PDynamicState = &vk.PipelineDynamicStateCreateInfo{
+			SType:             vk.StructureTypePipelineDynamicStateCreateInfo,
+			DynamicStateCount: uint32(len(b.DynamicStates)),
+			PDynamicStates:    b.DynamicStates,
+		}
+	}
+	pipelines, err := driver.CreateGraphicsPipelines(device, []vk.GraphicsPipelineCreateInfo{pipelineCreateInfo})
+	if err != nil {
+		p.Destroy()
+		return nil, err
+	}
+	p.Pipeline = pipelines[0]
+	return p, nil
+}
+
+// Destroy destroys the pipeline, and its layout if Build created it.
+func (p *GraphicsPipeline) Destroy() {
+	if p.Pipeline != vk.NullPipeline {
+		driver.DestroyPipeline(p.device, p.Pipeline)
+		p.Pipeline = vk.NullPipeline
+	}
+	if p.ownsLayout && p.Layout != vk.NullPipelineLayout {
+		driver.DestroyPipelineLayout(p.device, p.Layout)
+		p.Layout = vk.NullPipelineLayout
+	}
+}
+
+// CreatePipelineLayout creates a pipeline layout with the descriptor set
+// layouts and push constant ranges the shaders of a pipeline use.
+func CreatePipelineLayout(device vk.Device, setLayouts []vk.DescriptorSetLayout, pushConstants []vk.PushConstantRange) (vk.PipelineLayout, error) {
+	var pipelineLayoutCreateInfo = vk.PipelineLayoutCreateInfo{
+		SType:                  vk.StructureTypePipelineLayoutCreateInfo,
+		SetLayoutCount:         uint32(len(setLayouts)),
+		PSetLayouts:            setLayouts,
+		PushConstantRangeCount: uint32(len(pushConstants)),
+		PPushConstantRanges:    pushConstants,
+	}
+	return driver.CreatePipelineLayout(device, &pipelineLayoutCreateInfo)
+}
+
+// CmdSetViewportAndScissor sets a viewport and scissor covering extent, for
+// pipelines with a dynamic viewport and scissor.
+func CmdSetViewportAndScissor(commandBuffer vk.CommandBuffer, extent vk.Extent2D) {
+	vk.CmdSetViewport(commandBuffer, 0, 1, []vk.Viewport{{Width: float32(extent.Width), Height: float32(extent.Height), MaxDepth: 1.0}})
+	vk.CmdSetScissor(commandBuffer, 0, 1, []vk.Rect2D{{Extent: extent}})
+}
+
+func bool32(b bool) vk.Bool32 {
+	if b {
+		return vk.True
+	}
+	return vk.False
+}
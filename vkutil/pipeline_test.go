@@ -0,0 +1,113 @@
+package vkutil_test
+
+import (
+	"encoding/binary"
+	"strings"
+	"testing"
+
+	"github.com/goodshailesh/My-Vulkan-Projects/vkutil"
+	"github.com/goodshailesh/My-Vulkan-Projects/vkutil/vkfake"
+	vk "github.com/vulkan-go/vulkan"
+)
+
+// vertexShader is the smallest module ParseSPIRV accepts: the header and a
+// vertex entry point "main".
+func vertexShader(t *testing.T) *vkutil.SPIRV {
+	t.Helper()
+	words := []uint32{
+		vkutil.SPIRVMagic, 0x00010000, 0, 2, 0,
+		// OpEntryPoint Vertex %1 "main"
+		5<<16 | 15, 0, 1, 0x6e69616d, 0,
+	}
+	code := make([]byte, len(words)*4)
+	for idx, w := range words {
+		binary.LittleEndian.PutUint32(code[idx*4:], w)
+	}
+	spirv, err := vkutil.ParseSPIRV("vertex.spv", code)
+	if err != nil {
+		t.Fatalf("ParseSPIRV: %v", err)
+	}
+	return spirv
+}
+
+// newRenderPass creates a render pass of a single subpass drawing to one
+// color attachment on a fake device, destroyed when the test ends.
+func newRenderPass(t *testing.T) (*vkfake.Driver, vk.RenderPass, vk.Device) {
+	t.Helper()
+	fake, _, _, device, _ := newDevice(t)
+	renderPass, err := fake.CreateRenderPass(device, &vk.RenderPassCreateInfo{
+		SType:           vk.StructureTypeRenderPassCreateInfo,
+		AttachmentCount: 1,
+		PAttachments:    []vk.AttachmentDescription{{Format: vk.FormatR8g8b8a8Unorm, Samples: vk.SampleCount1Bit}},
+		SubpassCount:    1,
+		PSubpasses: []vk.SubpassDescription{{
+			PipelineBindPoint:    vk.PipelineBindPointGraphics,
+			ColorAttachmentCount: 1,
+			PColorAttachments:    []vk.AttachmentReference{{Attachment: 0, Layout: vk.ImageLayoutColorAttachmentOptimal}},
+		}},
+	})
+	if err != nil {
+		t.Fatalf("CreateRenderPass: %v", err)
+	}
+	t.Cleanup(func() { fake.DestroyRenderPass(device, renderPass) })
+	return fake, renderPass, device
+}
+
+func liveKinds(live []string) map[string]int {
+	kinds := make(map[string]int)
+	for _, l := range live {
+		kinds[strings.Fields(l)[0]]++
+	}
+	return kinds
+}
+
+func TestGraphicsPipelineBuild(t *testing.T) {
+	fake, renderPass, device := newRenderPass(t)
+	b := vkutil.NewGraphicsPipelineBuilder(renderPass)
+	b.AddShader(vertexShader(t), vk.ShaderStageVertexBit)
+	p, err := b.Build(device)
+	if err != nil {
+		t.Fatalf("Build: %v", err)
+	}
+	kinds := liveKinds(fake.Live())
+	if kinds["VkShaderModule"] != 0 {
+		t.Errorf("%v shader modules left after Build", kinds["VkShaderModule"])
+	}
+	if kinds["VkPipeline"] != 1 || kinds["VkPipelineLayout"] != 1 {
+		t.Errorf("live objects %v, want a pipeline and its layout", kinds)
+	}
+	p.Destroy()
+	kinds = liveKinds(fake.Live())
+	if kinds["VkPipeline"] != 0 || kinds["VkPipelineLayout"] != 0 {
+		t.Errorf("live objects %v after Destroy", kinds)
+	}
+}
+
+func TestGraphicsPipelineBuildFails(t *testing.T) {
+	fake, renderPass, device := newRenderPass(t)
+	b := vkutil.NewGraphicsPipelineBuilder(renderPass)
+	b.AddShader(vertexShader(t), vk.ShaderStageVertexBit)
+	// The render pass has a single subpass
+	b.Subpass = 1
+	if _, err := b.Build(device); err == nil || !strings.Contains(err.Error(), "subpass 1 of a render pass with 1") {
+		t.Fatalf("Build error = %v", err)
+	}
+	kinds := liveKinds(fake.Live())
+	if kinds["VkShaderModule"] != 0 || kinds["VkPipelineLayout"] != 0 || kinds["VkPipeline"] != 0 {
+		t.Errorf("live objects %v after a failed Build", kinds)
+	}
+}
+
+func TestGraphicsPipelineBuildInvalid(t *testing.T) {
+	b := vkutil.NewGraphicsPipelineBuilder(vk.NullRenderPass)
+	b.SetExtent(vk.Extent2D{})
+	_, err := b.Build(nil)
+	if err == nil {
+		t.Fatal("Build succeeded")
+	}
+	for _, want := range []string{"no render pass", "no vertex shader", "the viewport is neither dynamic nor set", "the scissor is neither dynamic nor set"} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("Build error %q does not say %q", err, want)
+		}
+	}
+}
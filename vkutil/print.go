@@ -0,0 +1,62 @@
+package vkutil
+
+import (
+	"fmt"
+
+	vk "github.com/vulkan-go/vulkan"
+)
+
+var memoryPropertyFlagNames = []struct {
+	name string
+	flag vk.MemoryPropertyFlagBits
+}{
+	{"MemoryPropertyDeviceLocalBit", vk.MemoryPropertyDeviceLocalBit},
+	{"MemoryPropertyHostVisibleBit", vk.MemoryPropertyHostVisibleBit},
+	{"MemoryPropertyHostCoherentBit", vk.MemoryPropertyHostCoherentBit},
+	{"MemoryPropertyHostCachedBit", vk.MemoryPropertyHostCachedBit},
+	{"MemoryPropertyLazilyAllocatedBit", vk.MemoryPropertyLazilyAllocatedBit},
+	{"MemoryPropertyProtectedBit", vk.MemoryPropertyProtectedBit},
+}
+
+// PrintDeviceQueueFamilyProperties prints the queue count and capabilities of every family.
+func PrintDeviceQueueFamilyProperties(qfs []vk.QueueFamilyProperties) {
+	fmt.Println("Print device queue properties..............")
+	fmt.Printf("\tFound %v families\n", len(qfs))
+	for idx, qf := range qfs {
+		fmt.Printf("\tFamily %v, Number of queues in the family (vk.QueueCount): %v\n", idx, qf.QueueCount)
+		flagBits := vk.QueueFlagBits(qf.QueueFlags)
+		if flagBits&vk.QueueGraphicsBit != 0x00000000 {
+			fmt.Printf("\t\tVK_QUEUE_GRAPHICS_BIT \t\t[vk.QueueGraphicsBit] \t\t[0x00000001\\1]\n")
+		}
+		if flagBits&vk.QueueComputeBit != 0x00000000 {
+			fmt.Printf("\t\tVK_QUEUE_COMPUTE_BIT \t\t[vk.QueueComputeBit] \t\t[0x00000002\\2]\n")
+		}
+		if flagBits&vk.QueueTransferBit != 0x00000000 {
+			fmt.Printf("\t\tVK_QUEUE_TRANSFER_BIT \t\t[vk.QueueTransferBit] \t\t[0x00000004\\4]\n")
+		}
+		if flagBits&vk.QueueSparseBindingBit != 0x00000000 {
+			fmt.Printf("\t\tVK_QUEUE_SPARSE_BINDING_BIT \t[vk.QueueSparseBindingBit] \t[0x00000008\\8]\n")
+		}
+		if flagBits&vk.QueueProtectedBit != 0x00000000 {
+			fmt.Printf("\t\tVK_QUEUE_PROTECTED_BIT \t\t[vk.QueueProtectedBit] \t\t[0x00000010\\16]\n")
+		}
+	}
+}
+
+// PrintPhysicalDeviceMemoryProperties prints the heap and property flags of every memory type.
+func PrintPhysicalDeviceMemoryProperties(memoryProperties vk.PhysicalDeviceMemoryProperties) {
+	fmt.Println("Printing Device memory properties.......................")
+	fmt.Printf("\t*\tMemoryTypeCount: %v\t\t \t\t\n", memoryProperties.MemoryTypeCount)
+	fmt.Printf("\t*\tMemoryHeapCount: %v\t\t \t\t\n", memoryProperties.MemoryHeapCount)
+	for idx := uint32(0); idx < memoryProperties.MemoryTypeCount; idx++ {
+		memoryType := memoryProperties.MemoryTypes[idx]
+		fmt.Println("\t* MemoryTypes index: ", idx)
+		fmt.Printf("\t\t*\tMemoryType HeapIndex: %v\t\t \t\t\n", memoryType.HeapIndex)
+		fmt.Printf("\t\t*\tMemoryType PropertyFlags: \t\t \t\t\n")
+		for _, f := range memoryPropertyFlagNames {
+			if f.flag&vk.MemoryPropertyFlagBits(memoryType.PropertyFlags) != 0x00000000 {
+				fmt.Printf("\t\t\t* Memory Property Flags: %v\t\t \t\t\n", f.name)
+			}
+		}
+	}
+}
@@ -0,0 +1,174 @@
+package vkutil
+
+import (
+	"fmt"
+	"strings"
+
+	vk "github.com/vulkan-go/vulkan"
+)
+
+// QueueFamilyIndices are the queue families a program submits to. A family
+// that was not found is vk.QueueFamilyIgnored. Several roles often share the
+// same family, Unique lists each family once.
+type QueueFamilyIndices struct {
+	Graphics uint32
+	Present  uint32
+	Compute  uint32
+	Transfer uint32
+}
+
+// Queues are the queues GetDeviceQueues fetched for QueueFamilyIndices.
+// Roles sharing a family share the queue.
+type Queues struct {
+	Graphics vk.Queue
+	Present  vk.Queue
+	Compute  vk.Queue
+	Transfer vk.Queue
+}
+
+// FindQueueFamilies walks the queue families of physicalDevice and picks:
+//   - Graphics: a family with VK_QUEUE_GRAPHICS_BIT, one that can also present
+//     to surface if there is one.
+//   - Present: the graphics family if it can present, else the first family
+//     that can. It is vk.QueueFamilyIgnored when surface is vk.NullSurface.
+//   - Compute: a dedicated compute family (no graphics) if there is one,
+//     else any compute family.
+//   - Transfer: a dedicated transfer family (no graphics nor compute) if
+//     there is one, else one without graphics, else the graphics or compute
+//     family, which support transfers implicitly.
+func FindQueueFamilies(physicalDevice vk.PhysicalDevice, surface vk.Surface) (QueueFamilyIndices, error) {
+	families := GetPhysicalDeviceQueueFamilyProperties(physicalDevice)
+	canPresent := make([]bool, len(families))
+	if surface != vk.NullSurface {
+		for idx := range families {
+			supported, err := driver.GetPhysicalDeviceSurfaceSupport(physicalDevice, uint32(idx), surface)
+			if err != nil {
+				return QueueFamilyIndices{}, err
+			}
+			canPresent[idx] = supported
+		}
+	}
+	return resolveQueueFamilies(families, canPresent), nil
+}
+
+func resolveQueueFamilies(families []vk.QueueFamilyProperties, canPresent []bool) QueueFamilyIndices {
+	q := QueueFamilyIndices{
+		Graphics: vk.QueueFamilyIgnored,
+		Present:  vk.QueueFamilyIgnored,
+		Compute:  vk.QueueFamilyIgnored,
+		Transfer: vk.QueueFamilyIgnored,
+	}
+	has := func(idx int, bit vk.QueueFlagBits) bool {
+		return families[idx].QueueCount > 0 && vk.QueueFlagBits(families[idx].QueueFlags)&bit != 0
+	}
+	// first returns the first family accepted by match, or vk.QueueFamilyIgnored
+	first := func(match func(idx int) bool) uint32 {
+		for idx := range families {
+			if match(idx) {
+				return uint32(idx)
+			}
+		}
+		return vk.QueueFamilyIgnored
+	}
+	orElse := func(found, fallback uint32) uint32 {
+		if found != vk.QueueFamilyIgnored {
+			return found
+		}
+		return fallback
+	}
+
+	q.Graphics = orElse(
+		first(func(idx int) bool { return has(idx, vk.QueueGraphicsBit) && canPresent[idx] }),
+		first(func(idx int) bool { return has(idx, vk.QueueGraphicsBit) }))
+	if q.Graphics != vk.QueueFamilyIgnored && canPresent[q.Graphics] {
+		q.Present = q.Graphics
+	} else {
+		q.Present = first(func(idx int) bool { return families[idx].QueueCount > 0 && canPresent[idx] })
+	}
+	q.Compute = orElse(
+		first(func(idx int) bool { return has(idx, vk.QueueComputeBit) && !has(idx, vk.QueueGraphicsBit) }),
+		first(func(idx int) bool { return has(idx, vk.QueueComputeBit) }))
+	q.Transfer = orElse(
+		first(func(idx int) bool {
+			return has(idx, vk.QueueTransferBit) && !has(idx, vk.QueueGraphicsBit) && !has(idx, vk.QueueComputeBit)
+		}),
+		orElse(
+			first(func(idx int) bool { return has(idx, vk.QueueTransferBit) && !has(idx, vk.QueueGraphicsBit) }),
+			orElse(q.Graphics, q.Compute)))
+	return q
+}
+
+// Require returns an error naming the missing families when the graphics
+// family, or the present family if present is true, was not found.
+func (q QueueFamilyIndices) Require(present bool) error {
+	var missing []string
+	if q.Graphics == vk.QueueFamilyIgnored {
+		missing = append(missing, "graphics")
+	}
+	if present && q.Present == vk.QueueFamilyIgnored {
+		missing = append(missing, "present")
+	}
+	if len(missing) > 0 {
+		return fmt.Errorf("no queue family found for %v", strings.Join(missing, ", "))
+	}
+	return nil
+}
+
+// Unique returns the distinct families that were found, in the order
+// graphics, present, compute, transfer.
+func (q QueueFamilyIndices) Unique() []uint32 {
+	var unique []uint32
+	for _, idx := range []uint32{q.Graphics, q.Present, q.Compute, q.Transfer} {
+		if idx == vk.QueueFamilyIgnored {
+			continue
+		}
+		seen := false
+		for _, u := range unique {
+			if u == idx {
+				seen = true
+				break
+			}
+		}
+		if !seen {
+			unique = append(unique, idx)
+		}
+	}
+	return unique
+}
+
+// SwapchainSharing returns the sharing mode and queue family indices for the
+// swapchain images: exclusive when one family draws and presents, concurrent
+// between the two families otherwise, so no ownership transfer is needed.
+func (q QueueFamilyIndices) SwapchainSharing() (vk.SharingMode, []uint32) {
+	if q.Present == vk.QueueFamilyIgnored || q.Present == q.Graphics {
+		return vk.SharingModeExclusive, []uint32{q.Graphics}
+	}
+	return vk.SharingModeConcurrent, []uint32{q.Graphics, q.Present}
+}
+
+func (q QueueFamilyIndices) String() string {
+	name := func(idx uint32) string {
+		if idx == vk.QueueFamilyIgnored {
+			return "none"
+		}
+		return fmt.Sprint(idx)
+	}
+	return fmt.Sprintf("graphics %v, present %v, compute %v, transfer %v", name(q.Graphics), name(q.Present), name(q.Compute), name(q.Transfer))
+}
+
+// GetDeviceQueues returns queue 0 of every family in q. The device must have
+// been created by CreateDevice with the same indices.
+func GetDeviceQueues(device vk.Device, q QueueFamilyIndices) Queues {
+	get := func(idx uint32) vk.Queue {
+		if idx == vk.QueueFamilyIgnored {
+			return nil
+		}
+		return GetDeviceQueue(device, idx, 0)
+	}
+	return Queues{
+		Graphics: get(q.Graphics),
+		Present:  get(q.Present),
+		Compute:  get(q.Compute),
+		Transfer: get(q.Transfer),
+	}
+}
@@ -0,0 +1,176 @@
+package vkutil_test
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/goodshailesh/My-Vulkan-Projects/vkutil"
+	"github.com/goodshailesh/My-Vulkan-Projects/vkutil/vkfake"
+	vk "github.com/vulkan-go/vulkan"
+)
+
+const (
+	graphics = vk.QueueFlags(vk.QueueGraphicsBit)
+	compute  = vk.QueueFlags(vk.QueueComputeBit)
+	transfer = vk.QueueFlags(vk.QueueTransferBit)
+	ignored  = vk.QueueFamilyIgnored
+)
+
+// families returns one queue per family with the given flags.
+func families(flags ...vk.QueueFlags) []vk.QueueFamilyProperties {
+	var properties []vk.QueueFamilyProperties
+	for _, f := range flags {
+		properties = append(properties, vk.QueueFamilyProperties{QueueFlags: f, QueueCount: 1})
+	}
+	return properties
+}
+
+func TestFindQueueFamilies(t *testing.T) {
+	for _, tc := range []struct {
+		name     string
+		families []vk.QueueFamilyProperties
+		present  []uint32
+		want     vkutil.QueueFamilyIndices
+	}{
+		{
+			name:     "dedicated compute and transfer",
+			families: families(graphics|compute|transfer, compute|transfer, transfer),
+			present:  []uint32{0},
+			want:     vkutil.QueueFamilyIndices{Graphics: 0, Present: 0, Compute: 1, Transfer: 2},
+		},
+		{
+			name:     "dedicated families listed first",
+			families: families(transfer, compute|transfer, graphics|compute|transfer),
+			present:  []uint32{2},
+			want:     vkutil.QueueFamilyIndices{Graphics: 2, Present: 2, Compute: 1, Transfer: 0},
+		},
+		{
+			name:     "transfer shares the async compute family",
+			families: families(graphics|compute|transfer, compute|transfer),
+			present:  []uint32{0},
+			want:     vkutil.QueueFamilyIndices{Graphics: 0, Present: 0, Compute: 1, Transfer: 1},
+		},
+		{
+			name:     "one family does it all",
+			families: families(graphics | compute | transfer),
+			present:  []uint32{0},
+			want:     vkutil.QueueFamilyIndices{Graphics: 0, Present: 0, Compute: 0, Transfer: 0},
+		},
+		{
+			// Transfer is implied by graphics and compute, even when not reported
+			name:     "transfer falls back to graphics",
+			families: families(graphics | compute),
+			present:  []uint32{0},
+			want:     vkutil.QueueFamilyIndices{Graphics: 0, Present: 0, Compute: 0, Transfer: 0},
+		},
+		{
+			name:     "graphics prefers a family that presents",
+			families: families(graphics|compute|transfer, graphics|transfer),
+			present:  []uint32{1},
+			want:     vkutil.QueueFamilyIndices{Graphics: 1, Present: 1, Compute: 0, Transfer: 1},
+		},
+		{
+			name:     "separate present family",
+			families: families(graphics|compute|transfer, transfer),
+			present:  []uint32{1},
+			want:     vkutil.QueueFamilyIndices{Graphics: 0, Present: 1, Compute: 0, Transfer: 1},
+		},
+		{
+			name:     "nothing presents",
+			families: families(graphics|compute|transfer, compute|transfer),
+			want:     vkutil.QueueFamilyIndices{Graphics: 0, Present: ignored, Compute: 1, Transfer: 1},
+		},
+		{
+			name:     "compute only device",
+			families: families(compute | transfer),
+			want:     vkutil.QueueFamilyIndices{Graphics: ignored, Present: ignored, Compute: 0, Transfer: 0},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			device := vkfake.NewDevice("GPU", vk.PhysicalDeviceTypeDiscreteGpu)
+			device.QueueFamilies = tc.families
+			device.PresentFamilies = tc.present
+			fake, physicalDevices := useFake(t, device)
+			got, err := vkutil.FindQueueFamilies(physicalDevices[0], fake.NewSurface())
+			if err != nil {
+				t.Fatalf("FindQueueFamilies: %v", err)
+			}
+			if got != tc.want {
+				t.Errorf("FindQueueFamilies = %v, want %v", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestFindQueueFamiliesSkipsEmptyFamilies(t *testing.T) {
+	device := vkfake.NewDevice("GPU", vk.PhysicalDeviceTypeDiscreteGpu)
+	device.QueueFamilies = families(graphics|compute|transfer, graphics|compute|transfer)
+	device.QueueFamilies[0].QueueCount = 0
+	device.PresentFamilies = []uint32{0, 1}
+	fake, physicalDevices := useFake(t, device)
+	got, err := vkutil.FindQueueFamilies(physicalDevices[0], fake.NewSurface())
+	if err != nil {
+		t.Fatalf("FindQueueFamilies: %v", err)
+	}
+	if want := (vkutil.QueueFamilyIndices{Graphics: 1, Present: 1, Compute: 1, Transfer: 1}); got != want {
+		t.Errorf("FindQueueFamilies = %v, want %v", got, want)
+	}
+}
+
+func TestFindQueueFamiliesWithoutSurface(t *testing.T) {
+	_, physicalDevices := useFake(t, vkfake.NewDevice("GPU", vk.PhysicalDeviceTypeDiscreteGpu))
+	got, err := vkutil.FindQueueFamilies(physicalDevices[0], vk.NullSurface)
+	if err != nil {
+		t.Fatalf("FindQueueFamilies: %v", err)
+	}
+	if got.Present != ignored {
+		t.Errorf("Present = %v without a surface", got.Present)
+	}
+	if err := got.Require(false); err != nil {
+		t.Errorf("Require(false) = %v", err)
+	}
+	if err := got.Require(true); err == nil {
+		t.Error("Require(true) succeeded without a present family")
+	}
+}
+
+func TestFindQueueFamiliesSurfaceLost(t *testing.T) {
+	fake, physicalDevices := useFake(t, vkfake.NewDevice("GPU", vk.PhysicalDeviceTypeDiscreteGpu))
+	fake.Results["vkGetPhysicalDeviceSurfaceSupportKHR"] = vk.ErrorSurfaceLost
+	if _, err := vkutil.FindQueueFamilies(physicalDevices[0], fake.NewSurface()); !errors.Is(err, vkutil.ErrSurfaceLost) {
+		t.Errorf("FindQueueFamilies error = %v, want VK_ERROR_SURFACE_LOST_KHR", err)
+	}
+}
+
+func TestQueueFamilyIndicesRequire(t *testing.T) {
+	q := vkutil.QueueFamilyIndices{Graphics: ignored, Present: ignored, Compute: 0, Transfer: 0}
+	err := q.Require(true)
+	if err == nil || err.Error() != "no queue family found for graphics, present" {
+		t.Errorf("Require(true) = %v", err)
+	}
+}
+
+func TestQueueFamilyIndicesUnique(t *testing.T) {
+	q := vkutil.QueueFamilyIndices{Graphics: 2, Present: 0, Compute: 2, Transfer: ignored}
+	if got, want := q.Unique(), []uint32{2, 0}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Unique = %v, want %v", got, want)
+	}
+}
+
+func TestQueueFamilyIndicesSwapchainSharing(t *testing.T) {
+	for _, tc := range []struct {
+		q       vkutil.QueueFamilyIndices
+		mode    vk.SharingMode
+		indices []uint32
+	}{
+		{vkutil.QueueFamilyIndices{Graphics: 0, Present: 0}, vk.SharingModeExclusive, []uint32{0}},
+		{vkutil.QueueFamilyIndices{Graphics: 1, Present: ignored}, vk.SharingModeExclusive, []uint32{1}},
+		{vkutil.QueueFamilyIndices{Graphics: 0, Present: 2}, vk.SharingModeConcurrent, []uint32{0, 2}},
+	} {
+		mode, indices := tc.q.SwapchainSharing()
+		if mode != tc.mode || !reflect.DeepEqual(indices, tc.indices) {
+			t.Errorf("%v: SwapchainSharing = %v, %v, want %v, %v", tc.q, mode, indices, tc.mode, tc.indices)
+		}
+	}
+}
@@ -0,0 +1,162 @@
+package vkutil
+
+import (
+	"fmt"
+	"sync"
+
+	vk "github.com/vulkan-go/vulkan"
+)
+
+// SamplerConfig describes a sampler. It is comparable, a SamplerCache hands
+// out one sampler per distinct config. LinearSampler and NearestSampler
+// return the usual ones.
+// https://www.khronos.org/registry/vulkan/specs/1.2-extensions/html/vkspec.html#samplers
+type SamplerConfig struct {
+	MagFilter, MinFilter                     vk.Filter
+	MipmapMode                               vk.SamplerMipmapMode
+	AddressModeU, AddressModeV, AddressModeW vk.SamplerAddressMode
+	// BorderColor is what vk.SamplerAddressModeClampToBorder reads outside the image.
+	BorderColor vk.BorderColor
+	// MipLodBias is added to the level of detail the shader computes, MinLod
+	// and MaxLod clamp it: 0 and vk.LodClampNone use every mip level, a
+	// MaxLod of 0 only the first.
+	MipLodBias, MinLod, MaxLod float32
+	// MaxAnisotropy above 1 turns on anisotropic filtering with up to that
+	// many samples. SamplerCache clamps it to what the device can do.
+	MaxAnisotropy float32
+}
+
+// LinearSampler is the trilinear sampler over all mip levels, addressing
+// with addressMode in every direction.
+func LinearSampler(addressMode vk.SamplerAddressMode) SamplerConfig {
+	return SamplerConfig{
+		MagFilter:    vk.FilterLinear,
+		MinFilter:    vk.FilterLinear,
+		MipmapMode:   vk.SamplerMipmapModeLinear,
+		AddressModeU: addressMode,
+		AddressModeV: addressMode,
+		AddressModeW: addressMode,
+		MaxLod:       vk.LodClampNone,
+	}
+}
+
+// NearestSampler is LinearSampler reading the closest texel of the closest
+// mip level, e.g. for pixel art.
+func NearestSampler(addressMode vk.SamplerAddressMode) SamplerConfig {
+	config := LinearSampler(addressMode)
+	config.MagFilter, config.MinFilter, config.MipmapMode = vk.FilterNearest, vk.FilterNearest, vk.SamplerMipmapModeNearest
+	return config
+}
+
+// CreateSampler creates the sampler of config as is. The device must have
+// been created with the samplerAnisotropy feature when config.MaxAnisotropy
+// is above 1; SamplerCache takes care of that.
+func CreateSampler(device vk.Device, config SamplerConfig) (vk.Sampler, error) {
+	samplerCreateInfo := vk.SamplerCreateInfo{
+		SType:            vk.StructureTypeSamplerCreateInfo,
+		MagFilter:        config.MagFilter,
+		MinFilter:        config.MinFilter,
+		MipmapMode:       config.MipmapMode,
+		AddressModeU:     config.AddressModeU,
+		AddressModeV:     config.AddressModeV,
+		AddressModeW:     config.AddressModeW,
+		MipLodBias:       config.MipLodBias,
+		AnisotropyEnable: vk.False,
+		MaxAnisotropy:    1,
+		CompareOp:        vk.CompareOpAlways,
+		MinLod:           config.MinLod,
+		MaxLod:           config.MaxLod,
+		BorderColor:      config.BorderColor,
+	}
+	if config.MaxAnisotropy > 1 {
+		samplerCreateInfo.AnisotropyEnable, samplerCreateInfo.MaxAnisotropy = vk.True, config.MaxAnisotropy
+	}
+	return driver.CreateSampler(device, &samplerCreateInfo)
+}
+
+// SamplerCache creates one sampler per SamplerConfig, so textures asking for
+// the same filtering share it instead of running into
+// maxSamplerAllocationCount (as low as 4000). It is safe for concurrent use.
+// https://www.khronos.org/registry/vulkan/specs/1.2-extensions/html/vkspec.html#limits-maxSamplerAllocationCount
+type SamplerCache struct {
+	device vk.Device
+	// maxAnisotropy is 1 when anisotropic filtering cannot be used.
+	maxAnisotropy float32
+	maxSamplers   uint32
+
+	mu       sync.Mutex
+	samplers map[SamplerConfig]vk.Sampler
+}
+
+// NewSamplerCache returns an empty cache creating samplers on device, which
+// was created from physicalDevice with the features enabled. Anisotropic
+// filtering is used only when physicalDevice reports samplerAnisotropy and
+// enabled has it, up to maxSamplerAnisotropy samples.
+func NewSamplerCache(device vk.Device, physicalDevice vk.PhysicalDevice, enabled *vk.PhysicalDeviceFeatures) *SamplerCache {
+	limits := GetPhysicalDeviceProperties(physicalDevice).Limits
+	c := &SamplerCache{
+		device:        device,
+		maxAnisotropy: 1,
+		maxSamplers:   limits.MaxSamplerAllocationCount,
+		samplers:      make(map[SamplerConfig]vk.Sampler),
+	}
+	if enabled != nil && enabled.SamplerAnisotropy == vk.True && GetPhysicalDeviceFeatures(physicalDevice).SamplerAnisotropy == vk.True {
+		c.maxAnisotropy = limits.MaxSamplerAnisotropy
+	}
+	return c
+}
+
+// MaxAnisotropy returns the highest MaxAnisotropy the cache creates
+// samplers with, 1 without anisotropic filtering.
+func (c *SamplerCache) MaxAnisotropy() float32 {
+	return c.maxAnisotropy
+}
+
+// Resolve returns config as Get creates it, MaxAnisotropy clamped to what
+// the device can do, 1 for none. Configs resolving the same share a sampler.
+func (c *SamplerCache) Resolve(config SamplerConfig) SamplerConfig {
+	if config.MaxAnisotropy > c.maxAnisotropy {
+		config.MaxAnisotropy = c.maxAnisotropy
+	}
+	if config.MaxAnisotropy < 1 {
+		config.MaxAnisotropy = 1
+	}
+	return config
+}
+
+// Get returns the sampler of config, creating it on first use. The cache
+// owns the sampler, do not destroy it.
+func (c *SamplerCache) Get(config SamplerConfig) (vk.Sampler, error) {
+	config = c.Resolve(config)
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if sampler, ok := c.samplers[config]; ok {
+		return sampler, nil
+	}
+	if c.maxSamplers != 0 && uint32(len(c.samplers)) >= c.maxSamplers {
+		return vk.NullSampler, fmt.Errorf("sampler cache: maxSamplerAllocationCount of %v reached", c.maxSamplers)
+	}
+	sampler, err := CreateSampler(c.device, config)
+	if err != nil {
+		return vk.NullSampler, err
+	}
+	c.samplers[config] = sampler
+	return sampler, nil
+}
+
+// Len returns the number of samplers created.
+func (c *SamplerCache) Len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return len(c.samplers)
+}
+
+// Destroy destroys every sampler. The GPU must be done with them.
+func (c *SamplerCache) Destroy() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for config, sampler := range c.samplers {
+		driver.DestroySampler(c.device, sampler)
+		delete(c.samplers, config)
+	}
+}
@@ -0,0 +1,130 @@
+package vkutil_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/goodshailesh/My-Vulkan-Projects/vkutil"
+	"github.com/goodshailesh/My-Vulkan-Projects/vkutil/vkfake"
+	vk "github.com/vulkan-go/vulkan"
+)
+
+// newSamplerCache returns a cache on a device of dev created with features,
+// told that enabled are the enabled features. The cache and the device are
+// destroyed when the test ends.
+func newSamplerCache(t *testing.T, dev *vkfake.Device, features, enabled *vk.PhysicalDeviceFeatures) (*vkfake.Driver, *vkutil.SamplerCache) {
+	t.Helper()
+	fake, physicalDevices := useFake(t, dev)
+	queueFamilies, err := vkutil.FindQueueFamilies(physicalDevices[0], vk.NullSurface)
+	if err != nil {
+		t.Fatalf("FindQueueFamilies: %v", err)
+	}
+	device, err := vkutil.CreateDevice(physicalDevices[0], queueFamilies, nil, features)
+	if err != nil {
+		t.Fatalf("CreateDevice: %v", err)
+	}
+	c := vkutil.NewSamplerCache(device, physicalDevices[0], enabled)
+	t.Cleanup(func() {
+		c.Destroy()
+		vkutil.DestroyDevice(device)
+		if live := fake.Live(); len(live) != 0 {
+			t.Errorf("not destroyed: %v", live)
+		}
+	})
+	return fake, c
+}
+
+func TestSamplerCacheAnisotropy(t *testing.T) {
+	anisotropy := &vk.PhysicalDeviceFeatures{SamplerAnisotropy: vk.True}
+	for _, tc := range []struct {
+		name              string
+		deviceAnisotropy  bool
+		limit             float32
+		features, enabled *vk.PhysicalDeviceFeatures
+		want              float32
+	}{
+		{"clamped to the limit", true, 16, anisotropy, anisotropy, 16},
+		{"lower limit", true, 4, anisotropy, anisotropy, 4},
+		{"not enabled", true, 16, nil, nil, 1},
+		{"enabled without the feature", true, 16, nil, &vk.PhysicalDeviceFeatures{}, 1},
+		// The caller claims a feature the device does not have
+		{"device without the feature", false, 16, nil, anisotropy, 1},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			dev := vkfake.NewDevice("GPU", vk.PhysicalDeviceTypeDiscreteGpu)
+			dev.Features.SamplerAnisotropy = vk.False
+			if tc.deviceAnisotropy {
+				dev.Features.SamplerAnisotropy = vk.True
+			}
+			dev.Properties.Limits.MaxSamplerAnisotropy = tc.limit
+			_, c := newSamplerCache(t, dev, tc.features, tc.enabled)
+			if got := c.MaxAnisotropy(); got != tc.want {
+				t.Errorf("MaxAnisotropy = %v, want %v", got, tc.want)
+			}
+			config := vkutil.LinearSampler(vk.SamplerAddressModeRepeat)
+			config.MaxAnisotropy = 64
+			if got := c.Resolve(config).MaxAnisotropy; got != tc.want {
+				t.Errorf("Resolve(64).MaxAnisotropy = %v, want %v", got, tc.want)
+			}
+			// The fake turns down anisotropy above the limit or without the feature
+			if _, err := c.Get(config); err != nil {
+				t.Errorf("Get: %v", err)
+			}
+		})
+	}
+}
+
+func TestSamplerCacheDedupes(t *testing.T) {
+	anisotropy := &vk.PhysicalDeviceFeatures{SamplerAnisotropy: vk.True}
+	fake, c := newSamplerCache(t, vkfake.NewDevice("GPU", vk.PhysicalDeviceTypeDiscreteGpu), anisotropy, anisotropy)
+	linear := vkutil.LinearSampler(vk.SamplerAddressModeRepeat)
+	first, err := c.Get(linear)
+	if err != nil {
+		t.Fatalf("Get: %v", err)
+	}
+	if again, err := c.Get(linear); err != nil || again != first {
+		t.Errorf("Get of the same config returned another sampler, %v", err)
+	}
+	// 32 and 64 both resolve to the limit of 16
+	linear.MaxAnisotropy = 32
+	anisotropic, err := c.Get(linear)
+	if err != nil {
+		t.Fatalf("Get: %v", err)
+	}
+	linear.MaxAnisotropy = 64
+	if again, err := c.Get(linear); err != nil || again != anisotropic {
+		t.Errorf("configs resolving alike got another sampler, %v", err)
+	}
+	if _, err := c.Get(vkutil.NearestSampler(vk.SamplerAddressModeRepeat)); err != nil {
+		t.Fatalf("Get: %v", err)
+	}
+	if c.Len() != 3 || countCalls(fake.Calls, "vkCreateSampler") != 3 {
+		t.Errorf("%v samplers, %v created, want 3", c.Len(), countCalls(fake.Calls, "vkCreateSampler"))
+	}
+}
+
+func TestSamplerCacheMaxSamplerAllocationCount(t *testing.T) {
+	dev := vkfake.NewDevice("GPU", vk.PhysicalDeviceTypeDiscreteGpu)
+	dev.Properties.Limits.MaxSamplerAllocationCount = 2
+	fake, c := newSamplerCache(t, dev, nil, nil)
+	for _, config := range []vkutil.SamplerConfig{
+		vkutil.LinearSampler(vk.SamplerAddressModeRepeat),
+		vkutil.NearestSampler(vk.SamplerAddressModeRepeat),
+	} {
+		if _, err := c.Get(config); err != nil {
+			t.Fatalf("Get: %v", err)
+		}
+	}
+	_, err := c.Get(vkutil.LinearSampler(vk.SamplerAddressModeClampToEdge))
+	if err == nil || !strings.Contains(err.Error(), "maxSamplerAllocationCount of 2") {
+		t.Errorf("Get past the limit error = %v, want maxSamplerAllocationCount reached", err)
+	}
+	// The cache turns it down before the driver has to
+	if n := countCalls(fake.Calls, "vkCreateSampler"); n != 2 {
+		t.Errorf("vkCreateSampler called %v times, want 2", n)
+	}
+	// Cached samplers are still handed out
+	if _, err := c.Get(vkutil.LinearSampler(vk.SamplerAddressModeRepeat)); err != nil {
+		t.Errorf("Get of a cached sampler at the limit: %v", err)
+	}
+}
@@ -0,0 +1,293 @@
+package vkutil
+
+import (
+	"bytes"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+
+	vk "github.com/vulkan-go/vulkan"
+)
+
+// DeviceRequirements are the hard requirements of SelectPhysicalDevice, a
+// device failing any of them is rejected.
+type DeviceRequirements struct {
+	// QueueFlags must each be supported by at least one queue family,
+	// e.g. vk.QueueGraphicsBit|vk.QueueComputeBit.
+	QueueFlags vk.QueueFlagBits
+	// Surface, when not vk.NullSurface, must be presentable from at least one queue family.
+	Surface vk.Surface
+	// Extensions are the device extensions that must be supported.
+	Extensions []string
+	// Features lists the features that must be supported, set the wanted
+	// fields to vk.True.
+	Features vk.PhysicalDeviceFeatures
+}
+
+// DevicePreferences rank the devices meeting the DeviceRequirements. A
+// device matching an explicit Name, VendorID or UUID always wins over one
+// that only has a better device type.
+type DevicePreferences struct {
+	// DeviceTypes from most to least wanted. When empty discrete GPUs are
+	// preferred, then integrated, virtual and CPU implementations.
+	DeviceTypes []vk.PhysicalDeviceType
+	// Name is matched case-insensitively against part of the device name.
+	Name string
+	// VendorID is the PCI vendor ID, e.g. 0x10DE NVIDIA, 0x8086 Intel, 0x1002 AMD.
+	VendorID uint32
+	// UUID is the deviceUUID of VkPhysicalDeviceIDProperties, see GetPhysicalDeviceUUID.
+	UUID []byte
+}
+
+var defaultDeviceTypes = []vk.PhysicalDeviceType{
+	vk.PhysicalDeviceTypeDiscreteGpu,
+	vk.PhysicalDeviceTypeIntegratedGpu,
+	vk.PhysicalDeviceTypeVirtualGpu,
+	vk.PhysicalDeviceTypeCpu,
+}
+
+// DeviceCandidate is what SelectPhysicalDevice found out about one device.
+type DeviceCandidate struct {
+	PhysicalDevice vk.PhysicalDevice
+	// Index into GetPhysicalDevices.
+	Index         int
+	Name          string
+	Properties    vk.PhysicalDeviceProperties
+	Features      vk.PhysicalDeviceFeatures
+	QueueFamilies []vk.QueueFamilyProperties
+	// Suitable is true when every requirement is met.
+	Suitable bool
+	// Score orders the suitable candidates, higher is better.
+	Score int
+	// Reasons explains the verdict and the score, one line each.
+	Reasons []string
+}
+
+func (c DeviceCandidate) String() string {
+	verdict := "rejected"
+	if c.Suitable {
+		verdict = fmt.Sprintf("accepted, score %v", c.Score)
+	}
+	return fmt.Sprintf("[%v] %v (%v): %v", c.Index, c.Name, deviceTypeName(c.Properties.DeviceType), verdict)
+}
+
+func (c *DeviceCandidate) explain(format string, a ...interface{}) {
+	c.Reasons = append(c.Reasons, fmt.Sprintf(format, a...))
+}
+
+// NoSuitableDeviceError is returned by SelectPhysicalDevice when every
+// device was rejected. Candidates tells why.
+type NoSuitableDeviceError struct {
+	Candidates []DeviceCandidate
+}
+
+func (e *NoSuitableDeviceError) Error() string {
+	if len(e.Candidates) == 0 {
+		return "no Vulkan physical device found"
+	}
+	var reasons []string
+	for _, c := range e.Candidates {
+		reasons = append(reasons, c.String()+": "+strings.Join(c.Reasons, "; "))
+	}
+	return "no suitable Vulkan physical device: " + strings.Join(reasons, " | ")
+}
+
+// RankPhysicalDevices evaluates every physical device against req and pref
+// and returns them best first, suitable devices before rejected ones.
+func RankPhysicalDevices(instance vk.Instance, req DeviceRequirements, pref DevicePreferences) ([]DeviceCandidate, error) {
+	physicalDevices, err := GetPhysicalDevices(instance)
+	if err != nil {
+		return nil, err
+	}
+	candidates := make([]DeviceCandidate, 0, len(physicalDevices))
+	for idx, pd := range physicalDevices {
+		c, err := evaluatePhysicalDevice(instance, idx, pd, req, pref)
+		if err != nil {
+			return nil, err
+		}
+		candidates = append(candidates, c)
+	}
+	sort.SliceStable(candidates, func(i, j int) bool {
+		if candidates[i].Suitable != candidates[j].Suitable {
+			return candidates[i].Suitable
+		}
+		return candidates[i].Score > candidates[j].Score
+	})
+	return candidates, nil
+}
+
+// SelectPhysicalDevice returns the best device meeting req, ranked by pref,
+// together with the evaluation of every device. When no device qualifies the
+// error is a *NoSuitableDeviceError.
+func SelectPhysicalDevice(instance vk.Instance, req DeviceRequirements, pref DevicePreferences) (DeviceCandidate, []DeviceCandidate, error) {
+	candidates, err := RankPhysicalDevices(instance, req, pref)
+	if err != nil {
+		return DeviceCandidate{}, nil, err
+	}
+	if len(candidates) == 0 || !candidates[0].Suitable {
+		return DeviceCandidate{}, candidates, &NoSuitableDeviceError{Candidates: candidates}
+	}
+	return candidates[0], candidates, nil
+}
+
+// PrintDeviceCandidates prints the verdict and reasons for every candidate.
+func PrintDeviceCandidates(candidates []DeviceCandidate) {
+	fmt.Println("Physical device selection..............")
+	for _, c := range candidates {
+		fmt.Printf("\t* %v\n", c)
+		for _, r := range c.Reasons {
+			fmt.Printf("\t\t- %v\n", r)
+		}
+	}
+}
+
+func evaluatePhysicalDevice(instance vk.Instance, idx int, pd vk.PhysicalDevice, req DeviceRequirements, pref DevicePreferences) (DeviceCandidate, error) {
+	c := DeviceCandidate{
+		PhysicalDevice: pd,
+		Index:          idx,
+		Properties:     GetPhysicalDeviceProperties(pd),
+		Features:       GetPhysicalDeviceFeatures(pd),
+		QueueFamilies:  GetPhysicalDeviceQueueFamilyProperties(pd),
+		Suitable:       true,
+	}
+	c.Name = vk.ToString(c.Properties.DeviceName[:])
+
+	// Requirements
+	for _, bit := range []vk.QueueFlagBits{vk.QueueGraphicsBit, vk.QueueComputeBit, vk.QueueTransferBit, vk.QueueSparseBindingBit, vk.QueueProtectedBit} {
+		if req.QueueFlags&bit == 0 {
+			continue
+		}
+		found := false
+		for _, qf := range c.QueueFamilies {
+			if qf.QueueCount > 0 && vk.QueueFlagBits(qf.QueueFlags)&bit != 0 {
+				found = true
+				break
+			}
+		}
+		if !found {
+			c.Suitable = false
+			c.explain("rejected: no queue family supports %v", queueFlagName(bit))
+		}
+	}
+	if req.Surface != vk.NullSurface {
+		found := false
+		for family := range c.QueueFamilies {
+			supported, err := driver.GetPhysicalDeviceSurfaceSupport(pd, uint32(family), req.Surface)
+			if err != nil {
+				return c, err
+			}
+			if supported {
+				found = true
+				break
+			}
+		}
+		if !found {
+			c.Suitable = false
+			c.explain("rejected: no queue family can present to the surface")
+		}
+	}
+	if len(req.Extensions) > 0 {
+		_, err := SelectDeviceExtensions(pd, req.Extensions, nil)
+		if missing, ok := err.(*MissingExtensionError); ok {
+			c.Suitable = false
+			c.explain("rejected: missing device extension(s) %v", strings.Join(missing.Missing, ", "))
+		} else if err != nil {
+			return c, err
+		}
+	}
+	if missing := missingFeatures(req.Features, c.Features); len(missing) > 0 {
+		c.Suitable = false
+		c.explain("rejected: missing feature(s) %v", strings.Join(missing, ", "))
+	}
+	if c.Suitable {
+		c.explain("accepted: meets every requirement")
+	}
+
+	// Preferences
+	types := pref.DeviceTypes
+	if len(types) == 0 {
+		types = defaultDeviceTypes
+	}
+	for rank, t := range types {
+		if t == c.Properties.DeviceType {
+			points := (len(types) - rank) * 1000
+			c.Score += points
+			c.explain("+%v device type %v is preference #%v", points, deviceTypeName(t), rank+1)
+			break
+		}
+	}
+	if pref.Name != "" && strings.Contains(strings.ToLower(c.Name), strings.ToLower(pref.Name)) {
+		c.Score += 100000
+		c.explain("+100000 name matches %q", pref.Name)
+	}
+	if pref.VendorID != 0 && pref.VendorID == c.Properties.VendorID {
+		c.Score += 100000
+		c.explain("+100000 vendor ID %#x", pref.VendorID)
+	}
+	if len(pref.UUID) > 0 {
+		uuid, ok := GetPhysicalDeviceUUID(instance, pd)
+		switch {
+		case !ok:
+			c.explain("device UUID not available, enable %v", PhysicalDeviceProperties2Extension)
+		case bytes.Equal(uuid[:], pref.UUID):
+			c.Score += 1000000
+			c.explain("+1000000 UUID matches")
+		}
+	}
+	// Tie breaker between otherwise equal devices, bigger images usually mean a more capable GPU
+	points := int(c.Properties.Limits.MaxImageDimension2D / 1024)
+	c.Score += points
+	c.explain("+%v max 2D image dimension %v", points, c.Properties.Limits.MaxImageDimension2D)
+	return c, nil
+}
+
+// missingFeatures returns the names of the features set in required but not in supported.
+func missingFeatures(required, supported vk.PhysicalDeviceFeatures) []string {
+	var missing []string
+	r := reflect.ValueOf(required)
+	s := reflect.ValueOf(supported)
+	bool32 := reflect.TypeOf(vk.Bool32(0))
+	for i := 0; i < r.NumField(); i++ {
+		field := r.Type().Field(i)
+		if field.PkgPath != "" || field.Type != bool32 {
+			continue
+		}
+		if r.Field(i).Uint() == uint64(vk.True) && s.Field(i).Uint() != uint64(vk.True) {
+			missing = append(missing, field.Name)
+		}
+	}
+	return missing
+}
+
+func queueFlagName(bit vk.QueueFlagBits) string {
+	switch bit {
+	case vk.QueueGraphicsBit:
+		return "VK_QUEUE_GRAPHICS_BIT"
+	case vk.QueueComputeBit:
+		return "VK_QUEUE_COMPUTE_BIT"
+	case vk.QueueTransferBit:
+		return "VK_QUEUE_TRANSFER_BIT"
+	case vk.QueueSparseBindingBit:
+		return "VK_QUEUE_SPARSE_BINDING_BIT"
+	case vk.QueueProtectedBit:
+		return "VK_QUEUE_PROTECTED_BIT"
+	}
+	return fmt.Sprintf("QueueFlagBits(%#x)", uint32(bit))
+}
+
+func deviceTypeName(t vk.PhysicalDeviceType) string {
+	switch t {
+	case vk.PhysicalDeviceTypeOther:
+		return "other"
+	case vk.PhysicalDeviceTypeIntegratedGpu:
+		return "integrated GPU"
+	case vk.PhysicalDeviceTypeDiscreteGpu:
+		return "discrete GPU"
+	case vk.PhysicalDeviceTypeVirtualGpu:
+		return "virtual GPU"
+	case vk.PhysicalDeviceTypeCpu:
+		return "CPU"
+	}
+	return fmt.Sprintf("PhysicalDeviceType(%v)", int32(t))
+}
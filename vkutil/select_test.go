@@ -0,0 +1,212 @@
+package vkutil_test
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/goodshailesh/My-Vulkan-Projects/vkutil"
+	"github.com/goodshailesh/My-Vulkan-Projects/vkutil/vkfake"
+	vk "github.com/vulkan-go/vulkan"
+)
+
+// useFake makes the helpers call a fake of devices until the test ends and
+// returns it with the enumerated physical devices.
+func useFake(t *testing.T, devices ...*vkfake.Device) (*vkfake.Driver, []vk.PhysicalDevice) {
+	t.Helper()
+	fake := vkfake.New(devices...)
+	previous := vkutil.SetDriver(fake)
+	t.Cleanup(func() { vkutil.SetDriver(previous) })
+	physicalDevices, err := vkutil.GetPhysicalDevices(nil)
+	if err != nil {
+		t.Fatalf("GetPhysicalDevices: %v", err)
+	}
+	return fake, physicalDevices
+}
+
+func names(candidates []vkutil.DeviceCandidate) []string {
+	var names []string
+	for _, c := range candidates {
+		names = append(names, c.Name)
+	}
+	return names
+}
+
+func TestSelectPhysicalDeviceRanking(t *testing.T) {
+	newDevices := func() []*vkfake.Device {
+		return []*vkfake.Device{
+			vkfake.NewDevice("llvmpipe", vk.PhysicalDeviceTypeCpu),
+			vkfake.NewDevice("Intel iGPU", vk.PhysicalDeviceTypeIntegratedGpu),
+			vkfake.NewDevice("NVIDIA dGPU", vk.PhysicalDeviceTypeDiscreteGpu),
+			vkfake.NewDevice("virtio", vk.PhysicalDeviceTypeVirtualGpu),
+		}
+	}
+	for _, tc := range []struct {
+		name   string
+		edit   func(devices []*vkfake.Device)
+		pref   vkutil.DevicePreferences
+		want   []string
+		reason string
+	}{
+		{
+			name:   "device types by default",
+			want:   []string{"NVIDIA dGPU", "Intel iGPU", "virtio", "llvmpipe"},
+			reason: "+4000 device type discrete GPU is preference #1",
+		},
+		{
+			name:   "explicit device types",
+			pref:   vkutil.DevicePreferences{DeviceTypes: []vk.PhysicalDeviceType{vk.PhysicalDeviceTypeCpu, vk.PhysicalDeviceTypeIntegratedGpu}},
+			want:   []string{"llvmpipe", "Intel iGPU", "NVIDIA dGPU", "virtio"},
+			reason: "+2000 device type CPU is preference #1",
+		},
+		{
+			name:   "name beats device type",
+			pref:   vkutil.DevicePreferences{Name: "igpu"},
+			want:   []string{"Intel iGPU", "NVIDIA dGPU", "virtio", "llvmpipe"},
+			reason: `+100000 name matches "igpu"`,
+		},
+		{
+			name: "vendor ID beats device type",
+			edit: func(devices []*vkfake.Device) {
+				devices[3].Properties.VendorID = 0x1AF4
+			},
+			pref:   vkutil.DevicePreferences{VendorID: 0x1AF4},
+			want:   []string{"virtio", "NVIDIA dGPU", "Intel iGPU", "llvmpipe"},
+			reason: "+100000 vendor ID 0x1af4",
+		},
+		{
+			name: "UUID beats name",
+			edit: func(devices []*vkfake.Device) {
+				devices[0].UUID = []byte("llvmpipe-uuid-01")
+			},
+			pref:   vkutil.DevicePreferences{Name: "nvidia", UUID: []byte("llvmpipe-uuid-01")},
+			want:   []string{"llvmpipe", "NVIDIA dGPU", "Intel iGPU", "virtio"},
+			reason: "+1000000 UUID matches",
+		},
+		{
+			name: "larger images break ties",
+			edit: func(devices []*vkfake.Device) {
+				devices[1].Properties.DeviceType = vk.PhysicalDeviceTypeDiscreteGpu
+				devices[1].Properties.Limits.MaxImageDimension2D = 32768
+			},
+			want:   []string{"Intel iGPU", "NVIDIA dGPU", "virtio", "llvmpipe"},
+			reason: "+32 max 2D image dimension 32768",
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			devices := newDevices()
+			if tc.edit != nil {
+				tc.edit(devices)
+			}
+			useFake(t, devices...)
+			selected, candidates, err := vkutil.SelectPhysicalDevice(nil, vkutil.DeviceRequirements{}, tc.pref)
+			if err != nil {
+				t.Fatalf("SelectPhysicalDevice: %v", err)
+			}
+			if got := names(candidates); strings.Join(got, ", ") != strings.Join(tc.want, ", ") {
+				t.Errorf("ranking = %v, want %v", got, tc.want)
+			}
+			if selected.Name != tc.want[0] || !selected.Suitable {
+				t.Errorf("selected %v", selected)
+			}
+			for idx := 1; idx < len(candidates); idx++ {
+				if candidates[idx].Score > candidates[idx-1].Score {
+					t.Errorf("%v scores %v, more than %v before it", candidates[idx], candidates[idx].Score, candidates[idx-1].Score)
+				}
+			}
+			if !containsReason(selected.Reasons, tc.reason) {
+				t.Errorf("reasons of %v = %q, want %q", selected.Name, selected.Reasons, tc.reason)
+			}
+		})
+	}
+}
+
+func containsReason(reasons []string, want string) bool {
+	for _, r := range reasons {
+		if r == want {
+			return true
+		}
+	}
+	return false
+}
+
+func TestSelectPhysicalDeviceRejections(t *testing.T) {
+	noSwapchain := vkfake.NewDevice("no swapchain", vk.PhysicalDeviceTypeDiscreteGpu)
+	noSwapchain.Extensions = nil
+	transferOnly := vkfake.NewDevice("transfer only", vk.PhysicalDeviceTypeDiscreteGpu)
+	transferOnly.QueueFamilies = []vk.QueueFamilyProperties{{QueueFlags: vk.QueueFlags(vk.QueueTransferBit), QueueCount: 1}}
+	noGeometry := vkfake.NewDevice("no geometry shaders", vk.PhysicalDeviceTypeDiscreteGpu)
+	headless := vkfake.NewDevice("headless", vk.PhysicalDeviceTypeDiscreteGpu)
+	headless.PresentFamilies = nil
+	suitable := vkfake.NewDevice("suitable", vk.PhysicalDeviceTypeIntegratedGpu)
+	suitable.Features.GeometryShader = vk.True
+
+	fake, _ := useFake(t, noSwapchain, transferOnly, noGeometry, headless, suitable)
+	req := vkutil.DeviceRequirements{
+		QueueFlags: vk.QueueGraphicsBit,
+		Surface:    fake.NewSurface(),
+		Extensions: []string{"VK_KHR_swapchain"},
+		Features:   vk.PhysicalDeviceFeatures{GeometryShader: vk.True},
+	}
+	selected, candidates, err := vkutil.SelectPhysicalDevice(nil, req, vkutil.DevicePreferences{})
+	if err != nil {
+		t.Fatalf("SelectPhysicalDevice: %v", err)
+	}
+	// The integrated GPU is the only one left, and ranks first despite its device type
+	if selected.Name != "suitable" || candidates[0].Name != "suitable" {
+		t.Fatalf("selected %v out of %v", selected, names(candidates))
+	}
+	want := map[string]string{
+		"no swapchain":        "rejected: missing device extension(s) VK_KHR_swapchain",
+		"transfer only":       "rejected: no queue family supports VK_QUEUE_GRAPHICS_BIT",
+		"no geometry shaders": "rejected: missing feature(s) GeometryShader",
+		"headless":            "rejected: no queue family can present to the surface",
+		"suitable":            "accepted: meets every requirement",
+	}
+	for _, c := range candidates {
+		if c.Suitable != (c.Name == "suitable") {
+			t.Errorf("%v: Suitable = %v", c, c.Suitable)
+		}
+		if !containsReason(c.Reasons, want[c.Name]) {
+			t.Errorf("reasons of %v = %q, want %q", c.Name, c.Reasons, want[c.Name])
+		}
+	}
+	if len(candidates) != len(want) {
+		t.Errorf("%v candidates, want %v", len(candidates), len(want))
+	}
+}
+
+func TestSelectPhysicalDeviceNoneSuitable(t *testing.T) {
+	noSwapchain := vkfake.NewDevice("no swapchain", vk.PhysicalDeviceTypeDiscreteGpu)
+	noSwapchain.Extensions = nil
+	useFake(t, noSwapchain)
+	_, candidates, err := vkutil.SelectPhysicalDevice(nil, vkutil.DeviceRequirements{Extensions: []string{"VK_KHR_swapchain"}}, vkutil.DevicePreferences{})
+	var noDevice *vkutil.NoSuitableDeviceError
+	if !errors.As(err, &noDevice) {
+		t.Fatalf("SelectPhysicalDevice error = %v, want a *NoSuitableDeviceError", err)
+	}
+	if len(noDevice.Candidates) != 1 || len(candidates) != 1 || candidates[0].Suitable {
+		t.Errorf("candidates = %v", candidates)
+	}
+	if !strings.Contains(err.Error(), "missing device extension(s) VK_KHR_swapchain") {
+		t.Errorf("error %q does not say why", err)
+	}
+}
+
+func TestSelectPhysicalDeviceNoDevice(t *testing.T) {
+	useFake(t)
+	_, _, err := vkutil.SelectPhysicalDevice(nil, vkutil.DeviceRequirements{}, vkutil.DevicePreferences{})
+	var noDevice *vkutil.NoSuitableDeviceError
+	if !errors.As(err, &noDevice) || err.Error() != "no Vulkan physical device found" {
+		t.Errorf("SelectPhysicalDevice error = %v", err)
+	}
+}
+
+func TestSelectPhysicalDeviceEnumerateFails(t *testing.T) {
+	fake, _ := useFake(t, vkfake.NewDevice("dGPU", vk.PhysicalDeviceTypeDiscreteGpu))
+	fake.Results["vkEnumeratePhysicalDevices"] = vk.ErrorInitializationFailed
+	_, _, err := vkutil.SelectPhysicalDevice(nil, vkutil.DeviceRequirements{}, vkutil.DevicePreferences{})
+	if !errors.Is(err, vkutil.ErrInitializationFailed) {
+		t.Errorf("SelectPhysicalDevice error = %v, want VK_ERROR_INITIALIZATION_FAILED", err)
+	}
+}
@@ -0,0 +1,218 @@
+package vkutil
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io/fs"
+	"os"
+	"strings"
+
+	vk "github.com/vulkan-go/vulkan"
+)
+
+// SPIRVMagic is the first word of every SPIR-V module.
+// https://www.khronos.org/registry/SPIR-V/specs/unified1/SPIRV.html#_magic_number
+const SPIRVMagic = 0x07230203
+
+// SPIR-V opcodes and execution models read by ParseSPIRV.
+const (
+	spirvOpEntryPoint = 15
+
+	spirvExecutionModelVertex                 = 0
+	spirvExecutionModelTessellationControl    = 1
+	spirvExecutionModelTessellationEvaluation = 2
+	spirvExecutionModelGeometry               = 3
+	spirvExecutionModelFragment               = 4
+	spirvExecutionModelGLCompute              = 5
+)
+
+// SPIRV is a validated SPIR-V module, ready for CreateShaderModule.
+type SPIRV struct {
+	// Name is the file it was loaded from, for error messages.
+	Name string
+	// Code is the module in host byte order.
+	Code []uint32
+	// Major and Minor are the SPIR-V version. Vulkan 1.0 accepts 1.0, 1.1
+	// accepts up to 1.3, 1.2 up to 1.5 and 1.3 up to 1.6.
+	Major, Minor uint32
+	// EntryPoints declared by the module, in declaration order.
+	EntryPoints []EntryPoint
+}
+
+// EntryPoint is an OpEntryPoint of a module: a function a pipeline stage can start at.
+type EntryPoint struct {
+	Name  string
+	Stage vk.ShaderStageFlagBits
+}
+
+// LoadSPIRV reads and validates the SPIR-V module at path, e.g. the output of
+// glslc shader.vert -o shader.vert.spv.
+func LoadSPIRV(path string) (*SPIRV, error) {
+	code, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return ParseSPIRV(path, code)
+}
+
+// LoadSPIRVFS is LoadSPIRV reading from fsys, e.g. an embed.FS.
+func LoadSPIRVFS(fsys fs.FS, path string) (*SPIRV, error) {
+	code, err := fs.ReadFile(fsys, path)
+	if err != nil {
+		return nil, err
+	}
+	return ParseSPIRV(path, code)
+}
+
+// ParseSPIRV validates the header of the SPIR-V module in code and lists its
+// entry points. name only shows up in errors. Modules written in either byte
+// order are accepted.
+func ParseSPIRV(name string, code []byte) (*SPIRV, error) {
+	if len(code) < 5*4 {
+		return nil, fmt.Errorf("%v: %v bytes is too short for a SPIR-V module", name, len(code))
+	}
+	var order binary.ByteOrder
+	switch {
+	case binary.LittleEndian.Uint32(code) == SPIRVMagic:
+		order = binary.LittleEndian
+	case binary.BigEndian.Uint32(code) == SPIRVMagic:
+		order = binary.BigEndian
+	default:
+		return nil, fmt.Errorf("%v: bad SPIR-V magic number %#08x, is it the GLSL source?", name, binary.LittleEndian.Uint32(code))
+	}
+	if len(code)%4 != 0 {
+		return nil, fmt.Errorf("%v: SPIR-V size %v is not a multiple of 4", name, len(code))
+	}
+	s := &SPIRV{Name: name, Code: make([]uint32, len(code)/4)}
+	for idx := range s.Code {
+		s.Code[idx] = order.Uint32(code[idx*4:])
+	}
+	// Version word: 0 | major | minor | 0
+	version := s.Code[1]
+	s.Major = version >> 16 & 0xff
+	s.Minor = version >> 8 & 0xff
+	if version&0xff0000ff != 0 || s.Major != 1 || s.Minor > 6 {
+		return nil, fmt.Errorf("%v: unsupported SPIR-V version word %#08x", name, version)
+	}
+
+	// Walk the instructions after the 5 word header: word count in the high half, opcode in the low half
+	for idx := 5; idx < len(s.Code); {
+		count := int(s.Code[idx] >> 16)
+		opcode := s.Code[idx] & 0xffff
+		if count == 0 || idx+count > len(s.Code) {
+			return nil, fmt.Errorf("%v: truncated SPIR-V instruction at word %v", name, idx)
+		}
+		if opcode == spirvOpEntryPoint {
+			// OpEntryPoint ExecutionModel <id> Name Interface...
+			if count < 4 {
+				return nil, fmt.Errorf("%v: malformed OpEntryPoint at word %v", name, idx)
+			}
+			stage, ok := spirvStage(s.Code[idx+1])
+			if !ok {
+				return nil, fmt.Errorf("%v: unsupported execution model %v at word %v", name, s.Code[idx+1], idx)
+			}
+			s.EntryPoints = append(s.EntryPoints, EntryPoint{Name: spirvString(s.Code[idx+3 : idx+count]), Stage: stage})
+		}
+		idx += count
+	}
+	if len(s.EntryPoints) == 0 {
+		return nil, fmt.Errorf("%v: the SPIR-V module has no entry point", name)
+	}
+	return s, nil
+}
+
+// EntryPoint returns the name of the entry point for stage. When the module
+// has several, name picks one of them; an empty name takes the first.
+func (s *SPIRV) EntryPoint(stage vk.ShaderStageFlagBits, name string) (string, error) {
+	var found []string
+	for _, e := range s.EntryPoints {
+		if e.Stage != stage {
+			continue
+		}
+		if name == "" || e.Name == name {
+			return e.Name, nil
+		}
+		found = append(found, e.Name)
+	}
+	if len(found) > 0 {
+		return "", fmt.Errorf("%v: no %v entry point %q, the module has %v", s.Name, ShaderStageName(stage), name, strings.Join(found, ", "))
+	}
+	return "", fmt.Errorf("%v: no %v entry point", s.Name, ShaderStageName(stage))
+}
+
+// Stages returns the stages the module has an entry point for.
+func (s *SPIRV) Stages() vk.ShaderStageFlags {
+	var stages vk.ShaderStageFlags
+	for _, e := range s.EntryPoints {
+		stages |= vk.ShaderStageFlags(e.Stage)
+	}
+	return stages
+}
+
+// CreateShaderModule creates a shader module from a module returned by
+// LoadSPIRV. It may be destroyed once the pipelines using it are created.
+func CreateShaderModule(device vk.Device, spirv *SPIRV) (vk.ShaderModule, error) {
+	var shaderModuleCreateInfo = vk.ShaderModuleCreateInfo{
+		SType:    vk.StructureTypeShaderModuleCreateInfo,
+		CodeSize: uint(len(spirv.Code) * 4), // in bytes
+		PCode:    spirv.Code,
+	}
+	shaderModule, err := driver.CreateShaderModule(device, &shaderModuleCreateInfo)
+	if err != nil {
+		return vk.NullShaderModule, fmt.Errorf("%v: %w", spirv.Name, err)
+	}
+	return shaderModule, nil
+}
+
+// ShaderStageName returns the lower case name of stage, e.g. "fragment".
+func ShaderStageName(stage vk.ShaderStageFlagBits) string {
+	switch stage {
+	case vk.ShaderStageVertexBit:
+		return "vertex"
+	case vk.ShaderStageTessellationControlBit:
+		return "tessellation control"
+	case vk.ShaderStageTessellationEvaluationBit:
+		return "tessellation evaluation"
+	case vk.ShaderStageGeometryBit:
+		return "geometry"
+	case vk.ShaderStageFragmentBit:
+		return "fragment"
+	case vk.ShaderStageComputeBit:
+		return "compute"
+	}
+	return fmt.Sprintf("ShaderStageFlagBits(%#x)", uint32(stage))
+}
+
+func spirvStage(executionModel uint32) (vk.ShaderStageFlagBits, bool) {
+	switch executionModel {
+	case spirvExecutionModelVertex:
+		return vk.ShaderStageVertexBit, true
+	case spirvExecutionModelTessellationControl:
+		return vk.ShaderStageTessellationControlBit, true
+	case spirvExecutionModelTessellationEvaluation:
+		return vk.ShaderStageTessellationEvaluationBit, true
+	case spirvExecutionModelGeometry:
+		return vk.ShaderStageGeometryBit, true
+	case spirvExecutionModelFragment:
+		return vk.ShaderStageFragmentBit, true
+	case spirvExecutionModelGLCompute:
+		return vk.ShaderStageComputeBit, true
+	}
+	return 0, false
+}
+
+// spirvString decodes a literal string: UTF-8 packed 4 bytes a word, low
+// byte first, null-terminated.
+func spirvString(words []uint32) string {
+	var b strings.Builder
+	for _, w := range words {
+		for shift := 0; shift < 32; shift += 8 {
+			c := byte(w >> shift)
+			if c == 0 {
+				return b.String()
+			}
+			b.WriteByte(c)
+		}
+	}
+	return b.String()
+}
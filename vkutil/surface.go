@@ -0,0 +1,84 @@
+package vkutil
+
+import (
+	"errors"
+	"fmt"
+
+	vk "github.com/vulkan-go/vulkan"
+)
+
+// GetPhysicalDeviceSurfaceCapabilities returns the image count, extent,
+// transform and usage limits the device supports on surface.
+func GetPhysicalDeviceSurfaceCapabilities(physicalDevice vk.PhysicalDevice, surface vk.Surface) (vk.SurfaceCapabilities, error) {
+	return driver.GetPhysicalDeviceSurfaceCapabilities(physicalDevice, surface)
+}
+
+// PrintSurfaceCapabilities prints what GetPhysicalDeviceSurfaceCapabilities returned.
+func PrintSurfaceCapabilities(surfaceCapabilities vk.SurfaceCapabilities) {
+	fmt.Println("Listing Device Surface Capabilities..........")
+	fmt.Printf("\t* MinImageCount : \t%v\n", surfaceCapabilities.MinImageCount)
+	fmt.Printf("\t* MaxImageCount : \t%v\n", surfaceCapabilities.MaxImageCount)
+	fmt.Printf("\t* CurrentExtent : \t%v\n", surfaceCapabilities.CurrentExtent)
+	fmt.Printf("\t* MinImageExtent : \t%v\n", surfaceCapabilities.MinImageExtent)
+	fmt.Printf("\t* MaxImageExtent  : \t%v\n", surfaceCapabilities.MaxImageExtent)
+	fmt.Printf("\t* MaxImageArrayLayers : \t%v\n", surfaceCapabilities.MaxImageArrayLayers)
+	fmt.Printf("\t* SurfaceTransformFlags : \t%v\n", surfaceCapabilities.SupportedTransforms)
+	fmt.Printf("\t* CurrentTransform : \t%v\n", surfaceCapabilities.CurrentTransform)
+	fmt.Printf("\t* SupportedCompositeAlpha : \t%v\n", surfaceCapabilities.SupportedCompositeAlpha)
+	fmt.Printf("\t* SupportedUsageFlags : \t%v\n", surfaceCapabilities.SupportedUsageFlags)
+}
+
+// GetPhysicalDeviceSurfaceFormats lists the image formats and color spaces
+// the device can present to surface.
+func GetPhysicalDeviceSurfaceFormats(physicalDevice vk.PhysicalDevice, surface vk.Surface) ([]vk.SurfaceFormat, error) {
+	return driver.GetPhysicalDeviceSurfaceFormats(physicalDevice, surface)
+}
+
+// GetPhysicalDeviceSurfacePresentModes lists the present modes the device
+// supports on surface. FIFO is always among them.
+func GetPhysicalDeviceSurfacePresentModes(physicalDevice vk.PhysicalDevice, surface vk.Surface) ([]vk.PresentMode, error) {
+	return driver.GetPhysicalDeviceSurfacePresentModes(physicalDevice, surface)
+}
+
+// CreateSwapChain creates a swapchain for surface with the settings of
+// config, see ResolveSwapchainConfig. The images are shared between the
+// graphics and present families of queueFamilies, see
+// QueueFamilyIndices.SwapchainSharing. oldSwapchain is the swapchain being
+// replaced or vk.NullSwapchain; it is retired but still has to be destroyed.
+func CreateSwapChain(device vk.Device, surface vk.Surface, config SwapchainConfig, queueFamilies QueueFamilyIndices, oldSwapchain vk.Swapchain) (vk.Swapchain, error) {
+	sharingMode, familyIndices := queueFamilies.SwapchainSharing()
+	var swapchainCreateInfo = vk.SwapchainCreateInfo{
+		SType:                 vk.StructureTypeSwapchainCreateInfo,
+		Surface:               surface,
+		MinImageCount:         config.ImageCount,
+		ImageFormat:           config.Format.Format,
+		ImageColorSpace:       config.Format.ColorSpace,
+		ImageExtent:           config.Extent,
+		ImageUsage:            config.Usage,
+		PreTransform:          config.PreTransform,
+		ImageArrayLayers:      1, // imageArrayLayers is the number of views in a multiview/stereo surface. For non-stereoscopic-3D applications, this value is 1.
+		ImageSharingMode:      sharingMode,
+		QueueFamilyIndexCount: uint32(len(familyIndices)),
+		PQueueFamilyIndices:   familyIndices,
+		PresentMode:           config.PresentMode,
+		OldSwapchain:          oldSwapchain,
+		Clipped:               vk.True, // Pixels hidden by other windows need not be rendered
+		CompositeAlpha:        config.CompositeAlpha,
+	}
+	return driver.CreateSwapchain(device, &swapchainCreateInfo)
+}
+
+// SwapchainOutOfDate reports whether err, returned by vkAcquireNextImageKHR
+// or vkQueuePresentKHR, means the swapchain no longer matches its surface and
+// has to be recreated: VK_ERROR_OUT_OF_DATE_KHR or VK_SUBOPTIMAL_KHR. After
+// VK_SUBOPTIMAL_KHR from acquire the image is still acquired and can be drawn
+// and presented first.
+func SwapchainOutOfDate(err error) bool {
+	return errors.Is(err, ErrOutOfDate) || errors.Is(err, ErrSuboptimal)
+}
+
+// GetSwapchainImages returns the presentable images owned by swapchain.
+// They are destroyed with the swapchain and must not be destroyed by the caller.
+func GetSwapchainImages(device vk.Device, swapchain vk.Swapchain) ([]vk.Image, error) {
+	return driver.GetSwapchainImages(device, swapchain)
+}
@@ -0,0 +1,280 @@
+package vkutil
+
+import (
+	"fmt"
+
+	vk "github.com/vulkan-go/vulkan"
+)
+
+// SwapchainPreferences are what a program would like its swapchain to be.
+// ResolveSwapchainConfig falls back to what the surface supports for every
+// wish it cannot grant.
+type SwapchainPreferences struct {
+	// Formats from most to least wanted. When empty the 8 bit BGRA and RGBA
+	// sRGB formats are preferred, then their UNORM variants, all with the
+	// sRGB non-linear color space.
+	Formats []vk.SurfaceFormat
+	// PresentModes from most to least wanted. FIFO is always supported and
+	// is the last resort. When empty MAILBOX is preferred, then IMMEDIATE,
+	// which may tear but does not block like FIFO, then FIFO. Pass FIFO
+	// alone for vsync.
+	PresentModes []vk.PresentMode
+	// ImageCount is the number of images wanted, clamped to the surface
+	// limits. 0 asks for one more than the minimum, so the application does
+	// not wait on the driver to acquire the next image.
+	ImageCount uint32
+	// Extent is used when the surface lets the swapchain decide its size
+	// (currentExtent is 0xFFFFFFFF, e.g. on Wayland). Pass the framebuffer
+	// size of the window in pixels, see window.FramebufferExtent.
+	Extent vk.Extent2D
+	// Usage is added to VK_IMAGE_USAGE_COLOR_ATTACHMENT_BIT, e.g.
+	// vk.ImageUsageTransferDstBit to blit into the images. Bits the surface
+	// does not support are dropped.
+	Usage vk.ImageUsageFlags
+}
+
+var defaultSurfaceFormats = []vk.SurfaceFormat{
+	{Format: vk.FormatB8g8r8a8Srgb, ColorSpace: vk.ColorSpaceSrgbNonlinear},
+	{Format: vk.FormatR8g8b8a8Srgb, ColorSpace: vk.ColorSpaceSrgbNonlinear},
+	{Format: vk.FormatB8g8r8a8Unorm, ColorSpace: vk.ColorSpaceSrgbNonlinear},
+	{Format: vk.FormatR8g8b8a8Unorm, ColorSpace: vk.ColorSpaceSrgbNonlinear},
+}
+
+var defaultPresentModes = []vk.PresentMode{vk.PresentModeMailbox, vk.PresentModeImmediate, vk.PresentModeFifo}
+
+// SwapchainConfig is the swapchain ResolveSwapchainConfig settled on.
+type SwapchainConfig struct {
+	Format         vk.SurfaceFormat
+	PresentMode    vk.PresentMode
+	ImageCount     uint32
+	Extent         vk.Extent2D
+	Usage          vk.ImageUsageFlags
+	PreTransform   vk.SurfaceTransformFlagBits
+	CompositeAlpha vk.CompositeAlphaFlagBits
+	// Capabilities the choices were made from.
+	Capabilities vk.SurfaceCapabilities
+	// Reasons explains each choice, one line each.
+	Reasons []string
+}
+
+func (c *SwapchainConfig) explain(format string, a ...interface{}) {
+	c.Reasons = append(c.Reasons, fmt.Sprintf(format, a...))
+}
+
+// ResolveSwapchainConfig queries the capabilities, formats and present modes
+// of surface on physicalDevice and picks the swapchain settings closest to pref.
+func ResolveSwapchainConfig(physicalDevice vk.PhysicalDevice, surface vk.Surface, pref SwapchainPreferences) (SwapchainConfig, error) {
+	capabilities, err := GetPhysicalDeviceSurfaceCapabilities(physicalDevice, surface)
+	if err != nil {
+		return SwapchainConfig{}, err
+	}
+	formats, err := GetPhysicalDeviceSurfaceFormats(physicalDevice, surface)
+	if err != nil {
+		return SwapchainConfig{}, err
+	}
+	presentModes, err := GetPhysicalDeviceSurfacePresentModes(physicalDevice, surface)
+	if err != nil {
+		return SwapchainConfig{}, err
+	}
+	return resolveSwapchainConfig(capabilities, formats, presentModes, pref)
+}
+
+func resolveSwapchainConfig(capabilities vk.SurfaceCapabilities, formats []vk.SurfaceFormat, presentModes []vk.PresentMode, pref SwapchainPreferences) (SwapchainConfig, error) {
+	c := SwapchainConfig{Capabilities: capabilities}
+
+	// Format and color space
+	if len(formats) == 0 {
+		return c, fmt.Errorf("the surface reports no format")
+	}
+	wantedFormats := pref.Formats
+	if len(wantedFormats) == 0 {
+		wantedFormats = defaultSurfaceFormats
+	}
+	if len(formats) == 1 && formats[0].Format == vk.FormatUndefined {
+		// The surface has no preferred format, anything goes
+		c.Format = wantedFormats[0]
+		c.explain("format %v: the surface accepts any format, preference #1", surfaceFormatName(c.Format))
+	} else {
+		found := false
+		for rank, wanted := range wantedFormats {
+			for _, f := range formats {
+				if f.Format == wanted.Format && f.ColorSpace == wanted.ColorSpace {
+					c.Format = f
+					found = true
+					c.explain("format %v: preference #%v", surfaceFormatName(f), rank+1)
+					break
+				}
+			}
+			if found {
+				break
+			}
+		}
+		if !found {
+			c.Format = formats[0]
+			c.explain("format %v: no preferred format supported, first one the surface reports", surfaceFormatName(c.Format))
+		}
+	}
+
+	// Present mode
+	wantedModes := pref.PresentModes
+	if len(wantedModes) == 0 {
+		wantedModes = defaultPresentModes
+	}
+	c.PresentMode = vk.PresentModeFifo
+	found := false
+	for rank, wanted := range wantedModes {
+		for _, m := range presentModes {
+			if m == wanted {
+				c.PresentMode = m
+				found = true
+				c.explain("present mode %v: preference #%v", presentModeName(m), rank+1)
+				break
+			}
+		}
+		if found {
+			break
+		}
+	}
+	if !found {
+		c.explain("present mode %v: no preferred mode supported, FIFO is always available", presentModeName(c.PresentMode))
+	}
+
+	// Image count, a MaxImageCount of 0 means no limit
+	c.ImageCount = pref.ImageCount
+	why := "requested"
+	if c.ImageCount == 0 {
+		c.ImageCount = capabilities.MinImageCount + 1
+		why = "minimum + 1"
+	}
+	if c.ImageCount < capabilities.MinImageCount {
+		c.ImageCount = capabilities.MinImageCount
+		why = "raised to the surface minimum"
+	}
+	if capabilities.MaxImageCount > 0 && c.ImageCount > capabilities.MaxImageCount {
+		c.ImageCount = capabilities.MaxImageCount
+		why = "lowered to the surface maximum"
+	}
+	c.explain("image count %v: %v (surface allows %v to %v)", c.ImageCount, why, capabilities.MinImageCount, maxImageCountString(capabilities.MaxImageCount))
+
+	// Extent
+	if capabilities.CurrentExtent.Width != vk.MaxUint32 {
+		c.Extent = capabilities.CurrentExtent
+		c.explain("extent %vx%v: dictated by the surface", c.Extent.Width, c.Extent.Height)
+	} else {
+		c.Extent = vk.Extent2D{
+			Width:  clampUint32(pref.Extent.Width, capabilities.MinImageExtent.Width, capabilities.MaxImageExtent.Width),
+			Height: clampUint32(pref.Extent.Height, capabilities.MinImageExtent.Height, capabilities.MaxImageExtent.Height),
+		}
+		c.explain("extent %vx%v: requested %vx%v clamped to %vx%v..%vx%v, the surface lets the swapchain decide",
+			c.Extent.Width, c.Extent.Height, pref.Extent.Width, pref.Extent.Height,
+			capabilities.MinImageExtent.Width, capabilities.MinImageExtent.Height,
+			capabilities.MaxImageExtent.Width, capabilities.MaxImageExtent.Height)
+	}
+
+	// Usage
+	color := vk.ImageUsageFlags(vk.ImageUsageColorAttachmentBit)
+	if capabilities.SupportedUsageFlags&color == 0 {
+		return c, fmt.Errorf("the surface does not support VK_IMAGE_USAGE_COLOR_ATTACHMENT_BIT")
+	}
+	c.Usage = color | pref.Usage&capabilities.SupportedUsageFlags
+	if dropped := pref.Usage &^ capabilities.SupportedUsageFlags; dropped != 0 {
+		c.explain("usage %#x: dropped unsupported %#x", uint32(c.Usage), uint32(dropped))
+	} else {
+		c.explain("usage %#x: as requested", uint32(c.Usage))
+	}
+
+	// Transform and alpha
+	c.PreTransform = capabilities.CurrentTransform
+	c.explain("pre-transform %#x: the current transform of the surface", uint32(c.PreTransform))
+	for _, alpha := range []vk.CompositeAlphaFlagBits{vk.CompositeAlphaOpaqueBit, vk.CompositeAlphaInheritBit, vk.CompositeAlphaPreMultipliedBit, vk.CompositeAlphaPostMultipliedBit} {
+		if capabilities.SupportedCompositeAlpha&vk.CompositeAlphaFlags(alpha) != 0 {
+			c.CompositeAlpha = alpha
+			break
+		}
+	}
+	c.explain("composite alpha %#x: first supported of opaque, inherit, pre- and post-multiplied", uint32(c.CompositeAlpha))
+	return c, nil
+}
+
+// PrintSwapchainConfig prints the choices of ResolveSwapchainConfig and why they were made.
+func PrintSwapchainConfig(c SwapchainConfig) {
+	fmt.Println("Swapchain configuration..............")
+	for _, r := range c.Reasons {
+		fmt.Printf("\t* %v\n", r)
+	}
+}
+
+func clampUint32(value, min, max uint32) uint32 {
+	if value < min {
+		return min
+	}
+	if value > max {
+		return max
+	}
+	return value
+}
+
+func maxImageCountString(max uint32) string {
+	if max == 0 {
+		return "unlimited"
+	}
+	return fmt.Sprint(max)
+}
+
+func presentModeName(m vk.PresentMode) string {
+	switch m {
+	case vk.PresentModeImmediate:
+		return "IMMEDIATE"
+	case vk.PresentModeMailbox:
+		return "MAILBOX"
+	case vk.PresentModeFifo:
+		return "FIFO"
+	case vk.PresentModeFifoRelaxed:
+		return "FIFO_RELAXED"
+	}
+	return fmt.Sprintf("PresentMode(%v)", int32(m))
+}
+
+func surfaceFormatName(f vk.SurfaceFormat) string {
+	colorSpace := fmt.Sprintf("ColorSpace(%v)", int32(f.ColorSpace))
+	if f.ColorSpace == vk.ColorSpaceSrgbNonlinear {
+		colorSpace = "SRGB_NONLINEAR"
+	}
+	return FormatName(f.Format) + "/" + colorSpace
+}
+
+// FormatName returns the C name of the common color and depth formats without
+// the VK_FORMAT_ prefix, e.g. "B8G8R8A8_SRGB", and Format(n) for the others.
+func FormatName(format vk.Format) string {
+	switch format {
+	case vk.FormatUndefined:
+		return "UNDEFINED"
+	case vk.FormatR8Unorm:
+		return "R8_UNORM"
+	case vk.FormatR8g8b8a8Unorm:
+		return "R8G8B8A8_UNORM"
+	case vk.FormatR8g8b8a8Srgb:
+		return "R8G8B8A8_SRGB"
+	case vk.FormatB8g8r8a8Unorm:
+		return "B8G8R8A8_UNORM"
+	case vk.FormatB8g8r8a8Srgb:
+		return "B8G8R8A8_SRGB"
+	case vk.FormatA2b10g10r10UnormPack32:
+		return "A2B10G10R10_UNORM_PACK32"
+	case vk.FormatA2r10g10b10UnormPack32:
+		return "A2R10G10B10_UNORM_PACK32"
+	case vk.FormatR16g16b16a16Sfloat:
+		return "R16G16B16A16_SFLOAT"
+	case vk.FormatR32g32b32a32Sfloat:
+		return "R32G32B32A32_SFLOAT"
+	case vk.FormatD16Unorm:
+		return "D16_UNORM"
+	case vk.FormatD32Sfloat:
+		return "D32_SFLOAT"
+	case vk.FormatD24UnormS8Uint:
+		return "D24_UNORM_S8_UINT"
+	case vk.FormatD32SfloatS8Uint:
+		return "D32_SFLOAT_S8_UINT"
+	}
+	return fmt.Sprintf("Format(%v)", int32(format))
+}
@@ -0,0 +1,218 @@
+package vkutil_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/goodshailesh/My-Vulkan-Projects/vkutil"
+	"github.com/goodshailesh/My-Vulkan-Projects/vkutil/vkfake"
+	vk "github.com/vulkan-go/vulkan"
+)
+
+var (
+	bgraSrgb  = vk.SurfaceFormat{Format: vk.FormatB8g8r8a8Srgb, ColorSpace: vk.ColorSpaceSrgbNonlinear}
+	bgraUnorm = vk.SurfaceFormat{Format: vk.FormatB8g8r8a8Unorm, ColorSpace: vk.ColorSpaceSrgbNonlinear}
+	rgbaUnorm = vk.SurfaceFormat{Format: vk.FormatR8g8b8a8Unorm, ColorSpace: vk.ColorSpaceSrgbNonlinear}
+	a2b10     = vk.SurfaceFormat{Format: vk.FormatA2b10g10r10UnormPack32, ColorSpace: vk.ColorSpaceSrgbNonlinear}
+)
+
+// resolveSwapchain resolves pref against the surface of a fake device after edit.
+func resolveSwapchain(t *testing.T, edit func(d *vkfake.Device), pref vkutil.SwapchainPreferences) (vkutil.SwapchainConfig, error) {
+	t.Helper()
+	device := vkfake.NewDevice("GPU", vk.PhysicalDeviceTypeDiscreteGpu)
+	if edit != nil {
+		edit(device)
+	}
+	fake, physicalDevices := useFake(t, device)
+	return vkutil.ResolveSwapchainConfig(physicalDevices[0], fake.NewSurface(), pref)
+}
+
+func TestResolveSwapchainConfigDefaults(t *testing.T) {
+	c, err := resolveSwapchain(t, nil, vkutil.SwapchainPreferences{})
+	if err != nil {
+		t.Fatalf("ResolveSwapchainConfig: %v", err)
+	}
+	if c.Format != bgraSrgb {
+		t.Errorf("Format = %v, want BGRA8 sRGB", c.Format)
+	}
+	if c.PresentMode != vk.PresentModeMailbox {
+		t.Errorf("PresentMode = %v, want MAILBOX", c.PresentMode)
+	}
+	if c.ImageCount != 3 {
+		t.Errorf("ImageCount = %v, want the minimum 2 + 1", c.ImageCount)
+	}
+	if c.Extent != (vk.Extent2D{Width: 800, Height: 600}) {
+		t.Errorf("Extent = %v, want the 800x600 of the surface", c.Extent)
+	}
+	if c.Usage != vk.ImageUsageFlags(vk.ImageUsageColorAttachmentBit) {
+		t.Errorf("Usage = %#x, want color attachment only", c.Usage)
+	}
+	if c.PreTransform != vk.SurfaceTransformIdentityBit || c.CompositeAlpha != vk.CompositeAlphaOpaqueBit {
+		t.Errorf("PreTransform, CompositeAlpha = %#x, %#x", c.PreTransform, c.CompositeAlpha)
+	}
+	if len(c.Reasons) != 7 {
+		t.Errorf("%v reasons, want one per choice: %q", len(c.Reasons), c.Reasons)
+	}
+}
+
+func TestResolveSwapchainConfigFormat(t *testing.T) {
+	for _, tc := range []struct {
+		name    string
+		surface []vk.SurfaceFormat
+		pref    []vk.SurfaceFormat
+		want    vk.SurfaceFormat
+	}{
+		{"default preference order", []vk.SurfaceFormat{bgraUnorm, bgraSrgb}, nil, bgraSrgb},
+		{"default UNORM fallback", []vk.SurfaceFormat{a2b10, rgbaUnorm}, nil, rgbaUnorm},
+		{"preference order", []vk.SurfaceFormat{bgraSrgb, bgraUnorm, rgbaUnorm}, []vk.SurfaceFormat{rgbaUnorm, bgraUnorm}, rgbaUnorm},
+		{"first reported when none is preferred", []vk.SurfaceFormat{a2b10, bgraUnorm}, []vk.SurfaceFormat{rgbaUnorm}, a2b10},
+		{"color space must match", []vk.SurfaceFormat{{Format: vk.FormatB8g8r8a8Srgb, ColorSpace: vk.ColorSpaceExtendedSrgbLinear}}, nil, vk.SurfaceFormat{Format: vk.FormatB8g8r8a8Srgb, ColorSpace: vk.ColorSpaceExtendedSrgbLinear}},
+		{"undefined takes the first preference", []vk.SurfaceFormat{{Format: vk.FormatUndefined, ColorSpace: vk.ColorSpaceSrgbNonlinear}}, []vk.SurfaceFormat{rgbaUnorm}, rgbaUnorm},
+		{"undefined takes the first default", []vk.SurfaceFormat{{Format: vk.FormatUndefined, ColorSpace: vk.ColorSpaceSrgbNonlinear}}, nil, bgraSrgb},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			c, err := resolveSwapchain(t, func(d *vkfake.Device) { d.SurfaceFormats = tc.surface }, vkutil.SwapchainPreferences{Formats: tc.pref})
+			if err != nil {
+				t.Fatalf("ResolveSwapchainConfig: %v", err)
+			}
+			if c.Format != tc.want {
+				t.Errorf("Format = %v, want %v", c.Format, tc.want)
+			}
+		})
+	}
+}
+
+func TestResolveSwapchainConfigNoFormat(t *testing.T) {
+	_, err := resolveSwapchain(t, func(d *vkfake.Device) { d.SurfaceFormats = nil }, vkutil.SwapchainPreferences{})
+	if err == nil {
+		t.Error("ResolveSwapchainConfig succeeded without surface formats")
+	}
+}
+
+func TestResolveSwapchainConfigPresentMode(t *testing.T) {
+	for _, tc := range []struct {
+		name    string
+		surface []vk.PresentMode
+		pref    []vk.PresentMode
+		want    vk.PresentMode
+	}{
+		{"default", []vk.PresentMode{vk.PresentModeFifo, vk.PresentModeImmediate, vk.PresentModeMailbox}, nil, vk.PresentModeMailbox},
+		{"default without MAILBOX", []vk.PresentMode{vk.PresentModeFifo, vk.PresentModeImmediate}, nil, vk.PresentModeImmediate},
+		{"default falls back to FIFO", []vk.PresentMode{vk.PresentModeFifo, vk.PresentModeFifoRelaxed}, nil, vk.PresentModeFifo},
+		{"preference order", []vk.PresentMode{vk.PresentModeFifo, vk.PresentModeImmediate, vk.PresentModeMailbox}, []vk.PresentMode{vk.PresentModeImmediate, vk.PresentModeMailbox}, vk.PresentModeImmediate},
+		{"unsupported preference", []vk.PresentMode{vk.PresentModeFifo, vk.PresentModeMailbox}, []vk.PresentMode{vk.PresentModeFifoRelaxed}, vk.PresentModeFifo},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			c, err := resolveSwapchain(t, func(d *vkfake.Device) { d.PresentModes = tc.surface }, vkutil.SwapchainPreferences{PresentModes: tc.pref})
+			if err != nil {
+				t.Fatalf("ResolveSwapchainConfig: %v", err)
+			}
+			if c.PresentMode != tc.want {
+				t.Errorf("PresentMode = %v, want %v", c.PresentMode, tc.want)
+			}
+		})
+	}
+}
+
+func TestResolveSwapchainConfigImageCount(t *testing.T) {
+	for _, tc := range []struct {
+		name     string
+		min, max uint32
+		pref     uint32
+		want     uint32
+	}{
+		{"minimum + 1", 2, 8, 0, 3},
+		{"minimum + 1 capped", 3, 3, 0, 3},
+		{"requested", 2, 8, 4, 4},
+		{"raised to the minimum", 3, 8, 1, 3},
+		{"lowered to the maximum", 2, 8, 20, 8},
+		{"no maximum", 2, 0, 20, 20},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			c, err := resolveSwapchain(t, func(d *vkfake.Device) {
+				d.SurfaceCapabilities.MinImageCount = tc.min
+				d.SurfaceCapabilities.MaxImageCount = tc.max
+			}, vkutil.SwapchainPreferences{ImageCount: tc.pref})
+			if err != nil {
+				t.Fatalf("ResolveSwapchainConfig: %v", err)
+			}
+			if c.ImageCount != tc.want {
+				t.Errorf("ImageCount = %v, want %v", c.ImageCount, tc.want)
+			}
+		})
+	}
+}
+
+func TestResolveSwapchainConfigExtent(t *testing.T) {
+	// 0xFFFFFFFF lets the swapchain decide, within the min and max extents
+	undefined := vk.Extent2D{Width: vk.MaxUint32, Height: vk.MaxUint32}
+	for _, tc := range []struct {
+		name    string
+		current vk.Extent2D
+		pref    vk.Extent2D
+		want    vk.Extent2D
+	}{
+		{"dictated by the surface", vk.Extent2D{Width: 800, Height: 600}, vk.Extent2D{Width: 1920, Height: 1080}, vk.Extent2D{Width: 800, Height: 600}},
+		{"requested", undefined, vk.Extent2D{Width: 1920, Height: 1080}, vk.Extent2D{Width: 1920, Height: 1080}},
+		{"raised to the minimum", undefined, vk.Extent2D{Width: 0, Height: 100}, vk.Extent2D{Width: 64, Height: 100}},
+		{"lowered to the maximum", undefined, vk.Extent2D{Width: 1024, Height: 40000}, vk.Extent2D{Width: 1024, Height: 4096}},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			c, err := resolveSwapchain(t, func(d *vkfake.Device) {
+				d.SurfaceCapabilities.CurrentExtent = tc.current
+				d.SurfaceCapabilities.MinImageExtent = vk.Extent2D{Width: 64, Height: 64}
+				d.SurfaceCapabilities.MaxImageExtent = vk.Extent2D{Width: 4096, Height: 4096}
+			}, vkutil.SwapchainPreferences{Extent: tc.pref})
+			if err != nil {
+				t.Fatalf("ResolveSwapchainConfig: %v", err)
+			}
+			if c.Extent != tc.want {
+				t.Errorf("Extent = %vx%v, want %vx%v", c.Extent.Width, c.Extent.Height, tc.want.Width, tc.want.Height)
+			}
+		})
+	}
+}
+
+func TestResolveSwapchainConfigUsage(t *testing.T) {
+	c, err := resolveSwapchain(t, nil, vkutil.SwapchainPreferences{Usage: vk.ImageUsageFlags(vk.ImageUsageTransferDstBit | vk.ImageUsageStorageBit)})
+	if err != nil {
+		t.Fatalf("ResolveSwapchainConfig: %v", err)
+	}
+	// The fake surface does not support storage
+	if want := vk.ImageUsageFlags(vk.ImageUsageColorAttachmentBit | vk.ImageUsageTransferDstBit); c.Usage != want {
+		t.Errorf("Usage = %#x, want %#x", c.Usage, want)
+	}
+
+	_, err = resolveSwapchain(t, func(d *vkfake.Device) {
+		d.SurfaceCapabilities.SupportedUsageFlags = vk.ImageUsageFlags(vk.ImageUsageTransferDstBit)
+	}, vkutil.SwapchainPreferences{})
+	if err == nil {
+		t.Error("ResolveSwapchainConfig succeeded without color attachment support")
+	}
+}
+
+func TestResolveSwapchainConfigCompositeAlpha(t *testing.T) {
+	c, err := resolveSwapchain(t, func(d *vkfake.Device) {
+		d.SurfaceCapabilities.SupportedCompositeAlpha = vk.CompositeAlphaFlags(vk.CompositeAlphaPostMultipliedBit | vk.CompositeAlphaInheritBit)
+		d.SurfaceCapabilities.CurrentTransform = vk.SurfaceTransformRotate90Bit
+	}, vkutil.SwapchainPreferences{})
+	if err != nil {
+		t.Fatalf("ResolveSwapchainConfig: %v", err)
+	}
+	if c.CompositeAlpha != vk.CompositeAlphaInheritBit {
+		t.Errorf("CompositeAlpha = %#x, want inherit", c.CompositeAlpha)
+	}
+	if c.PreTransform != vk.SurfaceTransformRotate90Bit {
+		t.Errorf("PreTransform = %#x, want the current transform", c.PreTransform)
+	}
+}
+
+func TestResolveSwapchainConfigSurfaceLost(t *testing.T) {
+	device := vkfake.NewDevice("GPU", vk.PhysicalDeviceTypeDiscreteGpu)
+	fake, physicalDevices := useFake(t, device)
+	fake.Results["vkGetPhysicalDeviceSurfaceCapabilitiesKHR"] = vk.ErrorSurfaceLost
+	_, err := vkutil.ResolveSwapchainConfig(physicalDevices[0], fake.NewSurface(), vkutil.SwapchainPreferences{})
+	if !errors.Is(err, vkutil.ErrSurfaceLost) {
+		t.Errorf("ResolveSwapchainConfig error = %v, want VK_ERROR_SURFACE_LOST_KHR", err)
+	}
+}
@@ -0,0 +1,384 @@
+package vkutil
+
+import (
+	"fmt"
+	"image"
+	"image/draw"
+	_ "image/jpeg" // registers the JPEG decoder with image.Decode
+	_ "image/png"  // registers the PNG decoder with image.Decode
+	"os"
+
+	vk "github.com/vulkan-go/vulkan"
+)
+
+// LoadImage decodes the PNG or JPEG file at path.
+func LoadImage(path string) (image.Image, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	img, _, err := image.Decode(f)
+	if err != nil {
+		return nil, fmt.Errorf("decoding %v: %w", path, err)
+	}
+	return img, nil
+}
+
+// TexturePixels converts img to the texels of format, tightly packed row by
+// row from the top left, as vkCmdCopyBufferToImage reads them. Colors are
+// not premultiplied by alpha. The bytes are the same for the Unorm and Srgb
+// variant of a format, the format only tells the sampler how to read them.
+// Supported are the 8 bit RGBA, BGRA and single channel formats.
+func TexturePixels(img image.Image, format vk.Format) ([]byte, error) {
+	bounds := img.Bounds()
+	switch format {
+	case vk.FormatR8g8b8a8Unorm, vk.FormatR8g8b8a8Srgb, vk.FormatB8g8r8a8Unorm, vk.FormatB8g8r8a8Srgb:
+		nrgba, ok := img.(*image.NRGBA)
+		if !ok || nrgba.Stride != 4*bounds.Dx() {
+			// draw converts from whatever color model the decoder picked, e.g. YCbCr for JPEG
+			nrgba = image.NewNRGBA(image.Rect(0, 0, bounds.Dx(), bounds.Dy()))
+			draw.Draw(nrgba, nrgba.Bounds(), img, bounds.Min, draw.Src)
+		}
+		pixels := nrgba.Pix[:4*bounds.Dx()*bounds.Dy()]
+		if format == vk.FormatB8g8r8a8Unorm || format == vk.FormatB8g8r8a8Srgb {
+			pixels = append([]byte(nil), pixels...)
+			for idx := 0; idx < len(pixels); idx += 4 {
+				pixels[idx], pixels[idx+2] = pixels[idx+2], pixels[idx]
+			}
+		}
+		return pixels, nil
+	case vk.FormatR8Unorm, vk.FormatR8Srgb:
+		gray, ok := img.(*image.Gray)
+		if !ok || gray.Stride != bounds.Dx() {
+			gray = image.NewGray(image.Rect(0, 0, bounds.Dx(), bounds.Dy()))
+			draw.Draw(gray, gray.Bounds(), img, bounds.Min, draw.Src)
+		}
+		return gray.Pix[:bounds.Dx()*bounds.Dy()], nil
+	}
+	return nil, fmt.Errorf("no conversion of images to %v", FormatName(format))
+}
+
+// TextureConfig tunes CreateTexture. Zero values pick the defaults.
+type TextureConfig struct {
+	// Format of the texels, vk.FormatR8g8b8a8Srgb by default. PNG and JPEG
+	// colors are sRGB encoded, an Srgb format makes the sampler return them
+	// linear. Use a Unorm format for data, e.g. normal maps.
+	Format vk.Format
+	// Nearest samples the closest texel instead of filtering linearly.
+	Nearest bool
+	// AddressMode is used for u, v and w, vk.SamplerAddressModeRepeat by default.
+	AddressMode vk.SamplerAddressMode
+	// MaxAnisotropy above 1 asks Samplers for anisotropic filtering.
+	MaxAnisotropy float32
+	// Samplers shares the sampler with the textures filtering alike. Without
+	// it the texture gets a sampler of its own, without anisotropy.
+	Samplers *SamplerCache
+	// MipLevels is the number of mip levels, the full chain of
+	// MipLevelCount by default. 1 turns mipmapping off.
+	MipLevels uint32
+	// MipFilter computes the mip levels on the CPU when the GPU cannot blit
+	// the format, MipFilterBox by default.
+	MipFilter MipFilter
+	// Name shows up in the allocator's leak report.
+	Name string
+}
+
+// Texture is a sampled image, 2D, a 2D array or a cube map, with its memory,
+// a view and a sampler, what a combined image sampler descriptor needs.
+type Texture struct {
+	Image      vk.Image
+	Allocation *Allocation
+	View       vk.ImageView
+	Sampler    vk.Sampler
+	Format     vk.Format
+	Extent     vk.Extent2D
+	MipLevels  uint32
+	// Layers is the number of array layers, 6 per cube.
+	Layers uint32
+	// ViewType is the type of View, what the shader declares: sampler2D,
+	// sampler2DArray, samplerCube or samplerCubeArray.
+	ViewType vk.ImageViewType
+
+	device      vk.Device
+	ownsSampler bool
+}
+
+// LoadTexture is CreateTexture for the PNG or JPEG file at path, named after
+// the file unless config has a Name.
+func (u *Uploader) LoadTexture(path string, config TextureConfig) (*Texture, error) {
+	img, err := LoadImage(path)
+	if err != nil {
+		return nil, err
+	}
+	if config.Name == "" {
+		config.Name = path
+	}
+	return u.CreateTexture(img, config)
+}
+
+// CreateTexture uploads img to a new device local texture of config.Format
+// and fills its mip levels, with vkCmdBlitImage on the graphics queue when
+// CanBlitMipmaps allows, with MipChain otherwise. The image ends in
+// SHADER_READ_ONLY_OPTIMAL, owned by the graphics queue family. Like Upload
+// it blocks until the copy is done.
+func (u *Uploader) CreateTexture(img image.Image, config TextureConfig) (*Texture, error) {
+	return u.CreateTextureArray([]image.Image{img}, vk.ImageViewType2d, config)
+}
+
+// CreateTextureArray is CreateTexture for a texture of layers, images of one
+// size, viewed as viewType: vk.ImageViewType2d for a single layer,
+// vk.ImageViewType2dArray, vk.ImageViewTypeCube for the six square faces of
+// a cube map in +X, -X, +Y, -Y, +Z, -Z order, or vk.ImageViewTypeCubeArray
+// for a multiple of six, which needs the imageCubeArray feature enabled as
+// passed to NewUploader. The layers are checked against the limits of
+// GetPhysicalDeviceImageProperties.
+func (u *Uploader) CreateTextureArray(layers []image.Image, viewType vk.ImageViewType, config TextureConfig) (*Texture, error) {
+	if config.Format == vk.FormatUndefined {
+		config.Format = vk.FormatR8g8b8a8Srgb
+	}
+	if len(layers) == 0 {
+		return nil, fmt.Errorf("texture %q: no images", config.Name)
+	}
+	bounds := layers[0].Bounds()
+	if bounds.Empty() {
+		return nil, fmt.Errorf("texture %q: empty image", config.Name)
+	}
+	for idx, layer := range layers {
+		if layer.Bounds().Size() != bounds.Size() {
+			return nil, fmt.Errorf("texture %q: layer %v is %v, layer 0 %v", config.Name, idx, layer.Bounds().Size(), bounds.Size())
+		}
+	}
+	t := &Texture{
+		Format:    config.Format,
+		Extent:    vk.Extent2D{Width: uint32(bounds.Dx()), Height: uint32(bounds.Dy())},
+		MipLevels: config.MipLevels,
+		Layers:    uint32(len(layers)),
+		ViewType:  viewType,
+		device:    u.device,
+	}
+	cube := viewType == vk.ImageViewTypeCube || viewType == vk.ImageViewTypeCubeArray
+	switch {
+	case viewType == vk.ImageViewType2d && t.Layers != 1:
+		return nil, fmt.Errorf("texture %q: %v layers for a 2D view, use vk.ImageViewType2dArray", config.Name, t.Layers)
+	case viewType == vk.ImageViewTypeCube && t.Layers != 6:
+		return nil, fmt.Errorf("texture %q: %v faces for a cube map", config.Name, t.Layers)
+	case viewType == vk.ImageViewTypeCubeArray && t.Layers%6 != 0:
+		return nil, fmt.Errorf("texture %q: %v faces for a cube map array, not a multiple of 6", config.Name, t.Layers)
+	case viewType != vk.ImageViewType2d && viewType != vk.ImageViewType2dArray && !cube:
+		return nil, fmt.Errorf("texture %q: view type %v is not 2D, 2D array or cube", config.Name, viewType)
+	case cube && t.Extent.Width != t.Extent.Height:
+		return nil, fmt.Errorf("texture %q: cube faces of %vx%v are not square", config.Name, t.Extent.Width, t.Extent.Height)
+	case viewType == vk.ImageViewTypeCubeArray && !u.imageCubeArray:
+		return nil, fmt.Errorf("texture %q: cube map arrays need the imageCubeArray feature enabled on the device", config.Name)
+	}
+	if t.MipLevels == 0 {
+		t.MipLevels = MipLevelCount(t.Extent)
+	} else if t.MipLevels > MipLevelCount(t.Extent) {
+		return nil, fmt.Errorf("texture %q: %v mip levels, %vx%v has %v", config.Name, t.MipLevels, t.Extent.Width, t.Extent.Height, MipLevelCount(t.Extent))
+	}
+
+	upload := imageUpload{extent: t.Extent, levels: t.MipLevels, layers: t.Layers}
+	usage := vk.ImageUsageFlags(vk.ImageUsageSampledBit | vk.ImageUsageTransferDstBit)
+	upload.blit = t.MipLevels > 1 && CanBlitMipmaps(u.physicalDevice, t.Format)
+	if upload.blit {
+		usage |= vk.ImageUsageFlags(vk.ImageUsageTransferSrcBit)
+	}
+	if err := checkImageLimits(u.physicalDevice, t, usage, cube); err != nil {
+		return nil, fmt.Errorf("texture %q: %w", config.Name, err)
+	}
+	var staged []byte
+	for layer, img := range layers {
+		pixels, err := TexturePixels(img, config.Format)
+		if err != nil {
+			return nil, fmt.Errorf("texture %q: %w", config.Name, err)
+		}
+		levelPixels := [][]byte{pixels}
+		if t.MipLevels > 1 && !upload.blit {
+			if levelPixels, err = MipChain(pixels, t.Extent, t.Format, t.MipLevels, config.MipFilter); err != nil {
+				return nil, fmt.Errorf("texture %q: %w", config.Name, err)
+			}
+		}
+		for level, data := range levelPixels {
+			upload.regions = append(upload.regions, stageRegion(&staged, data, MipExtent(t.Extent, uint32(level)), uint32(level), uint32(layer)))
+		}
+	}
+	staging, stagingAllocation, err := u.stage(staged, config.Name)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		driver.DestroyBuffer(u.device, staging)
+		u.allocator.Free(stagingAllocation)
+	}()
+
+	if t.Image, err = CreateImageArray(u.device, t.Format, t.Extent, t.MipLevels, t.Layers, cube, usage); err != nil {
+		return nil, err
+	}
+	if t.Allocation, err = u.allocator.AllocateForImage(t.Image, vk.ImageTilingOptimal, vk.MemoryPropertyFlags(vk.MemoryPropertyDeviceLocalBit), 0, config.Name); err != nil {
+		t.Destroy()
+		return nil, err
+	}
+	upload.image = t.Image
+	if err := u.copyToImage(staging, upload); err != nil {
+		t.Destroy()
+		return nil, fmt.Errorf("texture %q: %w", config.Name, err)
+	}
+	t.View, err = CreateImageViewRange(u.device, t.Image, t.Format, t.ViewType, vk.ImageSubresourceRange{
+		AspectMask: vk.ImageAspectFlags(vk.ImageAspectColorBit),
+		LevelCount: t.MipLevels,
+		LayerCount: t.Layers,
+	})
+	if err != nil {
+		t.Destroy()
+		return nil, err
+	}
+	samplerConfig := LinearSampler(config.AddressMode)
+	if config.Nearest {
+		samplerConfig = NearestSampler(config.AddressMode)
+	}
+	if config.Samplers != nil {
+		samplerConfig.MaxAnisotropy = config.MaxAnisotropy
+		t.Sampler, err = config.Samplers.Get(samplerConfig)
+	} else {
+		t.Sampler, err = CreateSampler(u.device, samplerConfig)
+		t.ownsSampler = err == nil
+	}
+	if err != nil {
+		t.Destroy()
+		return nil, err
+	}
+	return t, nil
+}
+
+// checkImageLimits returns an error when physicalDevice cannot create the
+// image of t with usage, of cube compatible layers when cube is set.
+func checkImageLimits(physicalDevice vk.PhysicalDevice, t *Texture, usage vk.ImageUsageFlags, cube bool) error {
+	var flags vk.ImageCreateFlags
+	if cube {
+		flags = vk.ImageCreateFlags(vk.ImageCreateCubeCompatibleBit)
+	}
+	limits, err := GetPhysicalDeviceImageProperties(physicalDevice, t.Format, vk.ImageType2d, vk.ImageTilingOptimal, usage, flags)
+	if err != nil {
+		return fmt.Errorf("%v images: %w", FormatName(t.Format), err)
+	}
+	switch {
+	case t.Extent.Width > limits.MaxExtent.Width || t.Extent.Height > limits.MaxExtent.Height:
+		return fmt.Errorf("%vx%v is larger than the %vx%v maximum", t.Extent.Width, t.Extent.Height, limits.MaxExtent.Width, limits.MaxExtent.Height)
+	case t.MipLevels > limits.MaxMipLevels:
+		return fmt.Errorf("%v mip levels, the maximum is %v", t.MipLevels, limits.MaxMipLevels)
+	case t.Layers > limits.MaxArrayLayers:
+		return fmt.Errorf("%v array layers, the maximum is %v", t.Layers, limits.MaxArrayLayers)
+	}
+	return nil
+}
+
+// Destroy destroys the view and image and frees its memory, and destroys
+// the sampler unless it came from a SamplerCache. The GPU must be done with it.
+func (t *Texture) Destroy() {
+	if t.ownsSampler {
+		driver.DestroySampler(t.device, t.Sampler)
+		t.ownsSampler = false
+	}
+	t.Sampler = vk.NullSampler
+	if t.View != vk.NullImageView {
+		driver.DestroyImageView(t.device, t.View)
+		t.View = vk.NullImageView
+	}
+	if t.Image != vk.NullImage {
+		driver.DestroyImage(t.device, t.Image)
+		t.Image = vk.NullImage
+	}
+	if t.Allocation != nil {
+		t.Allocation.allocator.Free(t.Allocation)
+		t.Allocation = nil
+	}
+}
+
+// imageUpload is what copyToImage copies to a color image.
+type imageUpload struct {
+	image          vk.Image
+	extent         vk.Extent2D
+	levels, layers uint32
+	// regions of the staging buffer to copy, see stageRegion.
+	regions []vk.BufferImageCopy
+	// blit fills levels 1 and up with CmdGenerateMipmaps instead.
+	blit bool
+}
+
+// stageRegion appends data, the texels of extent for mip level and array
+// layer, to staged and returns the copy of it. Regions start at multiples
+// of 4 as vkCmdCopyBufferToImage wants.
+func stageRegion(staged *[]byte, data []byte, extent vk.Extent2D, level, layer uint32) vk.BufferImageCopy {
+	for len(*staged)%4 != 0 {
+		*staged = append(*staged, 0)
+	}
+	region := vk.BufferImageCopy{
+		BufferOffset: vk.DeviceSize(len(*staged)),
+		// Zero row length and image height mean the texels are tightly packed
+		ImageSubresource: vk.ImageSubresourceLayers{
+			AspectMask:     vk.ImageAspectFlags(vk.ImageAspectColorBit),
+			MipLevel:       level,
+			BaseArrayLayer: layer,
+			LayerCount:     1,
+		},
+		ImageExtent: vk.Extent3D{Width: extent.Width, Height: extent.Height, Depth: 1},
+	}
+	*staged = append(*staged, data...)
+	return region
+}
+
+// copyToImage copies the regions of upload from src, moving the image from
+// UNDEFINED to TRANSFER_DST_OPTIMAL for the copy and on to
+// SHADER_READ_ONLY_OPTIMAL. Mip levels are blitted on the graphics queue,
+// after it acquired the image still in TRANSFER_DST_OPTIMAL.
+// https://www.khronos.org/registry/vulkan/specs/1.2-extensions/html/vkspec.html#copies-buffers-images
+func (u *Uploader) copyToImage(src vk.Buffer, upload imageUpload) error {
+	whole := vk.ImageSubresourceRange{
+		AspectMask: vk.ImageAspectFlags(vk.ImageAspectColorBit),
+		LevelCount: upload.levels,
+		LayerCount: upload.layers,
+	}
+	tracker := NewLayoutTracker()
+	tracker.Track(upload.image, upload.levels, upload.layers, vk.ImageLayoutUndefined)
+	waitStage, _ := layoutScope(vk.ImageLayoutShaderReadOnlyOptimal, false)
+	handoverLayout := vk.ImageLayoutShaderReadOnlyOptimal
+	if upload.blit {
+		waitStage, handoverLayout = vk.PipelineStageTransferBit, vk.ImageLayoutTransferDstOptimal
+	}
+	finish := func(cmd vk.CommandBuffer) error {
+		if upload.blit {
+			return CmdGenerateMipmaps(cmd, tracker, upload.image, upload.extent, upload.levels, upload.layers)
+		}
+		return tracker.Transition(cmd, upload.image, whole, vk.ImageLayoutShaderReadOnlyOptimal)
+	}
+	return u.runCopy(func(cmd vk.CommandBuffer) error {
+		if err := tracker.Transition(cmd, upload.image, whole, vk.ImageLayoutTransferDstOptimal); err != nil {
+			return err
+		}
+		vk.CmdCopyBufferToImage(cmd, src, upload.image, vk.ImageLayoutTransferDstOptimal, uint32(len(upload.regions)), upload.regions)
+		return nil
+	}, func(cmd vk.CommandBuffer, step handover) error {
+		if step == handoverVisible {
+			return finish(cmd)
+		}
+		// Release and acquire both do the layout transition, it happens once
+		barrier, srcStage, dstStage := ImageBarrier(upload.image, whole, vk.ImageLayoutTransferDstOptimal, handoverLayout)
+		barrier.SrcQueueFamilyIndex, barrier.DstQueueFamilyIndex = u.transferFamily, u.graphicsFamily
+		if step == handoverRelease {
+			barrier.DstAccessMask, dstStage = 0, vk.PipelineStageBottomOfPipeBit
+		} else {
+			// The transition must not start before the semaphore wait, chain it to the wait stage
+			barrier.SrcAccessMask, srcStage = 0, waitStage
+		}
+		vk.CmdPipelineBarrier(cmd, vk.PipelineStageFlags(srcStage), vk.PipelineStageFlags(dstStage), 0,
+			0, nil, 0, nil, 1, []vk.ImageMemoryBarrier{barrier})
+		if step == handoverRelease {
+			return nil
+		}
+		if err := tracker.Assume(upload.image, whole, handoverLayout); err != nil {
+			return err
+		}
+		return finish(cmd)
+	}, waitStage)
+}
@@ -0,0 +1,34 @@
+package vkutil_test
+
+import (
+	"image"
+	"strings"
+	"testing"
+
+	"github.com/goodshailesh/My-Vulkan-Projects/vkutil"
+	vk "github.com/vulkan-go/vulkan"
+)
+
+func TestCreateTextureArrayCubeArrayNotEnabled(t *testing.T) {
+	// The fake GPU supports imageCubeArray, the device is created without it
+	_, physicalDevice, _, device, queueFamilies := newDevice(t)
+	if vkutil.GetPhysicalDeviceFeatures(physicalDevice).ImageCubeArray != vk.True {
+		t.Fatal("the fake GPU does not support imageCubeArray")
+	}
+	allocator := vkutil.NewAllocator(device, physicalDevice, vkutil.AllocatorConfig{})
+	defer destroyAllocator(t, allocator)
+	uploader, err := vkutil.NewUploader(device, physicalDevice, nil, allocator, queueFamilies, vkutil.GetDeviceQueues(device, queueFamilies))
+	if err != nil {
+		t.Fatalf("NewUploader: %v", err)
+	}
+	defer uploader.Destroy()
+
+	var faces []image.Image
+	for idx := 0; idx < 6; idx++ {
+		faces = append(faces, image.NewNRGBA(image.Rect(0, 0, 4, 4)))
+	}
+	_, err = uploader.CreateTextureArray(faces, vk.ImageViewTypeCubeArray, vkutil.TextureConfig{Name: "sky"})
+	if err == nil || !strings.Contains(err.Error(), "imageCubeArray feature enabled") {
+		t.Errorf("CreateTextureArray error = %v, want the feature is not enabled", err)
+	}
+}
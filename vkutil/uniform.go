@@ -0,0 +1,69 @@
+package vkutil
+
+import (
+	"fmt"
+	"reflect"
+	"unsafe"
+
+	vk "github.com/vulkan-go/vulkan"
+)
+
+// UniformBuffer is a host visible, coherent uniform buffer holding one T,
+// written by the CPU every frame and read by shaders through a descriptor.
+// T must be plain data laid out like the std140 block of the shader: vec3
+// and vec4 members start at 16 bytes, so pad with float32 fields or use
+// [4]float32. Make one per frame in flight or swapchain image, the GPU may
+// still read the previous frame's while the next one is written.
+// https://www.khronos.org/registry/vulkan/specs/1.2-extensions/html/vkspec.html#interfaces-resources-layout
+type UniformBuffer[T any] struct {
+	Buffer     vk.Buffer
+	Allocation *Allocation
+	// Size is the size of T, the range to write into the descriptor.
+	Size vk.DeviceSize
+
+	device vk.Device
+	mapped unsafe.Pointer
+}
+
+// NewUniformBuffer creates the buffer with memory from allocator and maps it.
+func NewUniformBuffer[T any](device vk.Device, allocator *Allocator, name string) (*UniformBuffer[T], error) {
+	if err := checkPlainData(reflect.TypeOf((*T)(nil)).Elem()); err != nil {
+		return nil, fmt.Errorf("uniform buffer %q: %w", name, err)
+	}
+	u := &UniformBuffer[T]{Size: vk.DeviceSize(unsafe.Sizeof(*new(T))), device: device}
+	if u.Size == 0 {
+		return nil, fmt.Errorf("uniform buffer %q: %v is empty", name, reflect.TypeOf((*T)(nil)).Elem())
+	}
+	// Device local and host visible memory, where there is some, saves the GPU reading over the bus
+	var err error
+	u.Buffer, u.Allocation, err = CreateBufferWithMemory(device, allocator, u.Size, vk.BufferUsageFlags(vk.BufferUsageUniformBufferBit),
+		vk.MemoryPropertyFlags(vk.MemoryPropertyHostVisibleBit|vk.MemoryPropertyHostCoherentBit),
+		vk.MemoryPropertyFlags(vk.MemoryPropertyDeviceLocalBit), name)
+	if err != nil {
+		return nil, err
+	}
+	if u.mapped, err = u.Allocation.Map(); err != nil {
+		u.Destroy()
+		return nil, err
+	}
+	return u, nil
+}
+
+// Write copies value to the buffer. The memory is coherent, the next
+// submitted command buffer sees it without a flush.
+func (u *UniformBuffer[T]) Write(value T) {
+	*(*T)(u.mapped) = value
+}
+
+// Destroy destroys the buffer and frees its memory. The GPU must be done with it.
+func (u *UniformBuffer[T]) Destroy() {
+	if u.Buffer != vk.NullBuffer {
+		driver.DestroyBuffer(u.device, u.Buffer)
+		u.Buffer = vk.NullBuffer
+	}
+	if u.Allocation != nil {
+		u.Allocation.allocator.Free(u.Allocation)
+		u.Allocation = nil
+	}
+	u.mapped = nil
+}
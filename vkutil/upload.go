@@ -0,0 +1,475 @@
+package vkutil
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"unsafe"
+
+	vk "github.com/vulkan-go/vulkan"
+)
+
+// Uploader fills device local buffers from Go slices, and textures from
+// images. The data is copied to a host visible staging buffer, then
+// vkCmdCopyBuffer or vkCmdCopyBufferToImage on the transfer queue moves it
+// to the device local resource. When the transfer queue belongs to another
+// family than the graphics queue, the resource is released by the transfer
+// family and acquired by the graphics family so draw calls can use it.
+// https://www.khronos.org/registry/vulkan/specs/1.2-extensions/html/vkspec.html#synchronization-queue-transfers
+type Uploader struct {
+	device         vk.Device
+	physicalDevice vk.PhysicalDevice
+	// imageCubeArray is true when the device was created with the feature.
+	imageCubeArray bool
+	allocator      *Allocator
+	transferFamily uint32
+	graphicsFamily uint32
+	transfer       vk.Queue
+	graphics       vk.Queue
+	transferPool   vk.CommandPool
+	graphicsPool   vk.CommandPool
+}
+
+// NewUploader creates the command pools to upload with allocator's memory.
+// device was created from physicalDevice with the features enabled, queues
+// must come from GetDeviceQueues with queueFamilies. Without a transfer
+// family the copies run on the graphics queue.
+func NewUploader(device vk.Device, physicalDevice vk.PhysicalDevice, enabled *vk.PhysicalDeviceFeatures, allocator *Allocator, queueFamilies QueueFamilyIndices, queues Queues) (*Uploader, error) {
+	if queueFamilies.Graphics == vk.QueueFamilyIgnored {
+		return nil, errors.New("uploading needs a graphics queue family")
+	}
+	u := &Uploader{
+		device:         device,
+		physicalDevice: physicalDevice,
+		imageCubeArray: enabled != nil && enabled.ImageCubeArray == vk.True,
+		allocator:      allocator,
+		transferFamily: queueFamilies.Transfer,
+		graphicsFamily: queueFamilies.Graphics,
+		transfer:       queues.Transfer,
+		graphics:       queues.Graphics,
+	}
+	if u.transferFamily == vk.QueueFamilyIgnored {
+		u.transferFamily, u.transfer = u.graphicsFamily, u.graphics
+	}
+	// Upload command buffers are recorded once and freed, hence transient
+	var err error
+	u.transferPool, err = CreateCommandPool(device, u.transferFamily, vk.CommandPoolCreateFlags(vk.CommandPoolCreateTransientBit))
+	if err != nil {
+		return nil, err
+	}
+	if u.transferFamily != u.graphicsFamily {
+		u.graphicsPool, err = CreateCommandPool(device, u.graphicsFamily, vk.CommandPoolCreateFlags(vk.CommandPoolCreateTransientBit))
+		if err != nil {
+			u.Destroy()
+			return nil, err
+		}
+	}
+	return u, nil
+}
+
+// TransferFamily returns the queue family the copies run on.
+func (u *Uploader) TransferFamily() uint32 {
+	return u.transferFamily
+}
+
+// Destroy destroys the command pools. Buffers created by the uploader are
+// not affected.
+func (u *Uploader) Destroy() {
+	if u.transferPool != vk.NullCommandPool {
+		driver.DestroyCommandPool(u.device, u.transferPool)
+		u.transferPool = vk.NullCommandPool
+	}
+	if u.graphicsPool != vk.NullCommandPool {
+		driver.DestroyCommandPool(u.device, u.graphicsPool)
+		u.graphicsPool = vk.NullCommandPool
+	}
+}
+
+// DeviceBuffer is a device local buffer filled by an Uploader, owned by the
+// graphics queue family.
+type DeviceBuffer struct {
+	Buffer     vk.Buffer
+	Allocation *Allocation
+	// Size of the data in bytes.
+	Size vk.DeviceSize
+	// Count is the number of elements, e.g. the vertexCount of vkCmdDraw or
+	// the indexCount of vkCmdDrawIndexed.
+	Count uint32
+	// Stride is the size of one element in bytes.
+	Stride uint32
+	// IndexType is set for buffers made by CreateIndexBuffer.
+	IndexType vk.IndexType
+
+	device vk.Device
+}
+
+// CmdBindVertexBuffer binds b to binding of the vertex input.
+func (b *DeviceBuffer) CmdBindVertexBuffer(commandBuffer vk.CommandBuffer, binding uint32) {
+	vk.CmdBindVertexBuffers(commandBuffer, binding, 1, []vk.Buffer{b.Buffer}, []vk.DeviceSize{0})
+}
+
+// CmdBindIndexBuffer binds b, made by CreateIndexBuffer, as the index buffer.
+func (b *DeviceBuffer) CmdBindIndexBuffer(commandBuffer vk.CommandBuffer) {
+	vk.CmdBindIndexBuffer(commandBuffer, b.Buffer, 0, b.IndexType)
+}
+
+// Destroy destroys the buffer and frees its memory. The GPU must be done with it.
+func (b *DeviceBuffer) Destroy() {
+	if b.Buffer != vk.NullBuffer {
+		driver.DestroyBuffer(b.device, b.Buffer)
+		b.Buffer = vk.NullBuffer
+	}
+	if b.Allocation != nil {
+		b.Allocation.allocator.Free(b.Allocation)
+		b.Allocation = nil
+	}
+}
+
+// Index is the Go type of an index buffer element.
+type Index interface {
+	~uint16 | ~uint32
+}
+
+// CreateVertexBuffer uploads vertices to a new device local vertex buffer.
+// V must be plain data, without pointers, laid out like the vertex input of
+// the shader; VertexInput describes it for the pipeline.
+func CreateVertexBuffer[V any](u *Uploader, vertices []V, name string) (*DeviceBuffer, error) {
+	if err := checkPlainData(reflect.TypeOf((*V)(nil)).Elem()); err != nil {
+		return nil, fmt.Errorf("vertex buffer %q: %w", name, err)
+	}
+	b, err := u.Upload(sliceBytes(vertices), vk.BufferUsageFlags(vk.BufferUsageVertexBufferBit), name)
+	if err != nil {
+		return nil, err
+	}
+	b.Count, b.Stride = uint32(len(vertices)), uint32(unsafe.Sizeof(*new(V)))
+	return b, nil
+}
+
+// CreateIndexBuffer uploads indices to a new device local index buffer,
+// of vk.IndexTypeUint16 or vk.IndexTypeUint32 following I.
+func CreateIndexBuffer[I Index](u *Uploader, indices []I, name string) (*DeviceBuffer, error) {
+	b, err := u.Upload(sliceBytes(indices), vk.BufferUsageFlags(vk.BufferUsageIndexBufferBit), name)
+	if err != nil {
+		return nil, err
+	}
+	b.Count, b.Stride, b.IndexType = uint32(len(indices)), uint32(unsafe.Sizeof(*new(I))), indexType[I]()
+	return b, nil
+}
+
+// indexType returns the index type of elements of I.
+func indexType[I Index]() vk.IndexType {
+	if unsafe.Sizeof(*new(I)) == 4 {
+		return vk.IndexTypeUint32
+	}
+	return vk.IndexTypeUint16
+}
+
+// Upload copies data to a new device local buffer with usage, plus
+// TRANSFER_DST. It blocks until the copy is done, so the staging buffer can
+// be freed straight away; upload at load time, not every frame.
+func (u *Uploader) Upload(data []byte, usage vk.BufferUsageFlags, name string) (*DeviceBuffer, error) {
+	if len(data) == 0 {
+		return nil, fmt.Errorf("buffer %q: nothing to upload", name)
+	}
+	size := vk.DeviceSize(len(data))
+	staging, stagingAllocation, err := u.stage(data, name)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		driver.DestroyBuffer(u.device, staging)
+		u.allocator.Free(stagingAllocation)
+	}()
+
+	b := &DeviceBuffer{Size: size, device: u.device}
+	b.Buffer, b.Allocation, err = CreateBufferWithMemory(u.device, u.allocator, size, usage|vk.BufferUsageFlags(vk.BufferUsageTransferDstBit),
+		vk.MemoryPropertyFlags(vk.MemoryPropertyDeviceLocalBit), 0, name)
+	if err != nil {
+		return nil, err
+	}
+	if err := u.copyBuffer(staging, b.Buffer, size, usage); err != nil {
+		b.Destroy()
+		return nil, fmt.Errorf("buffer %q: %w", name, err)
+	}
+	return b, nil
+}
+
+// copyBuffer records the copy on the transfer queue and waits for it.
+func (u *Uploader) copyBuffer(src, dst vk.Buffer, size vk.DeviceSize, usage vk.BufferUsageFlags) error {
+	dstStage, dstAccess := bufferReadScope(usage)
+	return u.runCopy(func(cmd vk.CommandBuffer) error {
+		vk.CmdCopyBuffer(cmd, src, dst, 1, []vk.BufferCopy{{Size: size}})
+		return nil
+	}, func(cmd vk.CommandBuffer, step handover) error {
+		switch step {
+		case handoverVisible:
+			// One family: make the copy visible to the stages reading the buffer
+			bufferBarrier(cmd, dst, vk.PipelineStageTransferBit, dstStage, vk.AccessTransferWriteBit, dstAccess, vk.QueueFamilyIgnored, vk.QueueFamilyIgnored)
+		case handoverRelease:
+			// The destination access and stage are ignored on the releasing queue
+			bufferBarrier(cmd, dst, vk.PipelineStageTransferBit, vk.PipelineStageBottomOfPipeBit, vk.AccessTransferWriteBit, 0, u.transferFamily, u.graphicsFamily)
+		case handoverAcquire:
+			// Same barrier on the graphics queue, the source access is ignored there
+			bufferBarrier(cmd, dst, vk.PipelineStageTopOfPipeBit, dstStage, 0, dstAccess, u.transferFamily, u.graphicsFamily)
+		}
+		return nil
+	}, dstStage)
+}
+
+// stage copies data to a new host visible buffer the copies read from.
+func (u *Uploader) stage(data []byte, name string) (vk.Buffer, *Allocation, error) {
+	staging, stagingAllocation, err := CreateBufferWithMemory(u.device, u.allocator, vk.DeviceSize(len(data)), vk.BufferUsageFlags(vk.BufferUsageTransferSrcBit),
+		vk.MemoryPropertyFlags(vk.MemoryPropertyHostVisibleBit|vk.MemoryPropertyHostCoherentBit), 0, name+" staging")
+	if err != nil {
+		return vk.NullBuffer, nil, err
+	}
+	// Host coherent, so no vkFlushMappedMemoryRanges is needed, and the
+	// submit makes the host writes visible to the copy
+	mapped, err := stagingAllocation.Map()
+	if err != nil {
+		driver.DestroyBuffer(u.device, staging)
+		u.allocator.Free(stagingAllocation)
+		return vk.NullBuffer, nil, err
+	}
+	copy(unsafe.Slice((*byte)(mapped), len(data)), data)
+	return staging, stagingAllocation, nil
+}
+
+// handover is the barrier recorded after an upload copy.
+type handover int
+
+const (
+	// handoverVisible makes the copy visible to its readers, transfer and
+	// graphics queues are of one family.
+	handoverVisible handover = iota
+	// handoverRelease releases the resource from the transfer family.
+	handoverRelease
+	// handoverAcquire acquires the resource on the graphics family.
+	handoverAcquire
+)
+
+// runCopy records record on the transfer queue, then barrier, and waits for
+// it. Errors of record or barrier abandon the upload, the half recorded
+// command buffer is freed. With one family barrier is handoverVisible. With separate families the
+// copy ends with handoverRelease, and a second submit on the graphics queue
+// records handoverAcquire, ordered by a semaphore waited on at waitStage.
+func (u *Uploader) runCopy(record func(vk.CommandBuffer) error, barrier func(vk.CommandBuffer, handover) error, waitStage vk.PipelineStageFlagBits) error {
+	beginInfo := vk.CommandBufferBeginInfo{
+		SType: vk.StructureTypeCommandBufferBeginInfo,
+		Flags: vk.CommandBufferUsageFlags(vk.CommandBufferUsageOneTimeSubmitBit),
+	}
+	fenceCreateInfo := vk.FenceCreateInfo{SType: vk.StructureTypeFenceCreateInfo}
+	fence, err := driver.CreateFence(u.device, &fenceCreateInfo)
+	if err != nil {
+		return err
+	}
+	defer driver.DestroyFence(u.device, fence)
+
+	transferBuffers, err := AllocateCommandBuffers(u.device, u.transferPool, 1)
+	if err != nil {
+		return err
+	}
+	defer driver.FreeCommandBuffers(u.device, u.transferPool, transferBuffers)
+	cmd := transferBuffers[0]
+	if err := driver.BeginCommandBuffer(cmd, &beginInfo); err != nil {
+		return err
+	}
+	if err := record(cmd); err != nil {
+		return err
+	}
+
+	if u.transferFamily == u.graphicsFamily {
+		if err := barrier(cmd, handoverVisible); err != nil {
+			return err
+		}
+		if err := driver.EndCommandBuffer(cmd); err != nil {
+			return err
+		}
+		if err := submit(u.transfer, cmd, nil, 0, nil, fence); err != nil {
+			return err
+		}
+		return driver.WaitForFences(u.device, []vk.Fence{fence}, true, vk.MaxUint64)
+	}
+
+	if err := barrier(cmd, handoverRelease); err != nil {
+		return err
+	}
+	if err := driver.EndCommandBuffer(cmd); err != nil {
+		return err
+	}
+	semaphoreCreateInfo := vk.SemaphoreCreateInfo{SType: vk.StructureTypeSemaphoreCreateInfo}
+	released, err := driver.CreateSemaphore(u.device, &semaphoreCreateInfo)
+	if err != nil {
+		return err
+	}
+	defer driver.DestroySemaphore(u.device, released)
+	if err := submit(u.transfer, cmd, nil, 0, []vk.Semaphore{released}, vk.NullFence); err != nil {
+		return err
+	}
+
+	graphicsBuffers, err := AllocateCommandBuffers(u.device, u.graphicsPool, 1)
+	if err != nil {
+		// The release is already submitted, let it finish before its semaphore goes away
+		driver.QueueWaitIdle(u.transfer)
+		return err
+	}
+	defer driver.FreeCommandBuffers(u.device, u.graphicsPool, graphicsBuffers)
+	acquire := graphicsBuffers[0]
+	if err := driver.BeginCommandBuffer(acquire, &beginInfo); err != nil {
+		driver.QueueWaitIdle(u.transfer)
+		return err
+	}
+	if err := barrier(acquire, handoverAcquire); err != nil {
+		driver.QueueWaitIdle(u.transfer)
+		return err
+	}
+	if err := driver.EndCommandBuffer(acquire); err != nil {
+		driver.QueueWaitIdle(u.transfer)
+		return err
+	}
+	if err := submit(u.graphics, acquire, []vk.Semaphore{released}, waitStage, nil, fence); err != nil {
+		driver.QueueWaitIdle(u.transfer)
+		return err
+	}
+	return driver.WaitForFences(u.device, []vk.Fence{fence}, true, vk.MaxUint64)
+}
+
+func submit(queue vk.Queue, commandBuffer vk.CommandBuffer, wait []vk.Semaphore, waitStage vk.PipelineStageFlagBits, signal []vk.Semaphore, fence vk.Fence) error {
+	submitInfo := vk.SubmitInfo{
+		SType:                vk.StructureTypeSubmitInfo,
+		WaitSemaphoreCount:   uint32(len(wait)),
+		PWaitSemaphores:      wait,
+		CommandBufferCount:   1,
+		PCommandBuffers:      []vk.CommandBuffer{commandBuffer},
+		SignalSemaphoreCount: uint32(len(signal)),
+		PSignalSemaphores:    signal,
+	}
+	if len(wait) > 0 {
+		submitInfo.PWaitDstStageMask = []vk.PipelineStageFlags{vk.PipelineStageFlags(waitStage)}
+	}
+	return driver.QueueSubmit(queue, []vk.SubmitInfo{submitInfo}, fence)
+}
+
+func bufferBarrier(commandBuffer vk.CommandBuffer, buffer vk.Buffer, srcStage, dstStage vk.PipelineStageFlagBits, srcAccess, dstAccess vk.AccessFlagBits, srcFamily, dstFamily uint32) {
+	barrier := vk.BufferMemoryBarrier{
+		SType:               vk.StructureTypeBufferMemoryBarrier,
+		SrcAccessMask:       vk.AccessFlags(srcAccess),
+		DstAccessMask:       vk.AccessFlags(dstAccess),
+		SrcQueueFamilyIndex: srcFamily,
+		DstQueueFamilyIndex: dstFamily,
+		Buffer:              buffer,
+		Size:                vk.DeviceSize(vk.WholeSize),
+	}
+	vk.CmdPipelineBarrier(commandBuffer, vk.PipelineStageFlags(srcStage), vk.PipelineStageFlags(dstStage), 0,
+		0, nil, 1, []vk.BufferMemoryBarrier{barrier}, 0, nil)
+}
+
+// bufferReadScope returns the first stage and the access reading a buffer of usage.
+func bufferReadScope(usage vk.BufferUsageFlags) (vk.PipelineStageFlagBits, vk.AccessFlagBits) {
+	var stage vk.PipelineStageFlagBits
+	var access vk.AccessFlagBits
+	if usage&vk.BufferUsageFlags(vk.BufferUsageVertexBufferBit) != 0 {
+		stage, access = stage|vk.PipelineStageVertexInputBit, access|vk.AccessVertexAttributeReadBit
+	}
+	if usage&vk.BufferUsageFlags(vk.BufferUsageIndexBufferBit) != 0 {
+		stage, access = stage|vk.PipelineStageVertexInputBit, access|vk.AccessIndexReadBit
+	}
+	if usage&vk.BufferUsageFlags(vk.BufferUsageUniformBufferBit) != 0 {
+		stage, access = stage|vk.PipelineStageVertexShaderBit, access|vk.AccessUniformReadBit
+	}
+	if usage&vk.BufferUsageFlags(vk.BufferUsageStorageBufferBit) != 0 {
+		stage, access = stage|vk.PipelineStageVertexShaderBit, access|vk.AccessShaderReadBit
+	}
+	if usage&vk.BufferUsageFlags(vk.BufferUsageTransferSrcBit) != 0 {
+		stage, access = stage|vk.PipelineStageTransferBit, access|vk.AccessTransferReadBit
+	}
+	if stage == 0 {
+		stage = vk.PipelineStageAllCommandsBit
+	}
+	return stage, access
+}
+
+// sliceBytes returns the memory of s as bytes, without copying.
+func sliceBytes[T any](s []T) []byte {
+	if len(s) == 0 {
+		return nil
+	}
+	return unsafe.Slice((*byte)(unsafe.Pointer(unsafe.SliceData(s))), len(s)*int(unsafe.Sizeof(s[0])))
+}
+
+// checkPlainData returns an error when t holds Go pointers, which mean
+// nothing to the GPU.
+func checkPlainData(t reflect.Type) error {
+	switch t.Kind() {
+	case reflect.Bool, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Float32, reflect.Float64:
+		return nil
+	case reflect.Array:
+		return checkPlainData(t.Elem())
+	case reflect.Struct:
+		for idx := 0; idx < t.NumField(); idx++ {
+			if err := checkPlainData(t.Field(idx).Type); err != nil {
+				return fmt.Errorf("field %v: %w", t.Field(idx).Name, err)
+			}
+		}
+		return nil
+	}
+	return fmt.Errorf("%v is not plain data", t)
+}
+
+// VertexInput describes V, a struct of vertex attributes, for the pipeline:
+// a per-vertex binding with the size of V as stride, and one attribute per
+// field at locations 0, 1, 2... in field order. Fields are float32, int32
+// or uint32, or arrays of 2 to 4 of them like [3]float32 or a linmath
+// vector; [4]uint8 is a normalized RGBA color.
+func VertexInput[V any](binding uint32) (vk.VertexInputBindingDescription, []vk.VertexInputAttributeDescription, error) {
+	t := reflect.TypeOf((*V)(nil)).Elem()
+	bindingDescription := vk.VertexInputBindingDescription{
+		Binding:   binding,
+		Stride:    uint32(t.Size()),
+		InputRate: vk.VertexInputRateVertex,
+	}
+	if t.Kind() != reflect.Struct {
+		format, err := vertexFormat(t)
+		if err != nil {
+			return bindingDescription, nil, err
+		}
+		return bindingDescription, []vk.VertexInputAttributeDescription{{Location: 0, Binding: binding, Format: format}}, nil
+	}
+	var attributes []vk.VertexInputAttributeDescription
+	for idx := 0; idx < t.NumField(); idx++ {
+		field := t.Field(idx)
+		format, err := vertexFormat(field.Type)
+		if err != nil {
+			return bindingDescription, nil, fmt.Errorf("%v.%v: %w", t, field.Name, err)
+		}
+		attributes = append(attributes, vk.VertexInputAttributeDescription{
+			Location: uint32(idx),
+			Binding:  binding,
+			Format:   format,
+			Offset:   uint32(field.Offset),
+		})
+	}
+	return bindingDescription, attributes, nil
+}
+
+func vertexFormat(t reflect.Type) (vk.Format, error) {
+	// Formats by component kind, for 1 to 4 components
+	formats := map[reflect.Kind][4]vk.Format{
+		reflect.Float32: {vk.FormatR32Sfloat, vk.FormatR32g32Sfloat, vk.FormatR32g32b32Sfloat, vk.FormatR32g32b32a32Sfloat},
+		reflect.Int32:   {vk.FormatR32Sint, vk.FormatR32g32Sint, vk.FormatR32g32b32Sint, vk.FormatR32g32b32a32Sint},
+		reflect.Uint32:  {vk.FormatR32Uint, vk.FormatR32g32Uint, vk.FormatR32g32b32Uint, vk.FormatR32g32b32a32Uint},
+	}
+	kind, count := t.Kind(), 1
+	if kind == reflect.Array {
+		kind, count = t.Elem().Kind(), t.Len()
+		if kind == reflect.Uint8 && count == 4 {
+			return vk.FormatR8g8b8a8Unorm, nil
+		}
+	}
+	f, ok := formats[kind]
+	if !ok || count < 1 || count > 4 {
+		return vk.FormatUndefined, fmt.Errorf("no vertex format for %v", t)
+	}
+	return f[count-1], nil
+}
@@ -0,0 +1,117 @@
+package vkutil
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	vk "github.com/vulkan-go/vulkan"
+)
+
+type testVertex struct {
+	Position [3]float32
+	UV       [2]float32
+	Color    [4]uint8
+	Material uint32
+}
+
+func TestCheckPlainData(t *testing.T) {
+	type nested struct {
+		Vertices [2]testVertex
+		Weight   float64
+	}
+	for _, v := range []any{testVertex{}, nested{}, [4]int16{}, true} {
+		if err := checkPlainData(reflect.TypeOf(v)); err != nil {
+			t.Errorf("checkPlainData(%T): %v", v, err)
+		}
+	}
+	for _, tc := range []struct {
+		v    any
+		want string
+	}{
+		{struct{ Name string }{}, "field Name: string is not plain data"},
+		{struct{ Next *testVertex }{}, "field Next: *vkutil.testVertex is not plain data"},
+		{[]float32{}, "[]float32 is not plain data"},
+		{struct{ Inner struct{ Indices []uint16 } }{}, "field Inner: field Indices: []uint16 is not plain data"},
+	} {
+		if err := checkPlainData(reflect.TypeOf(tc.v)); err == nil || err.Error() != tc.want {
+			t.Errorf("checkPlainData(%T) = %v, want %q", tc.v, err, tc.want)
+		}
+	}
+}
+
+func TestVertexInput(t *testing.T) {
+	binding, attributes, err := VertexInput[testVertex](1)
+	if err != nil {
+		t.Fatalf("VertexInput: %v", err)
+	}
+	if binding.Binding != 1 || binding.Stride != 28 || binding.InputRate != vk.VertexInputRateVertex {
+		t.Errorf("binding = %+v, want binding 1 with a stride of 28 per vertex", binding)
+	}
+	want := []vk.VertexInputAttributeDescription{
+		{Location: 0, Binding: 1, Format: vk.FormatR32g32b32Sfloat, Offset: 0},
+		{Location: 1, Binding: 1, Format: vk.FormatR32g32Sfloat, Offset: 12},
+		{Location: 2, Binding: 1, Format: vk.FormatR8g8b8a8Unorm, Offset: 20},
+		{Location: 3, Binding: 1, Format: vk.FormatR32Uint, Offset: 24},
+	}
+	if !reflect.DeepEqual(attributes, want) {
+		t.Errorf("attributes = %+v, want %+v", attributes, want)
+	}
+
+	// A vertex that is not a struct is a single attribute
+	binding, attributes, err = VertexInput[[2]float32](0)
+	if err != nil {
+		t.Fatalf("VertexInput of [2]float32: %v", err)
+	}
+	if binding.Stride != 8 || len(attributes) != 1 || attributes[0].Format != vk.FormatR32g32Sfloat {
+		t.Errorf("VertexInput of [2]float32 = %+v, %+v", binding, attributes)
+	}
+}
+
+func TestVertexInputUnsupported(t *testing.T) {
+	type doubles struct {
+		Position [3]float64
+	}
+	if _, _, err := VertexInput[doubles](0); err == nil || !strings.Contains(err.Error(), "vkutil.doubles.Position: no vertex format for [3]float64") {
+		t.Errorf("VertexInput of float64 fields = %v", err)
+	}
+	for _, typ := range []reflect.Type{reflect.TypeOf([5]float32{}), reflect.TypeOf([3]uint8{}), reflect.TypeOf(int64(0))} {
+		if f, err := vertexFormat(typ); err == nil {
+			t.Errorf("vertexFormat(%v) = %v", typ, f)
+		}
+	}
+}
+
+func TestBufferReadScope(t *testing.T) {
+	for _, tc := range []struct {
+		usage  vk.BufferUsageFlagBits
+		stage  vk.PipelineStageFlagBits
+		access vk.AccessFlagBits
+	}{
+		{vk.BufferUsageVertexBufferBit, vk.PipelineStageVertexInputBit, vk.AccessVertexAttributeReadBit},
+		{vk.BufferUsageVertexBufferBit | vk.BufferUsageIndexBufferBit, vk.PipelineStageVertexInputBit, vk.AccessVertexAttributeReadBit | vk.AccessIndexReadBit},
+		{vk.BufferUsageUniformBufferBit, vk.PipelineStageVertexShaderBit, vk.AccessUniformReadBit},
+		{vk.BufferUsageStorageBufferBit, vk.PipelineStageVertexShaderBit, vk.AccessShaderReadBit},
+		{vk.BufferUsageIndexBufferBit | vk.BufferUsageTransferSrcBit, vk.PipelineStageVertexInputBit | vk.PipelineStageTransferBit, vk.AccessIndexReadBit | vk.AccessTransferReadBit},
+		// Nothing known reads it, wait for everything
+		{vk.BufferUsageTransferDstBit, vk.PipelineStageAllCommandsBit, 0},
+	} {
+		stage, access := bufferReadScope(vk.BufferUsageFlags(tc.usage))
+		if stage != tc.stage || access != tc.access {
+			t.Errorf("bufferReadScope(%#x) = %#x, %#x, want %#x, %#x", tc.usage, stage, access, tc.stage, tc.access)
+		}
+	}
+}
+
+func TestIndexType(t *testing.T) {
+	type meshIndex uint32
+	if got := indexType[uint16](); got != vk.IndexTypeUint16 {
+		t.Errorf("indexType[uint16] = %v", got)
+	}
+	if got := indexType[uint32](); got != vk.IndexTypeUint32 {
+		t.Errorf("indexType[uint32] = %v", got)
+	}
+	if got := indexType[meshIndex](); got != vk.IndexTypeUint32 {
+		t.Errorf("indexType[meshIndex] = %v", got)
+	}
+}
@@ -0,0 +1,55 @@
+package vkutil
+
+import (
+	"strings"
+
+	vk "github.com/vulkan-go/vulkan"
+)
+
+// OrPanic panics if err is a non-nil error, a non-success vk.Result or a
+// false condition. Handy in examples where there is nothing sensible to do
+// on failure.
+func OrPanic(err interface{}) {
+	switch v := err.(type) {
+	case error:
+		if v != nil {
+			panic(err)
+		}
+	case vk.Result:
+		if err := Check("", v); err != nil {
+			panic(err)
+		}
+	case bool:
+		if !v {
+			panic("condition failed: != true")
+		}
+	}
+}
+
+// safeString returns s null-terminated, as expected by the vk package for
+// the layer and extension names passed down to the C side.
+func safeString(s string) string {
+	if strings.HasSuffix(s, "\x00") {
+		return s
+	}
+	return s + "\x00"
+}
+
+func safeStrings(list []string) []string {
+	out := make([]string, 0, len(list))
+	for _, s := range list {
+		out = append(out, safeString(s))
+	}
+	return out
+}
+
+// Contains reports whether name is in names, ignoring a trailing null terminator.
+func Contains(names []string, name string) bool {
+	name = strings.TrimSuffix(name, "\x00")
+	for _, n := range names {
+		if strings.TrimSuffix(n, "\x00") == name {
+			return true
+		}
+	}
+	return false
+}
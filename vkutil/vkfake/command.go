@@ -0,0 +1,423 @@
+package vkfake
+
+import (
+	"errors"
+	"fmt"
+	"unsafe"
+
+	"github.com/goodshailesh/My-Vulkan-Projects/vkutil"
+	vk "github.com/vulkan-go/vulkan"
+)
+
+// commandBufferState is the lifecycle of a command buffer.
+// https://www.khronos.org/registry/vulkan/specs/1.2-extensions/html/vkspec.html#commandbuffers-lifecycle
+type commandBufferState int
+
+const (
+	commandBufferInitial commandBufferState = iota
+	commandBufferRecording
+	commandBufferExecutable
+	// commandBufferInvalid is a ONE_TIME_SUBMIT buffer after its submit.
+	commandBufferInvalid
+)
+
+func (s commandBufferState) String() string {
+	switch s {
+	case commandBufferInitial:
+		return "initial"
+	case commandBufferRecording:
+		return "recording"
+	case commandBufferExecutable:
+		return "executable"
+	case commandBufferInvalid:
+		return "invalid"
+	}
+	return fmt.Sprintf("commandBufferState(%d)", int(s))
+}
+
+func (d *Driver) CreateCommandPool(device vk.Device, createInfo *vk.CommandPoolCreateInfo) (vk.CommandPool, error) {
+	const command = "vkCreateCommandPool"
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	owner := d.mustLookup(command, "VkDevice", unsafe.Pointer(device))
+	if err := d.call(command); err != nil {
+		return vk.NullCommandPool, err
+	}
+	if _, ok := owner.families[createInfo.QueueFamilyIndex]; !ok {
+		return vk.NullCommandPool, invalid(command, "queue family %v was not requested at device creation", createInfo.QueueFamilyIndex)
+	}
+	handle, o := d.newObject("VkCommandPool", owner.device)
+	o.resetCommandBuffer = createInfo.Flags&vk.CommandPoolCreateFlags(vk.CommandPoolCreateResetCommandBufferBit) != 0
+	return vk.CommandPool(handle), nil
+}
+
+// DestroyCommandPool frees the command buffers of pool as well.
+func (d *Driver) DestroyCommandPool(device vk.Device, pool vk.CommandPool) {
+	d.destroy("vkDestroyCommandPool", "VkCommandPool", unsafe.Pointer(pool))
+}
+
+func (d *Driver) AllocateCommandBuffers(device vk.Device, allocateInfo *vk.CommandBufferAllocateInfo) ([]vk.CommandBuffer, error) {
+	const command = "vkAllocateCommandBuffers"
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	dev := d.mustLookup(command, "VkDevice", unsafe.Pointer(device)).device
+	if err := d.call(command); err != nil {
+		return nil, err
+	}
+	pool := d.lookup("VkCommandPool", unsafe.Pointer(allocateInfo.CommandPool))
+	if pool == nil {
+		return nil, invalid(command, "unknown or destroyed VkCommandPool")
+	}
+	if allocateInfo.CommandBufferCount == 0 {
+		return nil, invalid(command, "commandBufferCount is 0")
+	}
+	commandBuffers := make([]vk.CommandBuffer, 0, allocateInfo.CommandBufferCount)
+	for idx := uint32(0); idx < allocateInfo.CommandBufferCount; idx++ {
+		handle, o := d.newObject("VkCommandBuffer", dev)
+		o.parent = unsafe.Pointer(allocateInfo.CommandPool)
+		pool.commandBuffers = append(pool.commandBuffers, handle)
+		commandBuffers = append(commandBuffers, vk.CommandBuffer(handle))
+	}
+	return commandBuffers, nil
+}
+
+func (d *Driver) FreeCommandBuffers(device vk.Device, pool vk.CommandPool, commandBuffers []vk.CommandBuffer) {
+	const command = "vkFreeCommandBuffers"
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.Calls = append(d.Calls, command)
+	p := d.mustLookup(command, "VkCommandPool", unsafe.Pointer(pool))
+	for _, commandBuffer := range commandBuffers {
+		handle := unsafe.Pointer(commandBuffer)
+		if handle == nil {
+			continue
+		}
+		if d.mustLookup(command, "VkCommandBuffer", handle).parent != unsafe.Pointer(pool) {
+			panic(fmt.Sprintf("vkfake: %v: the VkCommandBuffer was not allocated from this pool", command))
+		}
+		delete(d.objects, handle)
+		for idx, h := range p.commandBuffers {
+			if h == handle {
+				p.commandBuffers = append(p.commandBuffers[:idx], p.commandBuffers[idx+1:]...)
+				break
+			}
+		}
+	}
+}
+
+// BeginCommandBuffer resets an executable command buffer only when its pool
+// was created with RESET_COMMAND_BUFFER, like the spec asks.
+func (d *Driver) BeginCommandBuffer(commandBuffer vk.CommandBuffer, beginInfo *vk.CommandBufferBeginInfo) error {
+	const command = "vkBeginCommandBuffer"
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	o := d.mustLookup(command, "VkCommandBuffer", unsafe.Pointer(commandBuffer))
+	if err := d.call(command); err != nil {
+		return err
+	}
+	switch {
+	case o.state == commandBufferRecording:
+		return invalid(command, "VkCommandBuffer #%v is already recording", o.serial)
+	case o.state != commandBufferInitial && !d.objects[o.parent].resetCommandBuffer:
+		return invalid(command, "VkCommandBuffer #%v is %v and its pool was not created with RESET_COMMAND_BUFFER", o.serial, o.state)
+	}
+	o.state = commandBufferRecording
+	o.oneTimeSubmit = beginInfo.Flags&vk.CommandBufferUsageFlags(vk.CommandBufferUsageOneTimeSubmitBit) != 0
+	return nil
+}
+
+func (d *Driver) EndCommandBuffer(commandBuffer vk.CommandBuffer) error {
+	const command = "vkEndCommandBuffer"
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	o := d.mustLookup(command, "VkCommandBuffer", unsafe.Pointer(commandBuffer))
+	if err := d.call(command); err != nil {
+		// Errors during recording are reported here and leave the command buffer invalid
+		if o.state == commandBufferRecording {
+			o.state = commandBufferInvalid
+		}
+		return err
+	}
+	if o.state != commandBufferRecording {
+		return invalid(command, "VkCommandBuffer #%v is %v, not recording", o.serial, o.state)
+	}
+	o.state = commandBufferExecutable
+	return nil
+}
+
+func (d *Driver) CreateSemaphore(device vk.Device, createInfo *vk.SemaphoreCreateInfo) (vk.Semaphore, error) {
+	const command = "vkCreateSemaphore"
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	dev := d.mustLookup(command, "VkDevice", unsafe.Pointer(device)).device
+	if err := d.call(command); err != nil {
+		return vk.NullSemaphore, err
+	}
+	handle, _ := d.newObject("VkSemaphore", dev)
+	return vk.Semaphore(handle), nil
+}
+
+func (d *Driver) DestroySemaphore(device vk.Device, semaphore vk.Semaphore) {
+	d.destroy("vkDestroySemaphore", "VkSemaphore", unsafe.Pointer(semaphore))
+}
+
+func (d *Driver) CreateFence(device vk.Device, createInfo *vk.FenceCreateInfo) (vk.Fence, error) {
+	const command = "vkCreateFence"
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	dev := d.mustLookup(command, "VkDevice", unsafe.Pointer(device)).device
+	if err := d.call(command); err != nil {
+		return vk.NullFence, err
+	}
+	handle, o := d.newObject("VkFence", dev)
+	o.signaled = createInfo.Flags&vk.FenceCreateFlags(vk.FenceCreateSignaledBit) != 0
+	return vk.Fence(handle), nil
+}
+
+func (d *Driver) DestroyFence(device vk.Device, fence vk.Fence) {
+	d.destroy("vkDestroyFence", "VkFence", unsafe.Pointer(fence))
+}
+
+// WaitForFences fails with VK_TIMEOUT right away instead of blocking: the
+// fake finishes work when it is submitted, a fence still unsignaled now
+// never will be.
+func (d *Driver) WaitForFences(device vk.Device, fences []vk.Fence, waitAll bool, timeout uint64) error {
+	const command = "vkWaitForFences"
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.mustLookup(command, "VkDevice", unsafe.Pointer(device))
+	if err := d.call(command); err != nil {
+		return err
+	}
+	if len(fences) == 0 {
+		return invalid(command, "no fences")
+	}
+	signaled := 0
+	for _, fence := range fences {
+		o := d.lookup("VkFence", unsafe.Pointer(fence))
+		if o == nil {
+			return invalid(command, "unknown or destroyed VkFence")
+		}
+		if o.signaled {
+			signaled++
+		}
+	}
+	if signaled == len(fences) || !waitAll && signaled > 0 {
+		return nil
+	}
+	return vkutil.Check(command, vk.Timeout)
+}
+
+func (d *Driver) ResetFences(device vk.Device, fences []vk.Fence) error {
+	const command = "vkResetFences"
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.mustLookup(command, "VkDevice", unsafe.Pointer(device))
+	if err := d.call(command); err != nil {
+		return err
+	}
+	for _, fence := range fences {
+		o := d.lookup("VkFence", unsafe.Pointer(fence))
+		if o == nil {
+			return invalid(command, "unknown or destroyed VkFence")
+		}
+		o.signaled = false
+	}
+	return nil
+}
+
+// queue checks that queue was handed out by vkGetDeviceQueue.
+func (d *Driver) queue(command string, queue vk.Queue) {
+	for _, handle := range d.queues {
+		if handle == unsafe.Pointer(queue) {
+			return
+		}
+	}
+	panic(fmt.Sprintf("vkfake: %v called with a VkQueue vkGetDeviceQueue did not return", command))
+}
+
+// QueueSubmit runs the work right away: the wait semaphores are consumed,
+// the signal semaphores and the fence signaled.
+func (d *Driver) QueueSubmit(queue vk.Queue, submits []vk.SubmitInfo, fence vk.Fence) error {
+	const command = "vkQueueSubmit"
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.queue(command, queue)
+	if err := d.call(command); err != nil {
+		return err
+	}
+	var f *object
+	if fence != vk.NullFence {
+		if f = d.lookup("VkFence", unsafe.Pointer(fence)); f == nil {
+			return invalid(command, "unknown or destroyed VkFence")
+		}
+		if f.signaled {
+			return invalid(command, "VkFence #%v is already signaled", f.serial)
+		}
+	}
+	// Checked before anything changes, a rejected submit has no effect
+	var waits, signals, commandBuffers []*object
+	for _, submit := range submits {
+		for _, semaphore := range submit.PWaitSemaphores[:submit.WaitSemaphoreCount] {
+			o := d.lookup("VkSemaphore", unsafe.Pointer(semaphore))
+			if o == nil {
+				return invalid(command, "unknown or destroyed VkSemaphore")
+			}
+			if !o.signaled {
+				return invalid(command, "waits on VkSemaphore #%v, which has no signal pending", o.serial)
+			}
+			waits = append(waits, o)
+		}
+		if len(submit.PWaitDstStageMask) < int(submit.WaitSemaphoreCount) {
+			return invalid(command, "%v wait stages for %v semaphores", len(submit.PWaitDstStageMask), submit.WaitSemaphoreCount)
+		}
+		for _, commandBuffer := range submit.PCommandBuffers[:submit.CommandBufferCount] {
+			o := d.lookup("VkCommandBuffer", unsafe.Pointer(commandBuffer))
+			if o == nil {
+				return invalid(command, "unknown or freed VkCommandBuffer")
+			}
+			if o.state != commandBufferExecutable {
+				return invalid(command, "VkCommandBuffer #%v is %v, not executable", o.serial, o.state)
+			}
+			commandBuffers = append(commandBuffers, o)
+		}
+		for _, semaphore := range submit.PSignalSemaphores[:submit.SignalSemaphoreCount] {
+			o := d.lookup("VkSemaphore", unsafe.Pointer(semaphore))
+			if o == nil {
+				return invalid(command, "unknown or destroyed VkSemaphore")
+			}
+			if o.signaled {
+				return invalid(command, "signals VkSemaphore #%v, which is already signaled", o.serial)
+			}
+			signals = append(signals, o)
+		}
+	}
+	for _, o := range waits {
+		o.signaled = false
+	}
+	for _, o := range commandBuffers {
+		if o.oneTimeSubmit {
+			o.state = commandBufferInvalid
+		}
+	}
+	for _, o := range signals {
+		o.signaled = true
+	}
+	if f != nil {
+		f.signaled = true
+	}
+	return nil
+}
+
+func (d *Driver) QueueWaitIdle(queue vk.Queue) error {
+	const command = "vkQueueWaitIdle"
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.queue(command, queue)
+	return d.call(command)
+}
+
+// AcquireNextImage hands out the images in AcquireOrder, or else the first
+// one not acquired, and signals semaphore and fence at once. A VK_SUBOPTIMAL_KHR scripted in
+// Results still acquires the image, like a real driver.
+func (d *Driver) AcquireNextImage(device vk.Device, swapchain vk.Swapchain, timeout uint64, semaphore vk.Semaphore, fence vk.Fence) (uint32, error) {
+	const command = "vkAcquireNextImageKHR"
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.mustLookup(command, "VkDevice", unsafe.Pointer(device))
+	s := d.mustLookup(command, "VkSwapchainKHR", unsafe.Pointer(swapchain))
+	err := d.call(command)
+	if err != nil && !errors.Is(err, vkutil.ErrSuboptimal) {
+		return 0, err
+	}
+	var sem, f *object
+	if semaphore == vk.NullSemaphore && fence == vk.NullFence {
+		return 0, invalid(command, "neither a semaphore nor a fence")
+	}
+	if semaphore != vk.NullSemaphore {
+		if sem = d.lookup("VkSemaphore", unsafe.Pointer(semaphore)); sem == nil {
+			return 0, invalid(command, "unknown or destroyed VkSemaphore")
+		}
+		if sem.signaled {
+			return 0, invalid(command, "VkSemaphore #%v still has a signal pending", sem.serial)
+		}
+	}
+	if fence != vk.NullFence {
+		if f = d.lookup("VkFence", unsafe.Pointer(fence)); f == nil {
+			return 0, invalid(command, "unknown or destroyed VkFence")
+		}
+		if f.signaled {
+			return 0, invalid(command, "VkFence #%v is already signaled", f.serial)
+		}
+	}
+	image := -1
+	if len(d.AcquireOrder) > 0 {
+		image = int(d.AcquireOrder[0])
+		d.AcquireOrder = d.AcquireOrder[1:]
+		if image >= len(s.acquired) || s.acquired[image] {
+			return 0, invalid(command, "scripted image %v of VkSwapchainKHR #%v cannot be acquired", image, s.serial)
+		}
+	} else {
+		for idx, acquired := range s.acquired {
+			if !acquired {
+				image = idx
+				break
+			}
+		}
+	}
+	if image < 0 {
+		return 0, vkutil.Check(command, vk.Timeout)
+	}
+	s.acquired[image] = true
+	if sem != nil {
+		sem.signaled = true
+	}
+	if f != nil {
+		f.signaled = true
+	}
+	return uint32(image), err
+}
+
+// QueuePresent releases the presented images. The wait semaphores are
+// consumed even when an error scripted in Results is VK_ERROR_OUT_OF_DATE_KHR
+// or VK_SUBOPTIMAL_KHR, as the present was still queued.
+func (d *Driver) QueuePresent(queue vk.Queue, presentInfo *vk.PresentInfo) error {
+	const command = "vkQueuePresentKHR"
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.queue(command, queue)
+	err := d.call(command)
+	if err != nil && !errors.Is(err, vkutil.ErrOutOfDate) && !errors.Is(err, vkutil.ErrSuboptimal) {
+		return err
+	}
+	var waits []*object
+	for _, semaphore := range presentInfo.PWaitSemaphores[:presentInfo.WaitSemaphoreCount] {
+		o := d.lookup("VkSemaphore", unsafe.Pointer(semaphore))
+		if o == nil {
+			return invalid(command, "unknown or destroyed VkSemaphore")
+		}
+		if !o.signaled {
+			return invalid(command, "waits on VkSemaphore #%v, which has no signal pending", o.serial)
+		}
+		waits = append(waits, o)
+	}
+	swapchains := make([]*object, presentInfo.SwapchainCount)
+	for idx, swapchain := range presentInfo.PSwapchains[:presentInfo.SwapchainCount] {
+		s := d.lookup("VkSwapchainKHR", unsafe.Pointer(swapchain))
+		if s == nil {
+			return invalid(command, "unknown or destroyed VkSwapchainKHR")
+		}
+		image := presentInfo.PImageIndices[idx]
+		if image >= uint32(len(s.acquired)) || !s.acquired[image] {
+			return invalid(command, "image %v of VkSwapchainKHR #%v was not acquired", image, s.serial)
+		}
+		swapchains[idx] = s
+	}
+	for idx, s := range swapchains {
+		s.acquired[presentInfo.PImageIndices[idx]] = false
+	}
+	for _, o := range waits {
+		o.signaled = false
+	}
+	return err
+}
@@ -0,0 +1,208 @@
+package vkfake
+
+import (
+	vk "github.com/vulkan-go/vulkan"
+)
+
+const gibibyte = 1 << 30
+
+// Device is the configuration of one fake physical device. Every field is
+// what the matching vkGetPhysicalDevice* command returns, edit them freely
+// before the device is enumerated.
+type Device struct {
+	Properties    vk.PhysicalDeviceProperties
+	Features      vk.PhysicalDeviceFeatures
+	Memory        vk.PhysicalDeviceMemoryProperties
+	QueueFamilies []vk.QueueFamilyProperties
+	// PresentFamilies are the queue families that can present to any surface.
+	PresentFamilies []uint32
+	Extensions      []string
+	Layers          []string
+	// UUID is the deviceUUID, nil when the device cannot tell.
+	UUID []byte
+
+	// Formats are the properties of the supported formats, the ones missing
+	// have no features and fail vkGetPhysicalDeviceImageFormatProperties.
+	Formats map[vk.Format]vk.FormatProperties
+	// ImageFormatProperties is returned for every supported format.
+	ImageFormatProperties vk.ImageFormatProperties
+
+	SurfaceCapabilities vk.SurfaceCapabilities
+	SurfaceFormats      []vk.SurfaceFormat
+	PresentModes        []vk.PresentMode
+
+	// BufferAlignment and ImageAlignment are the alignments of the memory
+	// requirements of buffers and images.
+	BufferAlignment vk.DeviceSize
+	ImageAlignment  vk.DeviceSize
+}
+
+// NewDevice returns a device of the given type shaped after a typical GPU
+// of that kind:
+//   - discrete: a device local heap plus a host heap, a graphics family, an
+//     async compute family and a transfer only family.
+//   - anything else: one heap shared with the host and one family doing it all.
+//
+// Both support VK_KHR_swapchain, present from family 0, offer FIFO, MAILBOX
+// and IMMEDIATE, BGRA8 sRGB and UNORM surface formats and an 800x600 surface.
+func NewDevice(name string, deviceType vk.PhysicalDeviceType) *Device {
+	d := &Device{
+		PresentFamilies: []uint32{0},
+		Extensions:      []string{"VK_KHR_swapchain"},
+		Formats:         defaultFormats(),
+		ImageFormatProperties: vk.ImageFormatProperties{
+			MaxExtent:       vk.Extent3D{Width: 16384, Height: 16384, Depth: 1},
+			MaxMipLevels:    15,
+			MaxArrayLayers:  2048,
+			SampleCounts:    vk.SampleCountFlags(vk.SampleCount1Bit | vk.SampleCount2Bit | vk.SampleCount4Bit | vk.SampleCount8Bit),
+			MaxResourceSize: 1 << 31,
+		},
+		SurfaceCapabilities: vk.SurfaceCapabilities{
+			MinImageCount:           2,
+			MaxImageCount:           8,
+			CurrentExtent:           vk.Extent2D{Width: 800, Height: 600},
+			MinImageExtent:          vk.Extent2D{Width: 1, Height: 1},
+			MaxImageExtent:          vk.Extent2D{Width: 16384, Height: 16384},
+			MaxImageArrayLayers:     1,
+			SupportedTransforms:     vk.SurfaceTransformFlags(vk.SurfaceTransformIdentityBit),
+			CurrentTransform:        vk.SurfaceTransformIdentityBit,
+			SupportedCompositeAlpha: vk.CompositeAlphaFlags(vk.CompositeAlphaOpaqueBit),
+			SupportedUsageFlags:     vk.ImageUsageFlags(vk.ImageUsageColorAttachmentBit | vk.ImageUsageTransferSrcBit | vk.ImageUsageTransferDstBit),
+		},
+		SurfaceFormats: []vk.SurfaceFormat{
+			{Format: vk.FormatB8g8r8a8Srgb, ColorSpace: vk.ColorSpaceSrgbNonlinear},
+			{Format: vk.FormatB8g8r8a8Unorm, ColorSpace: vk.ColorSpaceSrgbNonlinear},
+		},
+		PresentModes:    []vk.PresentMode{vk.PresentModeFifo, vk.PresentModeMailbox, vk.PresentModeImmediate},
+		BufferAlignment: 256,
+		ImageAlignment:  4096,
+	}
+	d.Properties = vk.PhysicalDeviceProperties{
+		ApiVersion:    vk.MakeVersion(1, 0, 0),
+		DriverVersion: vk.MakeVersion(1, 0, 0),
+		VendorID:      0x10005, // VK_VENDOR_ID_MESA
+		DeviceType:    deviceType,
+		Limits: vk.PhysicalDeviceLimits{
+			MaxImageDimension1D:              16384,
+			MaxImageDimension2D:              16384,
+			MaxImageDimension3D:              2048,
+			MaxImageDimensionCube:            16384,
+			MaxImageArrayLayers:              2048,
+			MaxMemoryAllocationCount:         4096,
+			MaxSamplerAllocationCount:        4000,
+			BufferImageGranularity:           1024,
+			MaxBoundDescriptorSets:           8,
+			MaxSamplerAnisotropy:             16,
+			MaxFramebufferWidth:              16384,
+			MaxFramebufferHeight:             16384,
+			MaxFramebufferLayers:             2048,
+			FramebufferColorSampleCounts:     vk.SampleCountFlags(vk.SampleCount1Bit | vk.SampleCount2Bit | vk.SampleCount4Bit | vk.SampleCount8Bit),
+			FramebufferDepthSampleCounts:     vk.SampleCountFlags(vk.SampleCount1Bit | vk.SampleCount2Bit | vk.SampleCount4Bit | vk.SampleCount8Bit),
+			FramebufferStencilSampleCounts:   vk.SampleCountFlags(vk.SampleCount1Bit | vk.SampleCount2Bit | vk.SampleCount4Bit | vk.SampleCount8Bit),
+			MaxColorAttachments:              8,
+			NonCoherentAtomSize:              64,
+			OptimalBufferCopyOffsetAlignment: 4,
+			MinUniformBufferOffsetAlignment:  256,
+			MaxUniformBufferRange:            65536,
+		},
+	}
+	copy(d.Properties.DeviceName[:len(d.Properties.DeviceName)-1], name)
+	d.Features = vk.PhysicalDeviceFeatures{
+		SamplerAnisotropy: vk.True,
+		ImageCubeArray:    vk.True,
+		FillModeNonSolid:  vk.True,
+		SampleRateShading: vk.True,
+	}
+
+	all := vk.QueueFlags(vk.QueueGraphicsBit | vk.QueueComputeBit | vk.QueueTransferBit)
+	if deviceType == vk.PhysicalDeviceTypeDiscreteGpu {
+		d.Memory = memoryProperties(
+			[]vk.MemoryHeap{
+				{Size: 8 * gibibyte, Flags: vk.MemoryHeapFlags(vk.MemoryHeapDeviceLocalBit)},
+				{Size: 16 * gibibyte},
+			},
+			[]vk.MemoryType{
+				{PropertyFlags: vk.MemoryPropertyFlags(vk.MemoryPropertyDeviceLocalBit), HeapIndex: 0},
+				{PropertyFlags: vk.MemoryPropertyFlags(vk.MemoryPropertyHostVisibleBit | vk.MemoryPropertyHostCoherentBit), HeapIndex: 1},
+				{PropertyFlags: vk.MemoryPropertyFlags(vk.MemoryPropertyHostVisibleBit | vk.MemoryPropertyHostCoherentBit | vk.MemoryPropertyHostCachedBit), HeapIndex: 1},
+			})
+		d.QueueFamilies = []vk.QueueFamilyProperties{
+			queueFamily(all, 16),
+			queueFamily(vk.QueueFlags(vk.QueueComputeBit|vk.QueueTransferBit), 8),
+			queueFamily(vk.QueueFlags(vk.QueueTransferBit), 2),
+		}
+	} else {
+		d.Memory = memoryProperties(
+			[]vk.MemoryHeap{
+				{Size: 4 * gibibyte, Flags: vk.MemoryHeapFlags(vk.MemoryHeapDeviceLocalBit)},
+			},
+			[]vk.MemoryType{
+				{PropertyFlags: vk.MemoryPropertyFlags(vk.MemoryPropertyDeviceLocalBit), HeapIndex: 0},
+				{PropertyFlags: vk.MemoryPropertyFlags(vk.MemoryPropertyDeviceLocalBit | vk.MemoryPropertyHostVisibleBit | vk.MemoryPropertyHostCoherentBit), HeapIndex: 0},
+				{PropertyFlags: vk.MemoryPropertyFlags(vk.MemoryPropertyDeviceLocalBit | vk.MemoryPropertyHostVisibleBit | vk.MemoryPropertyHostCoherentBit | vk.MemoryPropertyHostCachedBit), HeapIndex: 0},
+			})
+		d.QueueFamilies = []vk.QueueFamilyProperties{queueFamily(all, 1)}
+	}
+	return d
+}
+
+// SetName changes the name reported in the device properties.
+func (d *Device) SetName(name string) {
+	d.Properties.DeviceName = [len(d.Properties.DeviceName)]byte{}
+	copy(d.Properties.DeviceName[:len(d.Properties.DeviceName)-1], name)
+}
+
+func memoryProperties(heaps []vk.MemoryHeap, types []vk.MemoryType) vk.PhysicalDeviceMemoryProperties {
+	var memory vk.PhysicalDeviceMemoryProperties
+	memory.MemoryHeapCount = uint32(copy(memory.MemoryHeaps[:], heaps))
+	memory.MemoryTypeCount = uint32(copy(memory.MemoryTypes[:], types))
+	return memory
+}
+
+func queueFamily(flags vk.QueueFlags, count uint32) vk.QueueFamilyProperties {
+	return vk.QueueFamilyProperties{
+		QueueFlags:                  flags,
+		QueueCount:                  count,
+		TimestampValidBits:          64,
+		MinImageTransferGranularity: vk.Extent3D{Width: 1, Height: 1, Depth: 1},
+	}
+}
+
+func defaultFormats() map[vk.Format]vk.FormatProperties {
+	color := vk.FormatFeatureFlags(vk.FormatFeatureSampledImageBit | vk.FormatFeatureSampledImageFilterLinearBit |
+		vk.FormatFeatureColorAttachmentBit | vk.FormatFeatureColorAttachmentBlendBit |
+		vk.FormatFeatureBlitSrcBit | vk.FormatFeatureBlitDstBit |
+		vk.FormatFeatureTransferSrcBit | vk.FormatFeatureTransferDstBit)
+	depth := vk.FormatFeatureFlags(vk.FormatFeatureSampledImageBit | vk.FormatFeatureDepthStencilAttachmentBit |
+		vk.FormatFeatureBlitSrcBit | vk.FormatFeatureTransferSrcBit | vk.FormatFeatureTransferDstBit)
+	vertex := vk.FormatFeatureFlags(vk.FormatFeatureVertexBufferBit)
+	formats := make(map[vk.Format]vk.FormatProperties)
+	for _, f := range []vk.Format{vk.FormatR8g8b8a8Unorm, vk.FormatR8g8b8a8Srgb, vk.FormatB8g8r8a8Unorm, vk.FormatB8g8r8a8Srgb, vk.FormatR16g16b16a16Sfloat, vk.FormatR32g32b32a32Sfloat} {
+		formats[f] = vk.FormatProperties{LinearTilingFeatures: color, OptimalTilingFeatures: color, BufferFeatures: vertex}
+	}
+	for _, f := range []vk.Format{vk.FormatR32g32Sfloat, vk.FormatR32g32b32Sfloat} {
+		formats[f] = vk.FormatProperties{BufferFeatures: vertex}
+	}
+	for _, f := range []vk.Format{vk.FormatD32Sfloat, vk.FormatD32SfloatS8Uint, vk.FormatD24UnormS8Uint, vk.FormatD16Unorm} {
+		formats[f] = vk.FormatProperties{OptimalTilingFeatures: depth}
+	}
+	return formats
+}
+
+// texelSize is the size in bytes of one texel of format, 4 for the formats
+// it does not know.
+func texelSize(format vk.Format) vk.DeviceSize {
+	switch format {
+	case vk.FormatR8Unorm, vk.FormatR8Srgb:
+		return 1
+	case vk.FormatD16Unorm:
+		return 2
+	case vk.FormatR16g16b16a16Sfloat, vk.FormatR32g32Sfloat, vk.FormatD32SfloatS8Uint:
+		return 8
+	case vk.FormatR32g32b32Sfloat:
+		return 12
+	case vk.FormatR32g32b32a32Sfloat:
+		return 16
+	}
+	return 4
+}
@@ -0,0 +1,158 @@
+package vkfake
+
+import (
+	"unsafe"
+
+	"github.com/goodshailesh/My-Vulkan-Projects/vkutil"
+	vk "github.com/vulkan-go/vulkan"
+)
+
+func (d *Driver) CreateRenderPass(device vk.Device, createInfo *vk.RenderPassCreateInfo) (vk.RenderPass, error) {
+	const command = "vkCreateRenderPass"
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	dev := d.mustLookup(command, "VkDevice", unsafe.Pointer(device)).device
+	if err := d.call(command); err != nil {
+		return vk.NullRenderPass, err
+	}
+	if createInfo.SubpassCount == 0 {
+		return vk.NullRenderPass, invalid(command, "no subpass")
+	}
+	for idx, subpass := range createInfo.PSubpasses[:createInfo.SubpassCount] {
+		references := subpass.PColorAttachments[:subpass.ColorAttachmentCount]
+		if subpass.PDepthStencilAttachment != nil {
+			references = append(references[:len(references):len(references)], *subpass.PDepthStencilAttachment)
+		}
+		for _, reference := range references {
+			if reference.Attachment != vk.AttachmentUnused && reference.Attachment >= createInfo.AttachmentCount {
+				return vk.NullRenderPass, invalid(command, "subpass %v uses attachment %v of %v", idx, reference.Attachment, createInfo.AttachmentCount)
+			}
+		}
+	}
+	handle, o := d.newObject("VkRenderPass", dev)
+	o.attachments = createInfo.AttachmentCount
+	o.subpasses = createInfo.SubpassCount
+	return vk.RenderPass(handle), nil
+}
+
+func (d *Driver) DestroyRenderPass(device vk.Device, renderPass vk.RenderPass) {
+	d.destroy("vkDestroyRenderPass", "VkRenderPass", unsafe.Pointer(renderPass))
+}
+
+func (d *Driver) CreateFramebuffer(device vk.Device, createInfo *vk.FramebufferCreateInfo) (vk.Framebuffer, error) {
+	const command = "vkCreateFramebuffer"
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	dev := d.mustLookup(command, "VkDevice", unsafe.Pointer(device)).device
+	if err := d.call(command); err != nil {
+		return vk.NullFramebuffer, err
+	}
+	renderPass := d.lookup("VkRenderPass", unsafe.Pointer(createInfo.RenderPass))
+	switch {
+	case renderPass == nil:
+		return vk.NullFramebuffer, invalid(command, "unknown or destroyed VkRenderPass")
+	case createInfo.AttachmentCount != renderPass.attachments:
+		return vk.NullFramebuffer, invalid(command, "%v attachments for a render pass with %v", createInfo.AttachmentCount, renderPass.attachments)
+	case createInfo.Width == 0 || createInfo.Height == 0 || createInfo.Layers == 0:
+		return vk.NullFramebuffer, invalid(command, "empty framebuffer %vx%vx%v", createInfo.Width, createInfo.Height, createInfo.Layers)
+	}
+	for idx, view := range createInfo.PAttachments[:createInfo.AttachmentCount] {
+		if d.lookup("VkImageView", unsafe.Pointer(view)) == nil {
+			return vk.NullFramebuffer, invalid(command, "attachment %v: unknown or destroyed VkImageView", idx)
+		}
+	}
+	handle, o := d.newObject("VkFramebuffer", dev)
+	o.attachments = createInfo.AttachmentCount
+	return vk.Framebuffer(handle), nil
+}
+
+func (d *Driver) DestroyFramebuffer(device vk.Device, framebuffer vk.Framebuffer) {
+	d.destroy("vkDestroyFramebuffer", "VkFramebuffer", unsafe.Pointer(framebuffer))
+}
+
+func (d *Driver) CreateShaderModule(device vk.Device, createInfo *vk.ShaderModuleCreateInfo) (vk.ShaderModule, error) {
+	const command = "vkCreateShaderModule"
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	dev := d.mustLookup(command, "VkDevice", unsafe.Pointer(device)).device
+	if err := d.call(command); err != nil {
+		return vk.NullShaderModule, err
+	}
+	switch {
+	case createInfo.CodeSize == 0 || createInfo.CodeSize%4 != 0:
+		return vk.NullShaderModule, invalid(command, "codeSize %v is not a positive multiple of 4", createInfo.CodeSize)
+	case uint(len(createInfo.PCode)*4) < createInfo.CodeSize:
+		return vk.NullShaderModule, invalid(command, "codeSize %v but only %v words of code", createInfo.CodeSize, len(createInfo.PCode))
+	case createInfo.PCode[0] != vkutil.SPIRVMagic:
+		return vk.NullShaderModule, invalid(command, "bad SPIR-V magic number %#08x", createInfo.PCode[0])
+	}
+	handle, _ := d.newObject("VkShaderModule", dev)
+	return vk.ShaderModule(handle), nil
+}
+
+func (d *Driver) DestroyShaderModule(device vk.Device, module vk.ShaderModule) {
+	d.destroy("vkDestroyShaderModule", "VkShaderModule", unsafe.Pointer(module))
+}
+
+func (d *Driver) CreatePipelineLayout(device vk.Device, createInfo *vk.PipelineLayoutCreateInfo) (vk.PipelineLayout, error) {
+	const command = "vkCreatePipelineLayout"
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	dev := d.mustLookup(command, "VkDevice", unsafe.Pointer(device)).device
+	if err := d.call(command); err != nil {
+		return vk.NullPipelineLayout, err
+	}
+	for idx, layout := range createInfo.PSetLayouts[:createInfo.SetLayoutCount] {
+		if d.lookup("VkDescriptorSetLayout", unsafe.Pointer(layout)) == nil {
+			return vk.NullPipelineLayout, invalid(command, "set %v: unknown or destroyed VkDescriptorSetLayout", idx)
+		}
+	}
+	handle, _ := d.newObject("VkPipelineLayout", dev)
+	return vk.PipelineLayout(handle), nil
+}
+
+func (d *Driver) DestroyPipelineLayout(device vk.Device, layout vk.PipelineLayout) {
+	d.destroy("vkDestroyPipelineLayout", "VkPipelineLayout", unsafe.Pointer(layout))
+}
+
+// CreateGraphicsPipelines checks the shader modules, layout and render pass
+// are alive, the modules may be destroyed right after.
+func (d *Driver) CreateGraphicsPipelines(device vk.Device, createInfos []vk.GraphicsPipelineCreateInfo) ([]vk.Pipeline, error) {
+	const command = "vkCreateGraphicsPipelines"
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	dev := d.mustLookup(command, "VkDevice", unsafe.Pointer(device)).device
+	if err := d.call(command); err != nil {
+		return nil, err
+	}
+	for idx, createInfo := range createInfos {
+		if createInfo.StageCount == 0 {
+			return nil, invalid(command, "pipeline %v has no stage", idx)
+		}
+		for _, stage := range createInfo.PStages[:createInfo.StageCount] {
+			if d.lookup("VkShaderModule", unsafe.Pointer(stage.Module)) == nil {
+				return nil, invalid(command, "pipeline %v: unknown or destroyed VkShaderModule", idx)
+			}
+		}
+		if d.lookup("VkPipelineLayout", unsafe.Pointer(createInfo.Layout)) == nil {
+			return nil, invalid(command, "pipeline %v: unknown or destroyed VkPipelineLayout", idx)
+		}
+		renderPass := d.lookup("VkRenderPass", unsafe.Pointer(createInfo.RenderPass))
+		if renderPass == nil {
+			return nil, invalid(command, "pipeline %v: unknown or destroyed VkRenderPass", idx)
+		}
+		if createInfo.Subpass >= renderPass.subpasses {
+			return nil, invalid(command, "pipeline %v: subpass %v of a render pass with %v", idx, createInfo.Subpass, renderPass.subpasses)
+		}
+	}
+	pipelines := make([]vk.Pipeline, len(createInfos))
+	for idx := range pipelines {
+		handle, _ := d.newObject("VkPipeline", dev)
+		pipelines[idx] = vk.Pipeline(handle)
+	}
+	return pipelines, nil
+}
+
+func (d *Driver) DestroyPipeline(device vk.Device, pipeline vk.Pipeline) {
+	d.destroy("vkDestroyPipeline", "VkPipeline", unsafe.Pointer(pipeline))
+}
@@ -0,0 +1,1101 @@
+// Package vkfake is a scriptable vkutil.Driver that needs no GPU, so the
+// instance creation, device selection, queue family, swapchain, memory,
+// sampler, descriptor, pipeline, command buffer and frame loop code of vkutil
+// can be exercised in plain Go tests:
+//
+//	fake := vkfake.New(vkfake.NewDevice("Fake iGPU", vk.PhysicalDeviceTypeIntegratedGpu))
+//	fake.Devices[0].PresentModes = []vk.PresentMode{vk.PresentModeFifo}
+//	defer vkutil.SetDriver(vkutil.SetDriver(fake))
+//
+// Handles are fake pointers and must never reach the real vk package. The
+// commands check their arguments the way the validation layers would and
+// fail with VK_ERROR_VALIDATION_FAILED_EXT, so a test sees misuse as an
+// error instead of a crash. Results injects failures by command name.
+package vkfake
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+	"unsafe"
+
+	"github.com/goodshailesh/My-Vulkan-Projects/vkutil"
+	vk "github.com/vulkan-go/vulkan"
+)
+
+// Driver is the fake. The exported fields may be changed between commands.
+type Driver struct {
+	InstanceLayers     []string
+	InstanceExtensions []string
+	// Devices are returned by vkEnumeratePhysicalDevices, in order.
+	Devices []*Device
+
+	// Results makes a command, named as in C (e.g. "vkCreateSwapchainKHR"),
+	// fail with the given result instead of doing its job.
+	Results map[string]vk.Result
+	// AcquireOrder, when not empty, is the order vkAcquireNextImageKHR hands
+	// out swapchain images in, taken from the front. A scripted image must
+	// not be acquired already.
+	AcquireOrder []uint32
+	// Calls logs the name of every command called, in order.
+	Calls []string
+	// DeviceCreateInfos and SwapchainCreateInfos keep what vkCreateDevice and
+	// vkCreateSwapchainKHR were given, in order.
+	DeviceCreateInfos    []vk.DeviceCreateInfo
+	SwapchainCreateInfos []vk.SwapchainCreateInfo
+
+	mu       sync.Mutex
+	physical map[*Device]unsafe.Pointer
+	objects  map[unsafe.Pointer]*object
+	queues   map[queueKey]unsafe.Pointer
+	serial   int
+}
+
+var _ vkutil.Driver = (*Driver)(nil)
+
+// object is a handle created by the fake.
+type object struct {
+	kind   string // the C type, e.g. "VkBuffer"
+	serial int
+	device *Device
+	parent unsafe.Pointer // owning swapchain of swapchain images
+
+	// VkDevice
+	families map[uint32]uint32 // queue family index to queue count
+	features vk.PhysicalDeviceFeatures
+	// VkDeviceMemory
+	memoryTypeIndex uint32
+	data            []byte
+	mapped          bool
+	// VkBuffer, VkImage, VkDeviceMemory
+	size         vk.DeviceSize
+	requirements vk.MemoryRequirements
+	bound        bool
+	// VkImage
+	levels, layers uint32
+	cube           bool
+	// VkSwapchainKHR, and whether each image is acquired
+	images   []vk.Image
+	acquired []bool
+	// VkCommandPool
+	resetCommandBuffer bool
+	commandBuffers     []unsafe.Pointer
+	// VkCommandBuffer
+	state         commandBufferState
+	oneTimeSubmit bool
+	// VkSemaphore, VkFence
+	signaled bool
+	// VkRenderPass, and the attachments of a VkFramebuffer
+	attachments uint32
+	subpasses   uint32
+	// VkDescriptorSetLayout, and the layout a VkDescriptorSet was allocated with
+	bindings map[uint32]vk.DescriptorSetLayoutBinding
+	// VkDescriptorPool
+	maxSets   uint32
+	capacity  map[vk.DescriptorType]uint32
+	available map[vk.DescriptorType]uint32
+	sets      []unsafe.Pointer
+}
+
+type queueKey struct {
+	device        unsafe.Pointer
+	family, index uint32
+}
+
+// New returns a fake with the given devices, the surface extensions of every
+// platform and the Khronos validation layer.
+func New(devices ...*Device) *Driver {
+	return &Driver{
+		InstanceLayers: []string{"VK_LAYER_KHRONOS_validation"},
+		InstanceExtensions: []string{
+			"VK_KHR_surface", "VK_KHR_xcb_surface", "VK_KHR_xlib_surface", "VK_KHR_wayland_surface",
+			"VK_KHR_win32_surface", "VK_EXT_metal_surface", "VK_MVK_macos_surface",
+			"VK_EXT_debug_utils", "VK_EXT_debug_report", "VK_KHR_get_physical_device_properties2",
+		},
+		Devices: devices,
+		Results: make(map[string]vk.Result),
+	}
+}
+
+// call logs command and returns the error scripted for it in Results. The
+// caller holds d.mu.
+func (d *Driver) call(command string) error {
+	d.Calls = append(d.Calls, command)
+	if result, ok := d.Results[command]; ok {
+		return vkutil.Check(command, result)
+	}
+	return nil
+}
+
+func invalid(command, format string, a ...interface{}) error {
+	return fmt.Errorf("vkfake: %v: %w", fmt.Sprintf(format, a...), &vkutil.ResultError{Call: command, Result: vk.ErrorValidationFailed})
+}
+
+func (d *Driver) newObject(kind string, device *Device) (unsafe.Pointer, *object) {
+	if d.objects == nil {
+		d.objects = make(map[unsafe.Pointer]*object)
+	}
+	d.serial++
+	// A distinct Go allocation gives a distinct, never reused, address
+	handle := unsafe.Pointer(new(uint64))
+	o := &object{kind: kind, serial: d.serial, device: device}
+	d.objects[handle] = o
+	return handle, o
+}
+
+// lookup returns the live object of the given kind behind handle, or nil.
+func (d *Driver) lookup(kind string, handle unsafe.Pointer) *object {
+	o, ok := d.objects[handle]
+	if !ok || o.kind != kind {
+		return nil
+	}
+	return o
+}
+
+// mustLookup is lookup for the commands that cannot return an error, a real
+// driver would crash there.
+func (d *Driver) mustLookup(command, kind string, handle unsafe.Pointer) *object {
+	o := d.lookup(kind, handle)
+	if o == nil {
+		panic(fmt.Sprintf("vkfake: %v called with an unknown or destroyed %v", command, kind))
+	}
+	return o
+}
+
+func (d *Driver) destroy(command, kind string, handle unsafe.Pointer) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.Calls = append(d.Calls, command)
+	if handle == nil {
+		return
+	}
+	o := d.mustLookup(command, kind, handle)
+	for _, image := range o.images {
+		delete(d.objects, unsafe.Pointer(image))
+	}
+	for _, set := range o.sets {
+		delete(d.objects, set)
+	}
+	for _, commandBuffer := range o.commandBuffers {
+		delete(d.objects, commandBuffer)
+	}
+	delete(d.objects, handle)
+}
+
+func (d *Driver) device(command string, physicalDevice vk.PhysicalDevice) *Device {
+	for dev, handle := range d.physical {
+		if handle == unsafe.Pointer(physicalDevice) {
+			return dev
+		}
+	}
+	panic(fmt.Sprintf("vkfake: %v called with a VkPhysicalDevice that was not enumerated", command))
+}
+
+// Live lists the objects not destroyed yet, oldest first, e.g. "VkBuffer #7".
+// Swapchain images are owned by their swapchain and not listed.
+func (d *Driver) Live() []string {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	var live []*object
+	for _, o := range d.objects {
+		if o.parent == nil {
+			live = append(live, o)
+		}
+	}
+	sort.Slice(live, func(i, j int) bool { return live[i].serial < live[j].serial })
+	names := make([]string, 0, len(live))
+	for _, o := range live {
+		names = append(names, fmt.Sprintf("%v #%v", o.kind, o.serial))
+	}
+	return names
+}
+
+// NewSurface returns a surface handle for the Surface fields of vkutil, the
+// devices present to it from their PresentFamilies.
+func (d *Driver) NewSurface() vk.Surface {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	handle, _ := d.newObject("VkSurfaceKHR", nil)
+	return vk.Surface(handle)
+}
+
+func (d *Driver) CreateInstance(createInfo *vk.InstanceCreateInfo) (vk.Instance, error) {
+	const command = "vkCreateInstance"
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if err := d.call(command); err != nil {
+		return nil, err
+	}
+	for _, name := range createInfo.PpEnabledLayerNames[:createInfo.EnabledLayerCount] {
+		if !vkutil.Contains(d.InstanceLayers, strings.TrimRight(name, "\x00")) {
+			return nil, vkutil.Check(command, vk.ErrorLayerNotPresent)
+		}
+	}
+	for _, name := range createInfo.PpEnabledExtensionNames[:createInfo.EnabledExtensionCount] {
+		if !vkutil.Contains(d.InstanceExtensions, strings.TrimRight(name, "\x00")) {
+			return nil, vkutil.Check(command, vk.ErrorExtensionNotPresent)
+		}
+	}
+	handle, _ := d.newObject("VkInstance", nil)
+	return vk.Instance(handle), nil
+}
+
+func (d *Driver) DestroyInstance(instance vk.Instance) {
+	d.destroy("vkDestroyInstance", "VkInstance", unsafe.Pointer(instance))
+}
+
+func (d *Driver) EnumerateInstanceLayerProperties() ([]vk.LayerProperties, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if err := d.call("vkEnumerateInstanceLayerProperties"); err != nil {
+		return nil, err
+	}
+	return layerProperties(d.InstanceLayers), nil
+}
+
+func (d *Driver) EnumerateInstanceExtensionProperties(layerName string) ([]vk.ExtensionProperties, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if err := d.call("vkEnumerateInstanceExtensionProperties"); err != nil {
+		return nil, err
+	}
+	if layerName = strings.TrimRight(layerName, "\x00"); layerName != "" {
+		if !vkutil.Contains(d.InstanceLayers, layerName) {
+			return nil, vkutil.Check("vkEnumerateInstanceExtensionProperties", vk.ErrorLayerNotPresent)
+		}
+		return nil, nil
+	}
+	return extensionProperties(d.InstanceExtensions), nil
+}
+
+func (d *Driver) EnumeratePhysicalDevices(instance vk.Instance) ([]vk.PhysicalDevice, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if err := d.call("vkEnumeratePhysicalDevices"); err != nil {
+		return nil, err
+	}
+	if d.physical == nil {
+		d.physical = make(map[*Device]unsafe.Pointer)
+	}
+	physicalDevices := make([]vk.PhysicalDevice, 0, len(d.Devices))
+	for _, dev := range d.Devices {
+		handle, ok := d.physical[dev]
+		if !ok {
+			handle = unsafe.Pointer(new(uint64))
+			d.physical[dev] = handle
+		}
+		physicalDevices = append(physicalDevices, vk.PhysicalDevice(handle))
+	}
+	return physicalDevices, nil
+}
+
+func (d *Driver) GetPhysicalDeviceProperties(physicalDevice vk.PhysicalDevice) vk.PhysicalDeviceProperties {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.Calls = append(d.Calls, "vkGetPhysicalDeviceProperties")
+	return d.device("vkGetPhysicalDeviceProperties", physicalDevice).Properties
+}
+
+func (d *Driver) GetPhysicalDeviceFeatures(physicalDevice vk.PhysicalDevice) vk.PhysicalDeviceFeatures {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.Calls = append(d.Calls, "vkGetPhysicalDeviceFeatures")
+	return d.device("vkGetPhysicalDeviceFeatures", physicalDevice).Features
+}
+
+func (d *Driver) GetPhysicalDeviceMemoryProperties(physicalDevice vk.PhysicalDevice) vk.PhysicalDeviceMemoryProperties {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.Calls = append(d.Calls, "vkGetPhysicalDeviceMemoryProperties")
+	return d.device("vkGetPhysicalDeviceMemoryProperties", physicalDevice).Memory
+}
+
+func (d *Driver) GetPhysicalDeviceQueueFamilyProperties(physicalDevice vk.PhysicalDevice) []vk.QueueFamilyProperties {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.Calls = append(d.Calls, "vkGetPhysicalDeviceQueueFamilyProperties")
+	families := d.device("vkGetPhysicalDeviceQueueFamilyProperties", physicalDevice).QueueFamilies
+	return append([]vk.QueueFamilyProperties(nil), families...)
+}
+
+func (d *Driver) GetPhysicalDeviceFormatProperties(physicalDevice vk.PhysicalDevice, format vk.Format) vk.FormatProperties {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.Calls = append(d.Calls, "vkGetPhysicalDeviceFormatProperties")
+	return d.device("vkGetPhysicalDeviceFormatProperties", physicalDevice).Formats[format]
+}
+
+func (d *Driver) GetPhysicalDeviceImageFormatProperties(physicalDevice vk.PhysicalDevice, format vk.Format, imageType vk.ImageType, tiling vk.ImageTiling, usage vk.ImageUsageFlags, flags vk.ImageCreateFlags) (vk.ImageFormatProperties, error) {
+	const command = "vkGetPhysicalDeviceImageFormatProperties"
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	dev := d.device(command, physicalDevice)
+	if err := d.call(command); err != nil {
+		return vk.ImageFormatProperties{}, err
+	}
+	features := dev.Formats[format].OptimalTilingFeatures
+	if tiling == vk.ImageTilingLinear {
+		features = dev.Formats[format].LinearTilingFeatures
+	}
+	if features == 0 {
+		return vk.ImageFormatProperties{}, vkutil.Check(command, vk.ErrorFormatNotSupported)
+	}
+	return dev.ImageFormatProperties, nil
+}
+
+func (d *Driver) EnumerateDeviceExtensionProperties(physicalDevice vk.PhysicalDevice) ([]vk.ExtensionProperties, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	dev := d.device("vkEnumerateDeviceExtensionProperties", physicalDevice)
+	if err := d.call("vkEnumerateDeviceExtensionProperties"); err != nil {
+		return nil, err
+	}
+	return extensionProperties(dev.Extensions), nil
+}
+
+func (d *Driver) EnumerateDeviceLayerProperties(physicalDevice vk.PhysicalDevice) ([]vk.LayerProperties, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	dev := d.device("vkEnumerateDeviceLayerProperties", physicalDevice)
+	if err := d.call("vkEnumerateDeviceLayerProperties"); err != nil {
+		return nil, err
+	}
+	return layerProperties(dev.Layers), nil
+}
+
+func (d *Driver) GetPhysicalDeviceUUID(instance vk.Instance, physicalDevice vk.PhysicalDevice) (uuid [vk.UuidSize]byte, ok bool) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.Calls = append(d.Calls, "vkGetPhysicalDeviceProperties2")
+	dev := d.device("vkGetPhysicalDeviceProperties2", physicalDevice)
+	if dev.UUID == nil {
+		return uuid, false
+	}
+	copy(uuid[:], dev.UUID)
+	return uuid, true
+}
+
+func (d *Driver) GetPhysicalDeviceSurfaceSupport(physicalDevice vk.PhysicalDevice, queueFamilyIndex uint32, surface vk.Surface) (bool, error) {
+	const command = "vkGetPhysicalDeviceSurfaceSupportKHR"
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	dev := d.device(command, physicalDevice)
+	if err := d.call(command); err != nil {
+		return false, err
+	}
+	if d.lookup("VkSurfaceKHR", unsafe.Pointer(surface)) == nil {
+		return false, invalid(command, "unknown VkSurfaceKHR")
+	}
+	if queueFamilyIndex >= uint32(len(dev.QueueFamilies)) {
+		return false, invalid(command, "queue family %v out of range", queueFamilyIndex)
+	}
+	for _, family := range dev.PresentFamilies {
+		if family == queueFamilyIndex {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func (d *Driver) GetPhysicalDeviceSurfaceCapabilities(physicalDevice vk.PhysicalDevice, surface vk.Surface) (vk.SurfaceCapabilities, error) {
+	const command = "vkGetPhysicalDeviceSurfaceCapabilitiesKHR"
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	dev := d.device(command, physicalDevice)
+	if err := d.call(command); err != nil {
+		return vk.SurfaceCapabilities{}, err
+	}
+	return dev.SurfaceCapabilities, nil
+}
+
+func (d *Driver) GetPhysicalDeviceSurfaceFormats(physicalDevice vk.PhysicalDevice, surface vk.Surface) ([]vk.SurfaceFormat, error) {
+	const command = "vkGetPhysicalDeviceSurfaceFormatsKHR"
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	dev := d.device(command, physicalDevice)
+	if err := d.call(command); err != nil {
+		return nil, err
+	}
+	return append([]vk.SurfaceFormat(nil), dev.SurfaceFormats...), nil
+}
+
+func (d *Driver) GetPhysicalDeviceSurfacePresentModes(physicalDevice vk.PhysicalDevice, surface vk.Surface) ([]vk.PresentMode, error) {
+	const command = "vkGetPhysicalDeviceSurfacePresentModesKHR"
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	dev := d.device(command, physicalDevice)
+	if err := d.call(command); err != nil {
+		return nil, err
+	}
+	return append([]vk.PresentMode(nil), dev.PresentModes...), nil
+}
+
+func (d *Driver) CreateDevice(physicalDevice vk.PhysicalDevice, createInfo *vk.DeviceCreateInfo) (vk.Device, error) {
+	const command = "vkCreateDevice"
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	dev := d.device(command, physicalDevice)
+	d.DeviceCreateInfos = append(d.DeviceCreateInfos, *createInfo)
+	if err := d.call(command); err != nil {
+		return nil, err
+	}
+	families := make(map[uint32]uint32)
+	for _, q := range createInfo.PQueueCreateInfos[:createInfo.QueueCreateInfoCount] {
+		if q.QueueFamilyIndex >= uint32(len(dev.QueueFamilies)) {
+			return nil, invalid(command, "queue family %v out of range", q.QueueFamilyIndex)
+		}
+		if _, dup := families[q.QueueFamilyIndex]; dup {
+			return nil, invalid(command, "queue family %v requested twice", q.QueueFamilyIndex)
+		}
+		if q.QueueCount == 0 || q.QueueCount > dev.QueueFamilies[q.QueueFamilyIndex].QueueCount {
+			return nil, invalid(command, "%v queues requested from family %v", q.QueueCount, q.QueueFamilyIndex)
+		}
+		families[q.QueueFamilyIndex] = q.QueueCount
+	}
+	for _, name := range createInfo.PpEnabledExtensionNames[:createInfo.EnabledExtensionCount] {
+		if !vkutil.Contains(dev.Extensions, strings.TrimRight(name, "\x00")) {
+			return nil, vkutil.Check(command, vk.ErrorExtensionNotPresent)
+		}
+	}
+	if len(createInfo.PEnabledFeatures) > 0 && !featuresSupported(createInfo.PEnabledFeatures[0], dev.Features) {
+		return nil, vkutil.Check(command, vk.ErrorFeatureNotPresent)
+	}
+	handle, o := d.newObject("VkDevice", dev)
+	o.families = families
+	if len(createInfo.PEnabledFeatures) > 0 {
+		o.features = createInfo.PEnabledFeatures[0]
+	}
+	return vk.Device(handle), nil
+}
+
+func (d *Driver) DestroyDevice(device vk.Device) {
+	d.destroy("vkDestroyDevice", "VkDevice", unsafe.Pointer(device))
+}
+
+func (d *Driver) GetDeviceQueue(device vk.Device, queueFamilyIndex, queueIndex uint32) vk.Queue {
+	const command = "vkGetDeviceQueue"
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.Calls = append(d.Calls, command)
+	o := d.mustLookup(command, "VkDevice", unsafe.Pointer(device))
+	if count, ok := o.families[queueFamilyIndex]; !ok || queueIndex >= count {
+		panic(fmt.Sprintf("vkfake: %v: queue %v of family %v was not requested at device creation", command, queueIndex, queueFamilyIndex))
+	}
+	if d.queues == nil {
+		d.queues = make(map[queueKey]unsafe.Pointer)
+	}
+	key := queueKey{unsafe.Pointer(device), queueFamilyIndex, queueIndex}
+	if _, ok := d.queues[key]; !ok {
+		d.queues[key] = unsafe.Pointer(new(uint64))
+	}
+	return vk.Queue(d.queues[key])
+}
+
+func (d *Driver) DeviceWaitIdle(device vk.Device) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.mustLookup("vkDeviceWaitIdle", "VkDevice", unsafe.Pointer(device))
+	return d.call("vkDeviceWaitIdle")
+}
+
+func (d *Driver) CreateSwapchain(device vk.Device, createInfo *vk.SwapchainCreateInfo) (vk.Swapchain, error) {
+	const command = "vkCreateSwapchainKHR"
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	dev := d.mustLookup(command, "VkDevice", unsafe.Pointer(device)).device
+	d.SwapchainCreateInfos = append(d.SwapchainCreateInfos, *createInfo)
+	if err := d.call(command); err != nil {
+		return vk.NullSwapchain, err
+	}
+	caps := dev.SurfaceCapabilities
+	switch {
+	case d.lookup("VkSurfaceKHR", unsafe.Pointer(createInfo.Surface)) == nil:
+		return vk.NullSwapchain, invalid(command, "unknown VkSurfaceKHR")
+	case createInfo.MinImageCount < caps.MinImageCount || caps.MaxImageCount != 0 && createInfo.MinImageCount > caps.MaxImageCount:
+		return vk.NullSwapchain, invalid(command, "minImageCount %v outside [%v, %v]", createInfo.MinImageCount, caps.MinImageCount, caps.MaxImageCount)
+	case !extentWithin(createInfo.ImageExtent, caps.MinImageExtent, caps.MaxImageExtent):
+		return vk.NullSwapchain, invalid(command, "imageExtent %vx%v outside the surface limits", createInfo.ImageExtent.Width, createInfo.ImageExtent.Height)
+	case createInfo.ImageArrayLayers == 0 || createInfo.ImageArrayLayers > caps.MaxImageArrayLayers:
+		return vk.NullSwapchain, invalid(command, "imageArrayLayers %v", createInfo.ImageArrayLayers)
+	case createInfo.ImageUsage&^caps.SupportedUsageFlags != 0:
+		return vk.NullSwapchain, invalid(command, "imageUsage %#x not supported", createInfo.ImageUsage)
+	case !hasSurfaceFormat(dev.SurfaceFormats, createInfo.ImageFormat, createInfo.ImageColorSpace):
+		return vk.NullSwapchain, invalid(command, "imageFormat %v with color space %v not supported", createInfo.ImageFormat, createInfo.ImageColorSpace)
+	case !hasPresentMode(dev.PresentModes, createInfo.PresentMode):
+		return vk.NullSwapchain, invalid(command, "presentMode %v not supported", createInfo.PresentMode)
+	case createInfo.OldSwapchain != vk.NullSwapchain && d.lookup("VkSwapchainKHR", unsafe.Pointer(createInfo.OldSwapchain)) == nil:
+		return vk.NullSwapchain, invalid(command, "oldSwapchain was destroyed")
+	}
+	handle, o := d.newObject("VkSwapchainKHR", dev)
+	for idx := uint32(0); idx < createInfo.MinImageCount; idx++ {
+		image, io := d.newObject("VkImage", dev)
+		io.parent = handle
+		io.bound = true
+		io.levels, io.layers = 1, createInfo.ImageArrayLayers
+		o.images = append(o.images, vk.Image(image))
+	}
+	o.acquired = make([]bool, len(o.images))
+	return vk.Swapchain(handle), nil
+}
+
+func (d *Driver) DestroySwapchain(device vk.Device, swapchain vk.Swapchain) {
+	d.destroy("vkDestroySwapchainKHR", "VkSwapchainKHR", unsafe.Pointer(swapchain))
+}
+
+func (d *Driver) GetSwapchainImages(device vk.Device, swapchain vk.Swapchain) ([]vk.Image, error) {
+	const command = "vkGetSwapchainImagesKHR"
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	o := d.mustLookup(command, "VkSwapchainKHR", unsafe.Pointer(swapchain))
+	if err := d.call(command); err != nil {
+		return nil, err
+	}
+	return append([]vk.Image(nil), o.images...), nil
+}
+
+func (d *Driver) AllocateMemory(device vk.Device, allocateInfo *vk.MemoryAllocateInfo) (vk.DeviceMemory, error) {
+	const command = "vkAllocateMemory"
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	dev := d.mustLookup(command, "VkDevice", unsafe.Pointer(device)).device
+	if err := d.call(command); err != nil {
+		return vk.NullDeviceMemory, err
+	}
+	if allocateInfo.MemoryTypeIndex >= dev.Memory.MemoryTypeCount {
+		return vk.NullDeviceMemory, invalid(command, "memoryTypeIndex %v out of range", allocateInfo.MemoryTypeIndex)
+	}
+	if allocateInfo.AllocationSize == 0 {
+		return vk.NullDeviceMemory, invalid(command, "allocationSize is 0")
+	}
+	heap := dev.Memory.MemoryTypes[allocateInfo.MemoryTypeIndex].HeapIndex
+	if d.heapUsage(dev, heap)+allocateInfo.AllocationSize > dev.Memory.MemoryHeaps[heap].Size {
+		return vk.NullDeviceMemory, vkutil.Check(command, vk.ErrorOutOfDeviceMemory)
+	}
+	handle, o := d.newObject("VkDeviceMemory", dev)
+	o.memoryTypeIndex = allocateInfo.MemoryTypeIndex
+	o.size = allocateInfo.AllocationSize
+	return vk.DeviceMemory(handle), nil
+}
+
+// heapUsage returns the bytes allocated from heap of dev.
+func (d *Driver) heapUsage(dev *Device, heap uint32) vk.DeviceSize {
+	var used vk.DeviceSize
+	for _, o := range d.objects {
+		if o.kind == "VkDeviceMemory" && o.device == dev && dev.Memory.MemoryTypes[o.memoryTypeIndex].HeapIndex == heap {
+			used += o.size
+		}
+	}
+	return used
+}
+
+// HeapUsage returns the bytes allocated from every heap of dev.
+func (d *Driver) HeapUsage(dev *Device) []vk.DeviceSize {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	usage := make([]vk.DeviceSize, dev.Memory.MemoryHeapCount)
+	for heap := range usage {
+		usage[heap] = d.heapUsage(dev, uint32(heap))
+	}
+	return usage
+}
+
+func (d *Driver) FreeMemory(device vk.Device, memory vk.DeviceMemory) {
+	d.destroy("vkFreeMemory", "VkDeviceMemory", unsafe.Pointer(memory))
+}
+
+// MapMemory maps a Go byte slice, allocated on the first map, so tests can
+// read back what was written.
+func (d *Driver) MapMemory(device vk.Device, memory vk.DeviceMemory, offset, size vk.DeviceSize) (unsafe.Pointer, error) {
+	const command = "vkMapMemory"
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	o := d.mustLookup(command, "VkDeviceMemory", unsafe.Pointer(memory))
+	if err := d.call(command); err != nil {
+		return nil, err
+	}
+	if o.device.Memory.MemoryTypes[o.memoryTypeIndex].PropertyFlags&vk.MemoryPropertyFlags(vk.MemoryPropertyHostVisibleBit) == 0 {
+		return nil, invalid(command, "memory type %v is not host visible", o.memoryTypeIndex)
+	}
+	if o.mapped {
+		return nil, invalid(command, "memory is already mapped")
+	}
+	if size == vk.DeviceSize(vk.WholeSize) {
+		size = o.size - offset
+	}
+	if offset >= o.size || offset+size > o.size {
+		return nil, invalid(command, "range [%v, %v) outside the %v bytes allocation", offset, offset+size, o.size)
+	}
+	if o.data == nil {
+		o.data = make([]byte, o.size)
+	}
+	o.mapped = true
+	return unsafe.Pointer(&o.data[offset]), nil
+}
+
+func (d *Driver) UnmapMemory(device vk.Device, memory vk.DeviceMemory) {
+	const command = "vkUnmapMemory"
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.Calls = append(d.Calls, command)
+	d.mustLookup(command, "VkDeviceMemory", unsafe.Pointer(memory)).mapped = false
+}
+
+// MemoryContents returns the bytes of memory as last written through a
+// mapping, nil when it was never mapped.
+func (d *Driver) MemoryContents(memory vk.DeviceMemory) []byte {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return d.mustLookup("MemoryContents", "VkDeviceMemory", unsafe.Pointer(memory)).data
+}
+
+func (d *Driver) CreateBuffer(device vk.Device, createInfo *vk.BufferCreateInfo) (vk.Buffer, error) {
+	const command = "vkCreateBuffer"
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	dev := d.mustLookup(command, "VkDevice", unsafe.Pointer(device)).device
+	if err := d.call(command); err != nil {
+		return vk.NullBuffer, err
+	}
+	if createInfo.Size == 0 {
+		return vk.NullBuffer, invalid(command, "size is 0")
+	}
+	if createInfo.Usage == 0 {
+		return vk.NullBuffer, invalid(command, "usage is 0")
+	}
+	handle, o := d.newObject("VkBuffer", dev)
+	o.size = createInfo.Size
+	o.requirements = vk.MemoryRequirements{
+		Size:           alignUp(createInfo.Size, dev.BufferAlignment),
+		Alignment:      dev.BufferAlignment,
+		MemoryTypeBits: allTypes(dev),
+	}
+	return vk.Buffer(handle), nil
+}
+
+func (d *Driver) DestroyBuffer(device vk.Device, buffer vk.Buffer) {
+	d.destroy("vkDestroyBuffer", "VkBuffer", unsafe.Pointer(buffer))
+}
+
+func (d *Driver) GetBufferMemoryRequirements(device vk.Device, buffer vk.Buffer) vk.MemoryRequirements {
+	const command = "vkGetBufferMemoryRequirements"
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.Calls = append(d.Calls, command)
+	return d.mustLookup(command, "VkBuffer", unsafe.Pointer(buffer)).requirements
+}
+
+func (d *Driver) BindBufferMemory(device vk.Device, buffer vk.Buffer, memory vk.DeviceMemory, offset vk.DeviceSize) error {
+	const command = "vkBindBufferMemory"
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	o := d.mustLookup(command, "VkBuffer", unsafe.Pointer(buffer))
+	if err := d.call(command); err != nil {
+		return err
+	}
+	return d.bind(command, o, memory, offset)
+}
+
+func (d *Driver) bind(command string, o *object, memory vk.DeviceMemory, offset vk.DeviceSize) error {
+	m := d.lookup("VkDeviceMemory", unsafe.Pointer(memory))
+	switch {
+	case m == nil:
+		return invalid(command, "unknown or freed VkDeviceMemory")
+	case o.bound:
+		return invalid(command, "%v #%v already has memory bound", o.kind, o.serial)
+	case o.requirements.MemoryTypeBits&(1<<m.memoryTypeIndex) == 0:
+		return invalid(command, "memory type %v not allowed by the requirements", m.memoryTypeIndex)
+	case o.requirements.Alignment > 0 && offset%o.requirements.Alignment != 0:
+		return invalid(command, "offset %v not aligned to %v", offset, o.requirements.Alignment)
+	case offset+o.requirements.Size > m.size:
+		return invalid(command, "%v bytes at offset %v do not fit the %v bytes allocation", o.requirements.Size, offset, m.size)
+	}
+	o.bound = true
+	return nil
+}
+
+func (d *Driver) CreateImage(device vk.Device, createInfo *vk.ImageCreateInfo) (vk.Image, error) {
+	const command = "vkCreateImage"
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	dev := d.mustLookup(command, "VkDevice", unsafe.Pointer(device)).device
+	if err := d.call(command); err != nil {
+		return vk.NullImage, err
+	}
+	limits := dev.ImageFormatProperties
+	extent := createInfo.Extent
+	cube := createInfo.Flags&vk.ImageCreateFlags(vk.ImageCreateCubeCompatibleBit) != 0
+	switch {
+	case dev.Formats[createInfo.Format] == vk.FormatProperties{}:
+		return vk.NullImage, invalid(command, "format %v not supported", createInfo.Format)
+	case extent.Width == 0 || extent.Height == 0 || extent.Depth == 0 ||
+		extent.Width > limits.MaxExtent.Width || extent.Height > limits.MaxExtent.Height || extent.Depth > limits.MaxExtent.Depth && createInfo.ImageType == vk.ImageType3d:
+		return vk.NullImage, invalid(command, "extent %vx%vx%v", extent.Width, extent.Height, extent.Depth)
+	case createInfo.MipLevels == 0 || createInfo.MipLevels > limits.MaxMipLevels:
+		return vk.NullImage, invalid(command, "mipLevels %v", createInfo.MipLevels)
+	case createInfo.ArrayLayers == 0 || createInfo.ArrayLayers > limits.MaxArrayLayers:
+		return vk.NullImage, invalid(command, "arrayLayers %v", createInfo.ArrayLayers)
+	case vk.SampleCountFlags(createInfo.Samples)&limits.SampleCounts == 0:
+		return vk.NullImage, invalid(command, "samples %v", createInfo.Samples)
+	case cube && (extent.Width != extent.Height || createInfo.ArrayLayers < 6):
+		return vk.NullImage, invalid(command, "cube compatible image of %vx%v with %v layers", extent.Width, extent.Height, createInfo.ArrayLayers)
+	}
+	var size vk.DeviceSize
+	for level := uint32(0); level < createInfo.MipLevels; level++ {
+		size += vk.DeviceSize(mipExtent(extent.Width, level)) * vk.DeviceSize(mipExtent(extent.Height, level)) * vk.DeviceSize(mipExtent(extent.Depth, level))
+	}
+	size *= vk.DeviceSize(createInfo.ArrayLayers) * vk.DeviceSize(createInfo.Samples) * texelSize(createInfo.Format)
+	handle, o := d.newObject("VkImage", dev)
+	o.size = size
+	o.levels, o.layers, o.cube = createInfo.MipLevels, createInfo.ArrayLayers, cube
+	o.requirements = vk.MemoryRequirements{
+		Size:           alignUp(size, dev.ImageAlignment),
+		Alignment:      dev.ImageAlignment,
+		MemoryTypeBits: allTypes(dev),
+	}
+	return vk.Image(handle), nil
+}
+
+func (d *Driver) DestroyImage(device vk.Device, image vk.Image) {
+	d.mu.Lock()
+	if o := d.lookup("VkImage", unsafe.Pointer(image)); o != nil && o.parent != nil {
+		d.mu.Unlock()
+		panic("vkfake: vkDestroyImage called on a swapchain image")
+	}
+	d.mu.Unlock()
+	d.destroy("vkDestroyImage", "VkImage", unsafe.Pointer(image))
+}
+
+func (d *Driver) GetImageMemoryRequirements(device vk.Device, image vk.Image) vk.MemoryRequirements {
+	const command = "vkGetImageMemoryRequirements"
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.Calls = append(d.Calls, command)
+	return d.mustLookup(command, "VkImage", unsafe.Pointer(image)).requirements
+}
+
+func (d *Driver) BindImageMemory(device vk.Device, image vk.Image, memory vk.DeviceMemory, offset vk.DeviceSize) error {
+	const command = "vkBindImageMemory"
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	o := d.mustLookup(command, "VkImage", unsafe.Pointer(image))
+	if err := d.call(command); err != nil {
+		return err
+	}
+	return d.bind(command, o, memory, offset)
+}
+
+func (d *Driver) CreateImageView(device vk.Device, createInfo *vk.ImageViewCreateInfo) (vk.ImageView, error) {
+	const command = "vkCreateImageView"
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	owner := d.mustLookup(command, "VkDevice", unsafe.Pointer(device))
+	dev := owner.device
+	if err := d.call(command); err != nil {
+		return vk.NullImageView, err
+	}
+	image := d.lookup("VkImage", unsafe.Pointer(createInfo.Image))
+	if image == nil {
+		return vk.NullImageView, invalid(command, "unknown or destroyed VkImage")
+	}
+	if !image.bound {
+		return vk.NullImageView, invalid(command, "VkImage #%v has no memory bound", image.serial)
+	}
+	subresource := createInfo.SubresourceRange
+	levels, layers := subresource.LevelCount, subresource.LayerCount
+	if levels == vk.RemainingMipLevels && subresource.BaseMipLevel < image.levels {
+		levels = image.levels - subresource.BaseMipLevel
+	}
+	if layers == vk.RemainingArrayLayers && subresource.BaseArrayLayer < image.layers {
+		layers = image.layers - subresource.BaseArrayLayer
+	}
+	switch {
+	case levels == 0 || subresource.BaseMipLevel+levels > image.levels:
+		return vk.NullImageView, invalid(command, "mip levels %v+%v of an image of %v", subresource.BaseMipLevel, levels, image.levels)
+	case layers == 0 || subresource.BaseArrayLayer+layers > image.layers:
+		return vk.NullImageView, invalid(command, "array layers %v+%v of an image of %v", subresource.BaseArrayLayer, layers, image.layers)
+	case (createInfo.ViewType == vk.ImageViewType1d || createInfo.ViewType == vk.ImageViewType2d) && layers != 1:
+		return vk.NullImageView, invalid(command, "%v layers in a non-array view", layers)
+	case (createInfo.ViewType == vk.ImageViewTypeCube || createInfo.ViewType == vk.ImageViewTypeCubeArray) && !image.cube:
+		return vk.NullImageView, invalid(command, "cube view of VkImage #%v, which is not cube compatible", image.serial)
+	case createInfo.ViewType == vk.ImageViewTypeCube && layers != 6:
+		return vk.NullImageView, invalid(command, "cube view of %v layers", layers)
+	case createInfo.ViewType == vk.ImageViewTypeCubeArray && layers%6 != 0:
+		return vk.NullImageView, invalid(command, "cube array view of %v layers", layers)
+	case createInfo.ViewType == vk.ImageViewTypeCubeArray && owner.features.ImageCubeArray != vk.True:
+		return vk.NullImageView, invalid(command, "cube array view without the imageCubeArray feature")
+	}
+	handle, _ := d.newObject("VkImageView", dev)
+	return vk.ImageView(handle), nil
+}
+
+func (d *Driver) DestroyImageView(device vk.Device, imageView vk.ImageView) {
+	d.destroy("vkDestroyImageView", "VkImageView", unsafe.Pointer(imageView))
+}
+
+func (d *Driver) CreateSampler(device vk.Device, createInfo *vk.SamplerCreateInfo) (vk.Sampler, error) {
+	const command = "vkCreateSampler"
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	owner := d.mustLookup(command, "VkDevice", unsafe.Pointer(device))
+	dev := owner.device
+	if err := d.call(command); err != nil {
+		return vk.NullSampler, err
+	}
+	switch {
+	case createInfo.MinLod > createInfo.MaxLod:
+		return vk.NullSampler, invalid(command, "minLod %v above maxLod %v", createInfo.MinLod, createInfo.MaxLod)
+	case createInfo.AnisotropyEnable == vk.True && owner.features.SamplerAnisotropy != vk.True:
+		return vk.NullSampler, invalid(command, "anisotropyEnable without the samplerAnisotropy feature")
+	case createInfo.AnisotropyEnable == vk.True && (createInfo.MaxAnisotropy < 1 || createInfo.MaxAnisotropy > dev.Properties.Limits.MaxSamplerAnisotropy):
+		return vk.NullSampler, invalid(command, "maxAnisotropy %v", createInfo.MaxAnisotropy)
+	}
+	var live uint32
+	for _, o := range d.objects {
+		if o.kind == "VkSampler" && o.device == dev {
+			live++
+		}
+	}
+	if live >= dev.Properties.Limits.MaxSamplerAllocationCount {
+		return vk.NullSampler, vkutil.Check(command, vk.ErrorTooManyObjects)
+	}
+	handle, _ := d.newObject("VkSampler", dev)
+	return vk.Sampler(handle), nil
+}
+
+func (d *Driver) DestroySampler(device vk.Device, sampler vk.Sampler) {
+	d.destroy("vkDestroySampler", "VkSampler", unsafe.Pointer(sampler))
+}
+
+func (d *Driver) CreateDescriptorSetLayout(device vk.Device, createInfo *vk.DescriptorSetLayoutCreateInfo) (vk.DescriptorSetLayout, error) {
+	const command = "vkCreateDescriptorSetLayout"
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	dev := d.mustLookup(command, "VkDevice", unsafe.Pointer(device)).device
+	if err := d.call(command); err != nil {
+		return vk.NullDescriptorSetLayout, err
+	}
+	bindings := make(map[uint32]vk.DescriptorSetLayoutBinding)
+	for _, binding := range createInfo.PBindings[:createInfo.BindingCount] {
+		if _, dup := bindings[binding.Binding]; dup {
+			return vk.NullDescriptorSetLayout, invalid(command, "binding %v declared twice", binding.Binding)
+		}
+		bindings[binding.Binding] = binding
+	}
+	handle, o := d.newObject("VkDescriptorSetLayout", dev)
+	o.bindings = bindings
+	return vk.DescriptorSetLayout(handle), nil
+}
+
+func (d *Driver) DestroyDescriptorSetLayout(device vk.Device, layout vk.DescriptorSetLayout) {
+	d.destroy("vkDestroyDescriptorSetLayout", "VkDescriptorSetLayout", unsafe.Pointer(layout))
+}
+
+func (d *Driver) CreateDescriptorPool(device vk.Device, createInfo *vk.DescriptorPoolCreateInfo) (vk.DescriptorPool, error) {
+	const command = "vkCreateDescriptorPool"
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	dev := d.mustLookup(command, "VkDevice", unsafe.Pointer(device)).device
+	if err := d.call(command); err != nil {
+		return vk.NullDescriptorPool, err
+	}
+	if createInfo.MaxSets == 0 {
+		return vk.NullDescriptorPool, invalid(command, "maxSets is 0")
+	}
+	if createInfo.PoolSizeCount == 0 {
+		return vk.NullDescriptorPool, invalid(command, "poolSizeCount is 0")
+	}
+	capacity := make(map[vk.DescriptorType]uint32)
+	for _, size := range createInfo.PPoolSizes[:createInfo.PoolSizeCount] {
+		if size.DescriptorCount == 0 {
+			return vk.NullDescriptorPool, invalid(command, "descriptorCount of type %v is 0", size.Type)
+		}
+		capacity[size.Type] += size.DescriptorCount
+	}
+	handle, o := d.newObject("VkDescriptorPool", dev)
+	o.maxSets = createInfo.MaxSets
+	o.capacity = capacity
+	o.available = make(map[vk.DescriptorType]uint32)
+	for descriptorType, count := range capacity {
+		o.available[descriptorType] = count
+	}
+	return vk.DescriptorPool(handle), nil
+}
+
+func (d *Driver) DestroyDescriptorPool(device vk.Device, pool vk.DescriptorPool) {
+	d.destroy("vkDestroyDescriptorPool", "VkDescriptorPool", unsafe.Pointer(pool))
+}
+
+// ResetDescriptorPool frees the sets of pool, which then has its whole capacity again.
+func (d *Driver) ResetDescriptorPool(device vk.Device, pool vk.DescriptorPool) error {
+	const command = "vkResetDescriptorPool"
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	o := d.mustLookup(command, "VkDescriptorPool", unsafe.Pointer(pool))
+	if err := d.call(command); err != nil {
+		return err
+	}
+	for _, set := range o.sets {
+		delete(d.objects, set)
+	}
+	o.sets = nil
+	for descriptorType, count := range o.capacity {
+		o.available[descriptorType] = count
+	}
+	return nil
+}
+
+// AllocateDescriptorSets fails with VK_ERROR_OUT_OF_POOL_MEMORY when the
+// pool has not enough sets or descriptors left, like drivers implementing
+// VK_KHR_maintenance1 do.
+func (d *Driver) AllocateDescriptorSets(device vk.Device, allocateInfo *vk.DescriptorSetAllocateInfo) ([]vk.DescriptorSet, error) {
+	const command = "vkAllocateDescriptorSets"
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	dev := d.mustLookup(command, "VkDevice", unsafe.Pointer(device)).device
+	if err := d.call(command); err != nil {
+		return nil, err
+	}
+	pool := d.lookup("VkDescriptorPool", unsafe.Pointer(allocateInfo.DescriptorPool))
+	if pool == nil {
+		return nil, invalid(command, "unknown or destroyed VkDescriptorPool")
+	}
+	layouts := make([]*object, allocateInfo.DescriptorSetCount)
+	needed := make(map[vk.DescriptorType]uint32)
+	for idx, layout := range allocateInfo.PSetLayouts[:allocateInfo.DescriptorSetCount] {
+		if layouts[idx] = d.lookup("VkDescriptorSetLayout", unsafe.Pointer(layout)); layouts[idx] == nil {
+			return nil, invalid(command, "unknown or destroyed VkDescriptorSetLayout")
+		}
+		for _, binding := range layouts[idx].bindings {
+			needed[binding.DescriptorType] += binding.DescriptorCount
+		}
+	}
+	if uint32(len(pool.sets))+allocateInfo.DescriptorSetCount > pool.maxSets {
+		return nil, vkutil.Check(command, vk.ErrorOutOfPoolMemory)
+	}
+	for descriptorType, count := range needed {
+		if count > pool.available[descriptorType] {
+			return nil, vkutil.Check(command, vk.ErrorOutOfPoolMemory)
+		}
+	}
+	for descriptorType, count := range needed {
+		pool.available[descriptorType] -= count
+	}
+	sets := make([]vk.DescriptorSet, 0, len(layouts))
+	for _, layout := range layouts {
+		handle, o := d.newObject("VkDescriptorSet", dev)
+		o.parent = unsafe.Pointer(allocateInfo.DescriptorPool)
+		o.bindings = layout.bindings
+		pool.sets = append(pool.sets, handle)
+		sets = append(sets, vk.DescriptorSet(handle))
+	}
+	return sets, nil
+}
+
+// UpdateDescriptorSets panics when a write does not match the layout of its
+// set or names a destroyed buffer or image view.
+func (d *Driver) UpdateDescriptorSets(device vk.Device, writes []vk.WriteDescriptorSet) {
+	const command = "vkUpdateDescriptorSets"
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.Calls = append(d.Calls, command)
+	d.mustLookup(command, "VkDevice", unsafe.Pointer(device))
+	for _, write := range writes {
+		set := d.mustLookup(command, "VkDescriptorSet", unsafe.Pointer(write.DstSet))
+		binding, ok := set.bindings[write.DstBinding]
+		switch {
+		case !ok:
+			panic(fmt.Sprintf("vkfake: %v: the set has no binding %v", command, write.DstBinding))
+		case binding.DescriptorType != write.DescriptorType:
+			panic(fmt.Sprintf("vkfake: %v: binding %v is of type %v, not %v", command, write.DstBinding, binding.DescriptorType, write.DescriptorType))
+		case write.DstArrayElement+write.DescriptorCount > binding.DescriptorCount:
+			panic(fmt.Sprintf("vkfake: %v: elements %v to %v of binding %v of %v", command, write.DstArrayElement, write.DstArrayElement+write.DescriptorCount, write.DstBinding, binding.DescriptorCount))
+		}
+		for _, info := range write.PBufferInfo {
+			d.mustLookup(command, "VkBuffer", unsafe.Pointer(info.Buffer))
+		}
+		for _, info := range write.PImageInfo {
+			if info.ImageView != vk.NullImageView {
+				d.mustLookup(command, "VkImageView", unsafe.Pointer(info.ImageView))
+			}
+		}
+	}
+}
+
+func layerProperties(names []string) []vk.LayerProperties {
+	properties := make([]vk.LayerProperties, len(names))
+	for idx, name := range names {
+		copy(properties[idx].LayerName[:len(properties[idx].LayerName)-1], name)
+		properties[idx].SpecVersion = vk.MakeVersion(1, 0, 0)
+		properties[idx].ImplementationVersion = 1
+	}
+	return properties
+}
+
+func extensionProperties(names []string) []vk.ExtensionProperties {
+	properties := make([]vk.ExtensionProperties, len(names))
+	for idx, name := range names {
+		copy(properties[idx].ExtensionName[:len(properties[idx].ExtensionName)-1], name)
+		properties[idx].SpecVersion = 1
+	}
+	return properties
+}
+
+// featuresSupported reports whether every feature set in enabled is set in supported.
+func featuresSupported(enabled, supported vk.PhysicalDeviceFeatures) bool {
+	e := reflect.ValueOf(enabled)
+	s := reflect.ValueOf(supported)
+	bool32 := reflect.TypeOf(vk.Bool32(0))
+	for i := 0; i < e.NumField(); i++ {
+		field := e.Type().Field(i)
+		if field.PkgPath != "" || field.Type != bool32 {
+			continue
+		}
+		if e.Field(i).Uint() == uint64(vk.True) && s.Field(i).Uint() != uint64(vk.True) {
+			return false
+		}
+	}
+	return true
+}
+
+func extentWithin(extent, min, max vk.Extent2D) bool {
+	return extent.Width >= min.Width && extent.Height >= min.Height &&
+		extent.Width <= max.Width && extent.Height <= max.Height
+}
+
+func hasSurfaceFormat(formats []vk.SurfaceFormat, format vk.Format, colorSpace vk.ColorSpace) bool {
+	for _, f := range formats {
+		if f.Format == format && f.ColorSpace == colorSpace {
+			return true
+		}
+	}
+	return false
+}
+
+func hasPresentMode(modes []vk.PresentMode, mode vk.PresentMode) bool {
+	for _, m := range modes {
+		if m == mode {
+			return true
+		}
+	}
+	return false
+}
+
+func allTypes(dev *Device) uint32 {
+	return uint32(1)<<dev.Memory.MemoryTypeCount - 1
+}
+
+func alignUp(value, alignment vk.DeviceSize) vk.DeviceSize {
+	if alignment <= 1 {
+		return value
+	}
+	return (value + alignment - 1) / alignment * alignment
+}
+
+func mipExtent(size, level uint32) uint32 {
+	if size>>level == 0 {
+		return 1
+	}
+	return size >> level
+}
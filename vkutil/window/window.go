@@ -0,0 +1,116 @@
+// Package window holds the GLFW side of the vkutil helpers: window creation
+// and Vulkan surface creation for a GLFW window.
+package window
+
+import (
+	"fmt"
+	"sync"
+
+	"github.com/goodshailesh/My-Vulkan-Projects/vkutil"
+	"github.com/vulkan-go/glfw/v3.3/glfw"
+	vk "github.com/vulkan-go/vulkan"
+)
+
+// CreateWindow creates a resizable GLFW window without an OpenGL context.
+// glfw.Init must have been called. See TrackResize to follow its size.
+func CreateWindow(width, height int, title string) (*glfw.Window, error) {
+	// Because GLFW was originally designed to create an OpenGL context, we need to tell it to not create one
+	glfw.WindowHint(glfw.ClientAPI, glfw.NoAPI)
+	glfw.WindowHint(glfw.Resizable, glfw.True)
+	window, err := glfw.CreateWindow(width, height, title, nil, nil)
+	if err != nil {
+		return nil, fmt.Errorf("glfw.CreateWindow failed with %w", err)
+	}
+	return window, nil
+}
+
+// PrintRequiredExtensions prints the Vulkan instance extensions GLFW needs
+// to create surfaces for its windows.
+func PrintRequiredExtensions(window *glfw.Window) {
+	fmt.Println("Listing Vulkan instance extension required by GLFW for creating Vulkan surfaces for GLFW windows..........")
+	for idx, e := range window.GetRequiredInstanceExtensions() {
+		fmt.Printf("\t* [%v] %v\n", idx, e)
+	}
+}
+
+// InstanceExtensions returns the instance extensions to enable for a program
+// drawing into window: the ones GLFW requires for surface creation, which must
+// be present, plus whichever of optional the implementation offers.
+func InstanceExtensions(window *glfw.Window, optional ...string) ([]string, error) {
+	return vkutil.SelectInstanceExtensions(window.GetRequiredInstanceExtensions(), optional)
+}
+
+// CreateWindowSurface creates a Vulkan surface for window.
+func CreateWindowSurface(instance vk.Instance, window *glfw.Window) (vk.Surface, error) {
+	pSurface, err := window.CreateWindowSurface(instance, nil)
+	if err != nil {
+		return vk.NullSurface, fmt.Errorf("CreateWindowSurface failed with %w", err)
+	}
+	return vk.SurfaceFromPointer(pSurface), nil
+}
+
+// FramebufferExtent returns the size of the framebuffer of window in pixels,
+// which differs from the window size on high DPI screens. It is 0x0 while
+// the window is minimized.
+func FramebufferExtent(window *glfw.Window) vk.Extent2D {
+	width, height := window.GetFramebufferSize()
+	return vk.Extent2D{Width: uint32(width), Height: uint32(height)}
+}
+
+// ResizeTracker remembers that the framebuffer of a window changed size, so
+// the frame loop can recreate the swapchain even when the driver does not
+// report VK_ERROR_OUT_OF_DATE_KHR (which it is not required to do).
+type ResizeTracker struct {
+	mu      sync.Mutex
+	resized bool
+	extent  vk.Extent2D
+}
+
+// TrackResize installs a framebuffer-size callback on window, chaining to
+// the callback set before, and returns the tracker it feeds.
+func TrackResize(window *glfw.Window) *ResizeTracker {
+	t := &ResizeTracker{extent: FramebufferExtent(window)}
+	var previous glfw.FramebufferSizeCallback
+	previous = window.SetFramebufferSizeCallback(func(w *glfw.Window, width, height int) {
+		t.mu.Lock()
+		t.resized = true
+		t.extent = vk.Extent2D{Width: uint32(width), Height: uint32(height)}
+		t.mu.Unlock()
+		if previous != nil {
+			previous(w, width, height)
+		}
+	})
+	return t
+}
+
+// Resized reports whether the framebuffer changed size since the last call.
+func (t *ResizeTracker) Resized() bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	resized := t.resized
+	t.resized = false
+	return resized
+}
+
+// Extent returns the last framebuffer size seen by the callback.
+func (t *ResizeTracker) Extent() vk.Extent2D {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.extent
+}
+
+// Minimized reports whether the framebuffer of window has no area, as when
+// it is minimized. No swapchain can be created for it then.
+func Minimized(window *glfw.Window) bool {
+	extent := FramebufferExtent(window)
+	return extent.Width == 0 || extent.Height == 0
+}
+
+// WaitWhileMinimized blocks, processing events, while window is minimized
+// and not asked to close. It returns the framebuffer size once it has an area.
+func WaitWhileMinimized(window *glfw.Window) vk.Extent2D {
+	for Minimized(window) && !window.ShouldClose() {
+		glfw.WaitEvents()
+	}
+	return FramebufferExtent(window)
+}